@@ -16,9 +16,12 @@ package agent
 
 import (
 	"context"
+	"io"
 
 	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/parser"
 	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
 )
 
 // Agent represents a high-level abstraction for processing user requests.
@@ -50,3 +53,53 @@ func (a *Agent) Process(ctx context.Context, message string, progress progress.S
 	}
 	return &ProcessingResult{ProcessingResult: result}, nil
 }
+
+// ProposePlan plans and evaluates the message without executing it
+// (see handler.RequestHandler.ProposePlan).
+func (a *Agent) ProposePlan(ctx context.Context, message string, progress progress.Stream) (*handler.PlanProposal, error) {
+	return a.requestHandler.ProposePlan(ctx, message, progress)
+}
+
+// InvokeTool executes a single call of the function, bypassing the LLM
+// (see handler.RequestHandler.InvokeTool).
+func (a *Agent) InvokeTool(ctx context.Context, name string, args map[string]any, progress progress.Stream) (*ProcessingResult, error) {
+	result, err := a.requestHandler.InvokeTool(ctx, name, args, progress)
+	if err != nil {
+		return nil, err
+	}
+	return &ProcessingResult{ProcessingResult: result}, nil
+}
+
+// Autocomplete returns the metadata of the tools for a call being filled by
+// an operator (see handler.RequestHandler.Autocomplete).
+func (a *Agent) Autocomplete(call tools.PartialCall) *tools.Suggestions {
+	return a.requestHandler.Autocomplete(call)
+}
+
+// ExecutePlan executes a plan reviewed by an operator for the message
+// (see handler.RequestHandler.ExecutePlan).
+func (a *Agent) ExecutePlan(ctx context.Context, message string, funcCalls []parser.PlannedFuncCall, progress progress.Stream) (*ProcessingResult, error) {
+	result, err := a.requestHandler.ExecutePlan(ctx, message, funcCalls, progress)
+	if err != nil {
+		return nil, err
+	}
+	return &ProcessingResult{ProcessingResult: result}, nil
+}
+
+// ExportDataset writes a fine-tuning dataset built from the execution history
+// to w, returning the number of examples (see handler.RequestHandler.ExportDataset).
+func (a *Agent) ExportDataset(ctx context.Context, w io.Writer, options handler.DatasetOptions) (int, error) {
+	return a.requestHandler.ExportDataset(ctx, w, options)
+}
+
+// Choose answers a choice sent over the progress stream of a request
+// (see handler.RequestHandler.Choose).
+func (a *Agent) Choose(id string, option int) error {
+	return a.requestHandler.Choose(id, option)
+}
+
+// RunMaintenance runs the background maintenance of the handler until ctx is
+// done (see handler.RequestHandler.RunMaintenance).
+func (a *Agent) RunMaintenance(ctx context.Context) {
+	a.requestHandler.RunMaintenance(ctx)
+}
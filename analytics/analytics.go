@@ -0,0 +1,222 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analytics aggregates, per tool, how the planned calls fare through
+// the pipeline, to identify the tools whose descriptions confuse the model.
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ToolStats counts the calls of a tool at each stage of the pipeline.
+// Nested calls are counted as well, and share the verdict of the main call.
+type ToolStats struct {
+	Tool string `json:"tool"`
+	// Planned counts the calls generated by the planner.
+	Planned int `json:"planned"`
+	// Accepted counts the calls that passed the consistency evaluation.
+	Accepted int `json:"accepted"`
+	// Succeeded counts the calls executed without errors.
+	Succeeded int `json:"succeeded"`
+	// Contributed counts the calls whose results made it to the final answer.
+	Contributed int `json:"contributed"`
+}
+
+func (s *ToolStats) add(other ToolStats) {
+	s.Planned += other.Planned
+	s.Accepted += other.Accepted
+	s.Succeeded += other.Succeeded
+	s.Contributed += other.Contributed
+}
+
+// AcceptanceRate is the fraction of the planned calls passing evaluation.
+func (s ToolStats) AcceptanceRate() float64 { return rate(s.Accepted, s.Planned) }
+
+// SuccessRate is the fraction of the accepted calls executed without errors.
+func (s ToolStats) SuccessRate() float64 { return rate(s.Succeeded, s.Accepted) }
+
+// ContributionRate is the fraction of the successful calls contributing to the answer.
+func (s ToolStats) ContributionRate() float64 { return rate(s.Contributed, s.Succeeded) }
+
+func rate(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// NeedsReview returns the tools planned at least minPlanned times whose
+// acceptance rate is below minAcceptance, worst first: their descriptions
+// are likely to mislead the planner.
+func NeedsReview(stats []ToolStats, minPlanned int, minAcceptance float64) []ToolStats {
+	var review []ToolStats
+	for _, s := range stats {
+		if s.Planned >= minPlanned && s.AcceptanceRate() < minAcceptance {
+			review = append(review, s)
+		}
+	}
+	sort.SliceStable(review, func(i, j int) bool {
+		return review[i].AcceptanceRate() < review[j].AcceptanceRate()
+	})
+	return review
+}
+
+// Store accumulates the statistics in daily buckets (UTC), so that they can
+// be kept for a long time in little space.
+type Store interface {
+	// Add accumulates the statistics of a request processed at the given time.
+	Add(ctx context.Context, at time.Time, stats []ToolStats) error
+	// Stats returns the statistics of the requests processed in [from, to),
+	// at day granularity, sorted by tool. Zero times leave the range open.
+	Stats(ctx context.Context, from, to time.Time) ([]ToolStats, error)
+}
+
+// buckets maps days (YYYY-MM-DD) to the statistics by tool.
+type buckets map[string]map[string]ToolStats
+
+const dayLayout = "2006-01-02"
+
+func (b buckets) add(at time.Time, stats []ToolStats) {
+	day := at.UTC().Format(dayLayout)
+	bucket, ok := b[day]
+	if !ok {
+		bucket = make(map[string]ToolStats)
+		b[day] = bucket
+	}
+	for _, s := range stats {
+		total := bucket[s.Tool]
+		total.Tool = s.Tool
+		total.add(s)
+		bucket[s.Tool] = total
+	}
+}
+
+func (b buckets) stats(from, to time.Time) []ToolStats {
+	totals := make(map[string]ToolStats)
+	for day, bucket := range b {
+		if !from.IsZero() && day < from.UTC().Format(dayLayout) {
+			continue
+		}
+		if !to.IsZero() && day >= to.UTC().Format(dayLayout) {
+			continue
+		}
+		for tool, s := range bucket {
+			total := totals[tool]
+			total.Tool = tool
+			total.add(s)
+			totals[tool] = total
+		}
+	}
+	stats := make([]ToolStats, 0, len(totals))
+	for _, s := range totals {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Tool < stats[j].Tool })
+	return stats
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets buckets
+}
+
+// NewMemoryStore creates a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(buckets)}
+}
+
+func (m *MemoryStore) Add(_ context.Context, at time.Time, stats []ToolStats) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets.add(at, stats)
+	return nil
+}
+
+func (m *MemoryStore) Stats(_ context.Context, from, to time.Time) ([]ToolStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buckets.stats(from, to), nil
+}
+
+// FileStore is a Store persisting the buckets in a JSON file, rewritten on
+// every Add. It is meant for a single process.
+type FileStore struct {
+	Path    string
+	mu      sync.Mutex
+	buckets buckets
+}
+
+// NewFileStore loads the statistics from path, if it exists, and returns a
+// FileStore saving them there.
+func NewFileStore(path string) (*FileStore, error) {
+	f := &FileStore{Path: path, buckets: make(buckets)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading analytics: %w", err)
+	}
+	if err := json.Unmarshal(data, &f.buckets); err != nil {
+		return nil, fmt.Errorf("error decoding analytics: %w", err)
+	}
+	return f, nil
+}
+
+func (f *FileStore) Add(_ context.Context, at time.Time, stats []ToolStats) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buckets.add(at, stats)
+	return f.save()
+}
+
+func (f *FileStore) Stats(_ context.Context, from, to time.Time) ([]ToolStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buckets.stats(from, to), nil
+}
+
+func (f *FileStore) save() error {
+	data, err := json.Marshal(f.buckets)
+	if err != nil {
+		return fmt.Errorf("error encoding analytics: %w", err)
+	}
+
+	// Write to a temporary file first, so that a crash never leaves a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
@@ -0,0 +1,255 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package azureopenai is an LLM client of the Azure OpenAI Service, for the
+// deployments that must go through an Azure resource rather than the public
+// OpenAI endpoints.
+package azureopenai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+)
+
+// DefaultAPIVersion is the default version of the Azure OpenAI API, the first
+// GA version supporting structured outputs.
+const DefaultAPIVersion = "2024-10-21"
+
+// Config represents the configuration of the Azure OpenAI resource.
+type Config struct {
+	// Endpoint is the URL of the resource, e.g. https://my-resource.openai.azure.com.
+	Endpoint string
+	// Deployment is the name of the deployment of the chat model.
+	Deployment string
+	// EmbeddingDeployment is the name of the deployment of the embedding model.
+	EmbeddingDeployment string
+	// APIVersion is the api-version query parameter. DefaultAPIVersion if empty.
+	APIVersion string
+	// APIKey authenticates the requests, unless TokenSource is set.
+	APIKey string
+	// TokenSource, if set, returns the Microsoft Entra ID token authenticating
+	// the requests, instead of APIKey.
+	TokenSource func() (string, error)
+	Temperature float64
+	TopP        float64
+	MaxTokens   int
+	// StructuredOutputs constrains the completions to the JSON schema, which
+	// requires a model supporting it (gpt-4o 2024-08-06 or later). Otherwise
+	// the JSON is extracted from the completions by the Extractors.
+	StructuredOutputs bool
+	// Extractors post-process the completions. llm.DefaultExtractors if nil.
+	Extractors []llm.Extractor
+	Timeout    time.Duration
+}
+
+// CompletionRequest represents a request to the chat completions API.
+type CompletionRequest struct {
+	Messages       []Message       `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	TopP           float64         `json:"top_p"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Seed           *int            `json:"seed,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormat constrains the completion to a JSON schema.
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is a named JSON schema of a ResponseFormat.
+type JSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+// Message represents a chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionResponse represents a response of the chat completions API.
+type CompletionResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage llm.TokenUsage `json:"usage"`
+}
+
+type Client struct {
+	config Config
+	client *http.Client
+	// seed is set by WithSeed only, the service sampling randomly otherwise.
+	seed *int
+}
+
+func NewClient(c Config) *Client {
+	return &Client{
+		config: c,
+		client: &http.Client{
+			Timeout: c.Timeout,
+		},
+	}
+}
+
+// Settings reports the model settings, excluding the credentials.
+func (c *Client) Settings() map[string]any {
+	return map[string]any{
+		"endpoint":             c.config.Endpoint,
+		"deployment":           c.config.Deployment,
+		"embedding_deployment": c.config.EmbeddingDeployment,
+		"api_version":          c.apiVersion(),
+		"temperature":          c.config.Temperature,
+		"top_p":                c.config.TopP,
+		"max_tokens":           c.config.MaxTokens,
+		"structured_outputs":   c.config.StructuredOutputs,
+		"timeout":              c.config.Timeout.String(),
+		"seed":                 c.seed,
+	}
+}
+
+// WithSeed returns a copy of the client sampling greedily with the seed
+// (see llm.SeededCompleter).
+func (c *Client) WithSeed(seed int) llm.Completer {
+	clone := *c
+	clone.config.Temperature, clone.config.TopP = 0, 1
+	clone.seed = &seed
+	return &clone
+}
+
+func (c *Client) Complete(messages [][2]string, jsonSchema string) (string, error) {
+	content, _, err := c.CompleteWithUsage(messages, jsonSchema)
+	return content, err
+}
+
+// CompleteWithUsage is like Complete, also returning the token usage reported
+// by the service.
+func (c *Client) CompleteWithUsage(messages [][2]string, jsonSchema string) (string, llm.TokenUsage, error) {
+	requestBody := CompletionRequest{
+		Messages:    make([]Message, len(messages)),
+		Temperature: c.config.Temperature,
+		TopP:        c.config.TopP,
+		MaxTokens:   c.config.MaxTokens,
+		Seed:        c.seed,
+	}
+	for i, m := range messages {
+		requestBody.Messages[i] = Message{Role: m[0], Content: m[1]}
+	}
+	if jsonSchema != "" && c.config.StructuredOutputs {
+		requestBody.ResponseFormat = &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &JSONSchema{Name: "response", Schema: json.RawMessage(jsonSchema)},
+		}
+	}
+
+	var response CompletionResponse
+	if err := c.post(c.config.Deployment, "chat/completions", requestBody, &response); err != nil {
+		return "", llm.TokenUsage{}, fmt.Errorf("error getting completion response: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return "", response.Usage, fmt.Errorf("empty completion response")
+	}
+
+	extractors := c.config.Extractors
+	if extractors == nil {
+		extractors = llm.DefaultExtractors
+	}
+	content, err := llm.Chain(extractors...).Extract(response.Choices[0].Message.Content, jsonSchema)
+	if err != nil {
+		return "", response.Usage, fmt.Errorf("error extracting completion: %w", err)
+	}
+	return content, response.Usage, nil
+}
+
+func (c *Client) CreateEmbedding(text string) ([]float32, error) {
+	var response struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := c.post(c.config.EmbeddingDeployment, "embeddings", map[string]any{"input": text}, &response); err != nil {
+		return nil, fmt.Errorf("error getting embedding response: %w", err)
+	}
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("empty embedding response")
+	}
+	return response.Data[0].Embedding, nil
+}
+
+func (c *Client) apiVersion() string {
+	if c.config.APIVersion != "" {
+		return c.config.APIVersion
+	}
+	return DefaultAPIVersion
+}
+
+// post sends the request to the operation of the deployment, decoding the response.
+func (c *Client) post(deployment, operation string, requestBody, response any) error {
+	if deployment == "" {
+		return fmt.Errorf("missing deployment for %s", operation)
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		strings.TrimRight(c.config.Endpoint, "/"), url.PathEscape(deployment), operation, url.QueryEscape(c.apiVersion()))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.TokenSource != nil {
+		token, err := c.config.TokenSource()
+		if err != nil {
+			return fmt.Errorf("error getting token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("api-key", c.config.APIKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Context-overflow errors are recognized by llm.IsContextLengthError
+		return fmt.Errorf("error response (%s): %s", resp.Status, body)
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bedrock is an LLM client of Amazon Bedrock, for the deployments
+// that must go through an AWS account, possibly over a VPC endpoint. The
+// Anthropic Claude and Meta Llama model families are supported for
+// completions, and the Amazon Titan embedding models for embeddings.
+package bedrock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+)
+
+// anthropicVersion is the version of the Anthropic messages API on Bedrock.
+const anthropicVersion = "bedrock-2023-05-31"
+
+// defaultMaxTokens is required by the Claude models.
+const defaultMaxTokens = 4096
+
+// Config represents the configuration of the Bedrock client.
+type Config struct {
+	Region      string
+	Credentials Credentials
+	// ModelID is the ID of the chat model, or of its inference profile,
+	// e.g. anthropic.claude-3-5-sonnet-20240620-v1:0 or meta.llama3-1-70b-instruct-v1:0.
+	ModelID string
+	// EmbeddingModelID is the ID of the embedding model, e.g. amazon.titan-embed-text-v2:0.
+	EmbeddingModelID string
+	// Endpoint overrides the regional endpoint of the Bedrock runtime,
+	// e.g. for VPC endpoints.
+	Endpoint    string
+	Temperature float64
+	// TopP is sent only if positive, since recent Claude models reject it
+	// alongside the temperature.
+	TopP float64
+	// MaxTokens is the maximum length of the completions, 4096 if zero.
+	MaxTokens int
+	// Extractors post-process the completions. llm.DefaultExtractors if nil.
+	Extractors []llm.Extractor
+	Timeout    time.Duration
+}
+
+// CredentialsFromEnv returns the credentials of the standard AWS environment variables.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+func NewClient(c Config) *Client {
+	return &Client{
+		config: c,
+		client: &http.Client{
+			Timeout: c.Timeout,
+		},
+	}
+}
+
+// Settings reports the model settings, excluding the credentials.
+func (c *Client) Settings() map[string]any {
+	return map[string]any{
+		"region":             c.config.Region,
+		"model_id":           c.config.ModelID,
+		"embedding_model_id": c.config.EmbeddingModelID,
+		"endpoint":           c.endpoint(),
+		"temperature":        c.config.Temperature,
+		"top_p":              c.config.TopP,
+		"max_tokens":         c.maxTokens(),
+		"timeout":            c.config.Timeout.String(),
+	}
+}
+
+func (c *Client) Complete(messages [][2]string, jsonSchema string) (string, error) {
+	content, _, err := c.CompleteWithUsage(messages, jsonSchema)
+	return content, err
+}
+
+// CompleteWithUsage is like Complete, also returning the token usage reported
+// by the service. Bedrock has no constrained decoding: the JSON is extracted
+// from the completions by the Extractors.
+func (c *Client) CompleteWithUsage(messages [][2]string, jsonSchema string) (string, llm.TokenUsage, error) {
+	var content string
+	var usage llm.TokenUsage
+	var err error
+	switch id := c.config.ModelID; {
+	case strings.Contains(id, "anthropic."):
+		content, usage, err = c.completeClaude(messages)
+	case strings.Contains(id, "meta."):
+		content, usage, err = c.completeLlama(messages)
+	default:
+		return "", usage, fmt.Errorf("unsupported model %q", id)
+	}
+	if err != nil {
+		return "", usage, fmt.Errorf("error getting completion response: %w", err)
+	}
+
+	extractors := c.config.Extractors
+	if extractors == nil {
+		extractors = llm.DefaultExtractors
+	}
+	if content, err = llm.Chain(extractors...).Extract(content, jsonSchema); err != nil {
+		return "", usage, fmt.Errorf("error extracting completion: %w", err)
+	}
+	return content, usage, nil
+}
+
+// completeClaude uses the Anthropic messages API, where the system prompt is
+// separate and the roles must alternate.
+func (c *Client) completeClaude(messages [][2]string) (string, llm.TokenUsage, error) {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	var system []string
+	var conversation []message
+	for _, m := range messages {
+		switch {
+		case m[0] == "system":
+			system = append(system, m[1])
+		case len(conversation) > 0 && conversation[len(conversation)-1].Role == m[0]:
+			conversation[len(conversation)-1].Content += "\n\n" + m[1]
+		default:
+			conversation = append(conversation, message{Role: m[0], Content: m[1]})
+		}
+	}
+	requestBody := map[string]any{
+		"anthropic_version": anthropicVersion,
+		"max_tokens":        c.maxTokens(),
+		"messages":          conversation,
+		"temperature":       c.config.Temperature,
+	}
+	if c.config.TopP > 0 {
+		requestBody["top_p"] = c.config.TopP
+	}
+	if len(system) > 0 {
+		requestBody["system"] = strings.Join(system, "\n\n")
+	}
+
+	var response struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := c.invoke(c.config.ModelID, requestBody, &response); err != nil {
+		return "", llm.TokenUsage{}, err
+	}
+	usage := llm.TokenUsage{PromptTokens: response.Usage.InputTokens, CompletionTokens: response.Usage.OutputTokens}
+	var sb strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	return sb.String(), usage, nil
+}
+
+// completeLlama formats the conversation with the Llama 3 chat template.
+func (c *Client) completeLlama(messages [][2]string) (string, llm.TokenUsage, error) {
+	var prompt strings.Builder
+	prompt.WriteString("<|begin_of_text|>")
+	for _, m := range messages {
+		fmt.Fprintf(&prompt, "<|start_header_id|>%s<|end_header_id|>\n\n%s<|eot_id|>", m[0], m[1])
+	}
+	prompt.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+	requestBody := map[string]any{
+		"prompt":      prompt.String(),
+		"max_gen_len": c.maxTokens(),
+		"temperature": c.config.Temperature,
+	}
+	if c.config.TopP > 0 {
+		requestBody["top_p"] = c.config.TopP
+	}
+
+	var response struct {
+		Generation           string `json:"generation"`
+		PromptTokenCount     int    `json:"prompt_token_count"`
+		GenerationTokenCount int    `json:"generation_token_count"`
+	}
+	if err := c.invoke(c.config.ModelID, requestBody, &response); err != nil {
+		return "", llm.TokenUsage{}, err
+	}
+	usage := llm.TokenUsage{PromptTokens: response.PromptTokenCount, CompletionTokens: response.GenerationTokenCount}
+	return response.Generation, usage, nil
+}
+
+func (c *Client) CreateEmbedding(text string) ([]float32, error) {
+	if c.config.EmbeddingModelID == "" {
+		return nil, fmt.Errorf("missing embedding model")
+	}
+	var response struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := c.invoke(c.config.EmbeddingModelID, map[string]any{"inputText": text}, &response); err != nil {
+		return nil, fmt.Errorf("error getting embedding response: %w", err)
+	}
+	return response.Embedding, nil
+}
+
+func (c *Client) endpoint() string {
+	if c.config.Endpoint != "" {
+		return strings.TrimRight(c.config.Endpoint, "/")
+	}
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", c.config.Region)
+}
+
+func (c *Client) maxTokens() int {
+	if c.config.MaxTokens > 0 {
+		return c.config.MaxTokens
+	}
+	return defaultMaxTokens
+}
+
+// invoke calls the InvokeModel API, decoding the response.
+func (c *Client) invoke(modelID string, requestBody, response any) error {
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	// Model IDs contain colons, which must be escaped
+	req, err := http.NewRequest(http.MethodPost, c.endpoint()+"/model/"+uriEncode(modelID)+"/invoke", bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	signV4(req, jsonBody, c.config.Credentials, c.config.Region, "bedrock", time.Now())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Context-overflow errors are recognized by llm.IsContextLengthError
+		return fmt.Errorf("error response (%s): %s", resp.Status, body)
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blob provides the storage of large values, such as oversized
+// function results, which are referenced by Artifacts instead of being kept in memory.
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Artifact references a blob.
+type Artifact struct {
+	// ID identifies the blob in its Store. It is the SHA-256 of the content.
+	ID          string `json:"artifact_id"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// Store persists blobs. Blobs are addressed by content, so storing the same
+// data twice returns the same Artifact.
+type Store interface {
+	Put(ctx context.Context, contentType string, data []byte) (Artifact, error)
+	// Get returns the content of a blob, or ErrNotFound.
+	Get(ctx context.Context, id string) ([]byte, error)
+}
+
+func newArtifact(contentType string, data []byte) Artifact {
+	sum := sha256.Sum256(data)
+	return Artifact{
+		ID:          hex.EncodeToString(sum[:]),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+}
+
+// MemoryStore is an in-memory Store, mostly useful for testing.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryStore creates a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: make(map[string][]byte)}
+}
+
+func (m *MemoryStore) Put(_ context.Context, contentType string, data []byte) (Artifact, error) {
+	artifact := newArtifact(contentType, data)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[artifact.ID] = append([]byte(nil), data...)
+	return artifact, nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, id string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.blobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// DirStore is a Store keeping one file per blob in a directory.
+type DirStore struct {
+	Dir string
+}
+
+// NewDirStore creates the directory if needed and returns a DirStore using it.
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating blob directory: %w", err)
+	}
+	return &DirStore{Dir: dir}, nil
+}
+
+func (d *DirStore) Put(_ context.Context, contentType string, data []byte) (Artifact, error) {
+	artifact := newArtifact(contentType, data)
+	path := filepath.Join(d.Dir, artifact.ID)
+	if _, err := os.Stat(path); err == nil {
+		return artifact, nil
+	}
+
+	// Write to a temporary file first, so that readers never see partial blobs
+	tmp, err := os.CreateTemp(d.Dir, artifact.ID+".*.tmp")
+	if err != nil {
+		return Artifact{}, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return Artifact{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return Artifact{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return Artifact{}, err
+	}
+	return artifact, nil
+}
+
+func (d *DirStore) Get(_ context.Context, id string) ([]byte, error) {
+	if !isValidID(id) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(d.Dir, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// isValidID reports whether id is a hex-encoded SHA-256, which also prevents
+// path traversal through crafted IDs.
+func isValidID(id string) bool {
+	if len(id) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package builtin provides general-purpose tools implemented in pure Go, which
+// planners can use to resolve arguments through explicit calls instead of
+// computing values themselves.
+package builtin
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// intArg returns the integer value of an argument decoded from JSON.
+func intArg(args map[string]interface{}, name string) (int, bool, error) {
+	n, ok, err := execution.IntArg(args, name)
+	if err != nil || !ok {
+		return 0, ok, err
+	}
+	if n > math.MaxInt32 || n < math.MinInt32 {
+		return 0, false, fmt.Errorf("argument %s is out of range", name)
+	}
+	return int(n), true, nil
+}
+
+// stringArg returns the value of a string argument, or an empty string if it is missing.
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Calculator provides tools for evaluating arithmetic expressions and converting
+// measurement units, so that the planner never has to do math inside arguments.
+type Calculator struct{}
+
+func (c *Calculator) AvailableTools() *tools.ToolSet {
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "calculate",
+				Description: "Evaluate an arithmetic expression. Supports + - * / % ^, parentheses, pi, e and the functions sqrt, abs, round, floor, ceil, ln, log10, log2, exp, sin, cos, tan, pow, min, max. Always use it instead of computing numbers yourself.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"expression": {Type: "string", Description: "The expression to evaluate, e.g. (12.5 * 4) / 3"},
+					},
+					Required: []string{"expression"},
+				},
+				Returns: tools.TypeInfo{Type: "number"},
+			},
+			{
+				Name:        "convert_units",
+				Description: "Convert a quantity between units of length, mass, volume, area, time, speed, data size or temperature.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"value":     {Type: "number", Description: "The quantity to convert"},
+						"from_unit": {Type: "string", Description: "The unit of the quantity, e.g. km, miles, kg, °F, celsius"},
+						"to_unit":   {Type: "string", Description: "The unit to convert to"},
+					},
+					Required: []string{"value", "from_unit", "to_unit"},
+				},
+				Returns: tools.TypeInfo{Type: "number"},
+			},
+		},
+	}
+}
+
+func (c *Calculator) RegisterWith(ec *execution.Orchestrator) error {
+	ec.RegisterFunction("calculate", c.Calculate)
+	ec.RegisterFunction("convert_units", c.ConvertUnits)
+	return nil
+}
+
+func (c *Calculator) Calculate(_ context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	expression := stringArg(args, "expression")
+	if expression == "" {
+		return execution.FuncResult{}, fmt.Errorf("expression argument is required")
+	}
+
+	value, err := evaluate(expression)
+	if err != nil {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return fmt.Sprintf("Unable to calculate %s: %v", expression, err), nil
+			},
+		}, nil
+	}
+
+	return numberResult(value, func(v string) string {
+		return fmt.Sprintf("%s = %s", expression, v)
+	}), nil
+}
+
+func (c *Calculator) ConvertUnits(_ context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	value, ok, err := execution.FloatArg(args, "value")
+	if err != nil || !ok {
+		return execution.FuncResult{}, fmt.Errorf("value argument must be a number")
+	}
+	from, to := stringArg(args, "from_unit"), stringArg(args, "to_unit")
+
+	converted, err := convertUnits(value, from, to)
+	if err != nil {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return fmt.Sprintf("Unable to convert %s to %s: %v", from, to, err), nil
+			},
+		}, nil
+	}
+
+	return numberResult(converted, func(v string) string {
+		return fmt.Sprintf("%s %s = %s %s", formatNumber(value), from, v, to)
+	}), nil
+}
+
+func numberResult(value float64, format func(string) string) execution.FuncResult {
+	value = roundNoise(value)
+	return execution.FuncResult{
+		Present: true,
+		Value:   value,
+		FormatFunc: func() (string, error) {
+			return format(formatNumber(value)), nil
+		},
+	}
+}
+
+// roundNoise hides floating point noise such as 0.1+0.2 = 0.30000000000000004,
+// whatever its magnitude, rounding to 15 significant digits. The integers
+// within 2^53, exact in a float64, and the values already exact in 15 digits
+// are returned unchanged.
+func roundNoise(v float64) float64 {
+	if (v == math.Trunc(v) && math.Abs(v) <= 1<<53) || math.IsInf(v, 0) {
+		return v
+	}
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64)
+	if err != nil {
+		return v
+	}
+	return rounded
+}
+
+// formatNumber renders a number without exponent and trailing zeros.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
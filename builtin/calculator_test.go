@@ -0,0 +1,72 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestRoundNoise(t *testing.T) {
+	tests := []struct {
+		value, want float64
+	}{
+		{0.1 + 0.2, 0.3},
+		{1.1 * 1.1, 1.21},
+		{3e-12, 3e-12},
+		{1e-20 * 3, 3e-20},
+		{(0.1 + 0.2) * 1e-15, 3e-16},
+		{-0.1 - 0.2, -0.3},
+		{123456789.125, 123456789.125},
+		{1e20, 1e20},
+		{0, 0},
+		{1 << 40, 1099511627776},
+		{1 << 53, 9007199254740992},
+		{math.Pow(10, 300) * math.Pow(10, 5), 1e305},
+		{math.Inf(1), math.Inf(1)},
+	}
+	for _, tt := range tests {
+		if got := roundNoise(tt.value); got != tt.want {
+			t.Errorf("roundNoise(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateSmallMagnitudes(t *testing.T) {
+	result := execute(t, newTestOrchestrator(t, &Calculator{}), context.Background(), "calculate", map[string]any{"expression": "1.5e-11 * 2"})
+	if result.Value != 3e-11 {
+		t.Fatalf("got %v, want 3e-11", result.Value)
+	}
+}
+
+func TestCalculateBigIntegers(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"2^40", 1099511627776},
+		{"2^53 - 1", 9007199254740991},
+		{"10^300 * 10^5", 1e305},
+		{"1.5e200 / 3", 5e199},
+	}
+	o := newTestOrchestrator(t, &Calculator{})
+	for _, tt := range tests {
+		result := execute(t, o, context.Background(), "calculate", map[string]any{"expression": tt.expression})
+		if result.Value != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expression, result.Value, tt.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var absoluteDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"Monday, January 2, 2006",
+}
+
+// Layouts without a year, which is taken from the reference date.
+var yearlessDateLayouts = []string{
+	"January 2",
+	"Jan 2",
+	"2 January",
+	"2 Jan",
+}
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday,
+	"wednesday": time.Wednesday, "thursday": time.Thursday, "friday": time.Friday,
+	"saturday": time.Saturday,
+}
+
+var (
+	relativeInRe   = regexp.MustCompile(`^in (\w+) (day|week|month|year)s?$`)
+	relativeAgoRe  = regexp.MustCompile(`^(\w+) (day|week|month|year)s? (ago|before)$`)
+	relativeFromRe = regexp.MustCompile(`^(\w+) (day|week|month|year)s? (from now|from today|later|after)$`)
+	weekdayRe      = regexp.MustCompile(`^(?:(next|last|this|coming|previous) )?(sunday|monday|tuesday|wednesday|thursday|friday|saturday)$`)
+	periodRe       = regexp.MustCompile(`^(next|last|this|previous) (week|month|year)$`)
+	boundaryRe     = regexp.MustCompile(`^(?:the )?(start|beginning|end) of (?:the )?(?:(next|last|this) )?(week|month|year)$`)
+)
+
+// parseNaturalDate interprets an absolute or relative date expression
+// ("tomorrow", "next friday", "in 3 weeks", "2024-05-01", "March 3") against the reference time.
+func parseNaturalDate(text string, ref time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(text))
+	s = strings.TrimSuffix(s, ".")
+	s = strings.Join(strings.Fields(s), " ")
+	today := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+
+	switch s {
+	case "now":
+		return ref, nil
+	case "today", "tonight", "this morning", "this evening", "this afternoon":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "day after tomorrow", "the day after tomorrow":
+		return today.AddDate(0, 0, 2), nil
+	case "day before yesterday", "the day before yesterday":
+		return today.AddDate(0, 0, -2), nil
+	}
+
+	if m := relativeInRe.FindStringSubmatch(s); m != nil {
+		return shiftDate(today, m[1], m[2], 1)
+	}
+	if m := relativeFromRe.FindStringSubmatch(s); m != nil {
+		return shiftDate(today, m[1], m[2], 1)
+	}
+	if m := relativeAgoRe.FindStringSubmatch(s); m != nil {
+		return shiftDate(today, m[1], m[2], -1)
+	}
+
+	if m := weekdayRe.FindStringSubmatch(s); m != nil {
+		target := weekdays[m[2]]
+		diff := int(target - today.Weekday())
+		switch m[1] {
+		case "last", "previous":
+			if diff >= 0 {
+				diff -= 7
+			}
+		case "next":
+			if diff <= 0 {
+				diff += 7
+			}
+		default: // "this", "coming" or bare weekday: the upcoming occurrence, today included
+			if diff < 0 {
+				diff += 7
+			}
+		}
+		return today.AddDate(0, 0, diff), nil
+	}
+
+	if m := periodRe.FindStringSubmatch(s); m != nil {
+		sign := map[string]int{"next": 1, "last": -1, "previous": -1, "this": 0}[m[1]]
+		return shiftDate(today, strconv.Itoa(sign), m[2], 1)
+	}
+
+	if m := boundaryRe.FindStringSubmatch(s); m != nil {
+		sign := map[string]int{"next": 1, "last": -1, "this": 0, "": 0}[m[2]]
+		return periodBoundary(today, m[3], sign, m[1] == "end"), nil
+	}
+
+	for _, layout := range absoluteDateLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(text), ref.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range yearlessDateLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(text), ref.Location()); err == nil {
+			return time.Date(ref.Year(), t.Month(), t.Day(), 0, 0, 0, 0, ref.Location()), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date expression %q", text)
+}
+
+func shiftDate(t time.Time, amount, unit string, sign int) (time.Time, error) {
+	n, ok := numberWords[amount]
+	if !ok {
+		var err error
+		if n, err = strconv.Atoi(amount); err != nil {
+			return time.Time{}, fmt.Errorf("invalid amount %q", amount)
+		}
+	}
+	n *= sign
+
+	switch unit {
+	case "day":
+		return t.AddDate(0, 0, n), nil
+	case "week":
+		return t.AddDate(0, 0, 7*n), nil
+	case "month":
+		return addMonths(t, n), nil
+	case "year":
+		return addMonths(t, 12*n), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid unit %q", unit)
+}
+
+// addMonths adds months clamping the day to the length of the resulting month,
+// so that January 31 plus one month is the last day of February.
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	target := first.AddDate(0, months, 0)
+	lastDay := target.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(target.Year(), target.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// periodBoundary returns the first or last day of the week (Monday to Sunday), month or year
+// containing t, shifted by offset periods.
+func periodBoundary(t time.Time, unit string, offset int, end bool) time.Time {
+	switch unit {
+	case "week":
+		monday := t.AddDate(0, 0, -((int(t.Weekday())+6)%7)).AddDate(0, 0, 7*offset)
+		if end {
+			return monday.AddDate(0, 0, 6)
+		}
+		return monday
+	case "month":
+		first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, offset, 0)
+		if end {
+			return first.AddDate(0, 1, -1)
+		}
+		return first
+	default: // year
+		first := time.Date(t.Year()+offset, time.January, 1, 0, 0, 0, 0, t.Location())
+		if end {
+			return first.AddDate(1, 0, -1)
+		}
+		return first
+	}
+}
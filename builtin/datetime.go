@@ -0,0 +1,310 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+	_ "time/tzdata" // timezone conversions must not depend on the system database
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = time.RFC3339
+)
+
+// DateTime provides tools for date arithmetic, natural-language date parsing
+// and timezone conversion.
+type DateTime struct {
+	// Now returns the current time. Defaults to execution.Now.
+	Now func() time.Time
+	// Location is the timezone used when none is specified. Defaults to time.Local.
+	Location *time.Location
+}
+
+func (d *DateTime) AvailableTools() *tools.ToolSet {
+	timezone := tools.TypeInfo{Type: "string", Description: "IANA timezone name, e.g. Europe/Rome, America/New_York, UTC"}
+	// The functions are volatile, since the relative dates depend on the clock
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "get_current_datetime",
+				Description: "Return the current date and time, optionally in a given timezone.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"timezone": timezone,
+					},
+				},
+				Returns:  tools.TypeInfo{Type: "datetime_value"},
+				Volatile: true,
+			},
+			{
+				Name:        "parse_date",
+				Description: "Convert an absolute or relative date expression (e.g. 'tomorrow', 'next friday', 'in 3 weeks', 'March 3') into a calendar date.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"text":     {Type: "string", Description: "The date expression as written by the user"},
+						"timezone": timezone,
+					},
+					Required: []string{"text"},
+				},
+				Returns:  tools.TypeInfo{Type: "date_value"},
+				Volatile: true,
+			},
+			{
+				Name:        "add_to_date",
+				Description: "Add (or subtract, with negative amounts) days, weeks, months or years to a date.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"date":   {Type: "date_value", Description: "The starting date"},
+						"days":   {Type: "integer", Description: "Number of days to add"},
+						"weeks":  {Type: "integer", Description: "Number of weeks to add"},
+						"months": {Type: "integer", Description: "Number of months to add"},
+						"years":  {Type: "integer", Description: "Number of years to add"},
+					},
+					Required: []string{"date"},
+				},
+				Returns:  tools.TypeInfo{Type: "date_value"},
+				Volatile: true,
+			},
+			{
+				Name:        "days_between",
+				Description: "Count the number of days from a start date to an end date.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"start": {Type: "date_value", Description: "The start date"},
+						"end":   {Type: "date_value", Description: "The end date"},
+					},
+					Required: []string{"start", "end"},
+				},
+				Returns:  tools.TypeInfo{Type: "day_count_value"},
+				Volatile: true,
+			},
+			{
+				Name:        "convert_timezone",
+				Description: "Convert a date and time from one timezone to another.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"datetime":      {Type: "datetime_value", Description: "The date and time to convert"},
+						"from_timezone": {Type: "string", Description: "IANA timezone of the datetime, if it has no UTC offset"},
+						"to_timezone":   {Type: "string", Description: "IANA timezone to convert to, e.g. Asia/Tokyo"},
+					},
+					Required: []string{"datetime", "to_timezone"},
+				},
+				Returns:  tools.TypeInfo{Type: "datetime_value"},
+				Volatile: true,
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"date_value": {
+				Type:        "string",
+				Description: "A calendar date in YYYY-MM-DD format",
+				Pattern:     `^[0-9]{4}-[0-9]{2}-[0-9]{2}$`,
+			},
+			"datetime_value": {
+				Type:        "string",
+				Description: "A date and time in RFC 3339 format, e.g. 2024-05-01T15:04:05+02:00",
+			},
+			"day_count_value": {
+				Type:        "integer",
+				Description: "A number of days",
+			},
+		},
+	}
+}
+
+func (d *DateTime) RegisterWith(ec *execution.Orchestrator) error {
+	ec.RegisterFunction("get_current_datetime", d.GetCurrentDateTime)
+	ec.RegisterFunction("parse_date", d.ParseDate)
+	ec.RegisterFunction("add_to_date", d.AddToDate)
+	ec.RegisterFunction("days_between", d.DaysBetween)
+	ec.RegisterFunction("convert_timezone", d.ConvertTimezone)
+	return nil
+}
+
+func (d *DateTime) GetCurrentDateTime(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	loc, err := d.location(stringArg(args, "timezone"))
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	now := d.now(ctx).In(loc)
+	return dateTimeResult(now), nil
+}
+
+func (d *DateTime) ParseDate(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	text := stringArg(args, "text")
+	if text == "" {
+		return execution.FuncResult{}, fmt.Errorf("text argument is required")
+	}
+	loc, err := d.location(stringArg(args, "timezone"))
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	date, err := parseNaturalDate(text, d.now(ctx).In(loc))
+	if err != nil {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return fmt.Sprintf("Unable to understand the date %q", text), nil
+			},
+		}, nil
+	}
+	return dateResult(date), nil
+}
+
+func (d *DateTime) AddToDate(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	date, err := d.parseDateArg(ctx, args, "date")
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	amounts := make(map[string]int)
+	for _, name := range []string{"days", "weeks", "months", "years"} {
+		n, _, err := intArg(args, name)
+		if err != nil {
+			return execution.FuncResult{}, err
+		}
+		amounts[name] = n
+	}
+
+	date = addMonths(date, amounts["months"]+12*amounts["years"])
+	date = date.AddDate(0, 0, amounts["days"]+7*amounts["weeks"])
+	return dateResult(date), nil
+}
+
+func (d *DateTime) DaysBetween(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	start, err := d.parseDateArg(ctx, args, "start")
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	end, err := d.parseDateArg(ctx, args, "end")
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	// Compare the calendar days, unaffected by daylight saving transitions and
+	// by the range of time.Duration
+	days := julianDay(end) - julianDay(start)
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   days,
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("%d days from %s to %s", days, start.Format(dateLayout), end.Format(dateLayout)), nil
+		},
+	}, nil
+}
+
+func (d *DateTime) ConvertTimezone(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	text := stringArg(args, "datetime")
+	if text == "" {
+		return execution.FuncResult{}, fmt.Errorf("datetime argument is required")
+	}
+	to, err := time.LoadLocation(stringArg(args, "to_timezone"))
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("invalid to_timezone: %w", err)
+	}
+	from, err := d.location(stringArg(args, "from_timezone"))
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	t, err := time.Parse(dateTimeLayout, text)
+	if err != nil {
+		// Without an offset the datetime is interpreted in from_timezone
+		if t, err = parseNaturalDate(text, d.now(ctx).In(from)); err != nil {
+			return execution.FuncResult{}, fmt.Errorf("invalid datetime %q", text)
+		}
+	}
+	return dateTimeResult(t.In(to)), nil
+}
+
+// julianDay returns the Julian day number of the calendar date of t.
+func julianDay(t time.Time) int {
+	a := (14 - int(t.Month())) / 12
+	y := t.Year() + 4800 - a
+	m := int(t.Month()) + 12*a - 3
+	return t.Day() + (153*m+2)/5 + 365*y + y/4 - y/100 + y/400 - 32045
+}
+
+func (d *DateTime) now(ctx context.Context) time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	return execution.Now(ctx)
+}
+
+func (d *DateTime) location(name string) (*time.Location, error) {
+	if name == "" {
+		if d.Location != nil {
+			return d.Location, nil
+		}
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+func (d *DateTime) parseDateArg(ctx context.Context, args map[string]interface{}, name string) (time.Time, error) {
+	text := stringArg(args, name)
+	if text == "" {
+		return time.Time{}, fmt.Errorf("%s argument is required", name)
+	}
+	loc, err := d.location("")
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := parseNaturalDate(text, d.now(ctx).In(loc))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func dateResult(t time.Time) execution.FuncResult {
+	value := t.Format(dateLayout)
+	return execution.FuncResult{
+		Present: true,
+		Value:   value,
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("%s (%s)", t.Format("Monday, January 2, 2006"), value), nil
+		},
+	}
+}
+
+func dateTimeResult(t time.Time) execution.FuncResult {
+	value := t.Format(dateTimeLayout)
+	return execution.FuncResult{
+		Present: true,
+		Value:   value,
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("%s (%s)", t.Format("Monday, January 2, 2006 15:04 MST"), value), nil
+		},
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDaysBetween(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skipf("timezone data not available: %v", err)
+	}
+	d := &DateTime{
+		Now:      func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, rome) },
+		Location: rome,
+	}
+	o := newTestOrchestrator(t, d)
+	tests := []struct {
+		start, end string
+		want       int
+	}{
+		{"2024-03-30", "2024-04-01", 2},   // spring forward
+		{"2024-10-26", "2024-10-28", 2},   // fall back
+		{"2024-03-31", "2024-10-27", 210}, // between the transitions
+		{"2024-01-01", "2025-01-01", 366},
+		{"2024-02-01", "2023-02-01", -365},
+		{"1600-01-01", "2400-01-01", 292194},
+		{"0001-01-01", "9999-12-31", 3652058},
+	}
+	for _, tt := range tests {
+		result := execute(t, o, context.Background(), "days_between", map[string]any{"start": tt.start, "end": tt.end})
+		if result.Value != tt.want {
+			t.Errorf("days_between(%s, %s) = %v, want %d", tt.start, tt.end, result.Value, tt.want)
+		}
+	}
+}
+
+func TestJulianDay(t *testing.T) {
+	if got := julianDay(time.Date(2000, 1, 1, 23, 59, 0, 0, time.UTC)); got != 2451545 {
+		t.Fatalf("julianDay(2000-01-01) = %d, want 2451545", got)
+	}
+}
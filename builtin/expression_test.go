@@ -0,0 +1,80 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"12 / 3 / 2", 2},
+		{"7 % 4", 3},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"-3 + 5", 2},
+		{"2 * -3", -6},
+		{"--4", 4},
+		{"+4 - -4", 8},
+		{"sqrt(16) + abs(-2)", 6},
+		{"max(1, 5, 3) - min(4, 2)", 3},
+		{"pow(2, 10)", 1024},
+		{"2 * pi", 2 * math.Pi},
+		{"1e-12 * 3", 3e-12},
+		{"0.000000000002 / 2", 1e-12},
+	}
+	for _, tt := range tests {
+		got, err := evaluate(tt.expression)
+		if err != nil {
+			t.Errorf("evaluate(%q): %v", tt.expression, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9*math.Max(1, math.Abs(tt.want)) {
+			t.Errorf("evaluate(%q) = %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{"1 / 0", "division by zero"},
+		{"1 / (2 - 2)", "division by zero"},
+		{"5 % 0", "modulo by zero"},
+		{"sqrt(-1)", "not a finite number"},
+		{"1 +", "unexpected end"},
+		{"(1 + 2", "missing closing parenthesis"},
+		{"1 2", "unexpected"},
+		{"foo(1)", "unknown identifier"},
+		{"pow(2)", "pow expects 2 arguments"},
+		{strings.Repeat("1+", maxExpressionLength), "too long"},
+	}
+	for _, tt := range tests {
+		if _, err := evaluate(tt.expression); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("evaluate(%q): got error %v, want %q", tt.expression, err, tt.err)
+		}
+	}
+}
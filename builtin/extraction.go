@@ -0,0 +1,134 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Extraction provides the extract_structured tool, which uses constrained
+// generation to turn unstructured text into a value of one of the TypeDefinitions,
+// bridging the outputs of text-producing tools into typed arguments.
+type Extraction struct {
+	Completer llm.Completer
+	// TypeDefinitions are the types values can be extracted into.
+	TypeDefinitions map[string]tools.TypeInfo
+	// Prompts pins the versions of the prompt templates. Defaults to the latest.
+	Prompts prompt.Pins
+}
+
+func (e *Extraction) AvailableTools() *tools.ToolSet {
+	typeNames := make([]string, 0, len(e.TypeDefinitions))
+	for name := range e.TypeDefinitions {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "extract_structured",
+				Description: "Extract a value of the given type from a text, e.g. the result of another function. Use it when an argument requires a typed value that is only available as text.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"text":      {Type: "string", Description: "The text containing the information to extract"},
+						"type_name": {Type: "string", Description: "The name of the type to extract", Enum: typeNames},
+					},
+					Required: []string{"text", "type_name"},
+				},
+				Returns: tools.TypeInfo{Type: tools.AnyType},
+			},
+		},
+	}
+}
+
+func (e *Extraction) RegisterWith(ec *execution.Orchestrator) error {
+	if e.Completer == nil {
+		return fmt.Errorf("builtin: completer is required for the extraction tool")
+	}
+	ec.RegisterFunction("extract_structured", e.ExtractStructured)
+	return nil
+}
+
+func (e *Extraction) ExtractStructured(_ context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	text, typeName := stringArg(args, "text"), stringArg(args, "type_name")
+	if text == "" || typeName == "" {
+		return execution.FuncResult{}, fmt.Errorf("text and type_name arguments are required")
+	}
+
+	ts := &tools.ToolSet{TypeDefinitions: e.TypeDefinitions}
+	typeInfo, ok := e.TypeDefinitions[typeName]
+	if !ok {
+		return execution.FuncResult{}, fmt.Errorf("unknown type %s", typeName)
+	}
+
+	jsonSchema, err := ts.TypeJSONSchema(typeName)
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error generating JSON schema: %w", err)
+	}
+
+	typeDefinition, err := json.MarshalIndent(typeInfo, "", "  ")
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error marshaling type definition: %w", err)
+	}
+
+	userPrompt, err := e.Prompts.CreatePromptForExtraction(text, typeName, string(typeDefinition))
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error generating extraction prompt: %w", err)
+	}
+
+	progress.Send(fmt.Sprintf("Extracting %s...", typeName))
+
+	completion, err := e.Completer.Complete([]llm.Message{{"user", userPrompt}}, string(jsonSchema))
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error calling LLM: %w", err)
+	}
+
+	var extracted struct {
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(completion), &extracted); err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error unmarshaling extracted value: %w", err)
+	}
+
+	if extracted.Value == nil {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return fmt.Sprintf("No %s found in the text", typeName), nil
+			},
+		}, nil
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   extracted.Value,
+		FormatFunc: func() (string, error) {
+			data, err := json.MarshalIndent(extracted.Value, "", "  ")
+			return string(data), err
+		},
+		Metadata: map[string]any{"type_name": typeName},
+	}, nil
+}
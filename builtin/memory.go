@@ -0,0 +1,189 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/session"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Memory provides the remember and recall tools, which persist small facts
+// in the session store across the turns of a conversation.
+type Memory struct {
+	Store session.Store
+}
+
+func (m *Memory) AvailableTools() *tools.ToolSet {
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "remember",
+				Description: "Store a fact about the user for later turns of the conversation, e.g. their home city. Use it only when the user asks you to remember something.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"key":   {Type: "string", Description: "A short name for the fact, e.g. home city"},
+						"value": {Type: "string", Description: "The fact to remember, e.g. Turin"},
+					},
+					Required: []string{"key", "value"},
+				},
+				Returns:  tools.TypeInfo{Type: "memory_value"},
+				Volatile: true,
+			},
+			{
+				Name:        "recall",
+				Description: "Retrieve a fact previously stored with remember, e.g. when the user refers to 'my home city'. Without a key, return all the stored facts.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"key": {Type: "string", Description: "The name of the fact, e.g. home city"},
+					},
+				},
+				Returns:  tools.TypeInfo{Type: "memory_value"},
+				Volatile: true,
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"memory_value": {
+				Type:        "string",
+				Description: "A remembered fact",
+			},
+		},
+	}
+}
+
+func (m *Memory) RegisterWith(ec *execution.Orchestrator) error {
+	if m.Store == nil {
+		return fmt.Errorf("builtin: session store is required for memory tools")
+	}
+	ec.RegisterFunction("remember", m.Remember)
+	ec.RegisterFunction("recall", m.Recall)
+	return nil
+}
+
+func (m *Memory) Remember(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	key, value := normalizeKey(stringArg(args, "key")), strings.TrimSpace(stringArg(args, "value"))
+	if key == "" || value == "" {
+		return execution.FuncResult{}, fmt.Errorf("key and value arguments are required")
+	}
+
+	id, ok := session.IDFromContext(ctx)
+	if !ok {
+		return noSessionResult(), nil
+	}
+
+	err := m.Store.Update(ctx, id, func(s *session.Session) error {
+		s.Facts[key] = value
+		return nil
+	})
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error updating session: %w", err)
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   value,
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("I will remember that your %s is %s.", key, value), nil
+		},
+	}, nil
+}
+
+func (m *Memory) Recall(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	if _, ok := session.IDFromContext(ctx); !ok {
+		return noSessionResult(), nil
+	}
+	s, err := session.FromContext(ctx, m.Store)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	key := normalizeKey(stringArg(args, "key"))
+	if key == "" {
+		return recallAll(s.Facts), nil
+	}
+
+	value, ok := s.Facts[key]
+	if !ok {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return fmt.Sprintf("I don't know your %s yet.", key), nil
+			},
+		}, nil
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   value,
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("Your %s is %s.", key, value), nil
+		},
+	}, nil
+}
+
+func recallAll(facts map[string]string) execution.FuncResult {
+	if len(facts) == 0 {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return "I don't remember anything about you yet.", nil
+			},
+		}
+	}
+
+	keys := make([]string, 0, len(facts))
+	for k := range facts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = fmt.Sprintf("%s: %s", k, facts[k])
+	}
+	text := strings.Join(lines, "\n")
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   text,
+		FormatFunc: func() (string, error) {
+			return text, nil
+		},
+	}
+}
+
+func noSessionResult() execution.FuncResult {
+	return execution.FuncResult{
+		Present: false,
+		FormatFunc: func() (string, error) {
+			return "Memory is not available outside of a conversation session.", nil
+		},
+	}
+}
+
+// normalizeKey makes "My Home City" and "home city" refer to the same fact.
+func normalizeKey(key string) string {
+	key = strings.ToLower(strings.Join(strings.Fields(key), " "))
+	key = strings.TrimPrefix(key, "my ")
+	return key
+}
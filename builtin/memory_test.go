@@ -0,0 +1,141 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/session"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// newTestOrchestrator returns an orchestrator of the tools persisting the
+// results of the functions, like the ones of the handler with a MemoStore.
+func newTestOrchestrator(t *testing.T, ts interface {
+	AvailableTools() *tools.ToolSet
+	RegisterWith(ec *execution.Orchestrator) error
+}) *execution.Orchestrator {
+	t.Helper()
+	o := execution.NewOrchestrator(log.New(io.Discard, "", 0), time.Second, false, ts.AvailableTools())
+	memo, err := execution.NewDirMemoStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Memo = memo
+	if err := ts.RegisterWith(o); err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func execute(t *testing.T, o *execution.Orchestrator, ctx context.Context, name string, args map[string]any) execution.FuncResult {
+	t.Helper()
+	result, err := o.Execute(ctx, []parser.PlannedFuncCall{{Name: name, Args: args}}, &progress.NoOp{})
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return result.FuncCalls[0].Result
+}
+
+func TestRememberIsNotMemoized(t *testing.T) {
+	store := session.NewMemoryStore()
+	o := newTestOrchestrator(t, &Memory{Store: store})
+	ctx := session.WithID(context.Background(), "s1")
+	args := map[string]any{"key": "home city", "value": "Turin"}
+
+	execute(t, o, ctx, "remember", args)
+	if err := store.Update(ctx, "s1", func(s *session.Session) error {
+		delete(s.Facts, "home city")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	execute(t, o, ctx, "remember", args)
+
+	s, err := store.Get(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Facts["home city"]; got != "Turin" {
+		t.Fatalf("the second remember was not executed: home city is %q", got)
+	}
+}
+
+// barrierStore is a session store whose loads wait for each other, so that
+// the calls loading the sessions overlap.
+type barrierStore struct {
+	session.Store
+	arrived sync.WaitGroup
+}
+
+func (b *barrierStore) Get(ctx context.Context, id string) (*session.Session, error) {
+	b.arrived.Done()
+	done := make(chan struct{})
+	go func() {
+		b.arrived.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+	}
+	return b.Store.Get(ctx, id)
+}
+
+func TestRecallIsolatesSessions(t *testing.T) {
+	store := &barrierStore{Store: session.NewMemoryStore()}
+	o := newTestOrchestrator(t, &Memory{Store: store})
+	names := map[string]string{"alice": "Alice", "bob": "Bob"}
+	for id, name := range names {
+		ctx := session.WithID(context.Background(), id)
+		execute(t, o, ctx, "remember", map[string]any{"key": "name", "value": name})
+	}
+
+	// Concurrent calls with the same arguments, then sequential ones
+	store.arrived.Add(len(names))
+	for _, concurrent := range []bool{true, false} {
+		var wg sync.WaitGroup
+		for id, name := range names {
+			recall := func() {
+				defer wg.Done()
+				ctx := session.WithID(context.Background(), id)
+				result, err := o.Execute(ctx, []parser.PlannedFuncCall{{Name: "recall", Args: map[string]any{"key": "name"}}}, &progress.NoOp{})
+				if err != nil {
+					t.Errorf("recall: %v", err)
+					return
+				}
+				if got := result.FuncCalls[0].Result.Value; got != name {
+					t.Errorf("session %s recalled %v, want %s", id, got, name)
+				}
+			}
+			wg.Add(1)
+			if concurrent {
+				go recall()
+			} else {
+				store.arrived.Add(1)
+				recall()
+			}
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+const (
+	defaultSummaryWords = 100
+	maxSummaryWords     = 1000
+)
+
+// Summarization provides the summarize tool, which condenses long texts
+// (typically the outputs of other tools) through the Completer.
+type Summarization struct {
+	Completer llm.Completer
+	// Prompts pins the versions of the prompt templates. Defaults to the latest.
+	Prompts prompt.Pins
+}
+
+func (s *Summarization) AvailableTools() *tools.ToolSet {
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "summarize",
+				Description: "Summarize a long text, e.g. the result of another function, within a length limit.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"text":      {Type: "string", Description: "The text to summarize"},
+						"max_words": {Type: "integer", Description: fmt.Sprintf("The maximum length of the summary in words (default %d)", defaultSummaryWords)},
+						"style":     {Type: "string", Description: "The form of the summary (default paragraph)", Enum: prompt.SummarizationStyles()},
+					},
+					Required: []string{"text"},
+				},
+				Returns: tools.TypeInfo{Type: "summary_value"},
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"summary_value": {
+				Type:        "string",
+				Description: "A summary of a text",
+			},
+		},
+	}
+}
+
+func (s *Summarization) RegisterWith(ec *execution.Orchestrator) error {
+	if s.Completer == nil {
+		return fmt.Errorf("builtin: completer is required for the summarization tool")
+	}
+	ec.RegisterFunction("summarize", s.Summarize)
+	return nil
+}
+
+func (s *Summarization) Summarize(_ context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	text := stringArg(args, "text")
+	if text == "" {
+		return execution.FuncResult{}, fmt.Errorf("text argument is required")
+	}
+
+	maxWords, ok, err := intArg(args, "max_words")
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	if !ok || maxWords <= 0 {
+		maxWords = defaultSummaryWords
+	}
+	if maxWords > maxSummaryWords {
+		maxWords = maxSummaryWords
+	}
+
+	style := stringArg(args, "style")
+	if style == "" {
+		style = "paragraph"
+	}
+
+	// Short texts need no summary
+	if countWords(text) <= maxWords {
+		return summaryResult(text, false), nil
+	}
+
+	userPrompt, err := s.Prompts.CreatePromptForSummarization(text, style, maxWords)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	progress.Send("Summarizing...")
+
+	summary, err := s.Completer.Complete([]llm.Message{{"user", userPrompt}}, "")
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error calling LLM: %w", err)
+	}
+
+	summary, truncated := truncateWords(strings.TrimSpace(summary), maxWords)
+	return summaryResult(summary, truncated), nil
+}
+
+func summaryResult(summary string, truncated bool) execution.FuncResult {
+	return execution.FuncResult{
+		Present: true,
+		Value:   summary,
+		FormatFunc: func() (string, error) {
+			return summary, nil
+		},
+		Metadata: map[string]any{"truncated": truncated},
+	}
+}
+
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// truncateWords enforces the length budget when the model exceeds it,
+// preserving line breaks so that bullet points stay on separate lines.
+func truncateWords(text string, maxWords int) (string, bool) {
+	if countWords(text) <= maxWords {
+		return text, false
+	}
+	var b strings.Builder
+	words := 0
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if words+len(fields) > maxWords {
+			fields = fields[:maxWords-words]
+			b.WriteString(strings.Join(fields, " "))
+			b.WriteString("…")
+			break
+		}
+		words += len(fields)
+		b.WriteString(strings.Join(fields, " "))
+		b.WriteByte('\n')
+	}
+	return strings.TrimSpace(b.String()), true
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Translation provides the translate tool, which translates texts through the Completer.
+type Translation struct {
+	Completer llm.Completer
+	// Prompts pins the versions of the prompt templates. Defaults to the latest.
+	Prompts prompt.Pins
+}
+
+func (t *Translation) AvailableTools() *tools.ToolSet {
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "translate",
+				Description: "Translate a text, e.g. the result of another function, into another language.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"text":            {Type: "string", Description: "The text to translate"},
+						"target_language": {Type: "string", Description: "The language to translate into, e.g. 'Italian' or 'it'"},
+						"source_language": {Type: "string", Description: "The language of the text, if known"},
+					},
+					Required: []string{"text", "target_language"},
+				},
+				Returns: tools.TypeInfo{Type: "translation_value"},
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"translation_value": {
+				Type:        "string",
+				Description: "A translated text",
+			},
+		},
+	}
+}
+
+func (t *Translation) RegisterWith(ec *execution.Orchestrator) error {
+	if t.Completer == nil {
+		return fmt.Errorf("builtin: completer is required for the translation tool")
+	}
+	ec.RegisterFunction("translate", t.Translate)
+	return nil
+}
+
+func (t *Translation) Translate(_ context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	text, target := stringArg(args, "text"), stringArg(args, "target_language")
+	if text == "" || target == "" {
+		return execution.FuncResult{}, fmt.Errorf("text and target_language arguments are required")
+	}
+	source := stringArg(args, "source_language")
+
+	progress.Send(fmt.Sprintf("Translating into %s...", target))
+
+	translation, err := t.TranslateText(text, target, source)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   translation,
+		FormatFunc: func() (string, error) {
+			return translation, nil
+		},
+		Metadata: map[string]any{"target_language": target},
+	}, nil
+}
+
+// TranslateText translates text into the target language. The source language may be empty.
+func (t *Translation) TranslateText(text, targetLanguage, sourceLanguage string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+
+	userPrompt, err := t.Prompts.CreatePromptForTranslation(text, targetLanguage, sourceLanguage)
+	if err != nil {
+		return "", fmt.Errorf("error generating translation prompt: %w", err)
+	}
+
+	translation, err := t.Completer.Complete([]llm.Message{{"user", userPrompt}}, "")
+	if err != nil {
+		return "", fmt.Errorf("error calling LLM: %w", err)
+	}
+	return strings.TrimSpace(translation), nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unit describes a measurement unit as a linear transformation to the base unit
+// of its dimension: base = value*factor + offset.
+type unit struct {
+	dimension string
+	factor    float64
+	offset    float64
+}
+
+var units = map[string]unit{}
+
+func registerUnit(dimension string, factor, offset float64, names ...string) {
+	for _, name := range names {
+		units[name] = unit{dimension: dimension, factor: factor, offset: offset}
+	}
+}
+
+func init() {
+	// Length (base: meter)
+	registerUnit("length", 1, 0, "m", "meter", "meters", "metre", "metres")
+	registerUnit("length", 1e-3, 0, "mm", "millimeter", "millimeters", "millimetre", "millimetres")
+	registerUnit("length", 1e-2, 0, "cm", "centimeter", "centimeters", "centimetre", "centimetres")
+	registerUnit("length", 1e3, 0, "km", "kilometer", "kilometers", "kilometre", "kilometres")
+	registerUnit("length", 0.0254, 0, "in", "inch", "inches")
+	registerUnit("length", 0.3048, 0, "ft", "foot", "feet")
+	registerUnit("length", 0.9144, 0, "yd", "yard", "yards")
+	registerUnit("length", 1609.344, 0, "mi", "mile", "miles")
+	registerUnit("length", 1852, 0, "nmi", "nautical mile", "nautical miles")
+
+	// Mass (base: kilogram)
+	registerUnit("mass", 1, 0, "kg", "kilogram", "kilograms")
+	registerUnit("mass", 1e-3, 0, "g", "gram", "grams")
+	registerUnit("mass", 1e-6, 0, "mg", "milligram", "milligrams")
+	registerUnit("mass", 1e3, 0, "t", "tonne", "tonnes", "metric ton", "metric tons")
+	registerUnit("mass", 0.45359237, 0, "lb", "lbs", "pound", "pounds")
+	registerUnit("mass", 0.028349523125, 0, "oz", "ounce", "ounces")
+	registerUnit("mass", 6.35029318, 0, "st", "stone", "stones")
+
+	// Volume (base: liter)
+	registerUnit("volume", 1, 0, "l", "liter", "liters", "litre", "litres")
+	registerUnit("volume", 1e-3, 0, "ml", "milliliter", "milliliters", "millilitre", "millilitres")
+	registerUnit("volume", 1e3, 0, "m3", "cubic meter", "cubic meters")
+	registerUnit("volume", 3.785411784, 0, "gal", "gallon", "gallons")
+	registerUnit("volume", 0.946352946, 0, "qt", "quart", "quarts")
+	registerUnit("volume", 0.473176473, 0, "pt", "pint", "pints")
+	registerUnit("volume", 0.2365882365, 0, "cup", "cups")
+	registerUnit("volume", 0.0295735295625, 0, "fl oz", "fluid ounce", "fluid ounces")
+
+	// Area (base: square meter)
+	registerUnit("area", 1, 0, "m2", "square meter", "square meters")
+	registerUnit("area", 1e6, 0, "km2", "square kilometer", "square kilometers")
+	registerUnit("area", 1e4, 0, "ha", "hectare", "hectares")
+	registerUnit("area", 4046.8564224, 0, "acre", "acres")
+	registerUnit("area", 0.09290304, 0, "ft2", "square foot", "square feet")
+	registerUnit("area", 2589988.110336, 0, "mi2", "square mile", "square miles")
+
+	// Time (base: second)
+	registerUnit("time", 1, 0, "s", "sec", "second", "seconds")
+	registerUnit("time", 1e-3, 0, "ms", "millisecond", "milliseconds")
+	registerUnit("time", 60, 0, "min", "minute", "minutes")
+	registerUnit("time", 3600, 0, "h", "hr", "hour", "hours")
+	registerUnit("time", 86400, 0, "d", "day", "days")
+	registerUnit("time", 604800, 0, "week", "weeks")
+
+	// Speed (base: meter per second)
+	registerUnit("speed", 1, 0, "m/s", "meters per second")
+	registerUnit("speed", 1/3.6, 0, "km/h", "kmh", "kph", "kilometers per hour")
+	registerUnit("speed", 0.44704, 0, "mph", "miles per hour")
+	registerUnit("speed", 0.514444, 0, "kn", "knot", "knots")
+
+	// Data (base: byte)
+	registerUnit("data", 1, 0, "b", "byte", "bytes")
+	registerUnit("data", 1e3, 0, "kb", "kilobyte", "kilobytes")
+	registerUnit("data", 1e6, 0, "mb", "megabyte", "megabytes")
+	registerUnit("data", 1e9, 0, "gb", "gigabyte", "gigabytes")
+	registerUnit("data", 1e12, 0, "tb", "terabyte", "terabytes")
+	registerUnit("data", 1024, 0, "kib", "kibibyte", "kibibytes")
+	registerUnit("data", 1024*1024, 0, "mib", "mebibyte", "mebibytes")
+	registerUnit("data", 1024*1024*1024, 0, "gib", "gibibyte", "gibibytes")
+
+	// Temperature (base: kelvin)
+	registerUnit("temperature", 1, 0, "k", "kelvin")
+	registerUnit("temperature", 1, 273.15, "c", "°c", "celsius", "degrees celsius")
+	registerUnit("temperature", 5.0/9.0, 273.15-32*5.0/9.0, "f", "°f", "fahrenheit", "degrees fahrenheit")
+}
+
+// convertUnits converts a value between two units of the same dimension.
+func convertUnits(value float64, from, to string) (float64, error) {
+	fromUnit, ok := lookupUnit(from)
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	toUnit, ok := lookupUnit(to)
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if fromUnit.dimension != toUnit.dimension {
+		return 0, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, fromUnit.dimension, to, toUnit.dimension)
+	}
+	base := value*fromUnit.factor + fromUnit.offset
+	return (base - toUnit.offset) / toUnit.factor, nil
+}
+
+func lookupUnit(name string) (unit, bool) {
+	u, ok := units[strings.ToLower(strings.TrimSpace(name))]
+	return u, ok
+}
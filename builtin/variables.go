@@ -0,0 +1,144 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/session"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Variables provides the set_variable and get_variable tools, which keep
+// named values, e.g. the city selected by the user, in the session variables
+// (see session.VariablesFromContext).
+type Variables struct{}
+
+func (v *Variables) AvailableTools() *tools.ToolSet {
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "set_variable",
+				Description: "Set a session variable, to reuse a value in later turns of the conversation, e.g. the city the user is asking about.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"name":  {Type: "string", Description: "The name of the variable, in snake case, e.g. selected_city"},
+						"value": {Type: "string", Description: "The value of the variable, e.g. Turin"},
+					},
+					Required: []string{"name", "value"},
+				},
+				Returns:  tools.TypeInfo{Type: "string", Description: "The confirmation that the variable is set"},
+				Volatile: true,
+			},
+			{
+				Name:        "get_variable",
+				Description: "Get the value of a session variable previously set with set_variable.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"name": {Type: "string", Description: "The name of the variable, e.g. selected_city"},
+					},
+					Required: []string{"name"},
+				},
+				Returns:  tools.TypeInfo{Type: tools.AnyType},
+				Volatile: true,
+			},
+		},
+	}
+}
+
+func (v *Variables) RegisterWith(ec *execution.Orchestrator) error {
+	ec.RegisterFunction("set_variable", v.SetVariable)
+	ec.RegisterFunction("get_variable", v.GetVariable)
+	return nil
+}
+
+func (v *Variables) SetVariable(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	name := strings.TrimSpace(stringArg(args, "name"))
+	value := strings.TrimSpace(stringArg(args, "value"))
+	if name == "" || value == "" {
+		return execution.FuncResult{}, fmt.Errorf("name and value arguments are required")
+	}
+
+	vars, ok := session.VariablesFromContext(ctx)
+	if !ok {
+		return noVariablesResult(), nil
+	}
+	if err := vars.Set(ctx, name, value); err != nil {
+		return execution.FuncResult{}, err
+	}
+	confirmation := fmt.Sprintf("The variable %s is set to %s.", name, value)
+	return execution.FuncResult{
+		Present: true,
+		Value:   confirmation,
+		FormatFunc: func() (string, error) {
+			return confirmation, nil
+		},
+	}, nil
+}
+
+func (v *Variables) GetVariable(ctx context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	name := strings.TrimSpace(stringArg(args, "name"))
+	if name == "" {
+		return execution.FuncResult{}, fmt.Errorf("name argument is required")
+	}
+
+	vars, ok := session.VariablesFromContext(ctx)
+	if !ok {
+		return noVariablesResult(), nil
+	}
+	value, ok, err := vars.Get(ctx, name)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	if !ok {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return fmt.Sprintf("The variable %s is not set.", name), nil
+			},
+		}, nil
+	}
+	return variableResult(value), nil
+}
+
+func variableResult(value any) execution.FuncResult {
+	return execution.FuncResult{
+		Present: true,
+		Value:   value,
+		FormatFunc: func() (string, error) {
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}
+}
+
+func noVariablesResult() execution.FuncResult {
+	return execution.FuncResult{
+		Present: false,
+		FormatFunc: func() (string, error) {
+			return "Variables are not available outside of a conversation session.", nil
+		},
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nlpodyssey/funcallarchitect/session"
+)
+
+func withVariables(store session.Store, id string) context.Context {
+	ctx := session.WithID(context.Background(), id)
+	return session.WithVariables(ctx, session.NewVariables(store, id))
+}
+
+func TestGetVariableIsolatesSessions(t *testing.T) {
+	store := session.NewMemoryStore()
+	o := newTestOrchestrator(t, &Variables{})
+	alice, bob := withVariables(store, "alice"), withVariables(store, "bob")
+
+	execute(t, o, alice, "set_variable", map[string]any{"name": "selected_city", "value": "Turin"})
+	execute(t, o, bob, "set_variable", map[string]any{"name": "selected_city", "value": "Rome"})
+
+	args := map[string]any{"name": "selected_city"}
+	if got := execute(t, o, alice, "get_variable", args).Value; got != "Turin" {
+		t.Errorf("alice got %v, want Turin", got)
+	}
+	if got := execute(t, o, bob, "get_variable", args).Value; got != "Rome" {
+		t.Errorf("bob got %v, want Rome", got)
+	}
+}
+
+func TestSetVariableRepeatedWrites(t *testing.T) {
+	store := session.NewMemoryStore()
+	o := newTestOrchestrator(t, &Variables{})
+	ctx := withVariables(store, "s1")
+	turin := map[string]any{"name": "selected_city", "value": "Turin"}
+	get := map[string]any{"name": "selected_city"}
+
+	result := execute(t, o, ctx, "set_variable", turin)
+	if want := "The variable selected_city is set to Turin."; result.Value != want {
+		t.Errorf("set_variable returned %v, want %q", result.Value, want)
+	}
+	execute(t, o, ctx, "set_variable", map[string]any{"name": "selected_city", "value": "Rome"})
+	if got := execute(t, o, ctx, "get_variable", get).Value; got != "Rome" {
+		t.Fatalf("got %v after the second write, want Rome", got)
+	}
+
+	// Writing the first value again must run, not reuse the first result.
+	execute(t, o, ctx, "set_variable", turin)
+	if got := execute(t, o, ctx, "get_variable", get).Value; got != "Turin" {
+		t.Fatalf("got %v after the third write, want Turin", got)
+	}
+}
@@ -0,0 +1,395 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/history"
+)
+
+// benchRequest is a request of the corpus.
+type benchRequest struct {
+	Message   string `json:"message"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// benchSample is the outcome of a replayed request.
+type benchSample struct {
+	latency time.Duration
+	status  int // 0 for transport errors
+	err     error
+	record  *history.Record
+}
+
+// BenchReport summarizes a bench run.
+type BenchReport struct {
+	Requests int     `json:"requests"`
+	Skipped  int     `json:"skipped"`
+	Errors   int     `json:"errors"`
+	RPS      float64 `json:"rps"`
+	// ErrorRate is the share of failed requests among the sent ones.
+	ErrorRate float64        `json:"error_rate"`
+	Statuses  map[string]int `json:"statuses"`
+	Latency   BenchLatency   `json:"latency"`
+	// The following are computed from the execution reports, when the
+	// deployment stores them.
+	Reports          int     `json:"reports"`
+	Calls            int     `json:"calls"`
+	CachedCalls      int     `json:"cached_calls"`
+	CacheHitRate     float64 `json:"cache_hit_rate"`
+	ReusedPlans      int     `json:"reused_plans"`
+	Completions      int     `json:"completions"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+}
+
+// BenchLatency holds the latency percentiles of the successful requests.
+type BenchLatency struct {
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	url := flags.String("url", "http://localhost:8080", "Base URL of the deployment")
+	rps := flags.Float64("rps", 1, "Requests per second")
+	duration := flags.Duration("duration", 0, "Duration of a soak test, cycling through the corpus (defaults to one pass)")
+	concurrency := flags.Int("concurrency", 32, "Maximum number of requests in flight; requests beyond are skipped")
+	timeout := flags.Duration("timeout", 2*time.Minute, "Timeout of a request")
+	reports := flags.Bool("reports", true, "Fetch the execution reports for cache and token statistics")
+	jsonOutput := flags.Bool("json", false, "Print the report as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: funcall bench [flags] <corpus>")
+		fmt.Fprintln(flags.Output(), "\nThe corpus is a directory of execution records (history.DirStore), a JSON Lines")
+		fmt.Fprintln(flags.Output(), "file of records or of {\"message\", \"session_id\"} objects, or a text file with")
+		fmt.Fprintln(flags.Output(), "one request per line.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("corpus is required")
+	}
+	if *rps <= 0 {
+		return errors.New("rps must be positive")
+	}
+
+	corpus, err := loadCorpus(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(corpus) == 0 {
+		return errors.New("the corpus is empty")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	b := &bench{
+		url:     strings.TrimSuffix(*url, "/"),
+		client:  &http.Client{Timeout: *timeout},
+		reports: *reports,
+	}
+	samples, skipped, elapsed := b.run(ctx, corpus, *rps, *duration, *concurrency)
+	report := summarize(samples, skipped, elapsed)
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	report.print(os.Stdout)
+	return nil
+}
+
+func loadCorpus(path string) ([]benchRequest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		store, err := history.NewDirStore(path)
+		if err != nil {
+			return nil, err
+		}
+		records, err := store.List(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("error reading records: %w", err)
+		}
+		corpus := make([]benchRequest, 0, len(records))
+		for _, r := range records {
+			corpus = append(corpus, benchRequest{Message: r.Request, SessionID: r.SessionID})
+		}
+		return corpus, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var corpus []benchRequest
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "{") {
+			corpus = append(corpus, benchRequest{Message: line})
+			continue
+		}
+		var entry struct {
+			benchRequest
+			Request string `json:"request"` // history.Record
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if entry.Message == "" {
+			entry.Message = entry.Request
+		}
+		corpus = append(corpus, entry.benchRequest)
+	}
+	return corpus, scanner.Err()
+}
+
+type bench struct {
+	url     string
+	client  *http.Client
+	reports bool
+}
+
+// run sends the requests at the given rate, regardless of the responses
+// (open loop), so that a saturated deployment shows as growing latencies
+// and skipped requests rather than as a lower rate.
+func (b *bench) run(ctx context.Context, corpus []benchRequest, rps float64, duration time.Duration, concurrency int) ([]benchSample, int, time.Duration) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rps))
+	defer ticker.Stop()
+	var deadline <-chan time.Time
+	if duration > 0 {
+		deadline = time.After(duration)
+	}
+
+	var mu sync.Mutex
+	var samples []benchSample
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	skipped := 0
+	started := time.Now()
+loop:
+	for i := 0; duration > 0 || i < len(corpus); i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-deadline:
+				break loop
+			case <-ticker.C:
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		default:
+			skipped++
+			continue
+		}
+		wg.Add(1)
+		go func(r benchRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s := b.send(ctx, r)
+			mu.Lock()
+			samples = append(samples, s)
+			mu.Unlock()
+		}(corpus[i%len(corpus)])
+	}
+	wg.Wait()
+	return samples, skipped, time.Since(started)
+}
+
+func (b *bench) send(ctx context.Context, r benchRequest) benchSample {
+	body, _ := json.Marshal(r)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url+"/process", bytes.NewReader(body))
+	if err != nil {
+		return benchSample{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	started := time.Now()
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return benchSample{latency: time.Since(started), err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	s := benchSample{latency: time.Since(started), status: resp.StatusCode, err: err}
+	if err != nil || resp.StatusCode != http.StatusOK || !b.reports {
+		return s
+	}
+
+	var response struct {
+		ExecutionID string `json:"execution_id"`
+	}
+	if json.Unmarshal(data, &response) == nil && response.ExecutionID != "" {
+		s.record = b.fetchReport(ctx, response.ExecutionID)
+	}
+	return s
+}
+
+// fetchReport returns the execution report, or nil if it is not available.
+func (b *bench) fetchReport(ctx context.Context, id string) *history.Record {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.url+"/executions/"+id+"/report", nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	var record history.Record
+	if json.NewDecoder(resp.Body).Decode(&record) != nil {
+		return nil
+	}
+	return &record
+}
+
+func summarize(samples []benchSample, skipped int, elapsed time.Duration) *BenchReport {
+	report := &BenchReport{
+		Requests: len(samples),
+		Skipped:  skipped,
+		Statuses: make(map[string]int),
+	}
+	if elapsed > 0 {
+		report.RPS = float64(len(samples)) / elapsed.Seconds()
+	}
+	var latencies []time.Duration
+	var total time.Duration
+	for _, s := range samples {
+		switch {
+		case s.status == 0:
+			report.Statuses["transport_error"]++
+		default:
+			report.Statuses[fmt.Sprint(s.status)]++
+		}
+		if s.err != nil || s.status != http.StatusOK {
+			report.Errors++
+			continue
+		}
+		latencies = append(latencies, s.latency)
+		total += s.latency
+		if s.record != nil {
+			report.addRecord(s.record)
+		}
+	}
+	if len(samples) > 0 {
+		report.ErrorRate = float64(report.Errors) / float64(len(samples))
+	}
+	if report.Calls > 0 {
+		report.CacheHitRate = float64(report.CachedCalls) / float64(report.Calls)
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		report.Latency = BenchLatency{
+			Mean: total / time.Duration(len(latencies)),
+			P50:  percentile(latencies, 50),
+			P90:  percentile(latencies, 90),
+			P95:  percentile(latencies, 95),
+			P99:  percentile(latencies, 99),
+			Max:  latencies[len(latencies)-1],
+		}
+	}
+	return report
+}
+
+func (r *BenchReport) addRecord(record *history.Record) {
+	r.Reports++
+	r.Completions += record.LLM.Completions
+	r.PromptTokens += record.LLM.PromptTokens
+	r.CompletionTokens += record.LLM.CompletionTokens
+	for _, p := range record.Plans {
+		if p.ReusedFrom != "" {
+			r.ReusedPlans++
+		}
+	}
+	var count func(calls []history.Call)
+	count = func(calls []history.Call) {
+		for _, c := range calls {
+			r.Calls++
+			if c.Cached {
+				r.CachedCalls++
+			}
+			count(c.Calls)
+		}
+	}
+	count(record.Calls)
+}
+
+// percentile returns the p-th percentile of the sorted durations, by the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+func (r *BenchReport) print(w io.Writer) {
+	ms := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	fmt.Fprintf(w, "Requests:   %d sent (%.2f/s), %d skipped at the concurrency limit\n", r.Requests, r.RPS, r.Skipped)
+	fmt.Fprintf(w, "Errors:     %d (%.1f%%)\n", r.Errors, 100*r.ErrorRate)
+	statuses := make([]string, 0, len(r.Statuses))
+	for status, n := range r.Statuses {
+		statuses = append(statuses, fmt.Sprintf("%s: %d", status, n))
+	}
+	slices.Sort(statuses)
+	fmt.Fprintf(w, "Statuses:   %s\n", strings.Join(statuses, ", "))
+	l := r.Latency
+	fmt.Fprintf(w, "Latency:    mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+		ms(l.Mean), ms(l.P50), ms(l.P90), ms(l.P95), ms(l.P99), ms(l.Max))
+	if r.Reports == 0 {
+		fmt.Fprintln(w, "\nNo execution reports: enable the history of the deployment for cache and token statistics.")
+		return
+	}
+	perRequest := func(n int) float64 { return float64(n) / float64(r.Reports) }
+	fmt.Fprintf(w, "Cache:      %d of %d calls cached (%.1f%%), %d plans reused\n", r.CachedCalls, r.Calls, 100*r.CacheHitRate, r.ReusedPlans)
+	fmt.Fprintf(w, "LLM:        %d completions (%.1f per request)\n", r.Completions, perRequest(r.Completions))
+	fmt.Fprintf(w, "Tokens:     %d prompt, %d completion (%.0f per request)\n",
+		r.PromptTokens, r.CompletionTokens, perRequest(r.PromptTokens+r.CompletionTokens))
+}
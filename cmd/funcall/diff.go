@@ -0,0 +1,93 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nlpodyssey/funcallarchitect/history"
+)
+
+// runDiff compares two stored executions: their plans, the arguments and the
+// results of their calls, and their outputs.
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	historyDir := flags.String("history", "", "Directory of the execution history (history.DirStore), to load the executions by ID")
+	jsonOutput := flags.Bool("json", false, "Print the comparison as JSON instead of Markdown")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: funcall diff [flags] <execution> <execution>")
+		fmt.Fprintln(flags.Output(), "\nThe executions are JSON files of execution records, or their IDs with -history.")
+		fmt.Fprintln(flags.Output(), "The exit status is 1 when the results or the outputs differ.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("two executions are required")
+	}
+
+	var store history.Store
+	if *historyDir != "" {
+		s, err := history.NewDirStore(*historyDir)
+		if err != nil {
+			return err
+		}
+		store = s
+	}
+	var records [2]*history.Record
+	for i, arg := range flags.Args() {
+		record, err := loadRecord(store, arg)
+		if err != nil {
+			return err
+		}
+		records[i] = record
+	}
+
+	diff := history.Diff(records[0], records[1])
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(diff.Markdown())
+	}
+	if !diff.SameResults || !diff.SameOutput {
+		return errors.New("the executions differ")
+	}
+	return nil
+}
+
+// loadRecord reads a record file, or gets the record with the given ID from store.
+func loadRecord(store history.Store, arg string) (*history.Record, error) {
+	if data, err := os.ReadFile(arg); err == nil {
+		var record history.Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("%s: error decoding record: %w", arg, err)
+		}
+		return &record, nil
+	} else if store == nil {
+		return nil, err
+	}
+	return store.Get(context.Background(), arg)
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed all:templates/init
+var initTemplates embed.FS
+
+const initTemplatesRoot = "templates/init"
+
+// initParams are the values available to the init templates.
+type initParams struct {
+	Module  string
+	Package string
+}
+
+func runInit(args []string) error {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	dir := flags.String("dir", "", "Directory of the new project (defaults to the last element of the module path)")
+	force := flags.Bool("force", false, "Overwrite existing files")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: funcall init [-dir dir] [-force] <module path>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("module path is required")
+	}
+
+	module := flags.Arg(0)
+	if *dir == "" {
+		*dir = path.Base(module)
+	}
+
+	params := initParams{Module: module, Package: "main"}
+	written, err := scaffold(*dir, params, *force)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range written {
+		fmt.Println("created", filepath.Join(*dir, name))
+	}
+	fmt.Printf("\nNext steps:\n  cd %s\n  go mod tidy\n  go test ./...\n  cp .env.example .env  # then set LLM_ENDPOINT\n  go run .\n", *dir)
+	return nil
+}
+
+// scaffold renders the init templates into dir and returns the names of the written files.
+// Existing files are left untouched unless force is set.
+func scaffold(dir string, params initParams, force bool) ([]string, error) {
+	var files []string
+	err := fs.WalkDir(initTemplates, initTemplatesRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		files = append(files, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing templates: %w", err)
+	}
+
+	rendered := make(map[string][]byte, len(files))
+	for _, p := range files {
+		name := strings.TrimSuffix(strings.TrimPrefix(p, initTemplatesRoot+"/"), ".tmpl")
+		content, err := renderTemplate(p, name, params)
+		if err != nil {
+			return nil, err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(target); err == nil && !force {
+			return nil, fmt.Errorf("%s already exists (use -force to overwrite)", target)
+		}
+		rendered[name] = content
+	}
+
+	var written []string
+	for _, p := range files {
+		name := strings.TrimSuffix(strings.TrimPrefix(p, initTemplatesRoot+"/"), ".tmpl")
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return written, fmt.Errorf("error creating directory: %w", err)
+		}
+		if err := os.WriteFile(target, rendered[name], 0o644); err != nil {
+			return written, fmt.Errorf("error writing %s: %w", target, err)
+		}
+		written = append(written, name)
+	}
+	return written, nil
+}
+
+func renderTemplate(p, name string, params initParams) ([]byte, error) {
+	tmpl, err := template.ParseFS(initTemplates, p)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", name, err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return nil, fmt.Errorf("error executing template %s: %w", name, err)
+	}
+	if !strings.HasSuffix(name, ".go") {
+		return []byte(buf.String()), nil
+	}
+	formatted, err := format.Source([]byte(buf.String()))
+	if err != nil {
+		return nil, fmt.Errorf("error formatting %s: %w", name, err)
+	}
+	return formatted, nil
+}
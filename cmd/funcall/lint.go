@@ -0,0 +1,98 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nlpodyssey/funcallarchitect/llamacpp"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// runLint checks the tool definitions of a toolset, or of a snapshot, for the
+// problems likely to confuse the planner. It fails when issues are found.
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	embeddings := flags.String("embeddings", "", "Embeddings endpoint of an OpenAI-compatible server, to find overlapping descriptions (LLM_API_KEY is used as key)")
+	threshold := flags.Float64("threshold", tools.DefaultOverlapThreshold, "Cosine similarity beyond which two descriptions overlap")
+	jsonOutput := flags.Bool("json", false, "Print the issues as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: funcall lint [flags] <definitions>")
+		fmt.Fprintln(flags.Output(), "\nThe definitions are a JSON file holding a tools.ToolSet, or a handler snapshot.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("definitions are required")
+	}
+
+	ts, err := loadToolSet(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	options := tools.LintOptions{OverlapThreshold: *threshold}
+	if *embeddings != "" {
+		options.Embedder = llamacpp.NewClient(llamacpp.Config{Endpoint: *embeddings, APIKey: os.Getenv("LLM_API_KEY")})
+	}
+	issues, err := ts.Lint(options)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issues found", len(issues))
+	}
+	return nil
+}
+
+// loadToolSet reads a ToolSet, or the toolset of a snapshot.
+func loadToolSet(path string) (*tools.ToolSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var document struct {
+		*tools.ToolSet
+		Snapshot *tools.ToolSet `json:"toolset"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("error decoding definitions: %w", err)
+	}
+	if document.Snapshot != nil {
+		return document.Snapshot, nil
+	}
+	if document.ToolSet == nil || len(document.Functions) == 0 {
+		return nil, errors.New("no function definitions found")
+	}
+	return document.ToolSet, nil
+}
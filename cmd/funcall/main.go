@@ -0,0 +1,71 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command funcall provides development utilities for FunCallArchitect projects.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: funcall <command> [arguments]
+
+Commands:
+  init      scaffold a new project
+  bench     replay recorded requests against a deployment
+  prompts   list the versions of the built-in prompt templates
+  lint      check tool definitions for problems likely to confuse the planner
+  optimize  propose better tool descriptions from the planner failures
+  diff      compare two stored executions of a request
+  version   print the version of the build
+
+Run "funcall <command> -h" for the arguments of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "init":
+		err = runInit(args)
+	case "bench":
+		err = runBench(args)
+	case "prompts":
+		err = runPrompts(args)
+	case "lint":
+		err = runLint(args)
+	case "optimize":
+		err = runOptimize(args)
+	case "diff":
+		err = runDiff(args)
+	case "version":
+		err = runVersion(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "funcall: unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "funcall %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
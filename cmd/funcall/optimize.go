@@ -0,0 +1,139 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/history"
+	"github.com/nlpodyssey/funcallarchitect/llamacpp"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// runOptimize proposes improved descriptions for the tool definitions of a
+// ToolSet file, from the planner failures of an execution history, and prints
+// the diff of the file for review.
+func runOptimize(args []string) error {
+	flags := flag.NewFlagSet("optimize", flag.ContinueOnError)
+	historyDir := flags.String("history", "", "Directory of the execution history (history.DirStore)")
+	output := flags.String("o", "", "Write the optimized ToolSet to this file")
+	candidates := flags.Int("candidates", handler.DefaultDescriptionCandidates, "Descriptions proposed per function")
+	maxFailures := flags.Int("failures", handler.DefaultOptimizationCases, "Failed requests replayed per function")
+	maxRegressions := flags.Int("regressions", handler.DefaultOptimizationCases, "Successful requests replayed per function, to detect regressions")
+	since := flags.Duration("since", 0, "Only consider the executions of the given recent period")
+	jsonOutput := flags.Bool("json", false, "Print the proposals as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: funcall optimize [flags] -history <dir> <toolset.json>")
+		fmt.Fprintln(flags.Output(), "\nThe planner is the model at LLM_ENDPOINT (LLM_MODEL and LLM_API_KEY are optional).")
+		fmt.Fprintln(flags.Output(), "Nothing is executed: the requests are only planned and evaluated.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *historyDir == "" {
+		flags.Usage()
+		return errors.New("toolset and history are required")
+	}
+	endpoint := os.Getenv("LLM_ENDPOINT")
+	if endpoint == "" {
+		return errors.New("LLM_ENDPOINT must be set")
+	}
+
+	ts, err := loadToolSet(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	store, err := history.NewDirStore(*historyDir)
+	if err != nil {
+		return err
+	}
+	h, err := handler.NewRequestHandler(handler.RequestHandlerConfig{
+		Logger: log.New(os.Stderr, "", log.Ltime),
+		LLMClient: llamacpp.NewClient(llamacpp.Config{
+			APIKey:     os.Getenv("LLM_API_KEY"),
+			Model:      os.Getenv("LLM_MODEL"),
+			Endpoint:   endpoint,
+			TopP:       0.001,
+			MaxTokens:  5000,
+			Timeout:    60 * time.Second,
+			UseGrammar: true,
+		}),
+		Tools:   definitionsOnly{ts},
+		History: store,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	options := handler.DescriptionOptimization{
+		Candidates:     *candidates,
+		MaxFailures:    *maxFailures,
+		MaxRegressions: *maxRegressions,
+	}
+	if *since > 0 {
+		options.Since = time.Now().Add(-*since)
+	}
+	result, err := h.OptimizeDescriptions(ctx, ts, options, &progress.NoOp{})
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result.Proposals); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range result.Proposals {
+			fmt.Printf("%s: %.0f%% -> %.0f%% of %d requests planned correctly\n", p.Function, p.Baseline*100, p.Score*100, p.Cases)
+		}
+		if len(result.Proposals) > 0 {
+			fmt.Println()
+		}
+		fmt.Print(result.Diff)
+	}
+	if *output != "" && len(result.Proposals) > 0 {
+		data, err := json.MarshalIndent(result.ToolSet, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(*output, append(data, '\n'), 0o644)
+	}
+	return nil
+}
+
+// definitionsOnly provides tools that can be planned but not executed.
+type definitionsOnly struct {
+	ts *tools.ToolSet
+}
+
+func (d definitionsOnly) AvailableTools() *tools.ToolSet { return d.ts }
+
+func (d definitionsOnly) RegisterWith(*execution.Orchestrator) error { return nil }
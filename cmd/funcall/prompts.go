@@ -0,0 +1,51 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+)
+
+// runPrompts lists the versions of the built-in prompt templates, with their
+// migration notes, and verifies that none was modified without a new version.
+func runPrompts(args []string) error {
+	flags := flag.NewFlagSet("prompts", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: funcall prompts")
+		fmt.Fprintln(flags.Output(), "\nLists the versions of the built-in prompt templates, with their migration")
+		fmt.Fprintln(flags.Output(), "notes. Pin them with handler.RequestHandlerConfig.PromptVersions.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tVERSION\tFINGERPRINT\tNOTES")
+	for _, name := range prompt.Names() {
+		for _, t := range prompt.Versions(name) {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", t.Name, t.Version, t.Fingerprint[:12], t.Notes)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return prompt.Verify()
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nlpodyssey/funcallarchitect/version"
+)
+
+// runVersion prints the version of the command, with the schema and prompt
+// template versions it produces artifacts with.
+func runVersion(args []string) error {
+	flags := flag.NewFlagSet("version", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the version information as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: funcall version [-json]")
+		fmt.Fprintln(flags.Output(), "\nPrints the version, the commit and the schema version of the build.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	info := version.Get()
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	fmt.Printf("funcall %s\n", info.Version)
+	if info.Commit != "" {
+		modified := ""
+		if info.Modified {
+			modified = " (modified)"
+		}
+		fmt.Printf("commit: %s%s\n", info.Commit, modified)
+	}
+	fmt.Printf("schema: %d\n", info.Schema)
+	fmt.Printf("go: %s\n", info.GoVersion)
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ValueCodec encodes and decodes the values of a registered type to and
+// from JSON, so that they can be embedded in the persisted records.
+type ValueCodec struct {
+	Marshal   func(v any) (json.RawMessage, error)
+	Unmarshal func(data json.RawMessage) (any, error)
+}
+
+// TypedValue is the self-describing encoding of a value: Type is the name
+// of the registered type, empty for unregistered ones, which decode as
+// generic values (maps, slices, json.Number).
+type TypedValue struct {
+	Type string          `json:"type,omitempty"`
+	Data json.RawMessage `json:"data"`
+}
+
+type registeredValue struct {
+	name  string
+	codec ValueCodec
+}
+
+var values = struct {
+	sync.RWMutex
+	byName map[string]registeredValue
+	byType map[reflect.Type]registeredValue
+}{
+	byName: make(map[string]registeredValue),
+	byType: make(map[reflect.Type]registeredValue),
+}
+
+// RegisterValue registers the type T under name, encoding its values with
+// encoding/json. Like gob.Register, it is meant to be called from init
+// functions, and panics if the name or the type are already registered.
+func RegisterValue[T any](name string) {
+	RegisterValueCodec[T](name, ValueCodec{
+		Marshal: func(v any) (json.RawMessage, error) {
+			return json.Marshal(v)
+		},
+		Unmarshal: func(data json.RawMessage) (any, error) {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		},
+	})
+}
+
+// RegisterValueCodec registers the type T under name with a custom codec.
+func RegisterValueCodec[T any](name string, c ValueCodec) {
+	if name == "" {
+		panic("codec: empty value type name")
+	}
+	t := reflect.TypeFor[T]()
+	values.Lock()
+	defer values.Unlock()
+	if _, ok := values.byName[name]; ok {
+		panic(fmt.Sprintf("codec: value type name %q registered twice", name))
+	}
+	if r, ok := values.byType[t]; ok {
+		panic(fmt.Sprintf("codec: type %v already registered as %q", t, r.name))
+	}
+	r := registeredValue{name: name, codec: c}
+	values.byName[name] = r
+	values.byType[t] = r
+}
+
+// ValueType returns the registered name of the type of v, if any.
+func ValueType(v any) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	values.RLock()
+	defer values.RUnlock()
+	r, ok := values.byType[reflect.TypeOf(v)]
+	return r.name, ok
+}
+
+// EncodeValue encodes v with the codec of its type, or with encoding/json
+// when the type is not registered.
+func EncodeValue(v any) (TypedValue, error) {
+	var r registeredValue
+	var ok bool
+	if v != nil {
+		values.RLock()
+		r, ok = values.byType[reflect.TypeOf(v)]
+		values.RUnlock()
+	}
+	if !ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return TypedValue{}, fmt.Errorf("error marshaling value: %w", err)
+		}
+		return TypedValue{Data: data}, nil
+	}
+	data, err := r.codec.Marshal(v)
+	if err != nil {
+		return TypedValue{}, fmt.Errorf("error marshaling value of type %s: %w", r.name, err)
+	}
+	return TypedValue{Type: r.name, Data: data}, nil
+}
+
+// DecodeValue decodes a value encoded by EncodeValue, into its registered
+// type if any.
+func DecodeValue(tv TypedValue) (any, error) {
+	if len(tv.Data) == 0 {
+		return nil, nil
+	}
+	if tv.Type == "" {
+		var v any
+		if err := JSON.Unmarshal(tv.Data, &v); err != nil {
+			return nil, fmt.Errorf("error unmarshaling value: %w", err)
+		}
+		return v, nil
+	}
+	values.RLock()
+	r, ok := values.byName[tv.Type]
+	values.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown value type %q", tv.Type)
+	}
+	v, err := r.codec.Unmarshal(tv.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling value of type %s: %w", tv.Type, err)
+	}
+	return v, nil
+}
+
+// Generic decodes the value ignoring its type, as maps, slices and float64
+// numbers, a form that any Codec can encode.
+func (tv TypedValue) Generic() (any, error) {
+	var v any
+	if err := json.Unmarshal(tv.Data, &v); err != nil {
+		return nil, fmt.Errorf("error unmarshaling value: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,402 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fstool provides tools for listing and reading files beneath a set of
+// configured root directories, for local assistants working over document folders.
+package fstool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+const (
+	defaultMaxFileSize = 1 << 20 // 1 MiB
+	defaultMaxEntries  = 200
+)
+
+// Entry describes a file or directory in a listing.
+type Entry struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Size     int64  `json:"size"`
+	MIMEType string `json:"mime_type,omitempty"`
+}
+
+// File is the content of a text file.
+type File struct {
+	Path      string `json:"path"`
+	MIMEType  string `json:"mime_type"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+}
+
+// Tools exposes the list_directory and read_file tools over jailed roots.
+type Tools struct {
+	Roots []Root
+	// MaxFileSize is the number of bytes read from a file at most. Defaults to 1 MiB.
+	MaxFileSize int64
+	// MaxEntries limits the number of entries returned by a listing. Defaults to 200.
+	MaxEntries int
+	// AllowHidden exposes files and directories whose name starts with a dot.
+	AllowHidden bool
+}
+
+// New creates a new file system Tools instance over the given roots.
+func New(roots ...Root) *Tools {
+	return &Tools{Roots: roots, MaxFileSize: defaultMaxFileSize, MaxEntries: defaultMaxEntries}
+}
+
+func (t *Tools) AvailableTools() *tools.ToolSet {
+	rootNames := make([]string, len(t.Roots))
+	var descriptions []string
+	for i, r := range t.Roots {
+		rootNames[i] = r.Name
+		if r.Description != "" {
+			descriptions = append(descriptions, fmt.Sprintf("%s: %s", r.Name, r.Description))
+		}
+	}
+	rootDescription := "The root folder"
+	if len(descriptions) > 0 {
+		rootDescription += " (" + strings.Join(descriptions, "; ") + ")"
+	}
+	root := tools.TypeInfo{Type: "string", Description: rootDescription, Enum: rootNames}
+
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "list_directory",
+				Description: "List the files and subfolders of a folder.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"root": root,
+						"path": {Type: "string", Description: "The folder path relative to the root, e.g. reports/2024. Empty for the root itself."},
+					},
+					Required: []string{"root"},
+				},
+				Returns:  tools.TypeInfo{Type: "directory_listing_value"},
+				Volatile: true,
+			},
+			{
+				Name:        "read_file",
+				Description: "Read the content of a text file.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"root": root,
+						"path": {Type: "string", Description: "The file path relative to the root, e.g. reports/2024/summary.md"},
+					},
+					Required: []string{"root", "path"},
+				},
+				Returns:  tools.TypeInfo{Type: "file_content_value"},
+				Volatile: true,
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"directory_listing_value": {
+				Type:        "array",
+				Description: "List of directory entries.",
+				RenderAs:    tools.RenderTable,
+				Items: &tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"path": {Type: "string", Description: "Path relative to the root"},
+						"type": {Type: "string", Description: "Either file or directory"},
+					},
+				},
+			},
+			"file_content_value": {
+				Type:        "string",
+				Description: "The text content of a file",
+			},
+		},
+	}
+}
+
+func (t *Tools) RegisterWith(ec *execution.Orchestrator) error {
+	if err := validateRoots(t.Roots); err != nil {
+		return err
+	}
+	ec.RegisterFunction("list_directory", t.ListDirectory)
+	ec.RegisterFunction("read_file", t.ReadFile)
+	return nil
+}
+
+func (t *Tools) ListDirectory(_ context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	root, rel, err := t.rootAndPath(args)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	dir, err := t.resolve(root, rel)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrOutsideRoot) {
+		return notFound(rel), nil
+	}
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	progress.Send(fmt.Sprintf("Listing %s/%s...", root.Name, rel))
+
+	f, err := t.open(root, rel, dir)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrOutsideRoot) {
+		return notFound(rel), nil
+	}
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error listing directory: %w", err)
+	}
+	defer f.Close()
+	dirEntries, err := f.ReadDir(-1)
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error listing directory: %w", err)
+	}
+	sort.Slice(dirEntries, func(i, j int) bool { return dirEntries[i].Name() < dirEntries[j].Name() })
+
+	maxEntries := t.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	entries := make([]Entry, 0, len(dirEntries))
+	truncated := false
+	for _, de := range dirEntries {
+		if !t.AllowHidden && isHidden(de.Name()) {
+			continue
+		}
+		if len(entries) == maxEntries {
+			truncated = true
+			break
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		entry := Entry{Path: root.relative(filepath.Join(dir, de.Name())), Type: "file", Size: info.Size()}
+		if de.IsDir() {
+			entry.Type, entry.Size = "directory", 0
+		} else {
+			entry.MIMEType = mime.TypeByExtension(filepath.Ext(de.Name()))
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   entries,
+		FormatFunc: func() (string, error) {
+			if len(entries) == 0 {
+				return "The folder is empty.", nil
+			}
+			var b strings.Builder
+			for _, e := range entries {
+				if e.Type == "directory" {
+					fmt.Fprintf(&b, "%s/\n", e.Path)
+				} else {
+					fmt.Fprintf(&b, "%s (%d bytes)\n", e.Path, e.Size)
+				}
+			}
+			if truncated {
+				b.WriteString("...\n")
+			}
+			return b.String(), nil
+		},
+		Metadata: map[string]any{"root": root.Name, "truncated": truncated},
+	}, nil
+}
+
+func (t *Tools) ReadFile(_ context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	root, rel, err := t.rootAndPath(args)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	if rel == "" {
+		return execution.FuncResult{}, fmt.Errorf("path argument is required")
+	}
+	path, err := t.resolve(root, rel)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrOutsideRoot) {
+		return notFound(rel), nil
+	}
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	progress.Send(fmt.Sprintf("Reading %s/%s...", root.Name, rel))
+
+	f, err := t.open(root, rel, path)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrOutsideRoot) {
+		return notFound(rel), nil
+	}
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error reading file: %w", err)
+	}
+	if info.IsDir() {
+		return execution.FuncResult{}, fmt.Errorf("%s is a directory", rel)
+	}
+
+	maxSize := t.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxFileSize
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxSize))
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error reading file: %w", err)
+	}
+
+	mimeType := detectMIMEType(path, data)
+	if !isText(mimeType, data) {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return fmt.Sprintf("The file %s is not a text file (%s) and cannot be read.", rel, mimeType), nil
+			},
+		}, nil
+	}
+
+	file := File{
+		Path:      root.relative(path),
+		MIMEType:  mimeType,
+		Content:   strings.ToValidUTF8(string(data), ""),
+		Truncated: info.Size() > maxSize,
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   file.Content,
+		FormatFunc: func() (string, error) {
+			return file.Content, nil
+		},
+		Metadata: file,
+	}, nil
+}
+
+func (t *Tools) rootAndPath(args map[string]interface{}) (Root, string, error) {
+	name, _ := args["root"].(string)
+	rel, _ := args["path"].(string)
+	rel = strings.Trim(rel, "/")
+
+	if name == "" && len(t.Roots) == 1 {
+		return t.Roots[0], rel, nil
+	}
+	for _, r := range t.Roots {
+		if r.Name == name {
+			return r, rel, nil
+		}
+	}
+	return Root{}, "", fmt.Errorf("unknown root %q", name)
+}
+
+// resolve resolves the path in the root. Unless AllowHidden is set, hidden
+// files do not exist, whether named by rel or reached through symbolic links.
+func (t *Tools) resolve(root Root, rel string) (string, error) {
+	if !t.AllowHidden && hasHiddenComponent(rel) {
+		return "", fs.ErrNotExist
+	}
+	path, err := root.resolve(rel)
+	if err != nil {
+		return "", err
+	}
+	if !t.AllowHidden && hasHiddenComponent(root.relative(path)) {
+		return "", fs.ErrNotExist
+	}
+	return path, nil
+}
+
+// open opens the path resolved for rel, checking that the file opened is still
+// the one found under the root, in case a symbolic link was swapped in between.
+func (t *Tools) open(root Root, rel, path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	opened, err := f.Stat()
+	if err == nil {
+		var again string
+		if again, err = t.resolve(root, rel); err == nil {
+			var resolved fs.FileInfo
+			if resolved, err = os.Lstat(again); err == nil && (again != path || !os.SameFile(opened, resolved)) {
+				err = ErrOutsideRoot
+			}
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func hasHiddenComponent(rel string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if part != "." && part != ".." && isHidden(part) {
+			return true
+		}
+	}
+	return false
+}
+
+func notFound(rel string) execution.FuncResult {
+	return execution.FuncResult{
+		Present: false,
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("%s not found", rel), nil
+		},
+	}
+}
+
+// detectMIMEType uses the file extension, falling back to content sniffing.
+func detectMIMEType(path string, data []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		if mediaType, _, err := mime.ParseMediaType(t); err == nil {
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
+
+func isText(mimeType string, data []byte) bool {
+	switch {
+	case strings.HasPrefix(mimeType, "text/"):
+		return true
+	case mimeType == "application/json", mimeType == "application/xml",
+		mimeType == "application/yaml", mimeType == "application/x-yaml",
+		mimeType == "application/javascript", mimeType == "application/toml":
+		return true
+	case mimeType == "application/octet-stream":
+		// Unknown extensions: accept valid UTF-8 without NUL bytes
+		return utf8.Valid(data) && !strings.ContainsRune(string(data), 0)
+	}
+	return false
+}
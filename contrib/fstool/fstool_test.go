@@ -0,0 +1,151 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fstool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		base, path string
+		want       bool
+	}{
+		{"/srv/docs", "/srv/docs", true},
+		{"/srv/docs", "/srv/docs/a.txt", true},
+		{"/srv/docs", "/srv/docs-old/a.txt", false},
+		{"/srv/docs", "/srv", false},
+		{"/", "/", true},
+		{"/", "/etc/hosts", true},
+	}
+	for _, tt := range tests {
+		if got := within(filepath.FromSlash(tt.base), filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("within(%q, %q) = %v, want %v", tt.base, tt.path, got, tt.want)
+		}
+	}
+}
+
+// newTestRoot creates a root with a public file, and symbolic links with
+// plain names to a hidden file and a hidden directory.
+func newTestRoot(t *testing.T) Root {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{"notes.txt": "public", ".env": "SECRET=1", ".ssh/id_rsa": "key"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(".env", filepath.Join(dir, "config.txt")); err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+	if err := os.Symlink(".ssh", filepath.Join(dir, "keys")); err != nil {
+		t.Fatal(err)
+	}
+	return Root{Name: "docs", Dir: dir}
+}
+
+func TestHiddenFilesAreNotReachableThroughSymlinks(t *testing.T) {
+	ft := New(newTestRoot(t))
+	ctx := context.Background()
+
+	for _, path := range []string{".env", "config.txt", "keys/id_rsa"} {
+		result, err := ft.ReadFile(ctx, map[string]any{"path": path}, &progress.NoOp{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Present {
+			t.Errorf("read_file %s returned the hidden file: %v", path, result.Value)
+		}
+	}
+	result, err := ft.ListDirectory(ctx, map[string]any{"path": "keys"}, &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Present {
+		t.Errorf("list_directory keys listed the hidden directory: %v", result.Value)
+	}
+
+	result, err = ft.ReadFile(ctx, map[string]any{"path": "notes.txt"}, &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Value != "public" {
+		t.Errorf("read_file notes.txt returned %v, want public", result.Value)
+	}
+}
+
+func TestAllowHiddenFollowsSymlinks(t *testing.T) {
+	ft := New(newTestRoot(t))
+	ft.AllowHidden = true
+	result, err := ft.ReadFile(context.Background(), map[string]any{"path": "config.txt"}, &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Value != "SECRET=1" {
+		t.Errorf("read_file config.txt returned %v, want the linked file", result.Value)
+	}
+}
+
+func TestFileToolsAreVolatile(t *testing.T) {
+	for _, def := range New(Root{Name: "docs", Dir: t.TempDir()}).AvailableTools().Functions {
+		if !def.Volatile {
+			t.Errorf("%s is memoized", def.Name)
+		}
+	}
+}
+
+func TestOpenRejectsSymlinksSwappedAfterTheResolution(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	for _, d := range []string{filepath.Join(dir, "reports"), outside} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, "summary.txt"), []byte(d), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := Root{Name: "docs", Dir: dir}
+	ft := New(root)
+	path, err := ft.resolve(root, "reports/summary.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The directory is replaced by a link leading outside of the root
+	if err := os.Rename(filepath.Join(dir, "reports"), filepath.Join(dir, "reports-old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "reports")); err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+
+	if f, err := ft.open(root, "reports/summary.txt", path); err == nil {
+		f.Close()
+		t.Fatal("opened the file outside of the root")
+	}
+	if f, err := ft.open(root, "reports", filepath.Dir(path)); err == nil {
+		f.Close()
+		t.Fatal("opened the directory outside of the root")
+	}
+}
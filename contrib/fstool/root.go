@@ -0,0 +1,124 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fstool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideRoot is returned when a path escapes its root directory.
+var ErrOutsideRoot = errors.New("path is outside of the root directory")
+
+// Root is a directory whose content is exposed to the tools.
+type Root struct {
+	// Name identifies the root in tool arguments, e.g. "documents".
+	Name string
+	// Dir is the directory on the local file system.
+	Dir string
+	// Description tells the planner what the root contains.
+	Description string
+}
+
+// resolve maps a path relative to the root to an absolute path, making sure that
+// neither ".." components nor symbolic links lead outside of the root.
+func (r Root) resolve(rel string) (string, error) {
+	base, err := r.base()
+	if err != nil {
+		return "", err
+	}
+
+	// Clean the path as if it were absolute, so that ".." cannot climb above the root
+	cleaned := filepath.Clean(string(filepath.Separator) + filepath.FromSlash(rel))
+	target := filepath.Join(base, cleaned)
+
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", err
+	}
+	if !within(base, resolved) {
+		return "", ErrOutsideRoot
+	}
+	return resolved, nil
+}
+
+// relative returns the path of abs relative to the root, using forward slashes.
+func (r Root) relative(abs string) string {
+	base, err := r.base()
+	if err != nil {
+		return filepath.Base(abs)
+	}
+	rel, err := filepath.Rel(base, abs)
+	if err != nil {
+		return filepath.Base(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// base returns the absolute path of the root, with symbolic links evaluated.
+func (r Root) base() (string, error) {
+	abs, err := filepath.Abs(r.Dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid root %s: %w", r.Name, err)
+	}
+	base, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("invalid root %s: %w", r.Name, err)
+	}
+	return base, nil
+}
+
+func within(base, path string) bool {
+	if path == base {
+		return true
+	}
+	// The file system root already ends with a separator
+	prefix := base
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, prefix)
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+func validateRoots(roots []Root) error {
+	if len(roots) == 0 {
+		return fmt.Errorf("fstool: at least one root is required")
+	}
+	seen := make(map[string]bool)
+	for _, r := range roots {
+		if r.Name == "" {
+			return fmt.Errorf("fstool: root name is required")
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("fstool: duplicate root name %q", r.Name)
+		}
+		seen[r.Name] = true
+		info, err := os.Stat(r.Dir)
+		if err != nil {
+			return fmt.Errorf("fstool: invalid root %s: %w", r.Name, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("fstool: root %s is not a directory", r.Name)
+		}
+	}
+	return nil
+}
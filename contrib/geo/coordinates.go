@@ -0,0 +1,93 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/nlpodyssey/funcallarchitect/codec"
+)
+
+const earthRadiusKm = 6371.0088
+
+func init() {
+	codec.RegisterValue[Coordinates]("geo.Coordinates")
+}
+
+// Coordinates represents a location on the Earth, matching the coordinates_value type.
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Validate checks that the coordinates are within the valid ranges.
+func (c Coordinates) Validate() error {
+	if math.IsNaN(c.Lat) || c.Lat < -90 || c.Lat > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", c.Lat)
+	}
+	if math.IsNaN(c.Lon) || c.Lon < -180 || c.Lon > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", c.Lon)
+	}
+	return nil
+}
+
+// Round returns the coordinates rounded to the given number of decimal places.
+// Two decimal places are about one kilometer.
+func (c Coordinates) Round(decimals int) Coordinates {
+	scale := math.Pow10(decimals)
+	return Coordinates{
+		Lat: math.Round(c.Lat*scale) / scale,
+		Lon: math.Round(c.Lon*scale) / scale,
+	}
+}
+
+// Distance returns the great-circle distance in kilometers between two points,
+// computed with the haversine formula.
+func Distance(a, b Coordinates) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat, dLon := lat2-lat1, radians(b.Lon-a.Lon)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// CoordinatesArg decodes a coordinates_value argument, which is either the
+// Coordinates returned by another function or a JSON object produced by the planner.
+func CoordinatesArg(args map[string]interface{}, name string) (Coordinates, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return Coordinates{}, fmt.Errorf("%s argument is required", name)
+	case Coordinates:
+		return v, v.Validate()
+	case *Coordinates:
+		return *v, v.Validate()
+	}
+
+	data, err := json.Marshal(args[name])
+	if err != nil {
+		return Coordinates{}, fmt.Errorf("error marshalling %s: %w", name, err)
+	}
+	var c Coordinates
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Coordinates{}, fmt.Errorf("invalid %s argument: %w", name, err)
+	}
+	return c, c.Validate()
+}
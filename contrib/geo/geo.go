@@ -0,0 +1,314 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package geo provides geographic tools sharing the coordinates_value type:
+// geocoding, reverse geocoding, distances and timezones.
+package geo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// CoordinatesType is the name of the type shared by the geo tools,
+// which other Tools can use in their own definitions to accept coordinates.
+const CoordinatesType = "coordinates_value"
+
+// CoordinatesTypeInfo is the definition of CoordinatesType.
+var CoordinatesTypeInfo = tools.TypeInfo{
+	Type: "object",
+	Properties: map[string]tools.TypeInfo{
+		"lat": {Type: "number", Description: "Latitude of the location"},
+		"lon": {Type: "number", Description: "Longitude of the location"},
+	},
+	Required: []string{"lat", "lon"},
+	RenderAs: tools.RenderMapPoint,
+}
+
+// Tools exposes the get_coordinates, reverse_geocode, distance_between and
+// get_timezone tools. The distance tool is always available; the others
+// are advertised only when their backend is set.
+type Tools struct {
+	Geocoder Geocoder
+	Timezone TimezoneResolver
+	// Now returns the current time, used to report timezone offsets. Defaults to time.Now.
+	Now func() time.Time
+	// MemoPrecision, if positive, enables the semantic deduplication of the calls:
+	// city names are compared ignoring case and spacing, and coordinates once
+	// rounded to MemoPrecision decimal places.
+	MemoPrecision int
+}
+
+// New creates geo Tools backed by the public Nominatim and Open-Meteo APIs.
+func New() *Tools {
+	return &Tools{
+		Geocoder: &Nominatim{},
+		Timezone: &OpenMeteoTimezone{},
+	}
+}
+
+func (t *Tools) AvailableTools() *tools.ToolSet {
+	coordinatesParam := func(description string) tools.TypeInfo {
+		return tools.TypeInfo{Type: CoordinatesType, Description: description}
+	}
+
+	ts := &tools.ToolSet{
+		TypeDefinitions: map[string]tools.TypeInfo{
+			CoordinatesType: CoordinatesTypeInfo,
+			"distance_value": {
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"kilometers": {Type: "number", Description: "Great-circle distance in kilometers"},
+					"miles":      {Type: "number", Description: "Great-circle distance in miles"},
+				},
+			},
+		},
+	}
+
+	if t.Geocoder != nil {
+		ts.Functions = append(ts.Functions,
+			tools.FuncDefinition{
+				Name:        "get_coordinates",
+				Description: "Retrieve the latitude and longitude for a given location (city name or address).",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"city": {Type: "string", Description: "The city name or address, e.g. Berlin, London, 10 Downing Street"},
+					},
+					Required: []string{"city"},
+				},
+				Returns: tools.TypeInfo{Type: CoordinatesType},
+			},
+			tools.FuncDefinition{
+				Name:        "reverse_geocode",
+				Description: "Retrieve the name and address of the place at the given coordinates.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"coordinates": coordinatesParam("The latitude and longitude of the place."),
+					},
+					Required: []string{"coordinates"},
+				},
+				Returns: tools.TypeInfo{Type: "place_value"},
+			},
+		)
+		ts.TypeDefinitions["place_value"] = tools.TypeInfo{
+			Type: "object",
+			Properties: map[string]tools.TypeInfo{
+				"name":         {Type: "string", Description: "Full name and address of the place"},
+				"country":      {Type: "string", Description: "Country of the place"},
+				"country_code": {Type: "string", Description: "ISO 3166-1 alpha-2 country code"},
+				"coordinates":  {Type: CoordinatesType, Description: "Coordinates of the place"},
+			},
+		}
+	}
+
+	ts.Functions = append(ts.Functions, tools.FuncDefinition{
+		Name:        "distance_between",
+		Description: "Calculate the straight-line (great-circle) distance between two locations.",
+		Parameters: tools.TypeInfo{
+			Type: "object",
+			Properties: map[string]tools.TypeInfo{
+				"from": coordinatesParam("The coordinates of the first location."),
+				"to":   coordinatesParam("The coordinates of the second location."),
+			},
+			Required: []string{"from", "to"},
+		},
+		Returns: tools.TypeInfo{Type: "distance_value"},
+	})
+
+	if t.Timezone != nil {
+		ts.Functions = append(ts.Functions, tools.FuncDefinition{
+			Name:        "get_timezone",
+			Description: "Retrieve the timezone and current UTC offset of a location.",
+			Parameters: tools.TypeInfo{
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"coordinates": coordinatesParam("The latitude and longitude of the location."),
+				},
+				Required: []string{"coordinates"},
+			},
+			Returns: tools.TypeInfo{Type: "timezone_value"},
+		})
+		ts.TypeDefinitions["timezone_value"] = tools.TypeInfo{
+			Type:        "string",
+			Description: "IANA timezone name, e.g. Europe/Rome",
+		}
+	}
+
+	return ts
+}
+
+func (t *Tools) RegisterWith(ec *execution.Orchestrator) error {
+	if t.Geocoder != nil {
+		ec.RegisterFunction("get_coordinates", t.GetCoordinates)
+		ec.RegisterFunction("reverse_geocode", t.ReverseGeocode)
+	}
+	ec.RegisterFunction("distance_between", t.DistanceBetween)
+	if t.Timezone != nil {
+		ec.RegisterFunction("get_timezone", t.GetTimezone)
+	}
+	if t.MemoPrecision > 0 {
+		ec.RegisterKeyNormalizer("get_coordinates", execution.NormalizeText("city"))
+		ec.RegisterKeyNormalizer("reverse_geocode", RoundCoordinates(t.MemoPrecision, "coordinates"))
+		ec.RegisterKeyNormalizer("distance_between", RoundCoordinates(t.MemoPrecision, "from", "to"))
+		ec.RegisterKeyNormalizer("get_timezone", RoundCoordinates(t.MemoPrecision, "coordinates"))
+	}
+	return nil
+}
+
+// RoundCoordinates returns an execution.KeyNormalizer rounding the given coordinates
+// arguments to the given number of decimal places, so that calls about nearly the
+// same location share their memoized results.
+func RoundCoordinates(decimals int, argNames ...string) execution.KeyNormalizer {
+	return func(_ context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+		normalized := make(map[string]interface{}, len(args))
+		for k, v := range args {
+			normalized[k] = v
+		}
+		for _, name := range argNames {
+			c, err := CoordinatesArg(args, name)
+			if err != nil {
+				return nil, err
+			}
+			normalized[name] = c.Round(decimals)
+		}
+		return normalized, nil
+	}
+}
+
+func (t *Tools) GetCoordinates(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	city, ok := args["city"].(string)
+	if !ok || city == "" {
+		return execution.FuncResult{}, fmt.Errorf("city argument is required")
+	}
+
+	progress.Send(fmt.Sprintf("Retrieving coordinates for %s...", city))
+
+	place, err := t.Geocoder.Geocode(ctx, city)
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error geocoding %s: %w", city, err)
+	}
+	if place == nil {
+		return notFound("Location not found"), nil
+	}
+
+	c := place.Coordinates
+	return execution.FuncResult{
+		Present: true,
+		Value:   c,
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("Latitude: %f, Longitude: %f", c.Lat, c.Lon), nil
+		},
+		Metadata: map[string]any{"name": place.Name},
+	}, nil
+}
+
+func (t *Tools) ReverseGeocode(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	c, err := CoordinatesArg(args, "coordinates")
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	progress.Send(fmt.Sprintf("Retrieving the place at %f, %f...", c.Lat, c.Lon))
+
+	place, err := t.Geocoder.Reverse(ctx, c)
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error reverse geocoding %f, %f: %w", c.Lat, c.Lon, err)
+	}
+	if place == nil {
+		return notFound(fmt.Sprintf("No place found at %f, %f", c.Lat, c.Lon)), nil
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   *place,
+		FormatFunc: func() (string, error) {
+			return place.Name, nil
+		},
+	}, nil
+}
+
+func (t *Tools) DistanceBetween(_ context.Context, args map[string]interface{}, _ progress.Stream) (execution.FuncResult, error) {
+	from, err := CoordinatesArg(args, "from")
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	to, err := CoordinatesArg(args, "to")
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	km := Distance(from, to)
+	miles := km / 1.609344
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   map[string]float64{"kilometers": km, "miles": miles},
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("Distance: %.1f km (%.1f mi)", km, miles), nil
+		},
+	}, nil
+}
+
+func (t *Tools) GetTimezone(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	c, err := CoordinatesArg(args, "coordinates")
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	progress.Send(fmt.Sprintf("Retrieving the timezone of %f, %f...", c.Lat, c.Lon))
+
+	loc, err := t.Timezone.Timezone(ctx, c)
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error resolving timezone of %f, %f: %w", c.Lat, c.Lon, err)
+	}
+
+	now := time.Now
+	if t.Now != nil {
+		now = t.Now
+	}
+	abbreviation, offset := now().In(loc).Zone()
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   loc.String(),
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("Timezone: %s (%s, UTC%s)", loc, abbreviation, formatOffset(offset)), nil
+		},
+		Metadata: map[string]any{"utc_offset_seconds": offset},
+	}, nil
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+func notFound(message string) execution.FuncResult {
+	return execution.FuncResult{
+		Present: false,
+		FormatFunc: func() (string, error) {
+			return message, nil
+		},
+	}
+}
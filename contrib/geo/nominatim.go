@@ -0,0 +1,145 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+const (
+	defaultNominatimURL = "https://nominatim.openstreetmap.org"
+	defaultUserAgent    = "funcallarchitect-geo/1.0"
+)
+
+// Place represents a geocoded location.
+type Place struct {
+	Name        string      `json:"name"`
+	Country     string      `json:"country,omitempty"`
+	CountryCode string      `json:"country_code,omitempty"`
+	Coordinates Coordinates `json:"coordinates"`
+}
+
+// Geocoder resolves place names to coordinates and vice versa.
+// Both methods return a nil Place when nothing is found.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (*Place, error)
+	Reverse(ctx context.Context, c Coordinates) (*Place, error)
+}
+
+// Nominatim is a Geocoder backed by the OpenStreetMap Nominatim API.
+// The public instance requires an identifying UserAgent and at most one request per second.
+type Nominatim struct {
+	BaseURL   string
+	UserAgent string
+	Client    *http.Client
+}
+
+type nominatimPlace struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	DisplayName string `json:"display_name"`
+	Address     struct {
+		Country     string `json:"country"`
+		CountryCode string `json:"country_code"`
+	} `json:"address"`
+	Error string `json:"error"`
+}
+
+func (n *Nominatim) Geocode(ctx context.Context, query string) (*Place, error) {
+	params := url.Values{"q": {query}, "format": {"json"}, "addressdetails": {"1"}, "limit": {"1"}}
+
+	var places []nominatimPlace
+	if err := n.get(ctx, "/search", params, &places); err != nil {
+		return nil, err
+	}
+	if len(places) == 0 {
+		return nil, nil
+	}
+	return places[0].toPlace()
+}
+
+func (n *Nominatim) Reverse(ctx context.Context, c Coordinates) (*Place, error) {
+	params := url.Values{
+		"lat":    {strconv.FormatFloat(c.Lat, 'f', -1, 64)},
+		"lon":    {strconv.FormatFloat(c.Lon, 'f', -1, 64)},
+		"format": {"json"},
+	}
+
+	var place nominatimPlace
+	if err := n.get(ctx, "/reverse", params, &place); err != nil {
+		return nil, err
+	}
+	if place.Error != "" {
+		// Nominatim reports "Unable to geocode" for oceans and other unnamed places
+		return nil, nil
+	}
+	return place.toPlace()
+}
+
+func (n *Nominatim) get(ctx context.Context, path string, params url.Values, v any) error {
+	baseURL := n.BaseURL
+	if baseURL == "" {
+		baseURL = defaultNominatimURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	userAgent := n.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := execution.HTTPClient(ctx, n.Client).Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling nominatim: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("nominatim returned %s: %s", resp.Status, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding nominatim response: %w", err)
+	}
+	return nil
+}
+
+func (p nominatimPlace) toPlace() (*Place, error) {
+	lat, err := strconv.ParseFloat(p.Lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude %q: %w", p.Lat, err)
+	}
+	lon, err := strconv.ParseFloat(p.Lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude %q: %w", p.Lon, err)
+	}
+	return &Place{
+		Name:        p.DisplayName,
+		Country:     p.Address.Country,
+		CountryCode: p.Address.CountryCode,
+		Coordinates: Coordinates{Lat: lat, Lon: lon},
+	}, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+const defaultOpenMeteoURL = "https://api.open-meteo.com/v1/forecast"
+
+// TimezoneResolver returns the IANA timezone of a location.
+type TimezoneResolver interface {
+	Timezone(ctx context.Context, c Coordinates) (*time.Location, error)
+}
+
+// OpenMeteoTimezone is a TimezoneResolver backed by the Open-Meteo API,
+// which requires no API key.
+type OpenMeteoTimezone struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func (o *OpenMeteoTimezone) Timezone(ctx context.Context, c Coordinates) (*time.Location, error) {
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenMeteoURL
+	}
+	params := url.Values{
+		"latitude":      {strconv.FormatFloat(c.Lat, 'f', -1, 64)},
+		"longitude":     {strconv.FormatFloat(c.Lon, 'f', -1, 64)},
+		"timezone":      {"auto"},
+		"forecast_days": {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := execution.HTTPClient(ctx, o.Client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling open-meteo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("open-meteo returned %s", resp.Status)
+	}
+
+	var body struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error decoding open-meteo response: %w", err)
+	}
+	return time.LoadLocation(body.Timezone)
+}
+
+// NauticalTimezone is an offline TimezoneResolver returning the nautical
+// timezone of the longitude (a fixed offset of one hour every 15 degrees).
+// It ignores political boundaries and daylight saving time.
+type NauticalTimezone struct{}
+
+func (NauticalTimezone) Timezone(_ context.Context, c Coordinates) (*time.Location, error) {
+	offset := int(math.Round(c.Lon / 15))
+	if offset == 0 {
+		return time.UTC, nil
+	}
+	return time.FixedZone(fmt.Sprintf("UTC%+d", offset), offset*3600), nil
+}
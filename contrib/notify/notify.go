@@ -0,0 +1,186 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notify provides action-taking tools that send emails, Slack messages and
+// webhook notifications. All of them are declared as side-effect functions, so their
+// execution goes through the orchestrator approval gate and leaves an audit receipt.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Delivery describes a sent notification. It is returned as the result value and
+// metadata of the notification tools, so it ends up in the audit receipt details.
+type Delivery struct {
+	Channel   string    `json:"channel"`
+	Recipient string    `json:"recipient"`
+	MessageID string    `json:"message_id"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// Tools exposes the notification tools. A tool is advertised only when its sender is set.
+type Tools struct {
+	Email   *SMTPSender
+	Slack   *SlackWebhook
+	Webhook *Webhook
+}
+
+var deliveryType = tools.TypeInfo{
+	Type: "object",
+	Properties: map[string]tools.TypeInfo{
+		"channel":    {Type: "string", Description: "Channel used for the delivery"},
+		"recipient":  {Type: "string", Description: "Recipient of the notification"},
+		"message_id": {Type: "string", Description: "Identifier of the sent message"},
+	},
+}
+
+func (t *Tools) AvailableTools() *tools.ToolSet {
+	ts := &tools.ToolSet{
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"delivery_value": deliveryType,
+		},
+	}
+
+	if t.Email != nil {
+		ts.Functions = append(ts.Functions, tools.FuncDefinition{
+			Name:        "send_email",
+			Description: "Send an email to a recipient. Use it only when the user explicitly asks to send an email.",
+			Parameters: tools.TypeInfo{
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"to":      {Type: "string", Description: "The email address of the recipient"},
+					"subject": {Type: "string", Description: "The subject of the email"},
+					"body":    {Type: "string", Description: "The plain text content of the email"},
+				},
+				Required: []string{"to", "subject", "body"},
+			},
+			Returns:    tools.TypeInfo{Type: "delivery_value"},
+			SideEffect: true,
+		})
+	}
+
+	if t.Slack != nil {
+		ts.Functions = append(ts.Functions, tools.FuncDefinition{
+			Name:        "send_slack_message",
+			Description: "Post a message to the team Slack channel. Use it only when the user explicitly asks to notify the team on Slack.",
+			Parameters: tools.TypeInfo{
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"text": {Type: "string", Description: "The content of the message"},
+				},
+				Required: []string{"text"},
+			},
+			Returns:    tools.TypeInfo{Type: "delivery_value"},
+			SideEffect: true,
+		})
+	}
+
+	if t.Webhook != nil {
+		ts.Functions = append(ts.Functions, tools.FuncDefinition{
+			Name:        "send_notification",
+			Description: "Send a notification to the configured notification service. Use it only when the user explicitly asks to be notified.",
+			Parameters: tools.TypeInfo{
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"title":   {Type: "string", Description: "A short title for the notification"},
+					"message": {Type: "string", Description: "The content of the notification"},
+				},
+				Required: []string{"title", "message"},
+			},
+			Returns:    tools.TypeInfo{Type: "delivery_value"},
+			SideEffect: true,
+		})
+	}
+
+	return ts
+}
+
+func (t *Tools) RegisterWith(ec *execution.Orchestrator) error {
+	if t.Email == nil && t.Slack == nil && t.Webhook == nil {
+		return fmt.Errorf("notify: at least one sender is required")
+	}
+	if t.Email != nil {
+		ec.RegisterFunction("send_email", t.SendEmail)
+	}
+	if t.Slack != nil {
+		ec.RegisterFunction("send_slack_message", t.SendSlackMessage)
+	}
+	if t.Webhook != nil {
+		ec.RegisterFunction("send_notification", t.SendNotification)
+	}
+	return nil
+}
+
+func (t *Tools) SendEmail(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	to, _ := args["to"].(string)
+	subject, _ := args["subject"].(string)
+	body, _ := args["body"].(string)
+	if to == "" || body == "" {
+		return execution.FuncResult{}, fmt.Errorf("to and body arguments are required")
+	}
+
+	progress.Send(fmt.Sprintf("Sending email to %s...", to))
+	delivery, err := t.Email.Send(ctx, to, subject, body)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	return deliveryResult(delivery, fmt.Sprintf("Email sent to %s.", delivery.Recipient)), nil
+}
+
+func (t *Tools) SendSlackMessage(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	text, _ := args["text"].(string)
+	if text == "" {
+		return execution.FuncResult{}, fmt.Errorf("text argument is required")
+	}
+
+	progress.Send("Posting message to Slack...")
+	delivery, err := t.Slack.Send(ctx, text)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	return deliveryResult(delivery, "Message posted to Slack."), nil
+}
+
+func (t *Tools) SendNotification(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	title, _ := args["title"].(string)
+	message, _ := args["message"].(string)
+	if message == "" {
+		return execution.FuncResult{}, fmt.Errorf("message argument is required")
+	}
+
+	progress.Send("Sending notification...")
+	delivery, err := t.Webhook.Send(ctx, title, message)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+	return deliveryResult(delivery, "Notification sent."), nil
+}
+
+func deliveryResult(delivery Delivery, text string) execution.FuncResult {
+	return execution.FuncResult{
+		Present: true,
+		Value:   delivery,
+		FormatFunc: func() (string, error) {
+			return text, nil
+		},
+		Metadata: delivery,
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// smtpTimeout bounds the delivery of an email, dial included.
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig holds the configuration of an SMTP relay.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// AllowedDomains, if not empty, restricts recipients to these email domains.
+	AllowedDomains []string
+}
+
+// SMTPSender sends emails through an SMTP relay.
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender creates a new SMTPSender.
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	return &SMTPSender{config: config}
+}
+
+// Send delivers an email and returns its delivery details.
+func (s *SMTPSender) Send(ctx context.Context, to, subject, body string) (Delivery, error) {
+	addr, err := mail.ParseAddress(to)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("invalid recipient %q: %w", to, err)
+	}
+	if !s.domainAllowed(addr.Address) {
+		return Delivery{}, fmt.Errorf("recipient domain not allowed: %s", addr.Address)
+	}
+
+	messageID := fmt.Sprintf("<%s@%s>", randomHex(16), s.config.Host)
+	headers := []string{
+		"From: " + s.config.From,
+		"To: " + addr.String(),
+		"Subject: " + sanitizeHeader(subject),
+		"Message-ID: " + messageID,
+		"Date: " + execution.Now(ctx).Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	if err := s.sendMail(ctx, auth, addr.Address, []byte(msg)); err != nil {
+		return Delivery{}, fmt.Errorf("error sending email: %w", err)
+	}
+
+	return Delivery{
+		Channel:   "email",
+		Recipient: addr.Address,
+		MessageID: messageID,
+		SentAt:    execution.Now(ctx),
+	}, nil
+}
+
+// sendMail is smtp.SendMail within ctx: the connection is closed when ctx
+// ends, or when the delivery exceeds smtpTimeout.
+func (s *SMTPSender) sendMail(ctx context.Context, auth smtp.Auth, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port)))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	err = deliver(conn, s.config.Host, auth, s.config.From, to, msg)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		// The connection deadline is the one of ctx, which may not be marked done yet
+		<-ctx.Done()
+	}
+	if err != nil && ctx.Err() != nil {
+		// The network error is the result of closing the connection
+		return context.Cause(ctx)
+	}
+	return err
+}
+
+// deliver sends the message over conn, as smtp.SendMail does.
+func deliver(conn net.Conn, host string, auth smtp.Auth, from, to string, msg []byte) error {
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s *SMTPSender) domainAllowed(address string) bool {
+	if len(s.config.AllowedDomains) == 0 {
+		return true
+	}
+	_, domain, _ := strings.Cut(address, "@")
+	for _, allowed := range s.config.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeHeader prevents header injection through newlines.
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
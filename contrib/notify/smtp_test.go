@@ -0,0 +1,119 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// serveSMTP answers one SMTP session on l, sending the message received to messages.
+func serveSMTP(l net.Listener, messages chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch verb, _, _ := strings.Cut(line, " "); strings.ToUpper(verb) {
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			messages <- strings.Join(data, "\n")
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+func newTestSender(t *testing.T, l net.Listener) *SMTPSender {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	p, err := net.LookupPort("tcp", port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSMTPSender(SMTPConfig{Host: host, Port: p, From: "bot@example.com"})
+}
+
+func TestSMTPSenderUsesTheClockOfTheRequest(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	messages := make(chan string, 1)
+	go serveSMTP(l, messages)
+
+	frozen := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	ctx := execution.WithClock(context.Background(), func() time.Time { return frozen })
+	delivery, err := newTestSender(t, l).Send(ctx, "alice@example.com", "Hello", "Hi Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !delivery.SentAt.Equal(frozen) {
+		t.Errorf("SentAt is %v, want %v", delivery.SentAt, frozen)
+	}
+	if msg := <-messages; !strings.Contains(msg, "Date: "+frozen.Format(time.RFC1123Z)) {
+		t.Errorf("the message does not carry the date of the clock:\n%s", msg)
+	}
+}
+
+func TestSMTPSenderHonoursTheContext(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// The server accepts the connection but never greets
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			_, _ = io.Copy(io.Discard, conn)
+			conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err = newTestSender(t, l).Send(ctx, "alice@example.com", "Hello", "Hi Alice")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Fatalf("Send returned after %v", elapsed)
+	}
+}
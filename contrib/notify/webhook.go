@@ -0,0 +1,119 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// Webhook posts JSON notifications to a fixed URL.
+// When Secret is set, the body is signed with HMAC-SHA256 in the X-Signature-256 header.
+type Webhook struct {
+	URL    string
+	Secret string
+	client *http.Client
+}
+
+// NewWebhook creates a new Webhook notifier.
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{URL: url, Secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send posts the notification and returns its delivery details.
+func (w *Webhook) Send(ctx context.Context, title, message string) (Delivery, error) {
+	id := randomHex(16)
+	body, err := json.Marshal(map[string]string{
+		"id":      id,
+		"title":   title,
+		"message": message,
+	})
+	if err != nil {
+		return Delivery{}, fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	headers := map[string]string{}
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		headers["X-Signature-256"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	if err := postJSON(ctx, w.client, w.URL, body, headers); err != nil {
+		return Delivery{}, err
+	}
+
+	return Delivery{Channel: "webhook", Recipient: w.URL, MessageID: id, SentAt: execution.Now(ctx)}, nil
+}
+
+// SlackWebhook posts messages to a Slack channel through an incoming webhook.
+type SlackWebhook struct {
+	URL     string
+	Channel string
+	client  *http.Client
+}
+
+// NewSlackWebhook creates a new SlackWebhook. The channel is only used in delivery receipts,
+// since incoming webhooks are bound to a channel.
+func NewSlackWebhook(url, channel string) *SlackWebhook {
+	return &SlackWebhook{URL: url, Channel: channel, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send posts the message and returns its delivery details.
+func (s *SlackWebhook) Send(ctx context.Context, text string) (Delivery, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return Delivery{}, fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	if err := postJSON(ctx, s.client, s.URL, body, nil); err != nil {
+		return Delivery{}, err
+	}
+
+	return Delivery{Channel: "slack", Recipient: s.Channel, MessageID: randomHex(16), SentAt: execution.Now(ctx)}, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := execution.HTTPClient(ctx, client).Do(req)
+	if err != nil {
+		return fmt.Errorf("error making webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook error response: %s: %s", resp.Status, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ragtool
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tableNamePattern matches the table names, optionally qualified by schema,
+// that are safe to interpolate in the queries.
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// PgVectorStore is a VectorStore backed by a PostgreSQL table using the pgvector extension.
+//
+// The table is expected to have the following layout:
+//
+//	CREATE TABLE <table> (
+//	    id          TEXT PRIMARY KEY,
+//	    document_id TEXT NOT NULL,
+//	    source      TEXT NOT NULL,
+//	    text        TEXT NOT NULL,
+//	    metadata    JSONB,
+//	    embedding   VECTOR(<dimensions>)
+//	);
+//
+// The caller is responsible for opening the *sql.DB with a PostgreSQL driver.
+// Table is a plain identifier, optionally qualified by schema, e.g. public.chunks.
+type PgVectorStore struct {
+	DB    *sql.DB
+	Table string
+}
+
+// NewPgVectorStore creates a new PgVectorStore on the given table.
+func NewPgVectorStore(db *sql.DB, table string) *PgVectorStore {
+	return &PgVectorStore{DB: db, Table: table}
+}
+
+// Upsert inserts or replaces the given chunks.
+func (s *PgVectorStore) Upsert(ctx context.Context, chunks []Chunk) error {
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (id, document_id, source, text, metadata, embedding)
+VALUES ($1, $2, $3, $4, $5, $6::vector)
+ON CONFLICT (id) DO UPDATE SET
+	document_id = EXCLUDED.document_id,
+	source = EXCLUDED.source,
+	text = EXCLUDED.text,
+	metadata = EXCLUDED.metadata,
+	embedding = EXCLUDED.embedding`, table)
+
+	for _, c := range chunks {
+		metadata, err := json.Marshal(c.Metadata)
+		if err != nil {
+			return fmt.Errorf("error marshaling metadata for chunk %s: %w", c.ID, err)
+		}
+		if _, err := s.DB.ExecContext(ctx, query, c.ID, c.DocumentID, c.Source, c.Text, metadata, formatVector(c.Vector)); err != nil {
+			return fmt.Errorf("error upserting chunk %s: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// Search returns the topK chunks closest to the vector by cosine distance.
+func (s *PgVectorStore) Search(ctx context.Context, vector []float32, topK int) ([]Match, error) {
+	table, err := s.table()
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`SELECT id, document_id, source, text, metadata, 1 - (embedding <=> $1::vector) AS score
+FROM %s
+ORDER BY embedding <=> $1::vector
+LIMIT $2`, table)
+
+	rows, err := s.DB.QueryContext(ctx, query, formatVector(vector), topK)
+	if err != nil {
+		return nil, fmt.Errorf("error querying pgvector: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []Match
+	for rows.Next() {
+		var m Match
+		var metadata []byte
+		if err := rows.Scan(&m.Chunk.ID, &m.Chunk.DocumentID, &m.Chunk.Source, &m.Chunk.Text, &metadata, &m.Score); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &m.Chunk.Metadata); err != nil {
+				return nil, fmt.Errorf("error unmarshaling metadata for chunk %s: %w", m.Chunk.ID, err)
+			}
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// table returns the name of the table, which the queries interpolate, after
+// checking that it is a plain identifier.
+func (s *PgVectorStore) table() (string, error) {
+	if !tableNamePattern.MatchString(s.Table) {
+		return "", fmt.Errorf("invalid pgvector table name %q", s.Table)
+	}
+	return s.Table, nil
+}
+
+// formatVector renders a vector using the pgvector text representation, e.g. "[1,2,3]".
+func formatVector(v []float32) string {
+	parts := make([]string, len(v))
+	for i, x := range v {
+		parts[i] = strconv.FormatFloat(float64(x), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
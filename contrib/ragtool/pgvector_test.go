@@ -0,0 +1,41 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ragtool
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPgVectorStoreRejectsUnsafeTableNames(t *testing.T) {
+	for _, table := range []string{"chunks", "public.chunks", "_chunks_2"} {
+		if _, err := NewPgVectorStore(nil, table).table(); err != nil {
+			t.Errorf("table %q rejected: %v", table, err)
+		}
+	}
+	for _, table := range []string{"", "chunks; DROP TABLE users", "chunks--", `"chunks"`, "2chunks", "a.b.c", "public."} {
+		s := NewPgVectorStore(nil, table)
+		if _, err := s.table(); err == nil {
+			t.Errorf("table %q accepted", table)
+		}
+		// The queries are not sent
+		if err := s.Upsert(context.Background(), []Chunk{{ID: "1"}}); err == nil {
+			t.Errorf("upsert into table %q accepted", table)
+		}
+		if _, err := s.Search(context.Background(), []float32{1}, 1); err == nil {
+			t.Errorf("search in table %q accepted", table)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ragtool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// QdrantStore is a VectorStore backed by a Qdrant collection, accessed through its REST API.
+// Chunk fields are stored in the point payload.
+type QdrantStore struct {
+	Endpoint   string
+	Collection string
+	APIKey     string
+	client     *http.Client
+}
+
+// NewQdrantStore creates a new QdrantStore for the given endpoint (e.g. http://localhost:6333) and collection.
+func NewQdrantStore(endpoint, collection, apiKey string) *QdrantStore {
+	return &QdrantStore{
+		Endpoint:   strings.TrimRight(endpoint, "/"),
+		Collection: collection,
+		APIKey:     apiKey,
+		client:     &http.Client{},
+	}
+}
+
+type qdrantPayload struct {
+	ChunkID    string            `json:"chunk_id"`
+	DocumentID string            `json:"document_id"`
+	Source     string            `json:"source"`
+	Text       string            `json:"text"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// Upsert inserts or replaces the given chunks.
+// Qdrant point IDs must be unsigned integers or UUIDs, so chunk IDs are expected to be UUIDs.
+func (s *QdrantStore) Upsert(ctx context.Context, chunks []Chunk) error {
+	type point struct {
+		ID      string        `json:"id"`
+		Vector  []float32     `json:"vector"`
+		Payload qdrantPayload `json:"payload"`
+	}
+
+	points := make([]point, len(chunks))
+	for i, c := range chunks {
+		points[i] = point{
+			ID:     c.ID,
+			Vector: c.Vector,
+			Payload: qdrantPayload{
+				ChunkID:    c.ID,
+				DocumentID: c.DocumentID,
+				Source:     c.Source,
+				Text:       c.Text,
+				Metadata:   c.Metadata,
+			},
+		}
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points?wait=true", s.Endpoint, s.Collection)
+	return s.do(ctx, http.MethodPut, url, map[string]any{"points": points}, nil)
+}
+
+// Search returns the topK chunks most similar to the vector.
+func (s *QdrantStore) Search(ctx context.Context, vector []float32, topK int) ([]Match, error) {
+	var response struct {
+		Result []struct {
+			Score   float64       `json:"score"`
+			Payload qdrantPayload `json:"payload"`
+		} `json:"result"`
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/search", s.Endpoint, s.Collection)
+	request := map[string]any{
+		"vector":       vector,
+		"limit":        topK,
+		"with_payload": true,
+	}
+	if err := s.do(ctx, http.MethodPost, url, request, &response); err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, len(response.Result))
+	for i, r := range response.Result {
+		matches[i] = Match{
+			Chunk: Chunk{
+				ID:         r.Payload.ChunkID,
+				DocumentID: r.Payload.DocumentID,
+				Source:     r.Payload.Source,
+				Text:       r.Payload.Text,
+				Metadata:   r.Payload.Metadata,
+			},
+			Score: r.Score,
+		}
+	}
+	return matches, nil
+}
+
+func (s *QdrantStore) do(ctx context.Context, method, url string, body, out any) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("api-key", s.APIKey)
+	}
+
+	resp, err := execution.HTTPClient(ctx, s.client).Do(req)
+	if err != nil {
+		return fmt.Errorf("error making Qdrant request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("qdrant error response: %s: %s", resp.Status, respBody)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+	return nil
+}
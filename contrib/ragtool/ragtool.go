@@ -0,0 +1,189 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ragtool provides a retrieval tool that lets plans ground their answers
+// on documents indexed in a vector store.
+package ragtool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+const defaultTopK = 5
+
+// Document represents a retrieved chunk, ready to be cited in an answer.
+type Document struct {
+	Citation   int               `json:"citation"`
+	ChunkID    string            `json:"chunk_id"`
+	DocumentID string            `json:"document_id"`
+	Source     string            `json:"source"`
+	Text       string            `json:"text"`
+	Score      float64           `json:"score"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// Tools exposes the retrieve_documents tool over an Embedder and a VectorStore.
+type Tools struct {
+	Embedder llm.Embedder
+	Store    VectorStore
+	// TopK is the default number of documents to retrieve. Defaults to 5.
+	TopK int
+	// MinScore discards matches whose similarity score is lower than this value.
+	MinScore float64
+}
+
+// New creates a new retrieval Tools instance.
+func New(embedder llm.Embedder, store VectorStore) *Tools {
+	return &Tools{Embedder: embedder, Store: store, TopK: defaultTopK}
+}
+
+func (t *Tools) AvailableTools() *tools.ToolSet {
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "retrieve_documents",
+				Description: "Retrieve the passages of the knowledge base that are most relevant to a query. Use it to answer questions about the documents of the knowledge base.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"query": {Type: "string", Description: "The search query, phrased as a self-contained question or statement"},
+						"top_k": {Type: "integer", Description: "The maximum number of passages to retrieve"},
+					},
+					Required: []string{"query"},
+				},
+				Returns: tools.TypeInfo{Type: "retrieved_documents_value"},
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"retrieved_documents_value": {
+				Type:        "array",
+				Description: "List of retrieved passages with their source.",
+				Items: &tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"citation": {Type: "integer", Description: "Citation number of the passage"},
+						"source":   {Type: "string", Description: "Source of the passage"},
+						"text":     {Type: "string", Description: "Text of the passage"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (t *Tools) RegisterWith(ec *execution.Orchestrator) error {
+	if t.Embedder == nil || t.Store == nil {
+		return fmt.Errorf("ragtool: embedder and store are required")
+	}
+	ec.RegisterFunction("retrieve_documents", t.RetrieveDocuments)
+	return nil
+}
+
+// Index embeds the text of the chunks that have no vector yet and upserts them into the store.
+func (t *Tools) Index(ctx context.Context, chunks []Chunk) error {
+	for i := range chunks {
+		if len(chunks[i].Vector) > 0 {
+			continue
+		}
+		vector, err := t.Embedder.CreateEmbedding(chunks[i].Text)
+		if err != nil {
+			return fmt.Errorf("error embedding chunk %s: %w", chunks[i].ID, err)
+		}
+		chunks[i].Vector = vector
+	}
+	return t.Store.Upsert(ctx, chunks)
+}
+
+func (t *Tools) RetrieveDocuments(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	query, ok := args["query"].(string)
+	if !ok || query == "" {
+		return execution.FuncResult{}, fmt.Errorf("query argument is required")
+	}
+
+	topK := t.TopK
+	if topK <= 0 {
+		topK = defaultTopK
+	}
+	if v, ok, err := execution.IntArg(args, "top_k"); err != nil {
+		return execution.FuncResult{}, err
+	} else if ok && v > 0 {
+		topK = int(v)
+	}
+
+	progress.Send("Searching the knowledge base...")
+
+	vector, err := t.Embedder.CreateEmbedding(query)
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error embedding query: %w", err)
+	}
+
+	matches, err := t.Store.Search(ctx, vector, topK)
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("error searching vector store: %w", err)
+	}
+
+	docs := make([]Document, 0, len(matches))
+	for _, m := range matches {
+		if m.Score < t.MinScore {
+			continue
+		}
+		docs = append(docs, Document{
+			Citation:   len(docs) + 1,
+			ChunkID:    m.Chunk.ID,
+			DocumentID: m.Chunk.DocumentID,
+			Source:     m.Chunk.Source,
+			Text:       m.Chunk.Text,
+			Score:      m.Score,
+			Metadata:   m.Chunk.Metadata,
+		})
+	}
+
+	if len(docs) == 0 {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return "No relevant documents found", nil
+			},
+		}, nil
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   docs,
+		FormatFunc: func() (string, error) {
+			return formatDocuments(docs), nil
+		},
+		Metadata: docs,
+	}, nil
+}
+
+// formatDocuments renders the documents as numbered passages followed by their references.
+func formatDocuments(docs []Document) string {
+	var b strings.Builder
+	for _, d := range docs {
+		fmt.Fprintf(&b, "[%d] %s\n\n", d.Citation, strings.TrimSpace(d.Text))
+	}
+	b.WriteString("Sources:\n")
+	for _, d := range docs {
+		fmt.Fprintf(&b, "[%d] %s\n", d.Citation, d.Source)
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ragtool
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Chunk represents a piece of a document indexed in a vector store.
+type Chunk struct {
+	ID         string            `json:"id"`
+	DocumentID string            `json:"document_id"`
+	Source     string            `json:"source"`
+	Text       string            `json:"text"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Vector     []float32         `json:"-"`
+}
+
+// Match represents a chunk retrieved from a vector store along with its similarity score.
+type Match struct {
+	Chunk Chunk
+	Score float64
+}
+
+// VectorStore is the interface implemented by the vector databases backing the retrieval tool.
+type VectorStore interface {
+	// Upsert inserts or replaces the given chunks. Every chunk must carry its Vector.
+	Upsert(ctx context.Context, chunks []Chunk) error
+	// Search returns at most topK chunks ordered by decreasing similarity to the vector.
+	Search(ctx context.Context, vector []float32, topK int) ([]Match, error)
+}
+
+// MemoryStore is an in-memory VectorStore using cosine similarity.
+// It is meant for small corpora, tests and prototyping.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	chunks map[string]Chunk
+}
+
+// NewMemoryStore creates a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{chunks: make(map[string]Chunk)}
+}
+
+// Upsert inserts or replaces the given chunks.
+func (s *MemoryStore) Upsert(_ context.Context, chunks []Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range chunks {
+		s.chunks[c.ID] = c
+	}
+	return nil
+}
+
+// Search returns the topK chunks most similar to the vector.
+func (s *MemoryStore) Search(_ context.Context, vector []float32, topK int) ([]Match, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := make([]Match, 0, len(s.chunks))
+	for _, c := range s.chunks {
+		matches = append(matches, Match{Chunk: c, Score: cosineSimilarity(vector, c.Vector)})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score == matches[j].Score {
+			return matches[i].Chunk.ID < matches[j].Chunk.ID
+		}
+		return matches[i].Score > matches[j].Score
+	})
+
+	if topK > 0 && len(matches) > topK {
+		matches = matches[:topK]
+	}
+	return matches, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
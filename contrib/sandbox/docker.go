@@ -0,0 +1,141 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// killTimeout bounds the kill of a container that timed out.
+const killTimeout = 10 * time.Second
+
+// ContainerImage describes how to run a language inside a container.
+type ContainerImage struct {
+	Image string
+	// Command runs the program passed as its last argument, e.g. ["python3", "-c"]:
+	// the code is appended to it, and the snippet input is on standard input.
+	Command []string
+}
+
+// DefaultContainerImages returns the images used by NewDockerRunner.
+func DefaultContainerImages() map[string]ContainerImage {
+	return map[string]ContainerImage{
+		"python":     {Image: "python:3.12-alpine", Command: []string{"python3", "-c"}},
+		"javascript": {Image: "node:20-alpine", Command: []string{"node", "-e"}},
+	}
+}
+
+// DockerRunner executes snippets in throwaway containers, without network access,
+// with a read-only filesystem and with CPU, memory and process limits.
+type DockerRunner struct {
+	// Binary is the container CLI, "docker" by default. Podman works as well.
+	Binary string
+	Images map[string]ContainerImage
+}
+
+// NewDockerRunner creates a DockerRunner using the default images.
+func NewDockerRunner() *DockerRunner {
+	return &DockerRunner{Binary: "docker", Images: DefaultContainerImages()}
+}
+
+func (r *DockerRunner) Languages() []string {
+	languages := make([]string, 0, len(r.Images))
+	for l := range r.Images {
+		languages = append(languages, l)
+	}
+	return languages
+}
+
+func (r *DockerRunner) Run(ctx context.Context, snippet Snippet, limits Limits) (Output, error) {
+	image, ok := r.Images[snippet.Language]
+	if !ok {
+		return Output{}, fmt.Errorf("unsupported language %q", snippet.Language)
+	}
+
+	name := "funcall-sandbox-" + randomSuffix()
+	args := []string{
+		"run", "--rm", "-i",
+		"--name", name,
+		"--network", "none",
+		"--read-only",
+		"--tmpfs", "/tmp:rw,size=16m",
+		"--memory", strconv.FormatInt(limits.MemoryBytes, 10),
+		"--memory-swap", strconv.FormatInt(limits.MemoryBytes, 10),
+		"--cpus", strconv.FormatFloat(limits.CPUs, 'f', -1, 64),
+		"--pids-limit", "64",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--user", "65534:65534",
+		image.Image,
+	}
+	args = append(args, image.Command...)
+	args = append(args, snippet.Code)
+
+	runCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{max: limits.MaxOutputBytes}
+	stderr := &limitedBuffer{max: limits.MaxOutputBytes}
+
+	cmd := exec.CommandContext(runCtx, r.binary(), args...)
+	cmd.Stdin = strings.NewReader(snippet.Stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+
+	out := Output{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+
+	if runCtx.Err() != nil {
+		// Killing the CLI does not stop the container
+		r.kill(ctx, name)
+		out.TimedOut = true
+		return out, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		out.ExitCode = exitErr.ExitCode()
+		return out, nil
+	}
+	if err != nil {
+		return Output{}, fmt.Errorf("error running container: %w", err)
+	}
+	return out, nil
+}
+
+// kill stops the container, giving up after killTimeout if the daemon is stuck.
+func (r *DockerRunner) kill(ctx context.Context, name string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), killTimeout)
+	defer cancel()
+	_ = exec.CommandContext(ctx, r.binary(), "kill", name).Run()
+}
+
+func (r *DockerRunner) binary() string {
+	if r.Binary == "" {
+		return "docker"
+	}
+	return r.Binary
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// fakeDocker logs its arguments, one per line and each invocation ending with
+// "---", and behaves according to FAKE_DOCKER_MODE: it echoes its input,
+// floods its output, sleeps or fails.
+const fakeDocker = `#!/bin/sh
+{ printf '%s\n' "$@"; echo ---; } >> "$FAKE_DOCKER_LOG"
+[ "$1" = kill ] && exit 0
+case "$FAKE_DOCKER_MODE" in
+flood) yes | head -c 100000 ;;
+sleep) exec sleep 10 ;;
+fail) echo boom >&2; exit 3 ;;
+*) cat ;;
+esac
+`
+
+// newFakeRunner returns a DockerRunner using fakeDocker in the given mode, and
+// a function returning the arguments of each invocation.
+func newFakeRunner(t *testing.T, mode string) (*DockerRunner, func() [][]string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake container CLI is a shell script")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "docker")
+	if err := os.WriteFile(binary, []byte(fakeDocker), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "invocations")
+	t.Setenv("FAKE_DOCKER_LOG", log)
+	t.Setenv("FAKE_DOCKER_MODE", mode)
+
+	r := NewDockerRunner()
+	r.Binary = binary
+	return r, func() [][]string {
+		data, err := os.ReadFile(log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var invocations [][]string
+		var args []string
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			if line == "---" {
+				invocations = append(invocations, args)
+				args = nil
+				continue
+			}
+			args = append(args, line)
+		}
+		return invocations
+	}
+}
+
+func testLimits() Limits {
+	return Limits{Timeout: 5 * time.Second, MemoryBytes: 64 << 20, CPUs: 0.5, MaxOutputBytes: 1000}
+}
+
+// flag returns the value following name in args.
+func flag(args []string, name string) string {
+	if i := slices.Index(args, name); i >= 0 && i+1 < len(args) {
+		return args[i+1]
+	}
+	return ""
+}
+
+func TestDockerRunnerIsolatesTheContainer(t *testing.T) {
+	r, invocations := newFakeRunner(t, "echo")
+
+	out, err := r.Run(context.Background(), Snippet{Language: "python", Code: "print(input())", Stdin: "hello"}, testLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Stdout != "hello" || out.ExitCode != 0 || out.TimedOut || out.Truncated {
+		t.Fatalf("got %+v, want the input echoed", out)
+	}
+
+	calls := invocations()
+	if len(calls) != 1 {
+		t.Fatalf("got %d invocations, want 1", len(calls))
+	}
+	args := calls[0]
+	for name, want := range map[string]string{
+		"--network":     "none",
+		"--memory":      "67108864",
+		"--memory-swap": "67108864",
+		"--cpus":        "0.5",
+		"--pids-limit":  "64",
+		"--cap-drop":    "ALL",
+		"--user":        "65534:65534",
+	} {
+		if got := flag(args, name); got != want {
+			t.Errorf("%s %q, want %q", name, got, want)
+		}
+	}
+	if !slices.Contains(args, "--read-only") {
+		t.Error("the filesystem of the container is writable")
+	}
+	if want := []string{"python:3.12-alpine", "python3", "-c", "print(input())"}; !slices.Equal(args[len(args)-4:], want) {
+		t.Errorf("the container runs %q, want %q", args[len(args)-4:], want)
+	}
+}
+
+func TestDockerRunnerTruncatesTheOutput(t *testing.T) {
+	r, _ := newFakeRunner(t, "flood")
+
+	out, err := r.Run(context.Background(), Snippet{Language: "python", Code: "..."}, testLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Stdout) != 1000 || !out.Truncated {
+		t.Fatalf("got %d bytes, truncated %v, want 1000 bytes truncated", len(out.Stdout), out.Truncated)
+	}
+}
+
+func TestDockerRunnerKillsTheContainerOnTimeout(t *testing.T) {
+	r, invocations := newFakeRunner(t, "sleep")
+	limits := testLimits()
+	limits.Timeout = 200 * time.Millisecond
+
+	started := time.Now()
+	out, err := r.Run(context.Background(), Snippet{Language: "python", Code: "while True: pass"}, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.TimedOut {
+		t.Fatalf("got %+v, want a timeout", out)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("Run returned after %v", elapsed)
+	}
+
+	calls := invocations()
+	if len(calls) != 2 {
+		t.Fatalf("got %d invocations, want the run and the kill", len(calls))
+	}
+	if name := flag(calls[0], "--name"); !slices.Equal(calls[1], []string{"kill", name}) {
+		t.Errorf("got %q, want the kill of %s", calls[1], name)
+	}
+}
+
+func TestDockerRunnerReportsTheExitCode(t *testing.T) {
+	r, _ := newFakeRunner(t, "fail")
+
+	out, err := r.Run(context.Background(), Snippet{Language: "python", Code: "exit(3)"}, testLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.ExitCode != 3 || out.Stderr != "boom\n" {
+		t.Fatalf("got %+v, want exit code 3 and the error output", out)
+	}
+}
+
+func TestExecuteCodeMatchesTheLanguage(t *testing.T) {
+	r, invocations := newFakeRunner(t, "echo")
+	tools := New(r)
+
+	result, err := tools.ExecuteCode(context.Background(), map[string]any{"language": "JavaScript", "code": "1", "input": "42"}, &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Present || result.Value != "42" {
+		t.Fatalf("got %+v, want the output of the program", result)
+	}
+	if args := invocations()[0]; !slices.Contains(args, "node:20-alpine") {
+		t.Errorf("the container runs %q, want the javascript image", args)
+	}
+
+	if _, err := tools.ExecuteCode(context.Background(), map[string]any{"language": "ruby", "code": "1"}, &progress.NoOp{}); err == nil {
+		t.Error("expected an error for an unsupported language")
+	}
+	if _, err := r.Run(context.Background(), Snippet{Language: "ruby", Code: "1"}, testLimits()); err == nil {
+		t.Error("expected the runner to reject an unsupported language")
+	}
+	if n := len(invocations()); n != 1 {
+		t.Errorf("got %d invocations, want only the javascript one", n)
+	}
+}
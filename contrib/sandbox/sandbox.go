@@ -0,0 +1,216 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sandbox provides an opt-in tool executing short code snippets in an
+// isolated runner, for data transformations that other tools cannot express.
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Snippet is a piece of code to execute.
+type Snippet struct {
+	Language string
+	Code     string
+	Stdin    string
+}
+
+// Limits bounds the resources a snippet may use.
+type Limits struct {
+	// Timeout bounds the wall-clock execution time. Defaults to 10s.
+	Timeout time.Duration
+	// MemoryBytes bounds the memory available to the snippet. Defaults to 128 MiB.
+	MemoryBytes int64
+	// CPUs bounds the CPU share (e.g. 0.5 for half a core), when the runner supports it. Defaults to 1.
+	CPUs float64
+	// MaxOutputBytes truncates stdout and stderr. Defaults to 64 KiB.
+	MaxOutputBytes int
+}
+
+// Output is the outcome of a snippet execution.
+type Output struct {
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	ExitCode  int    `json:"exit_code"`
+	TimedOut  bool   `json:"timed_out"`
+	Truncated bool   `json:"truncated"`
+}
+
+// Runner executes snippets in isolation.
+type Runner interface {
+	// Languages returns the languages the runner can execute.
+	Languages() []string
+	// Run executes the snippet within the limits. A non-zero exit code is not an error.
+	Run(ctx context.Context, snippet Snippet, limits Limits) (Output, error)
+}
+
+// DefaultLimits returns the limits applied when none are configured.
+func DefaultLimits() Limits {
+	return Limits{
+		Timeout:        10 * time.Second,
+		MemoryBytes:    128 << 20,
+		CPUs:           1,
+		MaxOutputBytes: 64 << 10,
+	}
+}
+
+func (l Limits) withDefaults() Limits {
+	d := DefaultLimits()
+	if l.Timeout <= 0 {
+		l.Timeout = d.Timeout
+	}
+	if l.MemoryBytes <= 0 {
+		l.MemoryBytes = d.MemoryBytes
+	}
+	if l.CPUs <= 0 {
+		l.CPUs = d.CPUs
+	}
+	if l.MaxOutputBytes <= 0 {
+		l.MaxOutputBytes = d.MaxOutputBytes
+	}
+	return l
+}
+
+// Tools exposes the execute_code tool over a Runner.
+type Tools struct {
+	Runner Runner
+	Limits Limits
+}
+
+// New creates a new sandbox Tools instance with default limits.
+func New(runner Runner) *Tools {
+	return &Tools{Runner: runner, Limits: DefaultLimits()}
+}
+
+func (t *Tools) AvailableTools() *tools.ToolSet {
+	var languages []string
+	if t.Runner != nil {
+		languages = append(languages, t.Runner.Languages()...)
+		sort.Strings(languages)
+	}
+
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "execute_code",
+				Description: "Execute a short program in an isolated sandbox without network access and return what it prints to standard output. Use it only for data transformations that no other function can perform.",
+				Parameters: tools.TypeInfo{
+					Type: "object",
+					Properties: map[string]tools.TypeInfo{
+						"language": {Type: "string", Description: "The programming language of the code", Enum: languages},
+						"code":     {Type: "string", Description: "The source code to execute. It must print its result to standard output."},
+						"input":    {Type: "string", Description: "Optional data passed to the program on standard input"},
+					},
+					Required: []string{"language", "code"},
+				},
+				Returns: tools.TypeInfo{Type: "code_output_value"},
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"code_output_value": {
+				Type:        "string",
+				Description: "The standard output of the executed program",
+			},
+		},
+	}
+}
+
+func (t *Tools) RegisterWith(ec *execution.Orchestrator) error {
+	if t.Runner == nil {
+		return fmt.Errorf("sandbox: runner is required")
+	}
+	ec.RegisterFunction("execute_code", t.ExecuteCode)
+	return nil
+}
+
+func (t *Tools) ExecuteCode(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	language, _ := args["language"].(string)
+	code, _ := args["code"].(string)
+	input, _ := args["input"].(string)
+	if language == "" || code == "" {
+		return execution.FuncResult{}, fmt.Errorf("language and code arguments are required")
+	}
+
+	supported := false
+	for _, l := range t.Runner.Languages() {
+		if strings.EqualFold(l, language) {
+			language, supported = l, true
+			break
+		}
+	}
+	if !supported {
+		return execution.FuncResult{}, fmt.Errorf("unsupported language %q", language)
+	}
+
+	progress.Send(fmt.Sprintf("Executing %s code in the sandbox...", language))
+
+	out, err := t.Runner.Run(ctx, Snippet{Language: language, Code: code, Stdin: input}, t.Limits.withDefaults())
+	if err != nil {
+		return execution.FuncResult{}, fmt.Errorf("sandbox error: %w", err)
+	}
+
+	if out.TimedOut || out.ExitCode != 0 {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				if out.TimedOut {
+					return "The code execution timed out.", nil
+				}
+				return fmt.Sprintf("The code execution failed with exit code %d:\n%s", out.ExitCode, out.Stderr), nil
+			},
+			Metadata: out,
+		}, nil
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   out.Stdout,
+		FormatFunc: func() (string, error) {
+			return out.Stdout, nil
+		},
+		Metadata: out,
+	}, nil
+}
+
+// limitedBuffer accumulates up to max bytes and silently discards the rest.
+type limitedBuffer struct {
+	buf       strings.Builder
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.max - b.buf.Len(); remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
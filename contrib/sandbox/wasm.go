@@ -0,0 +1,128 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+const wasmPageSize = 64 << 10
+
+// WASMInterpreter is an interpreter compiled to WebAssembly with WASI support,
+// such as python.wasm or qjs.wasm.
+type WASMInterpreter struct {
+	Module []byte
+	// Args are the command line arguments preceding the code, e.g. ["python", "-c"].
+	Args []string
+}
+
+// WASMRunner executes snippets with interpreters compiled to WebAssembly.
+// Programs have no filesystem nor network access, and their memory is capped.
+type WASMRunner struct {
+	interpreters map[string]WASMInterpreter
+	cache        wazero.CompilationCache
+}
+
+// NewWASMRunner creates a WASMRunner for the given interpreters, keyed by language.
+func NewWASMRunner(interpreters map[string]WASMInterpreter) *WASMRunner {
+	return &WASMRunner{interpreters: interpreters, cache: wazero.NewCompilationCache()}
+}
+
+func (r *WASMRunner) Languages() []string {
+	languages := make([]string, 0, len(r.interpreters))
+	for l := range r.interpreters {
+		languages = append(languages, l)
+	}
+	return languages
+}
+
+func (r *WASMRunner) Run(ctx context.Context, snippet Snippet, limits Limits) (Output, error) {
+	interpreter, ok := r.interpreters[snippet.Language]
+	if !ok {
+		return Output{}, fmt.Errorf("unsupported language %q", snippet.Language)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	config := wazero.NewRuntimeConfig().
+		WithCompilationCache(r.cache).
+		WithMemoryLimitPages(uint32(limits.MemoryBytes / wasmPageSize)).
+		WithCloseOnContextDone(true)
+
+	runtime := wazero.NewRuntimeWithConfig(runCtx, config)
+	defer runtime.Close(context.Background())
+
+	if _, err := wasi_snapshot_preview1.Instantiate(runCtx, runtime); err != nil {
+		return Output{}, fmt.Errorf("error instantiating WASI: %w", err)
+	}
+
+	compiled, err := runtime.CompileModule(runCtx, interpreter.Module)
+	if err != nil {
+		return Output{}, fmt.Errorf("error compiling interpreter: %w", err)
+	}
+
+	stdout := &limitedBuffer{max: limits.MaxOutputBytes}
+	stderr := &limitedBuffer{max: limits.MaxOutputBytes}
+
+	args := append(append([]string(nil), interpreter.Args...), snippet.Code)
+	moduleConfig := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(args...).
+		WithStdin(strings.NewReader(snippet.Stdin)).
+		WithStdout(stdout).
+		WithStderr(stderr)
+
+	_, err = runtime.InstantiateModule(runCtx, compiled, moduleConfig)
+
+	out := Output{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+
+	if runCtx.Err() != nil {
+		out.TimedOut = true
+		return out, nil
+	}
+
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) {
+		out.ExitCode = int(exitErr.ExitCode())
+		return out, nil
+	}
+	if err != nil {
+		// Traps, such as out-of-memory conditions, are reported as failed executions
+		out.ExitCode = 1
+		out.Stderr += err.Error()
+		return out, nil
+	}
+	return out, nil
+}
+
+func randomSuffix() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
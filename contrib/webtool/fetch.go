@@ -0,0 +1,244 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+const (
+	defaultUserAgent = "FunCallArchitect/1.0"
+	defaultMaxBytes  = 2 << 20 // 2 MiB
+	defaultTimeout   = 15 * time.Second
+)
+
+// ErrDisallowedByRobots is returned when robots.txt forbids fetching a URL.
+var ErrDisallowedByRobots = errors.New("fetching disallowed by robots.txt")
+
+// ErrForbiddenAddress is returned when a URL resolves to a loopback, private or link-local address.
+var ErrForbiddenAddress = errors.New("forbidden network address")
+
+// FetcherConfig holds the limits applied by the Fetcher.
+type FetcherConfig struct {
+	UserAgent string
+	// MaxBytes is the maximum number of bytes read from a response body. Defaults to 2 MiB.
+	MaxBytes int64
+	// Timeout bounds the whole fetch, including robots.txt retrieval. Defaults to 15s.
+	Timeout time.Duration
+	// IgnoreRobots disables robots.txt checks.
+	IgnoreRobots bool
+	// AllowPrivateNetworks allows fetching URLs that resolve to non-public addresses.
+	// Only then the proxy of the environment is used, since through a proxy the
+	// addresses of the targets cannot be checked.
+	AllowPrivateNetworks bool
+}
+
+// Page represents a fetched web page converted to plain text.
+type Page struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	ContentType string `json:"content_type"`
+	Text        string `json:"text"`
+	Truncated   bool   `json:"truncated"`
+}
+
+// Fetcher retrieves web pages safely, honoring robots.txt and size/time limits.
+type Fetcher struct {
+	config FetcherConfig
+	client *http.Client
+
+	robots *robotsCache
+}
+
+// NewFetcher creates a new Fetcher with the given configuration.
+func NewFetcher(config FetcherConfig) *Fetcher {
+	if config.UserAgent == "" {
+		config.UserAgent = defaultUserAgent
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultMaxBytes
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	transport := &http.Transport{DialContext: dialer.DialContext}
+	if config.AllowPrivateNetworks {
+		transport.Proxy = http.ProxyFromEnvironment
+	} else {
+		dialer.Control = rejectPrivateAddresses
+	}
+
+	return &Fetcher{
+		config: config,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return fmt.Errorf("stopped after 5 redirects")
+				}
+				return checkScheme(req.URL)
+			},
+		},
+		robots: newRobotsCache(),
+	}
+}
+
+// Fetch retrieves the page at rawURL and extracts its text.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.config.Timeout)
+	defer cancel()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if err := checkScheme(u); err != nil {
+		return nil, err
+	}
+
+	if !f.config.IgnoreRobots {
+		rules, err := f.robotsFor(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if !rules.allowed(robotsPath(u)) {
+			return nil, ErrDisallowedByRobots
+		}
+	}
+
+	resp, err := f.get(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.config.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	truncated := int64(len(body)) > f.config.MaxBytes
+	if truncated {
+		body = body[:f.config.MaxBytes]
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	page := &Page{URL: resp.Request.URL.String(), ContentType: contentType, Truncated: truncated}
+
+	switch {
+	case contentType == "text/html" || contentType == "application/xhtml+xml":
+		page.Title, page.Text, err = extractText(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("error extracting text: %w", err)
+		}
+	case strings.HasPrefix(contentType, "text/") || contentType == "application/json":
+		page.Text = string(body)
+	default:
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	return page, nil
+}
+
+func (f *Fetcher) robotsFor(ctx context.Context, u *url.URL) (robotsRules, error) {
+	key := u.Scheme + "://" + u.Host
+
+	rules, ok := f.robots.get(key, time.Now())
+	if ok {
+		return rules, nil
+	}
+
+	resp, err := f.get(ctx, key+"/robots.txt")
+	if err != nil {
+		return robotsRules{}, fmt.Errorf("error fetching robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		rules = parseRobots(io.LimitReader(resp.Body, 512<<10), f.config.UserAgent)
+	case resp.StatusCode >= 500:
+		// Server errors mean the site is temporarily unavailable: be conservative.
+		rules = robotsRules{disallow: []string{"/"}}
+	default:
+		// A missing robots.txt allows everything.
+		rules = robotsRules{}
+	}
+
+	f.robots.put(key, rules, time.Now())
+	return rules, nil
+}
+
+// robotsPath returns the path and query of u matched against the robots.txt
+// rules: the host alone stands for the root.
+func robotsPath(u *url.URL) string {
+	return u.RequestURI()
+}
+
+func (f *Fetcher) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+
+	resp, err := execution.HTTPClient(ctx, f.client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", u, err)
+	}
+	return resp, nil
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	return nil
+}
+
+// rejectPrivateAddresses is a net.Dialer Control function refusing connections
+// to non-public addresses, preventing requests to internal services.
+func rejectPrivateAddresses(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
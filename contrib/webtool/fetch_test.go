@@ -0,0 +1,182 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRobotsRulesAllowed(t *testing.T) {
+	robots := `
+User-agent: otherbot
+Disallow: /
+
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+`
+	rules := parseRobots(strings.NewReader(robots), defaultUserAgent)
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/index.html", true},
+		{"/private", false},
+		{"/private/secret", false},
+		{"/private/public/page", true},
+		{"/docs/manual.pdf", false},
+		{"/docs/manual.pdf.html", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if rules := parseRobots(strings.NewReader(robots), "OtherBot/2.0"); rules.allowed("/") {
+		t.Error("the group of the user agent was not applied")
+	}
+}
+
+func TestRobotsMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/", "/anything", true},
+		{"", "/anything", true},
+		{"/a*b", "/axxb/c", true},
+		{"/a*b$", "/axxb/c", false},
+		{"/a*b$", "/axbxb", true},
+		{"*.php?", "/index.php?id=1", true},
+		{"/*?sessionid=", "/cart?user=1", false},
+		{"/a$b", "/a$b", true},
+		{"$", "", true},
+		{"/**", "/", true},
+	}
+	for _, tt := range tests {
+		if got := robotsMatch(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("robotsMatch(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRobotsMatchHostilePatterns(t *testing.T) {
+	pattern := "/" + strings.Repeat("*a", 200) + "b$"
+	path := "/" + strings.Repeat("a", 5000)
+	started := time.Now()
+	if robotsMatch(pattern, path) {
+		t.Fatal("the pattern matched a path without b")
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("matching took %v", elapsed)
+	}
+}
+
+func TestRobotsRulesMatchTheQuery(t *testing.T) {
+	rules := parseRobots(strings.NewReader("User-agent: *\nDisallow: /*?sessionid=\n"), defaultUserAgent)
+	for rawURL, want := range map[string]bool{
+		"https://example.com/cart":              true,
+		"https://example.com/cart?sessionid=42": false,
+		"https://example.com?sessionid=42":      false,
+	} {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rules.allowed(robotsPath(u)); got != want {
+			t.Errorf("allowed(%s) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
+func TestRobotsCacheIsBounded(t *testing.T) {
+	c := newRobotsCache()
+	now := time.Now()
+	for i := range robotsCacheSize + 10 {
+		c.put(fmt.Sprintf("https://host%d", i), robotsRules{}, now)
+	}
+	if n := c.order.Len(); n != robotsCacheSize || len(c.entries) != robotsCacheSize {
+		t.Fatalf("the cache holds %d entries, want %d", n, robotsCacheSize)
+	}
+	if _, ok := c.get("https://host0", now); ok {
+		t.Error("the least recently used host was not evicted")
+	}
+	last := fmt.Sprintf("https://host%d", robotsCacheSize+9)
+	if _, ok := c.get(last, now); !ok {
+		t.Error("the most recent host was evicted")
+	}
+	if _, ok := c.get(last, now.Add(robotsCacheTTL+time.Minute)); ok {
+		t.Error("the rules did not expire")
+	}
+}
+
+func TestFetchChecksRobotsForTheRoot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /\n")
+			return
+		}
+		fmt.Fprint(w, "home")
+	}))
+	defer server.Close()
+
+	f := NewFetcher(FetcherConfig{AllowPrivateNetworks: true})
+	if _, err := f.Fetch(context.Background(), server.URL); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Fatalf("fetching the root: got %v, want %v", err, ErrDisallowedByRobots)
+	}
+}
+
+func TestFetchRejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "internal")
+	}))
+	defer server.Close()
+
+	f := NewFetcher(FetcherConfig{IgnoreRobots: true})
+	if _, err := f.Fetch(context.Background(), server.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("got %v, want %v", err, ErrForbiddenAddress)
+	}
+
+	f = NewFetcher(FetcherConfig{IgnoreRobots: true, AllowPrivateNetworks: true})
+	page, err := f.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Text != "internal" {
+		t.Fatalf("got %q, want the page of the private network", page.Text)
+	}
+}
+
+func TestRejectPrivateAddresses(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:80", "10.0.0.1:80", "192.168.1.1:443", "169.254.169.254:80", "[::1]:80", "0.0.0.0:80"} {
+		if err := rejectPrivateAddresses("tcp", address, nil); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("%s: got %v, want %v", address, err, ErrForbiddenAddress)
+		}
+	}
+	if err := rejectPrivateAddresses("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("public address rejected: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webtool
+
+import (
+	"bufio"
+	"container/list"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsRules holds the Allow/Disallow rules that apply to a user agent.
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// parseRobots parses a robots.txt file and returns the rules of the group matching
+// the user agent, falling back to the "*" group.
+func parseRobots(r io.Reader, userAgent string) robotsRules {
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+
+	var groups []*group
+	var current *group
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+		case "allow", "disallow":
+			lastWasAgent = false
+			if current == nil || value == "" {
+				continue
+			}
+			if key == "allow" {
+				current.rules.allow = append(current.rules.allow, value)
+			} else {
+				current.rules.disallow = append(current.rules.disallow, value)
+			}
+		default:
+			lastWasAgent = false
+		}
+	}
+
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexByte(token, '/'); i >= 0 {
+		token = token[:i]
+	}
+
+	var fallback *group
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if fallback == nil {
+					fallback = g
+				}
+			} else if token != "" && strings.Contains(token, agent) {
+				return g.rules
+			}
+		}
+	}
+	if fallback != nil {
+		return fallback.rules
+	}
+	return robotsRules{}
+}
+
+// allowed reports whether the path may be fetched. The longest matching rule wins,
+// and Allow wins over Disallow on ties.
+func (r robotsRules) allowed(path string) bool {
+	best, allow := -1, true
+	for _, pattern := range r.disallow {
+		if robotsMatch(pattern, path) && len(pattern) > best {
+			best, allow = len(pattern), false
+		}
+	}
+	for _, pattern := range r.allow {
+		if robotsMatch(pattern, path) && len(pattern) >= best {
+			best, allow = len(pattern), true
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches a path against a robots.txt pattern supporting the "*" and
+// "$" wildcards. Patterns are prefixes unless they end with "$". The matching
+// backtracks only to the last "*", so that it takes at most len(pattern)*len(path)
+// steps whatever the pattern of the remote robots.txt.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	p, s := 0, 0
+	star, mark := -1, 0
+	for s < len(path) {
+		switch {
+		case p == len(pattern) && !anchored:
+			return true
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, s
+			p++
+		case p < len(pattern) && pattern[p] == path[s]:
+			p++
+			s++
+		case star >= 0:
+			// Let the last "*" absorb one more byte
+			mark++
+			p, s = star+1, mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+const (
+	robotsCacheSize = 256
+	robotsCacheTTL  = 24 * time.Hour
+)
+
+// robotsCache holds the rules of the most recently used hosts, for at most
+// robotsCacheTTL.
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+}
+
+type robotsEntry struct {
+	key      string
+	rules    robotsRules
+	storedAt time.Time
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{entries: make(map[string]*list.Element), order: list.New()}
+}
+
+func (c *robotsCache) get(key string, now time.Time) (robotsRules, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return robotsRules{}, false
+	}
+	entry := e.Value.(*robotsEntry)
+	if now.Sub(entry.storedAt) > robotsCacheTTL {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		return robotsRules{}, false
+	}
+	c.order.MoveToFront(e)
+	return entry.rules, true
+}
+
+func (c *robotsCache) put(key string, rules robotsRules, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value = &robotsEntry{key: key, rules: rules, storedAt: now}
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&robotsEntry{key: key, rules: rules, storedAt: now})
+	if c.order.Len() > robotsCacheSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*robotsEntry).key)
+	}
+}
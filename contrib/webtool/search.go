@@ -0,0 +1,144 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webtool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// SearchResult represents a single web search hit.
+type SearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// SearchProvider is the interface implemented by the web search backends.
+type SearchProvider interface {
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
+
+// SearXNG is a SearchProvider querying a SearXNG instance through its JSON API.
+// The instance must have the "json" output format enabled.
+type SearXNG struct {
+	Endpoint string
+	client   *http.Client
+}
+
+// NewSearXNG creates a new SearXNG provider for the given instance URL.
+func NewSearXNG(endpoint string) *SearXNG {
+	return &SearXNG{Endpoint: strings.TrimRight(endpoint, "/"), client: &http.Client{}}
+}
+
+func (s *SearXNG) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	u := fmt.Sprintf("%s/search?q=%s&format=json", s.Endpoint, url.QueryEscape(query))
+
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getJSON(ctx, s.client, u, nil, &response); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(response.Results))
+	for _, r := range response.Results {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+// Brave is a SearchProvider backed by the Brave Search API.
+type Brave struct {
+	APIKey string
+	client *http.Client
+}
+
+// NewBrave creates a new Brave Search provider.
+func NewBrave(apiKey string) *Brave {
+	return &Brave{APIKey: apiKey, client: &http.Client{}}
+}
+
+func (b *Brave) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	u := fmt.Sprintf("https://api.search.brave.com/res/v1/web/search?q=%s", url.QueryEscape(query))
+	if limit > 0 {
+		u += fmt.Sprintf("&count=%d", limit)
+	}
+
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	headers := map[string]string{"X-Subscription-Token": b.APIKey}
+	if err := getJSON(ctx, b.client, u, headers, &response); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, len(response.Web.Results))
+	for i, r := range response.Web.Results {
+		results[i] = SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description}
+	}
+	return results, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, u string, headers map[string]string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := execution.HTTPClient(ctx, client).Do(req)
+	if err != nil {
+		return fmt.Errorf("error making search request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("search error response: %s", resp.Status)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webtool provides tools for searching the web and fetching web pages.
+package webtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+const (
+	defaultMaxResults    = 5
+	defaultMaxTextLength = 8000
+)
+
+// Tools exposes the web_search and fetch_url tools.
+// A tool is advertised only when its backend (Search or Fetcher) is set.
+type Tools struct {
+	Search  SearchProvider
+	Fetcher *Fetcher
+	// MaxResults is the default number of search results. Defaults to 5.
+	MaxResults int
+	// MaxTextLength truncates the text of fetched pages to this number of characters. Defaults to 8000.
+	MaxTextLength int
+}
+
+// New creates a new web Tools instance. Either argument may be nil to disable the corresponding tool.
+func New(search SearchProvider, fetcher *Fetcher) *Tools {
+	return &Tools{
+		Search:        search,
+		Fetcher:       fetcher,
+		MaxResults:    defaultMaxResults,
+		MaxTextLength: defaultMaxTextLength,
+	}
+}
+
+func (t *Tools) AvailableTools() *tools.ToolSet {
+	ts := &tools.ToolSet{TypeDefinitions: map[string]tools.TypeInfo{}}
+
+	if t.Search != nil {
+		ts.Functions = append(ts.Functions, tools.FuncDefinition{
+			Name:        "web_search",
+			Description: "Search the web and return a list of matching pages with title, URL and snippet. Use it for up-to-date information or topics not covered by other functions.",
+			Parameters: tools.TypeInfo{
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"query":       {Type: "string", Description: "The search query"},
+					"max_results": {Type: "integer", Description: "The maximum number of results to return"},
+				},
+				Required: []string{"query"},
+			},
+			Returns: tools.TypeInfo{Type: "web_search_results_value"},
+		})
+		ts.TypeDefinitions["web_search_results_value"] = tools.TypeInfo{
+			Type:        "array",
+			Description: "List of search results.",
+			RenderAs:    tools.RenderTable,
+			Items: &tools.TypeInfo{
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"title":   {Type: "string", Description: "Title of the page"},
+					"url":     {Type: "string", Description: "URL of the page"},
+					"snippet": {Type: "string", Description: "Short excerpt of the page"},
+				},
+			},
+		}
+	}
+
+	if t.Fetcher != nil {
+		ts.Functions = append(ts.Functions, tools.FuncDefinition{
+			Name:        "fetch_url",
+			Description: "Download a web page and return its title and readable text content. Use it only with URLs given by the user or returned by a search.",
+			Parameters: tools.TypeInfo{
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"url": {Type: "string", Description: "The absolute http or https URL of the page, e.g. https://example.com/page"},
+				},
+				Required: []string{"url"},
+			},
+			Returns: tools.TypeInfo{Type: "web_page_value"},
+		})
+		ts.TypeDefinitions["web_page_value"] = tools.TypeInfo{
+			Type: "object",
+			Properties: map[string]tools.TypeInfo{
+				"url":   {Type: "string", Description: "Final URL of the page, after redirects"},
+				"title": {Type: "string", Description: "Title of the page"},
+				"text":  {Type: "string", Description: "Readable text content of the page"},
+			},
+		}
+	}
+
+	return ts
+}
+
+func (t *Tools) RegisterWith(ec *execution.Orchestrator) error {
+	if t.Search == nil && t.Fetcher == nil {
+		return fmt.Errorf("webtool: at least one of search provider and fetcher is required")
+	}
+	if t.Search != nil {
+		ec.RegisterFunction("web_search", t.WebSearch)
+	}
+	if t.Fetcher != nil {
+		ec.RegisterFunction("fetch_url", t.FetchURL)
+	}
+	return nil
+}
+
+func (t *Tools) WebSearch(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	query, ok := args["query"].(string)
+	if !ok || query == "" {
+		return execution.FuncResult{}, fmt.Errorf("query argument is required")
+	}
+
+	limit := t.MaxResults
+	if limit <= 0 {
+		limit = defaultMaxResults
+	}
+	if v, ok, err := execution.IntArg(args, "max_results"); err != nil {
+		return execution.FuncResult{}, err
+	} else if ok && v > 0 {
+		limit = int(v)
+	}
+
+	progress.Send(fmt.Sprintf("Searching the web for %q...", query))
+
+	results, err := t.Search.Search(ctx, query, limit)
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	if len(results) == 0 {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return "No search results found", nil
+			},
+		}, nil
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   results,
+		FormatFunc: func() (string, error) {
+			var b strings.Builder
+			for i, r := range results {
+				fmt.Fprintf(&b, "%d. %s\n   %s\n   %s\n", i+1, r.Title, r.URL, r.Snippet)
+			}
+			return b.String(), nil
+		},
+	}, nil
+}
+
+func (t *Tools) FetchURL(ctx context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
+	u, ok := args["url"].(string)
+	if !ok || u == "" {
+		return execution.FuncResult{}, fmt.Errorf("url argument is required")
+	}
+
+	progress.Send(fmt.Sprintf("Fetching %s...", u))
+
+	page, err := t.Fetcher.Fetch(ctx, u)
+	if errors.Is(err, ErrDisallowedByRobots) {
+		return execution.FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				return fmt.Sprintf("The page %s cannot be accessed: the website does not allow automated access", u), nil
+			},
+		}, nil
+	}
+	if err != nil {
+		return execution.FuncResult{}, err
+	}
+
+	maxLength := t.MaxTextLength
+	if maxLength <= 0 {
+		maxLength = defaultMaxTextLength
+	}
+	if runes := []rune(page.Text); len(runes) > maxLength {
+		page.Text = string(runes[:maxLength])
+		page.Truncated = true
+	}
+
+	return execution.FuncResult{
+		Present: true,
+		Value:   page,
+		FormatFunc: func() (string, error) {
+			return fmt.Sprintf("%s (%s)\n\n%s", page.Title, page.URL, page.Text), nil
+		},
+		Metadata: map[string]any{"truncated": page.Truncated, "content_type": page.ContentType},
+	}, nil
+}
@@ -22,6 +22,7 @@ import (
 	"math"
 	"net/http"
 
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
 	"github.com/nlpodyssey/funcallarchitect/execution"
 	"github.com/nlpodyssey/funcallarchitect/progress"
 )
@@ -40,9 +41,9 @@ type WeatherData struct {
 }
 
 func (t *Tools) GetWeatherForecast(_ context.Context, args map[string]interface{}, progress progress.Stream) (execution.FuncResult, error) {
-	coordinates, err := argsToGetWeatherForecastRequest(args)
+	coordinates, err := geo.CoordinatesArg(args, "coordinates")
 	if err != nil {
-		return execution.FuncResult{}, fmt.Errorf("coordinates argument is required")
+		return execution.FuncResult{}, err
 	}
 
 	latitude, longitude := coordinates.Lat, coordinates.Lon
@@ -91,25 +92,6 @@ func (t *Tools) GetWeatherForecast(_ context.Context, args map[string]interface{
 	}, nil
 }
 
-func argsToGetWeatherForecastRequest(args map[string]interface{}) (Coordinates, error) {
-	if c, ok := args["coordinates"].(Coordinates); ok {
-		return c, nil
-	}
-
-	jsonBytes, err := json.Marshal(args)
-	if err != nil {
-		return Coordinates{}, fmt.Errorf("error marshalling args to JSON: %w", err)
-	}
-
-	var req struct {
-		Coordinates Coordinates `json:"coordinates"`
-	}
-	if err := json.Unmarshal(jsonBytes, &req); err != nil {
-		return Coordinates{}, fmt.Errorf("error unmarshalling args: %w", err)
-	}
-	return req.Coordinates, nil
-}
-
 func calculateAverage(data []float64) float64 {
 	var sum float64
 	for _, value := range data {
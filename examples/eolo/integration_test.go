@@ -0,0 +1,176 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+// The integration tests run the whole pipeline against a real model endpoint
+// and the public APIs used by the tools. They are excluded from the default build:
+//
+//	LLM_ENDPOINT=http://localhost:8000/v1/chat/completions go test -tags integration ./examples/eolo/
+//
+// LLM_API_KEY and LLM_MODEL are optional.
+
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/llamacpp"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+func newIntegrationHandler(t *testing.T) *handler.RequestHandler {
+	t.Helper()
+
+	endpoint := os.Getenv("LLM_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("LLM_ENDPOINT is not set")
+	}
+
+	h, err := handler.NewRequestHandler(handler.RequestHandlerConfig{
+		Logger: log.New(io.Discard, "", 0),
+		LLMClient: llamacpp.NewClient(llamacpp.Config{
+			APIKey:      os.Getenv("LLM_API_KEY"),
+			Model:       os.Getenv("LLM_MODEL"),
+			Endpoint:    endpoint,
+			Temperature: 0.0,
+			TopP:        0.001,
+			MaxTokens:   5000,
+			Timeout:     120 * time.Second,
+			UseGrammar:  true,
+		}),
+		Tools:                handler.ComposeTools(geo.New(), &Tools{}),
+		Timeout:              60 * time.Second,
+		EnableConcurrentExec: true,
+		DisableBuiltinTools:  true,
+	})
+	if err != nil {
+		t.Fatalf("error creating handler: %v", err)
+	}
+	return h
+}
+
+func process(t *testing.T, h *handler.RequestHandler, query string) *execution.Result {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := h.ProcessUserRequest(ctx, query, &progress.NoOp{})
+	if err != nil {
+		t.Fatalf("error processing %q: %v", query, err)
+	}
+	return result.Execution
+}
+
+// findCall returns the first main function call with the given name.
+func findCall(exec *execution.Result, name string) *execution.ExecutedFuncCall {
+	for _, f := range exec.FuncCalls {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func callNames(exec *execution.Result) []string {
+	names := make([]string, len(exec.FuncCalls))
+	for i, f := range exec.FuncCalls {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func TestIntegrationWeatherForecast(t *testing.T) {
+	exec := process(t, newIntegrationHandler(t), "What's the weather like in Turin?")
+
+	forecast := findCall(exec, "get_weather_forecast")
+	if forecast == nil {
+		t.Fatalf("expected a get_weather_forecast call, got %v", callNames(exec))
+	}
+
+	// The coordinates must come from a nested get_coordinates call
+	nested, ok := execution.GetFuncCall(forecast.Args["coordinates"])
+	if !ok || nested.Name != "get_coordinates" {
+		t.Fatalf("expected coordinates from a nested get_coordinates call, got %#v", forecast.Args["coordinates"])
+	}
+	city, _ := execution.GetValue(nested.Args["city"])
+	if s, _ := city.(string); !strings.Contains(strings.ToLower(s), "turin") && !strings.Contains(strings.ToLower(s), "torino") {
+		t.Errorf("expected get_coordinates for Turin, got city %v", city)
+	}
+
+	coordinates, ok := nested.Result.Value.(geo.Coordinates)
+	if !nested.Result.Present || !ok {
+		t.Fatalf("expected coordinates to be found, got %#v", nested.Result)
+	}
+	if geo.Distance(coordinates, geo.Coordinates{Lat: 45.07, Lon: 7.69}) > 25 {
+		t.Errorf("coordinates %v are too far from Turin", coordinates)
+	}
+
+	value, ok := forecast.Result.Value.(GetWeatherForecastResponse)
+	if !forecast.Result.Present || !ok || len(value.Temperature2M) == 0 {
+		t.Fatalf("expected a weather forecast, got %#v", forecast.Result)
+	}
+}
+
+func TestIntegrationCoordinatesOnly(t *testing.T) {
+	exec := process(t, newIntegrationHandler(t), "What are the latitude and longitude of Berlin?")
+
+	if findCall(exec, "get_weather_forecast") != nil {
+		t.Errorf("unexpected get_weather_forecast call in %v", callNames(exec))
+	}
+	call := findCall(exec, "get_coordinates")
+	if call == nil {
+		t.Fatalf("expected a get_coordinates call, got %v", callNames(exec))
+	}
+	if !call.Result.Present {
+		t.Fatalf("expected coordinates to be found")
+	}
+}
+
+func TestIntegrationDistance(t *testing.T) {
+	exec := process(t, newIntegrationHandler(t), "How far is Rome from Milan?")
+
+	call := findCall(exec, "distance_between")
+	if call == nil {
+		t.Fatalf("expected a distance_between call, got %v", callNames(exec))
+	}
+	for _, arg := range []string{"from", "to"} {
+		if nested, ok := execution.GetFuncCall(call.Args[arg]); !ok || nested.Name != "get_coordinates" {
+			t.Errorf("expected %s from a nested get_coordinates call, got %#v", arg, call.Args[arg])
+		}
+	}
+	distance, _ := call.Result.Value.(map[string]float64)
+	if km := distance["kilometers"]; km < 400 || km > 550 {
+		t.Errorf("expected a distance of about 480 km, got %v", km)
+	}
+}
+
+func TestIntegrationUnprocessableRequest(t *testing.T) {
+	exec := process(t, newIntegrationHandler(t), "Write a sonnet about the sea.")
+
+	for _, f := range exec.FuncCalls {
+		if f.Name != "__builtin__.unprocessable_request" {
+			t.Errorf("expected the request to be unprocessable, got a %s call", f.Name)
+		}
+	}
+}
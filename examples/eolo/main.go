@@ -25,6 +25,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/nlpodyssey/funcallarchitect/agent"
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
 	"github.com/nlpodyssey/funcallarchitect/handler"
 	"github.com/nlpodyssey/funcallarchitect/llamacpp"
 	"github.com/nlpodyssey/funcallarchitect/server"
@@ -92,6 +93,7 @@ func parseConfig() (config, error) {
 		MaxTokens:   5000,
 		Timeout:     60 * time.Second,
 		UseGrammar:  true,
+		ModelFamily: getEnv("LLM_MODEL_FAMILY", ""),
 	}
 
 	return cfg, validateConfig(cfg)
@@ -115,7 +117,7 @@ func setupAgent(cfg config) (*agent.Agent, error) {
 
 	return agent.NewAgent(handler.RequestHandlerConfig{
 		LLMClient:            completionClient,
-		Tools:                &Tools{},
+		Tools:                handler.ComposeTools(geo.New(), &Tools{}),
 		Timeout:              60 * time.Second,
 		EnableConcurrentExec: true,
 	})
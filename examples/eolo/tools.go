@@ -15,27 +15,18 @@
 package main
 
 import (
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
 	"github.com/nlpodyssey/funcallarchitect/execution"
 	"github.com/nlpodyssey/funcallarchitect/tools"
 )
 
+// Tools provides the weather forecast, which takes the coordinates_value
+// returned by the geo tools it is composed with.
 type Tools struct{}
 
 func (t *Tools) AvailableTools() *tools.ToolSet {
 	return &tools.ToolSet{
 		Functions: []tools.FuncDefinition{
-			{
-				Name:        "get_coordinates",
-				Description: "Retrieve the latitude and longitude for a given location (city name).",
-				Parameters: tools.TypeInfo{
-					Type: "object",
-					Properties: map[string]tools.TypeInfo{
-						"city": {Type: "string", Description: "The city name, e.g. Berlin, London, New York City"},
-					},
-					Required: []string{"city"},
-				},
-				Returns: tools.TypeInfo{Type: "coordinates_value"},
-			},
 			{
 				Name:        "get_weather_forecast",
 				Description: "Retrieve the weather forecast for a given location (latitude and longitude).",
@@ -43,7 +34,7 @@ func (t *Tools) AvailableTools() *tools.ToolSet {
 					Type: "object",
 					Properties: map[string]tools.TypeInfo{
 						"coordinates": {
-							Type:        "coordinates_value",
+							Type:        geo.CoordinatesType,
 							Description: "The latitude and longitude of the location.",
 						},
 					},
@@ -53,13 +44,7 @@ func (t *Tools) AvailableTools() *tools.ToolSet {
 			},
 		},
 		TypeDefinitions: map[string]tools.TypeInfo{
-			"coordinates_value": {
-				Type: "object",
-				Properties: map[string]tools.TypeInfo{
-					"lat": {Type: "number", Description: "Latitude of the location"},
-					"lon": {Type: "number", Description: "Longitude of the location"},
-				},
-			},
+			geo.CoordinatesType: geo.CoordinatesTypeInfo,
 			"weather_forecast_value": {
 				Type: "object",
 				Properties: map[string]tools.TypeInfo{
@@ -84,7 +69,6 @@ func (t *Tools) AvailableTools() *tools.ToolSet {
 }
 
 func (t *Tools) RegisterWith(ec *execution.Orchestrator) error {
-	ec.RegisterFunction("get_coordinates", t.GetCoordinates)
 	ec.RegisterFunction("get_weather_forecast", t.GetWeatherForecast)
 	return nil
 }
@@ -12,165 +12,52 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Command web serves the chat frontend of the ui package for a remote
+// FunCallArchitect server, forwarding the API requests to it.
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"embed"
 	"flag"
 	"fmt"
-	"html/template"
-	"io"
 	"log"
 	"net/http"
-	"os"
-	"strings"
+	"net/http/httputil"
+	"net/url"
 
-	"gopkg.in/yaml.v2"
+	"github.com/nlpodyssey/funcallarchitect/ui"
 )
 
-//go:embed template.html
-var templateFS embed.FS
-
-//go:embed favicon.ico
-var favicon []byte
-
-type Config struct {
-	CompanyNamePrefix  string `yaml:"company_name_prefix"`
-	CompanyNameSuffix  string `yaml:"company_name_suffix"`
-	CompanySuffixColor string `yaml:"company_suffix_color"`
-	ProductName        string `yaml:"product_name"`
-	EnvironmentName    string `yaml:"environment_name"`
-	ProductAccentColor string `yaml:"product_accent_color"`
-	FaviconPath        string `yaml:"favicon_path"`
-	InitialQuestion    string `yaml:"initial_question"`
-}
-
-func loadConfig(filename string) (*Config, error) {
-	config := &Config{}
-
-	file, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("error reading config file: %v", err)
-	}
-
-	err = yaml.Unmarshal(file, config)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling config: %v", err)
-	}
-
-	return config, nil
-}
-
 func main() {
 	configFile := flag.String("config", "config.yaml", "Path to the configuration file")
 	serverPort := flag.String("port", "8080", "Port for the server to listen on")
-	backendURL := flag.String("backend-url", "", "URL of the backend stream processing service")
+	backendURL := flag.String("backend-url", "", "Base URL of the FunCallArchitect server")
 
 	flag.Parse()
 
 	if *backendURL == "" {
-		fmt.Println("Error: process-endpoint flag is required")
+		fmt.Println("Error: backend-url flag is required")
 		return
 	}
-
-	config, err := loadConfig(*configFile)
+	backend, err := url.Parse(*backendURL)
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		fmt.Printf("Error parsing backend URL: %v\n", err)
 		return
 	}
 
-	htmlTemplate, err := templateFS.ReadFile("template.html")
+	config, err := ui.LoadConfig(*configFile)
 	if err != nil {
-		fmt.Println("Error reading embedded template file:", err)
+		fmt.Printf("Error loading config: %v\n", err)
 		return
 	}
+	config.APIBase = "/api"
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		tmpl, err := template.New("index").Parse(string(htmlTemplate))
-		if err != nil {
-			http.Error(w, "Error parsing template", http.StatusInternalServerError)
-			return
-		}
-		err = tmpl.Execute(w, config)
-		if err != nil {
-			return
-		}
-	})
+	// The events of the streamed requests are forwarded as they come
+	proxy := httputil.NewSingleHostReverseProxy(backend)
+	proxy.FlushInterval = -1
 
-	http.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "image/x-icon")
-		w.Write(favicon)
-	})
-
-	http.HandleFunc("/api/process", func(w http.ResponseWriter, r *http.Request) {
-		handleProcessRequest(w, r, *backendURL)
-	})
+	http.Handle("/", ui.Handler(config))
+	http.Handle("/api/", http.StripPrefix("/api", proxy))
 
 	fmt.Printf("Server is running on http://localhost:%s\n", *serverPort)
-	err = http.ListenAndServe(":"+*serverPort, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-}
-
-func handleProcessRequest(w http.ResponseWriter, r *http.Request, processEndpoint string) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	message, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Error reading request body", http.StatusInternalServerError)
-		return
-	}
-
-	req, err := http.NewRequest("POST", processEndpoint, bytes.NewBuffer(message))
-	if err != nil {
-		http.Error(w, "Error creating request", http.StatusInternalServerError)
-		return
-	}
-
-	req.Header.Set("Content-Type", "text/plain")
-	req.Header.Set("Accept", "text/event-stream")
-
-	client := &http.Client{}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		http.Error(w, "Error sending request", http.StatusInternalServerError)
-		return
-	}
-	defer resp.Body.Close()
-
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-
-	reader := bufio.NewReader(resp.Body)
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			_, err := fmt.Fprintf(w, "data: Error reading response: %v\n\n", err)
-			if err != nil {
-				return
-			}
-			return
-		}
-
-		_, err = fmt.Fprint(w, line)
-		if err != nil {
-			return
-		}
-		w.(http.Flusher).Flush()
-
-		if strings.HasPrefix(strings.TrimSpace(line), "data: err:") {
-			break
-		}
-	}
+	log.Fatal(http.ListenAndServe(":"+*serverPort, nil))
 }
@@ -0,0 +1,128 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/version"
+)
+
+// Approver decides whether a side-effect function may be executed with the given arguments.
+// Returning false rejects the call, which is then reported as not performed; returning an
+// error aborts the execution.
+type Approver func(ctx context.Context, funcName string, args map[string]any) (bool, error)
+
+// ReceiptOutcome describes what happened to a side-effect function call.
+type ReceiptOutcome string
+
+const (
+	ReceiptPerformed ReceiptOutcome = "performed"
+	ReceiptRejected  ReceiptOutcome = "rejected"
+	ReceiptFailed    ReceiptOutcome = "failed"
+	// ReceiptCompensated and ReceiptCompensationFailed are the outcomes of the
+	// receipts recorded when compensating a performed call.
+	ReceiptCompensated        ReceiptOutcome = "compensated"
+	ReceiptCompensationFailed ReceiptOutcome = "compensation_failed"
+)
+
+// Receipt is the audit record of a side-effect function call.
+type Receipt struct {
+	ID        string         `json:"id"`
+	FuncName  string         `json:"func_name"`
+	Args      map[string]any `json:"args"`
+	Approved  bool           `json:"approved"`
+	Outcome   ReceiptOutcome `json:"outcome"`
+	Error     string         `json:"error,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+	// Details holds the FuncResult.Metadata returned by the executor, e.g. a delivery ID.
+	Details any `json:"details,omitempty"`
+	// Compensates is the ID of the receipt of the call undone, for compensation receipts.
+	Compensates string `json:"compensates,omitempty"`
+	// Build identifies the code executing the call.
+	Build version.Build `json:"build"`
+}
+
+// AuditLog stores the receipts of side-effect function calls.
+type AuditLog interface {
+	Record(ctx context.Context, receipt Receipt) error
+}
+
+// MemoryAuditLog is an in-memory AuditLog.
+type MemoryAuditLog struct {
+	mu       sync.Mutex
+	receipts []Receipt
+}
+
+// Record appends the receipt to the log.
+func (l *MemoryAuditLog) Record(_ context.Context, receipt Receipt) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.receipts = append(l.receipts, receipt)
+	return nil
+}
+
+// Receipts returns a copy of the recorded receipts.
+func (l *MemoryAuditLog) Receipts() []Receipt {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Receipt(nil), l.receipts...)
+}
+
+type receiptCollectorContextKey struct{}
+
+type receiptCollector struct {
+	parent   *receiptCollector
+	mu       sync.Mutex
+	receipts []Receipt
+}
+
+// CollectReceipts returns a copy of ctx collecting the receipts of the side-effect
+// calls executed with it, and a function returning them in the order they were recorded.
+func CollectReceipts(ctx context.Context) (context.Context, func() []Receipt) {
+	parent, _ := ctx.Value(receiptCollectorContextKey{}).(*receiptCollector)
+	c := &receiptCollector{parent: parent}
+	return context.WithValue(ctx, receiptCollectorContextKey{}, c), func() []Receipt {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return append([]Receipt(nil), c.receipts...)
+	}
+}
+
+func collectReceipt(ctx context.Context, receipt Receipt) {
+	// Collectors are nested: the outer ones receive the receipts too
+	c, _ := ctx.Value(receiptCollectorContextKey{}).(*receiptCollector)
+	for ; c != nil; c = c.parent {
+		c.mu.Lock()
+		c.receipts = append(c.receipts, receipt)
+		c.mu.Unlock()
+	}
+}
+
+func newReceipt(ctx context.Context, funcName string, args map[string]any) *Receipt {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return &Receipt{
+		ID:        hex.EncodeToString(id[:]),
+		FuncName:  funcName,
+		Args:      args,
+		Timestamp: Now(ctx),
+		Build:     version.Current(),
+	}
+}
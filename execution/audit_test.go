@@ -0,0 +1,146 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+func executeSideEffect(o *Orchestrator, ctx context.Context, call parser.PlannedFuncCall) (*ExecutedFuncCall, error) {
+	result, err := o.Execute(ctx, []parser.PlannedFuncCall{call}, &progress.NoOp{})
+	if err != nil {
+		return nil, err
+	}
+	return result.FuncCalls[0], nil
+}
+
+func TestRejectedSideEffectsAreNotPerformed(t *testing.T) {
+	desk := &ticketDesk{}
+	o := newTicketOrchestrator(desk)
+	log := &MemoryAuditLog{}
+	o.AuditLog = log
+	var asked map[string]any
+	o.Approver = func(_ context.Context, name string, args map[string]any) (bool, error) {
+		asked = args
+		return false, nil
+	}
+
+	call, err := executeSideEffect(o, context.Background(), parser.PlannedFuncCall{Name: "create_ticket", Args: map[string]any{"title": "printer"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if call.Result.Present || len(desk.snapshot(&desk.titles)) != 0 {
+		t.Fatalf("the rejected call was performed")
+	}
+	if asked["title"] != "printer" {
+		t.Errorf("the approver was asked about %v", asked)
+	}
+	receipts := log.Receipts()
+	if len(receipts) != 1 || receipts[0].Outcome != ReceiptRejected || receipts[0].Approved {
+		t.Fatalf("got receipts %+v, want one rejected", receipts)
+	}
+}
+
+func TestApprovedSideEffectsAreAudited(t *testing.T) {
+	desk := &ticketDesk{}
+	o := newTicketOrchestrator(desk)
+	log := &MemoryAuditLog{}
+	o.AuditLog = log
+	o.Approver = func(context.Context, string, map[string]any) (bool, error) { return true, nil }
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	ctx := WithClock(context.Background(), func() time.Time { return now })
+
+	if _, err := executeSideEffect(o, ctx, parser.PlannedFuncCall{Name: "create_ticket", Args: map[string]any{"title": "printer"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeSideEffect(o, ctx, parser.PlannedFuncCall{Name: "page_oncall", Args: map[string]any{"team": "ops"}}); err == nil {
+		t.Fatal("expected the error of page_oncall")
+	}
+
+	receipts := log.Receipts()
+	if len(receipts) != 2 {
+		t.Fatalf("got %d receipts, want 2", len(receipts))
+	}
+	if r := receipts[0]; r.Outcome != ReceiptPerformed || !r.Approved || r.Details != "T1" || !r.Timestamp.Equal(now) {
+		t.Errorf("got receipt %+v, want create_ticket performed at %v with details T1", r, now)
+	}
+	if r := receipts[1]; r.Outcome != ReceiptFailed || r.Error == "" {
+		t.Errorf("got receipt %+v, want page_oncall failed", r)
+	}
+}
+
+func TestApprovalErrorsFailTheCall(t *testing.T) {
+	desk := &ticketDesk{}
+	o := newTicketOrchestrator(desk)
+	o.Approver = func(context.Context, string, map[string]any) (bool, error) {
+		return false, errors.New("approval service down")
+	}
+
+	if _, err := executeSideEffect(o, context.Background(), parser.PlannedFuncCall{Name: "create_ticket", Args: map[string]any{"title": "printer"}}); err == nil {
+		t.Fatal("expected the error of the approver")
+	}
+	if got := desk.snapshot(&desk.titles); len(got) != 0 {
+		t.Errorf("created tickets %v without approval", got)
+	}
+}
+
+func TestIdenticalSideEffectsAreAllPerformed(t *testing.T) {
+	o := newTicketOrchestrator(&ticketDesk{})
+	log := &MemoryAuditLog{}
+	o.AuditLog = log
+	// The sends wait for each other, so that identical calls are in flight together
+	var sent sync.WaitGroup
+	sent.Add(2)
+	var mu sync.Mutex
+	n := 0
+	o.RegisterFunction("send_email", func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		mu.Lock()
+		n++
+		id := fmt.Sprintf("M%d", n)
+		mu.Unlock()
+		sent.Done()
+		done := make(chan struct{})
+		go func() { sent.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(200 * time.Millisecond):
+		}
+		return FuncResult{Present: true, Value: "sent", Metadata: id}, nil
+	})
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = executeSideEffect(o, context.Background(), parser.PlannedFuncCall{Name: "send_email", Args: map[string]any{"to": "ops"}})
+		}()
+	}
+	wg.Wait()
+
+	receipts := log.Receipts()
+	if len(receipts) != 2 || receipts[0].Details == receipts[1].Details {
+		t.Fatalf("got receipts %+v, want two distinct sends", receipts)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// CallContext describes the function call being executed. Besides the flattened
+// arguments received by every FuncExecutor, it gives access to the original Arg
+// map, telling which arguments came from nested function calls.
+type CallContext struct {
+	FuncName string
+	Purpose  string
+	// Args holds a ValueArg or a FuncArg (with the nested ExecutedFuncCall) per argument.
+	Args map[string]Arg
+	// ProcessedArgs holds the values passed to the executor, with nested calls
+	// replaced by their results (and omitted when they found nothing).
+	ProcessedArgs map[string]interface{}
+}
+
+// FromNestedCall returns the nested call which produced the value of the argument, if any.
+func (c *CallContext) FromNestedCall(name string) (*ExecutedFuncCall, bool) {
+	return GetFuncCall(c.Args[name])
+}
+
+// CallExecutor is a FuncExecutor receiving the CallContext instead of the flattened arguments.
+type CallExecutor func(ctx context.Context, call *CallContext, progress progress.Stream) (FuncResult, error)
+
+type callContextKey struct{}
+
+// CallContextFromContext returns the CallContext of the call being executed.
+// It is available to every executor through its context.
+func CallContextFromContext(ctx context.Context) (*CallContext, bool) {
+	call, ok := ctx.Value(callContextKey{}).(*CallContext)
+	return call, ok
+}
+
+func withCallContext(ctx context.Context, call *CallContext) context.Context {
+	return context.WithValue(ctx, callContextKey{}, call)
+}
+
+// RegisterCallFunction registers a CallExecutor for the function.
+func (o *Orchestrator) RegisterCallFunction(name string, executor CallExecutor) {
+	o.RegisterFunction(name, func(ctx context.Context, args map[string]interface{}, progress progress.Stream) (FuncResult, error) {
+		call, ok := CallContextFromContext(ctx)
+		if !ok {
+			call = &CallContext{FuncName: name, ProcessedArgs: args}
+		}
+		return executor(ctx, call, progress)
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// Candidate is one of the alternative values of a result, e.g. one of the
+// places matching an ambiguous name (see FuncResult.Candidates).
+type Candidate struct {
+	// Label describes the candidate to the user, e.g. "Springfield, Illinois".
+	Label string `json:"label"`
+	Value any    `json:"value"`
+}
+
+// Choice asks which of the candidates returned by a nested call is bound to
+// the argument of the calling function.
+type Choice struct {
+	// FuncName is the function returning the candidates.
+	FuncName string
+	// CallerName and ArgName are the function and the argument receiving the
+	// chosen value.
+	CallerName string
+	ArgName    string
+	Candidates []Candidate
+}
+
+// Chooser selects the candidate of the choice, typically asking the user,
+// and returns its index. A negative index keeps the value of the result;
+// returning an error aborts the execution.
+type Chooser func(ctx context.Context, choice Choice, progress progress.Stream) (int, error)
+
+// choose binds the candidate selected by the Chooser, if any, to the result of
+// the nested call received by the argument.
+func (o *Orchestrator) choose(ctx context.Context, caller, arg string, call *ExecutedFuncCall, progress progress.Stream) error {
+	candidates := call.Result.Candidates
+	if o.Chooser == nil || len(candidates) < 2 {
+		return nil
+	}
+	i, err := o.Chooser(ctx, Choice{FuncName: call.Name, CallerName: caller, ArgName: arg, Candidates: candidates}, progress)
+	if err != nil {
+		return fmt.Errorf("choice failed: %w", err)
+	}
+	if i < 0 {
+		return nil
+	}
+	if i >= len(candidates) {
+		return fmt.Errorf("choice failed: invalid candidate %d of %d", i, len(candidates))
+	}
+	o.logger(ctx).Printf("Candidate %q of %s chosen for argument '%s' in function '%s'", candidates[i].Label, call.Name, arg, caller)
+	label := candidates[i].Label
+	call.Result = FuncResult{
+		Present:    true,
+		Value:      candidates[i].Value,
+		FormatFunc: func() (string, error) { return label, nil },
+		Metadata:   call.Result.Metadata,
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoCompensator is returned by Compensate for the performed calls that cannot
+// be undone, since their function has no Compensator.
+var ErrNoCompensator = errors.New("no compensator registered")
+
+// Compensator undoes the side effect of a performed call, described by its receipt,
+// e.g. cancel_ticket for create_ticket. The receipt Details hold the metadata
+// returned by the executor, such as the ID of the created resource.
+type Compensator func(ctx context.Context, receipt Receipt) error
+
+// RegisterCompensator sets the Compensator of a side-effect function.
+func (o *Orchestrator) RegisterCompensator(name string, compensator Compensator) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.compensators[name] = compensator
+}
+
+func (o *Orchestrator) lookupCompensator(name string) (Compensator, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	c, ok := o.compensators[name]
+	return c, ok
+}
+
+// Compensate runs the compensators of the performed calls, in reverse order,
+// recording the outcome of each one. The calls without a compensator are
+// recorded as failed compensations, since their side effect is still in place.
+// It returns the errors of the compensations that failed, ErrNoCompensator
+// for the calls without a compensator.
+func (o *Orchestrator) Compensate(ctx context.Context, receipts []Receipt) error {
+	var errs []error
+	for i := len(receipts) - 1; i >= 0; i-- {
+		receipt := receipts[i]
+		if receipt.Outcome != ReceiptPerformed {
+			continue
+		}
+		compensation := newReceipt(ctx, receipt.FuncName, receipt.Args)
+		compensation.Approved = true
+		compensation.Compensates = receipt.ID
+		compensation.Outcome = ReceiptCompensated
+
+		compensator, ok := o.lookupCompensator(receipt.FuncName)
+		if !ok {
+			o.logger(ctx).Printf("Warning: no compensator for %s, receipt %s cannot be undone", receipt.FuncName, receipt.ID)
+			compensation.Outcome = ReceiptCompensationFailed
+			compensation.Error = ErrNoCompensator.Error()
+			errs = append(errs, fmt.Errorf("error compensating %s: %w", receipt.FuncName, ErrNoCompensator))
+			o.recordReceipt(ctx, compensation)
+			continue
+		}
+
+		o.logger(ctx).Printf("Compensating %s (receipt %s)", receipt.FuncName, receipt.ID)
+		if err := o.runCompensator(ctx, compensator, receipt); err != nil {
+			o.logger(ctx).Printf("Compensation of %s failed: %v", receipt.FuncName, err)
+			compensation.Outcome = ReceiptCompensationFailed
+			compensation.Error = err.Error()
+			errs = append(errs, fmt.Errorf("error compensating %s: %w", receipt.FuncName, err))
+		}
+		o.recordReceipt(ctx, compensation)
+	}
+	return errors.Join(errs...)
+}
+
+func (o *Orchestrator) runCompensator(ctx context.Context, compensator Compensator, receipt Receipt) error {
+	// Compensations run after failures, possibly of the request context itself
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), max(o.Timeout, time.Second))
+	defer cancel()
+	return compensator(ctx, receipt)
+}
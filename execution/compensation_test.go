@@ -0,0 +1,168 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// ticketDesk is the state behind the side-effect functions of newTicketOrchestrator.
+type ticketDesk struct {
+	mu        sync.Mutex
+	lookups   int
+	titles    []string
+	cancelled []string
+}
+
+func (d *ticketDesk) record(list *[]string, value string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	*list = append(*list, value)
+	return len(*list)
+}
+
+func (d *ticketDesk) snapshot(list *[]string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(*list)
+}
+
+// newTicketOrchestrator returns an orchestrator with the read-only function
+// "lookup", which finds nothing for the key "missing", and the side-effect
+// functions "create_ticket", compensated by cancelling the ticket, "send_email",
+// without a compensator, and "page_oncall", which always fails.
+func newTicketOrchestrator(desk *ticketDesk) *Orchestrator {
+	object := tools.TypeInfo{Type: "object"}
+	ts := &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{Name: "lookup", Parameters: object, Returns: tools.TypeInfo{Type: "string"}},
+			{Name: "create_ticket", Parameters: object, Returns: tools.TypeInfo{Type: "string"}, SideEffect: true},
+			{Name: "send_email", Parameters: object, Returns: tools.TypeInfo{Type: "string"}, SideEffect: true},
+			{Name: "page_oncall", Parameters: object, Returns: tools.TypeInfo{Type: "string"}, SideEffect: true},
+		},
+	}
+	o := NewOrchestrator(log.New(io.Discard, "", 0), time.Second, false, ts)
+	o.RegisterFunction("lookup", func(_ context.Context, args map[string]interface{}, _ progress.Stream) (FuncResult, error) {
+		desk.mu.Lock()
+		desk.lookups++
+		desk.mu.Unlock()
+		if args["key"] == "missing" {
+			return FuncResult{Present: false, FormatFunc: func() (string, error) { return "No such key.", nil }}, nil
+		}
+		return FuncResult{Present: true, Value: fmt.Sprintf("title of %v", args["key"])}, nil
+	})
+	o.RegisterFunction("create_ticket", func(_ context.Context, args map[string]interface{}, _ progress.Stream) (FuncResult, error) {
+		id := fmt.Sprintf("T%d", desk.record(&desk.titles, fmt.Sprint(args["title"])))
+		return FuncResult{Present: true, Value: id, Metadata: id}, nil
+	})
+	o.RegisterFunction("send_email", func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		return FuncResult{Present: true, Value: "sent"}, nil
+	})
+	o.RegisterFunction("page_oncall", func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		return FuncResult{}, errors.New("pager unavailable")
+	})
+	o.RegisterCompensator("create_ticket", func(_ context.Context, receipt Receipt) error {
+		desk.record(&desk.cancelled, fmt.Sprint(receipt.Details))
+		return nil
+	})
+	return o
+}
+
+func perform(t *testing.T, o *Orchestrator, ctx context.Context, calls ...parser.PlannedFuncCall) {
+	t.Helper()
+	for _, call := range calls {
+		if _, err := o.Execute(ctx, []parser.PlannedFuncCall{call}, &progress.NoOp{}); err != nil {
+			t.Fatalf("%s: %v", call.Name, err)
+		}
+	}
+}
+
+func outcomes(receipts []Receipt) []ReceiptOutcome {
+	result := make([]ReceiptOutcome, len(receipts))
+	for i, r := range receipts {
+		result[i] = r.Outcome
+	}
+	return result
+}
+
+func TestCompensateUndoesInReverseOrder(t *testing.T) {
+	desk := &ticketDesk{}
+	o := newTicketOrchestrator(desk)
+	ctx, receipts := CollectReceipts(context.Background())
+	perform(t, o, ctx,
+		parser.PlannedFuncCall{Name: "create_ticket", Args: map[string]any{"title": "first"}},
+		parser.PlannedFuncCall{Name: "send_email", Args: map[string]any{"to": "ops"}},
+		parser.PlannedFuncCall{Name: "create_ticket", Args: map[string]any{"title": "second"}},
+	)
+	performed := receipts()
+
+	// send_email has no compensator: the email stays sent
+	if err := o.Compensate(ctx, performed); !errors.Is(err, ErrNoCompensator) {
+		t.Fatalf("got %v, want %v", err, ErrNoCompensator)
+	}
+
+	if got := desk.snapshot(&desk.cancelled); !slices.Equal(got, []string{"T2", "T1"}) {
+		t.Errorf("cancelled tickets %v, want [T2 T1]", got)
+	}
+	compensations := receipts()[len(performed):]
+	want := []ReceiptOutcome{ReceiptCompensated, ReceiptCompensationFailed, ReceiptCompensated}
+	if got := outcomes(compensations); !slices.Equal(got, want) {
+		t.Fatalf("compensation outcomes %v, want %v", got, want)
+	}
+	for i, c := range compensations {
+		if p := performed[len(performed)-1-i]; c.Compensates != p.ID {
+			t.Errorf("compensation %d refers to %q, want %q", i, c.Compensates, p.ID)
+		}
+	}
+}
+
+func TestCompensateReportsTheFailedCompensations(t *testing.T) {
+	desk := &ticketDesk{}
+	o := newTicketOrchestrator(desk)
+	o.RegisterCompensator("send_email", func(context.Context, Receipt) error {
+		return errors.New("emails cannot be recalled")
+	})
+	ctx, receipts := CollectReceipts(context.Background())
+	perform(t, o, ctx,
+		parser.PlannedFuncCall{Name: "create_ticket", Args: map[string]any{"title": "first"}},
+		parser.PlannedFuncCall{Name: "send_email", Args: map[string]any{"to": "ops"}},
+	)
+	performed := receipts()
+
+	err := o.Compensate(ctx, performed)
+	if err == nil {
+		t.Fatal("expected the error of the send_email compensator")
+	}
+
+	if got := desk.snapshot(&desk.cancelled); !slices.Equal(got, []string{"T1"}) {
+		t.Errorf("cancelled tickets %v, want [T1] despite the failure", got)
+	}
+	compensations := receipts()[len(performed):]
+	if got := outcomes(compensations); !slices.Equal(got, []ReceiptOutcome{ReceiptCompensationFailed, ReceiptCompensated}) {
+		t.Errorf("compensation outcomes %v, want [compensation_failed compensated]", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// The environment of the executors: the clock and the HTTP transport they
+// should use, so that they can be frozen and recorded to make the executions
+// reproducible.
+
+type clockContextKey struct{}
+
+// WithClock returns a copy of ctx in which Now returns the time of clock,
+// typically frozen.
+func WithClock(ctx context.Context, clock func() time.Time) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, clock)
+}
+
+// Now returns the current time according to the clock carried by ctx, or
+// time.Now. Executors should use it instead of time.Now.
+func Now(ctx context.Context) time.Time {
+	if clock, ok := ctx.Value(clockContextKey{}).(func() time.Time); ok {
+		return clock()
+	}
+	return time.Now()
+}
+
+// HTTPMiddleware wraps the transport of an HTTP client.
+type HTTPMiddleware func(next http.RoundTripper) http.RoundTripper
+
+type httpMiddlewareContextKey struct{}
+
+// WithHTTPMiddleware returns a copy of ctx in which HTTPClient wraps the
+// transports with middleware, e.g. to record or replay the requests.
+func WithHTTPMiddleware(ctx context.Context, middleware HTTPMiddleware) context.Context {
+	return context.WithValue(ctx, httpMiddlewareContextKey{}, middleware)
+}
+
+// HTTPClient returns client, or a copy of it whose transport is wrapped by the
+// middleware carried by ctx. Executors calling external services should send
+// their requests through it. A nil client stands for http.DefaultClient.
+func HTTPClient(ctx context.Context, client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	middleware, ok := ctx.Value(httpMiddlewareContextKey{}).(HTTPMiddleware)
+	if !ok {
+		return client
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = middleware(next)
+	return &wrapped
+}
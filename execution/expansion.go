@@ -0,0 +1,111 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// maxExpansionDepth bounds the expansions of composite functions expanding
+// to other composite functions, which also stops cyclic definitions.
+const maxExpansionDepth = 8
+
+// Expander turns a call of a composite function, such as a skill or a macro,
+// into the tree of calls it stands for.
+type Expander func(call parser.PlannedFuncCall) (parser.PlannedFuncCall, error)
+
+// RegisterExpander adds (or replaces) the definition of a composite function.
+// Its calls are expanded before the execution of a plan, nested calls included.
+func (o *Orchestrator) RegisterExpander(definition tools.FuncDefinition, expander Expander) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.setDefinition(definition)
+	delete(o.functions, definition.Name)
+	o.expanders[definition.Name] = expander
+}
+
+// RegisterMacro adds (or replaces) a macro, whose calls are expanded into its
+// template before execution.
+func (o *Orchestrator) RegisterMacro(m tools.Macro) {
+	o.RegisterExpander(m.FuncDefinition, m.Expand)
+}
+
+func (o *Orchestrator) lookupExpander(name string) (Expander, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	expander, ok := o.expanders[name]
+	return expander, ok
+}
+
+// expandAll expands the composite function calls of the plan.
+func (o *Orchestrator) expandAll(functions []parser.PlannedFuncCall) ([]parser.PlannedFuncCall, error) {
+	o.mu.RLock()
+	none := len(o.expanders) == 0
+	o.mu.RUnlock()
+	if none {
+		return functions, nil
+	}
+	expanded := make([]parser.PlannedFuncCall, len(functions))
+	for i, function := range functions {
+		call, err := o.expand(function, 0)
+		if err != nil {
+			return nil, err
+		}
+		expanded[i] = call
+	}
+	return expanded, nil
+}
+
+func (o *Orchestrator) expand(call parser.PlannedFuncCall, depth int) (parser.PlannedFuncCall, error) {
+	if expander, ok := o.lookupExpander(call.Name); ok {
+		if depth == maxExpansionDepth {
+			return call, &Error{FuncName: call.Name, Err: fmt.Errorf("too many nested expansions")}
+		}
+		expanded, err := expander(call)
+		if err != nil {
+			return call, &Error{FuncName: call.Name, Err: fmt.Errorf("expansion failed: %w", err)}
+		}
+		if expanded.Purpose == "" {
+			expanded.Purpose = call.Purpose
+		}
+		return o.expand(expanded, depth+1)
+	}
+
+	var args map[string]interface{}
+	for key, value := range call.Args {
+		nested, ok := value.(*parser.PlannedFuncCall)
+		if !ok {
+			continue
+		}
+		expanded, err := o.expand(*nested, depth)
+		if err != nil {
+			return call, err
+		}
+		if args == nil {
+			args = make(map[string]interface{}, len(call.Args))
+			for k, v := range call.Args {
+				args[k] = v
+			}
+		}
+		args[key] = &expanded
+	}
+	if args != nil {
+		call.Args = args
+	}
+	return call, nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// The content types of the built-in formatters.
+const (
+	ContentTypeText     = "text/plain"
+	ContentTypeJSON     = "application/json"
+	ContentTypeMarkdown = "text/markdown"
+	ContentTypeHTML     = "text/html"
+)
+
+// Formatter renders the results of the main calls of an execution into the
+// output of a request.
+type Formatter interface {
+	// ContentType is the MIME type of the output.
+	ContentType() string
+	Format(result *Result) (string, error)
+}
+
+// TextFormatter joins the formatted results, like FuncResults.Format.
+type TextFormatter struct {
+	// Separator defaults to DefaultSeparator.
+	Separator string
+}
+
+func (f TextFormatter) ContentType() string { return ContentTypeText }
+
+func (f TextFormatter) Format(result *Result) (string, error) {
+	return result.MainFuncResults().Format(f.Separator)
+}
+
+// JSONFormatter encodes the results as a JSON array of objects with the name
+// of the function, its value and its formatted text. Values that cannot be
+// encoded are omitted.
+type JSONFormatter struct{}
+
+func (JSONFormatter) ContentType() string { return ContentTypeJSON }
+
+func (JSONFormatter) Format(result *Result) (string, error) {
+	type item struct {
+		Function string          `json:"function"`
+		Present  bool            `json:"present"`
+		Value    json.RawMessage `json:"value,omitempty"`
+		Text     string          `json:"text,omitempty"`
+	}
+	items := make([]item, 0, len(result.FuncCalls))
+	for _, f := range result.FuncCalls {
+		if f.Result.FormatFunc == nil {
+			continue // skip silent functions
+		}
+		text, err := f.Result.FormatFunc()
+		if err != nil {
+			return "", fmt.Errorf("error formatting result: %v", err)
+		}
+		it := item{Function: f.Name, Present: f.Result.Present, Text: text}
+		if f.Result.Present {
+			if value, err := json.Marshal(f.Result.Value); err == nil {
+				it.Value = value
+			}
+		}
+		items = append(items, it)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", fmt.Errorf("error encoding results: %w", err)
+	}
+	return string(data), nil
+}
+
+// MarkdownFormatter renders the results with the table hint as Markdown
+// tables, and the others as their formatted text.
+type MarkdownFormatter struct{}
+
+func (MarkdownFormatter) ContentType() string { return ContentTypeMarkdown }
+
+func (MarkdownFormatter) Format(result *Result) (string, error) {
+	return formatEach(result, "\n\n---\n\n", func(f *ExecutedFuncCall, text string) string {
+		columns, rows, ok := tableOf(f)
+		if !ok {
+			return text
+		}
+		escape := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+		var sb strings.Builder
+		for _, c := range columns {
+			sb.WriteString("| " + escape(c) + " ")
+		}
+		sb.WriteString("|\n" + strings.Repeat("| --- ", len(columns)) + "|\n")
+		for _, row := range rows {
+			for _, cell := range row {
+				sb.WriteString("| " + escape(cell) + " ")
+			}
+			sb.WriteString("|\n")
+		}
+		return strings.TrimSuffix(sb.String(), "\n")
+	})
+}
+
+// HTMLFormatter renders each result as a section, holding a table for the
+// results with the table hint and the escaped formatted text otherwise.
+type HTMLFormatter struct{}
+
+func (HTMLFormatter) ContentType() string { return ContentTypeHTML }
+
+func (HTMLFormatter) Format(result *Result) (string, error) {
+	return formatEach(result, "\n", func(f *ExecutedFuncCall, text string) string {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "<section class=\"result\" data-function=\"%s\">", html.EscapeString(f.Name))
+		if columns, rows, ok := tableOf(f); ok {
+			sb.WriteString("<table><thead><tr>")
+			for _, c := range columns {
+				sb.WriteString("<th>" + html.EscapeString(c) + "</th>")
+			}
+			sb.WriteString("</tr></thead><tbody>")
+			for _, row := range rows {
+				sb.WriteString("<tr>")
+				for _, cell := range row {
+					sb.WriteString("<td>" + html.EscapeString(cell) + "</td>")
+				}
+				sb.WriteString("</tr>")
+			}
+			sb.WriteString("</tbody></table>")
+		} else {
+			sb.WriteString("<pre>" + html.EscapeString(text) + "</pre>")
+		}
+		sb.WriteString("</section>")
+		return sb.String()
+	})
+}
+
+// formatEach renders the main results with render, skipping the silent
+// functions and the duplicates, as FuncResults.Format does.
+func formatEach(result *Result, separator string, render func(f *ExecutedFuncCall, text string) string) (string, error) {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, f := range result.FuncCalls {
+		if f.Result.FormatFunc == nil {
+			continue // skip silent functions
+		}
+		text, err := f.Result.FormatFunc()
+		if err != nil {
+			return "", fmt.Errorf("error formatting result: %v", err)
+		}
+		if s := render(f, text); !seen[s] {
+			seen[s] = true
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// tableOf returns the columns, sorted by name, and the rows of a result with
+// the table hint whose value is a list of objects.
+func tableOf(f *ExecutedFuncCall) ([]string, [][]string, bool) {
+	if f.Render != tools.RenderTable || !f.Result.Present {
+		return nil, nil, false
+	}
+	data, err := json.Marshal(f.Result.Value)
+	if err != nil {
+		return nil, nil, false
+	}
+	var objects []map[string]any
+	if err := json.Unmarshal(data, &objects); err != nil || len(objects) == 0 {
+		return nil, nil, false
+	}
+	seen := make(map[string]bool)
+	var columns []string
+	for _, o := range objects {
+		for k := range o {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+	rows := make([][]string, len(objects))
+	for i, o := range objects {
+		rows[i] = make([]string, len(columns))
+		for j, c := range columns {
+			rows[i][j] = cellText(o[c])
+		}
+	}
+	return columns, rows, true
+}
+
+func cellText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default settings of a HealthTracker.
+const (
+	DefaultFailureThreshold = 3
+	DefaultCooldown         = 30 * time.Second
+)
+
+// HealthTracker is a per-function circuit breaker. After FailureThreshold
+// consecutive failures (errors or timeouts) a function is unhealthy: its calls
+// fail immediately and planners should not be offered it. Once Cooldown has
+// elapsed the function is given another chance; a new failure restarts the cooldown.
+type HealthTracker struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*healthState
+}
+
+type healthState struct {
+	failures  int
+	lastError string
+	openedAt  time.Time
+}
+
+// HealthStatus describes the health of a function.
+type HealthStatus struct {
+	Healthy bool `json:"healthy"`
+	// ConsecutiveFailures is reset by every successful call.
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	UnhealthySince      time.Time `json:"unhealthy_since,omitempty"`
+}
+
+// NewHealthTracker creates a HealthTracker with the default settings.
+func NewHealthTracker() *HealthTracker {
+	return &HealthTracker{FailureThreshold: DefaultFailureThreshold, Cooldown: DefaultCooldown}
+}
+
+func (h *HealthTracker) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+func (h *HealthTracker) threshold() int {
+	if h.FailureThreshold <= 0 {
+		return DefaultFailureThreshold
+	}
+	return h.FailureThreshold
+}
+
+// Healthy reports whether calls to the function are allowed.
+func (h *HealthTracker) Healthy(name string) bool {
+	return h.Status(name).Healthy
+}
+
+// Status returns the health of the function.
+func (h *HealthTracker) Status(name string) HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status(name)
+}
+
+func (h *HealthTracker) status(name string) HealthStatus {
+	s, ok := h.states[name]
+	if !ok {
+		return HealthStatus{Healthy: true}
+	}
+	status := HealthStatus{
+		Healthy:             true,
+		ConsecutiveFailures: s.failures,
+		LastError:           s.lastError,
+	}
+	if s.failures >= h.threshold() && h.now().Sub(s.openedAt) < h.Cooldown {
+		status.Healthy = false
+		status.UnhealthySince = s.openedAt
+	}
+	return status
+}
+
+// Unhealthy returns the names of the functions currently unhealthy, sorted.
+func (h *HealthTracker) Unhealthy() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var names []string
+	for name := range h.states {
+		if !h.status(name).Healthy {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Record updates the health of the function with the outcome of a call.
+func (h *HealthTracker) Record(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err == nil {
+		delete(h.states, name)
+		return
+	}
+	if h.states == nil {
+		h.states = make(map[string]*healthState)
+	}
+	s, ok := h.states[name]
+	if !ok {
+		s = &healthState{}
+		h.states[name] = s
+	}
+	s.failures++
+	s.lastError = err.Error()
+	if s.failures >= h.threshold() {
+		// Opening, or re-opening after a failed retry
+		s.openedAt = h.now()
+	}
+}
+
+// checkHealth returns an error if the function is unhealthy.
+func (o *Orchestrator) checkHealth(name string) error {
+	if o.Health == nil {
+		return nil
+	}
+	if status := o.Health.Status(name); !status.Healthy {
+		return fmt.Errorf("function is unhealthy after %d consecutive failures (last error: %s)", status.ConsecutiveFailures, status.LastError)
+	}
+	return nil
+}
+
+func (o *Orchestrator) recordHealth(name string, err error) {
+	if o.Health != nil {
+		o.Health.Record(name, err)
+	}
+}
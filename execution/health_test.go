@@ -0,0 +1,61 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+func TestUnhealthyFunctionFailsFastUntilCooldown(t *testing.T) {
+	calls := 0
+	o := newTestOrchestrator(time.Second, func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		calls++
+		return FuncResult{}, errors.New("backend down")
+	})
+	now := time.Now()
+	o.Health = &HealthTracker{FailureThreshold: 2, Cooldown: time.Minute, Now: func() time.Time { return now }}
+	execute := func() {
+		t.Helper()
+		if _, err := o.Execute(context.Background(), []parser.PlannedFuncCall{{Name: "slow"}}, &progress.NoOp{}); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+
+	execute()
+	execute()
+	if o.Health.Healthy("slow") {
+		t.Fatal("the function is healthy after reaching the failure threshold")
+	}
+	execute()
+	if calls != 2 {
+		t.Fatalf("the executor ran %d times, want 2: unhealthy calls must fail fast", calls)
+	}
+
+	// After the cooldown the function gets another chance
+	now = now.Add(time.Minute)
+	execute()
+	if calls != 3 {
+		t.Fatalf("the executor ran %d times after the cooldown, want 3", calls)
+	}
+	if got := o.Health.Unhealthy(); len(got) != 1 || got[0] != "slow" {
+		t.Fatalf("unhealthy functions are %v after a new failure", got)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// slowCallNotice is the fraction of the timeout after which the user is told
+// that a call is still running.
+const slowCallNotice = 0.75
+
+type livenessContextKey struct{}
+
+// liveness records the heartbeats of an executor.
+type liveness struct {
+	last atomic.Int64
+}
+
+func withLiveness(ctx context.Context, l *liveness) context.Context {
+	return context.WithValue(ctx, livenessContextKey{}, l)
+}
+
+// beatSince reports whether a heartbeat was received at or after t.
+func (l *liveness) beatSince(t time.Time) bool {
+	return l.last.Load() >= t.UnixNano()
+}
+
+// Heartbeat reports that the executor running with ctx is still making progress.
+// When the Orchestrator has a TimeoutExtension, an executor that sends a heartbeat
+// after the slow-call notice (at three quarters of the timeout) has its deadline
+// extended once instead of timing out. It does nothing outside of an executor.
+func Heartbeat(ctx context.Context) {
+	if l, ok := ctx.Value(livenessContextKey{}).(*liveness); ok {
+		l.last.Store(time.Now().UnixNano())
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/blob"
+	"github.com/nlpodyssey/funcallarchitect/codec"
+)
+
+// MemoStore persists the results of function calls by fingerprint, so that
+// memoization survives across requests and restarts.
+type MemoStore interface {
+	// Load returns the encoded entry stored under key, reporting whether it exists.
+	Load(ctx context.Context, key string) ([]byte, bool, error)
+	// Store saves the encoded entry under key, replacing any previous one.
+	Store(ctx context.Context, key string, data []byte) error
+}
+
+// memoEntry is the persisted form of a FuncResult. Values are stored through
+// the memo codec and come back as generic decoded values (maps, slices, numbers),
+// except for spilled values, whose Artifact is stored apart, and for values of
+// types registered with codec.RegisterValue, which are decoded back into them.
+type memoEntry struct {
+	Present   bool           `json:"present"`
+	Type      string         `json:"type,omitempty"`
+	Value     any            `json:"value,omitempty"`
+	Artifact  *blob.Artifact `json:"artifact,omitempty"`
+	Formatted string         `json:"formatted,omitempty"`
+	Metadata  any            `json:"metadata,omitempty"`
+	// Candidates are decoded as generic values, like Value.
+	Candidates []Candidate `json:"candidates,omitempty"`
+	StoredAt   time.Time   `json:"stored_at,omitempty"`
+}
+
+func (o *Orchestrator) memoCodec() codec.Codec {
+	if o.MemoCodec == nil {
+		return codec.JSON
+	}
+	return o.MemoCodec
+}
+
+// loadMemo returns the memoized result of the call with the given fingerprint, if any.
+// Failures and expired entries are logged and treated as misses.
+func (o *Orchestrator) loadMemo(ctx context.Context, name, fingerprint string, args map[string]any) (FuncResult, bool) {
+	if o.Memo == nil || isRefreshing(ctx) {
+		return FuncResult{}, false
+	}
+	data, ok, err := o.Memo.Load(ctx, fingerprint)
+	if err != nil {
+		o.logger(ctx).Printf("Failed to load memoized result of %s: %v", name, err)
+		return FuncResult{}, false
+	}
+	if !ok {
+		return FuncResult{}, false
+	}
+	var entry memoEntry
+	if err := o.memoCodec().Unmarshal(data, &entry); err != nil {
+		o.logger(ctx).Printf("Failed to decode memoized result of %s: %v", name, err)
+		return FuncResult{}, false
+	}
+	if o.MemoTTL > 0 && Now(ctx).Sub(entry.StoredAt) > o.MemoTTL {
+		o.logger(ctx).Printf("Memoized result of %s expired", name)
+		return FuncResult{}, false
+	}
+	o.trackMemo(fingerprint, name, args, true, entry.StoredAt)
+	formatted := entry.Formatted
+	value := entry.Value
+	if entry.Artifact != nil {
+		value = *entry.Artifact
+	}
+	if entry.Type != "" {
+		var err error
+		if value, err = decodeMemoValue(entry.Type, value); err != nil {
+			o.logger(ctx).Printf("Failed to decode memoized result of %s: %v", name, err)
+			return FuncResult{}, false
+		}
+	}
+	return FuncResult{
+		Present:    entry.Present,
+		Value:      value,
+		FormatFunc: func() (string, error) { return formatted, nil },
+		Metadata:   entry.Metadata,
+		Candidates: entry.Candidates,
+	}, true
+}
+
+// storeMemo persists the result of the call with the given fingerprint.
+// Failures are logged, since they only affect later requests.
+func (o *Orchestrator) storeMemo(ctx context.Context, name, fingerprint string, args map[string]any, result FuncResult) {
+	if o.Memo == nil {
+		return
+	}
+	entry := memoEntry{Present: result.Present, Value: result.Value, Metadata: result.Metadata, Candidates: result.Candidates, StoredAt: Now(ctx)}
+	if artifact, ok := result.Value.(blob.Artifact); ok {
+		entry.Value, entry.Artifact = nil, &artifact
+	} else if _, ok := codec.ValueType(result.Value); ok {
+		tv, err := codec.EncodeValue(result.Value)
+		if err != nil {
+			o.logger(ctx).Printf("Not memoizing %s: %v", name, err)
+			return
+		}
+		// The generic form keeps the entry in the memo codec
+		if entry.Value, err = tv.Generic(); err != nil {
+			o.logger(ctx).Printf("Not memoizing %s: %v", name, err)
+			return
+		}
+		entry.Type = tv.Type
+	}
+	if result.FormatFunc != nil {
+		formatted, err := result.FormatFunc()
+		if err != nil {
+			o.logger(ctx).Printf("Not memoizing %s: error formatting result: %v", name, err)
+			return
+		}
+		entry.Formatted = formatted
+	}
+	data, err := o.memoCodec().Marshal(entry)
+	if err != nil {
+		o.logger(ctx).Printf("Failed to encode result of %s for memoization: %v", name, err)
+		return
+	}
+	if err := o.Memo.Store(ctx, fingerprint, data); err != nil {
+		o.logger(ctx).Printf("Failed to store memoized result of %s: %v", name, err)
+		return
+	}
+	o.trackMemo(fingerprint, name, args, false, entry.StoredAt)
+}
+
+// decodeMemoValue decodes the generic form of a value of a registered type.
+func decodeMemoValue(typ string, value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling value: %w", err)
+	}
+	return codec.DecodeValue(codec.TypedValue{Type: typ, Data: data})
+}
+
+// DirMemoStore is a MemoStore keeping one file per entry in a directory.
+type DirMemoStore struct {
+	Dir string
+}
+
+// NewDirMemoStore creates the directory if needed and returns a DirMemoStore using it.
+func NewDirMemoStore(dir string) (*DirMemoStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating memo directory: %w", err)
+	}
+	return &DirMemoStore{Dir: dir}, nil
+}
+
+func (d *DirMemoStore) Load(_ context.Context, key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(filepath.Join(d.Dir, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (d *DirMemoStore) Store(_ context.Context, key string, data []byte) error {
+	// Write to a temporary file first, so that readers never see partial entries
+	tmp, err := os.CreateTemp(d.Dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(d.Dir, key))
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"strings"
+)
+
+// KeyNormalizer maps the arguments of a call to the canonical form used for its
+// memoization key, so that near-identical argument sets (e.g. "Turin" and " turin")
+// share the same cache entry. Executors still receive the original arguments.
+// A KeyNormalizer must not modify args.
+type KeyNormalizer func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error)
+
+// RegisterKeyNormalizer sets the KeyNormalizer of a function, enabling the semantic
+// deduplication of its calls. Results are shared between the calls whose normalized
+// arguments are equal, so the normalizer must only merge arguments that the function
+// treats as equivalent.
+func (o *Orchestrator) RegisterKeyNormalizer(name string, normalizer KeyNormalizer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.keyNormalizers[name] = normalizer
+}
+
+// memoKey returns the memoization key of a call. Normalization failures are
+// logged and fall back to the original arguments.
+func (o *Orchestrator) memoKey(ctx context.Context, name string, args map[string]interface{}) string {
+	o.mu.RLock()
+	normalizer, ok := o.keyNormalizers[name]
+	o.mu.RUnlock()
+	if !ok {
+		return generateFingerprint(name, args)
+	}
+
+	normalized, err := normalizer(ctx, args)
+	if err != nil {
+		o.logger(ctx).Printf("Failed to normalize the arguments of %s, using them as they are: %v", name, err)
+		return generateFingerprint(name, args)
+	}
+	return generateFingerprint(name, normalized)
+}
+
+// NormalizeText returns a KeyNormalizer that case-folds the given string arguments,
+// trimming and collapsing their whitespace.
+func NormalizeText(argNames ...string) KeyNormalizer {
+	return func(_ context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+		normalized := make(map[string]interface{}, len(args))
+		for k, v := range args {
+			normalized[k] = v
+		}
+		for _, name := range argNames {
+			if s, ok := args[name].(string); ok {
+				normalized[name] = strings.ToLower(strings.Join(strings.Fields(s), " "))
+			}
+		}
+		return normalized, nil
+	}
+}
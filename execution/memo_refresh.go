@@ -0,0 +1,143 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// maxTrackedMemoCalls bounds the calls whose memoized results are tracked
+// for RefreshMemo; the least used ones are forgotten first.
+const maxTrackedMemoCalls = 1024
+
+// memoUses tracks the use of the memoized results, when they expire.
+type memoUses struct {
+	mu    sync.Mutex
+	calls map[string]*memoUse
+}
+
+type memoUse struct {
+	name     string
+	args     map[string]any
+	hits     int
+	storedAt time.Time
+}
+
+// trackMemo records the use of the memoized result of a call: a hit, or a
+// store at the given time.
+func (o *Orchestrator) trackMemo(fingerprint, name string, args map[string]any, hit bool, storedAt time.Time) {
+	if o.MemoTTL <= 0 {
+		return
+	}
+	u := &o.memoUses
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.calls == nil {
+		u.calls = make(map[string]*memoUse)
+	}
+	use, ok := u.calls[fingerprint]
+	if !ok {
+		if len(u.calls) >= maxTrackedMemoCalls {
+			u.evict()
+		}
+		use = &memoUse{name: name, args: args}
+		u.calls[fingerprint] = use
+	}
+	if hit {
+		use.hits++
+	}
+	use.storedAt = storedAt
+}
+
+// evict forgets the least used call. The caller holds mu.
+func (u *memoUses) evict() {
+	var least string
+	for fingerprint, use := range u.calls {
+		if least == "" || use.hits < u.calls[least].hits {
+			least = fingerprint
+		}
+	}
+	delete(u.calls, least)
+}
+
+type refreshContextKey struct{}
+
+// isRefreshing reports whether ctx is the one of RefreshMemo, which bypasses
+// the memoized results.
+func isRefreshing(ctx context.Context) bool {
+	return ctx.Value(refreshContextKey{}) != nil
+}
+
+// RefreshMemo executes again the calls whose memoized results expire within
+// the given time (see MemoTTL), and were used at least minHits times since
+// they were stored, so that the frequent requests keep finding them. It
+// returns the number of results refreshed; failures are logged.
+//
+// The calls run with ctx rather than the one of the requests, so only the
+// ones depending on their arguments alone are refreshed: the calls of
+// volatile and side-effect functions never are.
+func (o *Orchestrator) RefreshMemo(ctx context.Context, within time.Duration, minHits int) int {
+	if o.Memo == nil || o.MemoTTL <= 0 {
+		return 0
+	}
+	type due struct {
+		name string
+		args map[string]any
+		hits int
+	}
+	var calls []due
+	now := Now(ctx)
+	u := &o.memoUses
+	u.mu.Lock()
+	for fingerprint, use := range u.calls {
+		if o.isVolatile(use.name) || o.isSideEffect(use.name) {
+			delete(u.calls, fingerprint)
+			continue
+		}
+		expires := use.storedAt.Add(o.MemoTTL)
+		switch {
+		case use.hits >= max(minHits, 1) && expires.Sub(now) <= within:
+			calls = append(calls, due{use.name, use.args, use.hits})
+			use.hits = 0
+		case now.After(expires):
+			// Expired without being used enough: the next call stores it again
+			delete(u.calls, fingerprint)
+		}
+	}
+	u.mu.Unlock()
+
+	// The most used first, in case ctx ends before all are refreshed
+	slices.SortStableFunc(calls, func(a, b due) int { return cmp.Compare(b.hits, a.hits) })
+	ctx = context.WithValue(ctx, refreshContextKey{}, true)
+	refreshed := 0
+	for _, call := range calls {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := o.executeFunc(ctx, parser.PlannedFuncCall{Name: call.name, Args: call.args}, &progress.NoOp{}); err != nil {
+			o.logger(ctx).Printf("Failed to refresh the memoized result of %s: %v", call.name, err)
+			continue
+		}
+		refreshed++
+	}
+	return refreshed
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// clockAt returns a context whose clock is frozen at start plus offset.
+func clockAt(start time.Time, offset time.Duration) context.Context {
+	return WithClock(context.Background(), func() time.Time { return start.Add(offset) })
+}
+
+func TestMemoExpiresWithTheClockOfTheRequest(t *testing.T) {
+	var runs atomic.Int32
+	o := newMemoOrchestrator(newTestMemoStore(t), &runs, 0)
+	o.MemoTTL = time.Hour
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	executeOne(t, o, clockAt(start, 0), "lookup")
+	if call := executeOne(t, o, clockAt(start, 30*time.Minute), "lookup"); !call.Cached {
+		t.Fatal("the result was not memoized")
+	}
+	if call := executeOne(t, o, clockAt(start, 2*time.Hour), "lookup"); call.Cached {
+		t.Fatal("the expired result was reused")
+	}
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("lookup ran %d times, want 2", n)
+	}
+}
+
+func TestRefreshMemoRefreshesTheUsedResultsAboutToExpire(t *testing.T) {
+	var runs atomic.Int32
+	o := newMemoOrchestrator(newTestMemoStore(t), &runs, 0)
+	o.MemoTTL = time.Hour
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	executeOne(t, o, clockAt(start, 0), "lookup")
+	executeOne(t, o, clockAt(start, 10*time.Minute), "lookup")
+	for range 3 {
+		executeOne(t, o, clockAt(start, 10*time.Minute), "now")
+	}
+	runsBefore := runs.Load()
+
+	if n := o.RefreshMemo(clockAt(start, 30*time.Minute), 15*time.Minute, 1); n != 0 {
+		t.Fatalf("refreshed %d results expiring in 30 minutes, want 0", n)
+	}
+	// Only the stable call is refreshed: the volatile ones were never memoized
+	if n := o.RefreshMemo(clockAt(start, 50*time.Minute), 15*time.Minute, 1); n != 1 {
+		t.Fatalf("refreshed %d results, want 1", n)
+	}
+	if n := runs.Load() - runsBefore; n != 1 {
+		t.Fatalf("the refresh ran %d functions, want 1", n)
+	}
+
+	// The refreshed result is valid for another TTL
+	if call := executeOne(t, o, clockAt(start, 100*time.Minute), "lookup"); !call.Cached {
+		t.Fatal("the refreshed result was not reused")
+	}
+	if n := o.RefreshMemo(clockAt(start, 100*time.Minute), 15*time.Minute, 2); n != 0 {
+		t.Fatalf("refreshed %d results used less than the minimum, want 0", n)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// newMemoOrchestrator returns an orchestrator with the stable function
+// "lookup" and the volatile function "now", both counting their runs, and
+// memoizing in store.
+func newMemoOrchestrator(store MemoStore, runs *atomic.Int32, delay time.Duration) *Orchestrator {
+	ts := &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{Name: "lookup", Parameters: tools.TypeInfo{Type: "object"}, Returns: tools.TypeInfo{Type: "string"}},
+			{Name: "now", Parameters: tools.TypeInfo{Type: "object"}, Returns: tools.TypeInfo{Type: "string"}, Volatile: true},
+		},
+	}
+	o := NewOrchestrator(log.New(io.Discard, "", 0), time.Second, false, ts)
+	o.Memo = store
+	executor := func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		n := runs.Add(1)
+		time.Sleep(delay)
+		return FuncResult{Present: true, Value: "result", FormatFunc: func() (string, error) { return fmt.Sprintf("run %d", n), nil }}, nil
+	}
+	o.RegisterFunction("lookup", executor)
+	o.RegisterFunction("now", executor)
+	return o
+}
+
+func newTestMemoStore(t *testing.T) MemoStore {
+	t.Helper()
+	store, err := NewDirMemoStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func executeOne(t *testing.T, o *Orchestrator, ctx context.Context, name string) *ExecutedFuncCall {
+	t.Helper()
+	result, err := o.Execute(ctx, []parser.PlannedFuncCall{{Name: name, Args: map[string]any{"key": "a"}}}, &progress.NoOp{})
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return result.FuncCalls[0]
+}
+
+func TestMemoIsSharedByTheOrchestrators(t *testing.T) {
+	store := newTestMemoStore(t)
+	var runs atomic.Int32
+	first := executeOne(t, newMemoOrchestrator(store, &runs, 0), context.Background(), "lookup")
+	second := executeOne(t, newMemoOrchestrator(store, &runs, 0), context.Background(), "lookup")
+
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("lookup ran %d times, want 1", n)
+	}
+	if !second.Cached {
+		t.Error("the second call is not reported as cached")
+	}
+	want, _ := first.Result.FormatFunc()
+	if got, _ := second.Result.FormatFunc(); got != want {
+		t.Errorf("the memoized result is formatted as %q, want %q", got, want)
+	}
+}
+
+func TestVolatileCallsAreNeitherMemoizedNorMerged(t *testing.T) {
+	var runs atomic.Int32
+	o := newMemoOrchestrator(newTestMemoStore(t), &runs, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := o.Execute(context.Background(), []parser.PlannedFuncCall{{Name: "now", Args: map[string]any{"key": "a"}}}, &progress.NoOp{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("the concurrent calls ran %d times, want 2", n)
+	}
+
+	if call := executeOne(t, o, context.Background(), "now"); call.Cached {
+		t.Error("the volatile call was memoized")
+	}
+	if n := runs.Load(); n != 3 {
+		t.Fatalf("now ran %d times, want 3", n)
+	}
+}
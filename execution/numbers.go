@@ -0,0 +1,141 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// NumberMode controls how the numbers of planned arguments, which the parser
+// decodes as json.Number, are passed to executors.
+type NumberMode int
+
+const (
+	// NumbersAsFloat64 converts every number to float64, like encoding/json
+	// does by default. Integers beyond 2^53 lose precision.
+	NumbersAsFloat64 NumberMode = iota
+	// NumbersPreserved converts integers to int64 and other numbers to float64,
+	// keeping as json.Number the values that neither type represents exactly
+	// (e.g. large IDs), so that they are re-marshaled unchanged.
+	NumbersPreserved
+)
+
+func (m NumberMode) String() string {
+	if m == NumbersPreserved {
+		return "preserved"
+	}
+	return "float64"
+}
+
+// normalizeNumbers converts the json.Number values contained in v according to the mode.
+func normalizeNumbers(v interface{}, mode NumberMode) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		return convertNumber(x, mode)
+	case map[string]interface{}:
+		for k, item := range x {
+			x[k] = normalizeNumbers(item, mode)
+		}
+		return x
+	case []interface{}:
+		for i, item := range x {
+			x[i] = normalizeNumbers(item, mode)
+		}
+		return x
+	default:
+		return v
+	}
+}
+
+func convertNumber(n json.Number, mode NumberMode) interface{} {
+	if mode == NumbersPreserved {
+		if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+			return i
+		}
+		f, err := strconv.ParseFloat(string(n), 64)
+		if err != nil || math.IsInf(f, 0) || !isExactFloat(string(n), f) {
+			return n
+		}
+		return f
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return n
+	}
+	return f
+}
+
+// isExactFloat reports whether the literal survives a round trip through f,
+// which is false for integers too large for int64 and float64 alike.
+func isExactFloat(literal string, f float64) bool {
+	for _, c := range literal {
+		if c == '.' || c == 'e' || c == 'E' {
+			return true // decimal literals are approximated by design
+		}
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64) == literal
+}
+
+// IntArg returns the value of an integer argument, whatever the NumberMode.
+// The boolean is false if the argument is missing.
+func IntArg(args map[string]interface{}, name string) (int64, bool, error) {
+	switch n := args[name].(type) {
+	case nil:
+		return 0, false, nil
+	case int:
+		return int64(n), true, nil
+	case int64:
+		return n, true, nil
+	case float64:
+		if n != math.Trunc(n) || math.Abs(n) > math.MaxInt64 {
+			return 0, false, fmt.Errorf("argument %s must be an integer", name)
+		}
+		return int64(n), true, nil
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			return 0, false, fmt.Errorf("argument %s must be an integer", name)
+		}
+		return i, true, nil
+	default:
+		return 0, false, fmt.Errorf("argument %s must be an integer", name)
+	}
+}
+
+// FloatArg returns the value of a numeric argument, whatever the NumberMode.
+// The boolean is false if the argument is missing.
+func FloatArg(args map[string]interface{}, name string) (float64, bool, error) {
+	switch n := args[name].(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return n, true, nil
+	case int:
+		return float64(n), true, nil
+	case int64:
+		return float64(n), true, nil
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return 0, false, fmt.Errorf("argument %s must be a number", name)
+		}
+		return f, true, nil
+	default:
+		return 0, false, fmt.Errorf("argument %s must be a number", name)
+	}
+}
@@ -15,16 +15,23 @@
 package execution
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"sort"
-	"strings"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/nlpodyssey/funcallarchitect/blob"
+	"github.com/nlpodyssey/funcallarchitect/codec"
+	"github.com/nlpodyssey/funcallarchitect/logging"
 	"github.com/nlpodyssey/funcallarchitect/parser"
 	"github.com/nlpodyssey/funcallarchitect/progress"
 	"github.com/nlpodyssey/funcallarchitect/tools"
@@ -38,22 +45,79 @@ import (
 // while FuncResult.Present indicates whether data was found/generated.
 type FuncExecutor func(ctx context.Context, args map[string]interface{}, progress progress.Stream) (FuncResult, error)
 
-// Orchestrator holds the context for function execution, including memoization
+// Orchestrator holds the context for function execution, including memoization.
+// Functions and tools can be registered while executions are running.
 type Orchestrator struct {
-	Functions map[string]FuncExecutor
-	inFlight  singleflight.Group
-	Logger    *log.Logger
-	Timeout   time.Duration
+	mu             sync.RWMutex
+	functions      map[string]FuncExecutor
+	keyNormalizers map[string]KeyNormalizer
+	compensators   map[string]Compensator
+	expanders      map[string]Expander
+	// resultSizeLimits holds the per-function overrides of MaxResultSize
+	resultSizeLimits map[string]int
+	toolSet          *tools.ToolSet
+
+	inFlight singleflight.Group
+	Logger   *log.Logger
+	Timeout  time.Duration
+	// TimeoutExtension, if positive, is the additional time granted once to
+	// the executors that report their liveness through Heartbeat.
+	TimeoutExtension time.Duration
 
 	EnableConcurrentExec bool
-	ToolSet              *tools.ToolSet
+
+	// NumberMode controls how the numbers of planned arguments are passed to executors.
+	NumberMode NumberMode
+
+	// Approver, if set, is consulted before executing side-effect functions.
+	Approver Approver
+	// Chooser, if set, selects the candidate bound to an argument when a
+	// nested call returns several of them (see FuncResult.Candidates).
+	Chooser Chooser
+	// AuditLog, if set, receives a Receipt for every side-effect function call.
+	AuditLog AuditLog
+	// CompensateOnFailure runs the compensators of the side effects performed
+	// by a plan whose execution fails (see RegisterCompensator).
+	CompensateOnFailure bool
+	// Transactional executes the plans involving side effects as transactions
+	// (see ExecuteTransaction).
+	Transactional bool
+
+	// Health, if set, tracks failures per function and fails the calls of
+	// unhealthy functions immediately.
+	Health *HealthTracker
+
+	// Memo, if set, persists the results of functions without side effects
+	// across requests. MemoCodec selects their encoding (JSON by default).
+	// MemoTTL, if positive, is how long the results are valid (see RefreshMemo).
+	Memo      MemoStore
+	MemoCodec codec.Codec
+	MemoTTL   time.Duration
+
+	// MaxResultSize, if positive, is the maximum size in bytes of the JSON encoding
+	// of the values returned by functions (see SetMaxResultSize for per-function limits).
+	// Oversized values are spilled to Blobs and replaced by a blob.Artifact.
+	MaxResultSize int
+	Blobs         blob.Store
+
+	executors executorTracker
+	memoUses  memoUses
 }
 
+// errTimeout is the error of the calls exceeding their deadline.
+var errTimeout = errors.New("function execution timed out")
+
 // Error represents an error that occurred during function execution
 type Error struct {
 	FuncName string
 	ArgName  string
-	Err      error
+	// Args are the arguments of the call, set when the executor failed or timed out.
+	Args map[string]interface{}
+	Err  error
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
 }
 
 func (e *Error) Error() string {
@@ -63,6 +127,36 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("error in function '%s': %v", e.FuncName, e.Err)
 }
 
+// PartialError is returned by Execute when some of the main calls failed,
+// with the result of the ones completed before the failure.
+type PartialError struct {
+	// Completed holds the completed main calls, in the order of the plan.
+	Completed *Result
+	Err       error
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+// partial wraps err into a PartialError when some of the calls completed.
+func partial(calls []*ExecutedFuncCall, err error) error {
+	var completed []*ExecutedFuncCall
+	for _, f := range calls {
+		if f != nil {
+			completed = append(completed, f)
+		}
+	}
+	if len(completed) == 0 {
+		return err
+	}
+	return &PartialError{Completed: &Result{FuncCalls: completed}, Err: err}
+}
+
 type FormattableError struct {
 	FormatFunc FormatFunc
 }
@@ -93,22 +187,110 @@ func AsFormattableError(err error) (*FormattableError, bool) {
 // NewOrchestrator creates a new Orchestrator
 func NewOrchestrator(logger *log.Logger, timeout time.Duration, enableConcurrentExec bool, toolSet *tools.ToolSet) *Orchestrator {
 	return &Orchestrator{
-		Functions:            make(map[string]FuncExecutor),
+		functions:            make(map[string]FuncExecutor),
+		keyNormalizers:       make(map[string]KeyNormalizer),
+		resultSizeLimits:     make(map[string]int),
+		compensators:         make(map[string]Compensator),
+		expanders:            make(map[string]Expander),
+		toolSet:              toolSet,
 		Logger:               logger,
 		Timeout:              timeout,
 		EnableConcurrentExec: enableConcurrentExec,
-		ToolSet:              toolSet,
 	}
 }
 
 // RegisterFunction registers a function executor with the context
 func (o *Orchestrator) RegisterFunction(name string, executor FuncExecutor) {
-	o.Functions[name] = executor
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.functions[name] = executor
+}
+
+// UnregisterFunction removes the executor of a function. Calls already running are not affected.
+func (o *Orchestrator) UnregisterFunction(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.functions, name)
+}
+
+// RegisterTool adds (or replaces) the definition of a function together with its executor.
+// The ToolSet is copied on write, so that ToolSets returned earlier are never modified.
+func (o *Orchestrator) RegisterTool(definition tools.FuncDefinition, executor FuncExecutor) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.setDefinition(definition)
+	delete(o.expanders, definition.Name)
+	o.functions[definition.Name] = executor
+}
+
+// setDefinition replaces the definition of a function in a copy of the ToolSet.
+// It must be called with o.mu locked.
+func (o *Orchestrator) setDefinition(definition tools.FuncDefinition) {
+	ts := &tools.ToolSet{}
+	if o.toolSet != nil {
+		ts.TypeDefinitions = o.toolSet.TypeDefinitions
+		for _, f := range o.toolSet.Functions {
+			if f.Name != definition.Name {
+				ts.Functions = append(ts.Functions, f)
+			}
+		}
+	}
+	ts.Functions = append(ts.Functions, definition)
+	o.toolSet = ts
+}
+
+// ToolSet returns the definitions of the functions. It must not be modified.
+func (o *Orchestrator) ToolSet() *tools.ToolSet {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.toolSet
+}
+
+// SetToolSet replaces the definitions of the functions.
+func (o *Orchestrator) SetToolSet(ts *tools.ToolSet) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.toolSet = ts
+}
+
+// logger returns the request-scoped logger carried by ctx, falling back to Logger.
+func (o *Orchestrator) logger(ctx context.Context) *log.Logger {
+	return logging.FromContext(ctx, o.Logger)
+}
+
+func (o *Orchestrator) lookupFunction(name string) (FuncExecutor, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	executor, ok := o.functions[name]
+	return executor, ok
 }
 
 // Execute executes a slice of PlannedFuncCall and returns the results
 func (o *Orchestrator) Execute(ctx context.Context, functions []parser.PlannedFuncCall, progress progress.Stream) (*Result, error) {
-	if o.EnableConcurrentExec {
+	functions, err := o.expandAll(functions)
+	if err != nil {
+		return nil, err
+	}
+	if o.Transactional {
+		return o.ExecuteTransaction(ctx, functions, progress)
+	}
+	if !o.CompensateOnFailure {
+		return o.execute(ctx, functions, progress)
+	}
+
+	ctx, receipts := CollectReceipts(ctx)
+	result, err := o.execute(ctx, functions, progress)
+	if err != nil {
+		if cerr := o.Compensate(ctx, receipts()); cerr != nil {
+			o.logger(ctx).Printf("Some side effects could not be compensated: %v", cerr)
+		}
+	}
+	return result, err
+}
+
+func (o *Orchestrator) execute(ctx context.Context, functions []parser.PlannedFuncCall, progress progress.Stream) (*Result, error) {
+	// A single call gains nothing from the errgroup machinery
+	if o.EnableConcurrentExec && len(functions) > 1 {
 		return o.executeConcurrent(ctx, functions, progress)
 	}
 	return o.executeSeq(ctx, functions, progress)
@@ -118,13 +300,13 @@ func (o *Orchestrator) executeSeq(ctx context.Context, functions []parser.Planne
 	functionsExecution := make([]*ExecutedFuncCall, len(functions))
 
 	for i, function := range functions {
-		o.Logger.Printf("Executing function: %s", function.Name)
+		o.logger(ctx).Printf("Executing function: %s", function.Name)
 		funcExe, err := o.executeFunc(ctx, function, progress)
 		if err != nil {
-			return nil, &Error{FuncName: function.Name, Err: err}
+			return nil, partial(functionsExecution, &Error{FuncName: function.Name, Err: err})
 		}
 		functionsExecution[i] = funcExe
-		o.Logger.Printf("Function %s executed successfully", function.Name)
+		o.logger(ctx).Printf("Function %s executed successfully", function.Name)
 	}
 
 	exe := &Result{FuncCalls: functionsExecution}
@@ -139,20 +321,20 @@ func (o *Orchestrator) executeConcurrent(ctx context.Context, functions []parser
 	for i, function := range functions {
 		i, function := i, function
 		group.Go(func() error {
-			o.Logger.Printf("Executing function: %s", function.Name)
+			o.logger(ctx).Printf("Executing function: %s", function.Name)
 			funcExe, err := o.executeFunc(ctx, function, progress)
 			if err != nil {
 				return &Error{FuncName: function.Name, Err: err}
 			}
 			functionsExecution[i] = funcExe
-			o.Logger.Printf("Function %s executed successfully", function.Name)
+			o.logger(ctx).Printf("Function %s executed successfully", function.Name)
 			return nil
 		})
 	}
 
 	// Wait for all functions to complete or for an error to occur
 	if err := group.Wait(); err != nil {
-		return nil, err
+		return nil, partial(functionsExecution, err)
 	}
 
 	exe := &Result{FuncCalls: functionsExecution}
@@ -161,7 +343,7 @@ func (o *Orchestrator) executeConcurrent(ctx context.Context, functions []parser
 
 // executeFunc executes a single PlannedFunctionCall
 func (o *Orchestrator) executeFunc(ctx context.Context, function parser.PlannedFuncCall, progress progress.Stream) (*ExecutedFuncCall, error) {
-	executor, ok := o.Functions[function.Name]
+	executor, ok := o.lookupFunction(function.Name)
 	if !ok {
 		return nil, &Error{FuncName: function.Name, Err: fmt.Errorf("unknown function")}
 	}
@@ -178,42 +360,164 @@ func (o *Orchestrator) executeFunc(ctx context.Context, function parser.PlannedF
 	}
 
 	processedArgs := createProcessedArgs(argsExecution)
+	if err := o.resolveArtifacts(ctx, processedArgs); err != nil {
+		return nil, &Error{FuncName: function.Name, Err: err}
+	}
 
-	// Generate a fingerprint for memoization
-	fingerprint := generateFingerprint(function.Name, processedArgs)
+	// Generate a fingerprint for memoization, normalized if the function has a KeyNormalizer
+	fingerprint := o.memoKey(ctx, function.Name, processedArgs)
 
-	// Use singleflight for both caching and concurrency control
-	result, err, _ := o.inFlight.Do(fingerprint, func() (interface{}, error) {
-		// Create a context with timeout
-		execCtx, cancel := context.WithTimeout(ctx, o.Timeout)
-		defer cancel()
+	// Side-effect functions require approval and leave an audit receipt
+	var receipt *Receipt
+	if o.isSideEffect(function.Name) {
+		receipt = newReceipt(ctx, function.Name, processedArgs)
+		approved, err := o.approve(ctx, function.Name, processedArgs)
+		if err != nil {
+			return nil, &Error{FuncName: function.Name, Err: fmt.Errorf("approval failed: %w", err)}
+		}
+		receipt.Approved = approved
+		if !approved {
+			o.logger(ctx).Printf("Function %s was not approved", function.Name)
+			receipt.Outcome = ReceiptRejected
+			o.recordReceipt(ctx, receipt)
+			return &ExecutedFuncCall{
+				Name:    function.Name,
+				Purpose: function.Purpose,
+				Args:    argsExecution,
+				Result: FuncResult{
+					Present: false,
+					FormatFunc: func() (string, error) {
+						return fmt.Sprintf("The action %s was not approved and has not been performed.", function.Name), nil
+					},
+				},
+				Receipt: receipt,
+			}, nil
+		}
+	}
+
+	// Use singleflight for both caching and concurrency control, except for
+	// volatile functions, whose identical calls may have different results,
+	// and side-effect functions, whose identical calls must all be performed
+	started := time.Now()
+	ran := false
+	volatile := o.isVolatile(function.Name)
+	call := func() (interface{}, error) {
+		memoizable := receipt == nil && !volatile
+		if memoizable {
+			if memoized, ok := o.loadMemo(ctx, function.Name, fingerprint, processedArgs); ok {
+				o.logger(ctx).Printf("Function %s memoized", function.Name)
+				return memoized, nil
+			}
+		}
 
-		// Execute the function with timeout
-		resultChan := make(chan FuncResult, 1)
-		errChan := make(chan error, 1)
+		if err := o.checkHealth(function.Name); err != nil {
+			return nil, &Error{FuncName: function.Name, Err: err}
+		}
+
+		// Create a context with timeout. It is cancelled on return, which
+		// asks executors still running after a timeout to stop. The timeout is
+		// enforced below, since it can be extended for executors sending heartbeats.
+		execCtx, cancel := context.WithTimeout(ctx, o.Timeout+o.TimeoutExtension)
+		defer cancel()
+		execCtx = withCallContext(execCtx, &CallContext{
+			FuncName:      function.Name,
+			Purpose:       function.Purpose,
+			Args:          argsExecution,
+			ProcessedArgs: processedArgs,
+		})
+		live := &liveness{}
+		execCtx = withLiveness(execCtx, live)
+
+		// Execute the function with timeout. The channel is buffered so that
+		// the executor goroutine never blocks, even after a timeout.
+		type outcome struct {
+			result FuncResult
+			err    error
+		}
+		done := make(chan outcome, 1)
+		run := o.executors.begin()
+		ran = true
 
 		go func() {
-			result, err := executor(execCtx, processedArgs, progress)
-			if err != nil {
-				errChan <- &Error{FuncName: function.Name, Err: err}
-			} else {
-				resultChan <- result
+			// Label the executor goroutine so that profiles can be broken down by function
+			pprof.Do(execCtx, pprof.Labels("funcall_function", function.Name), func(execCtx context.Context) {
+				result, err := executor(execCtx, processedArgs, progress)
+				done <- outcome{result: result, err: err}
+			})
+			if run.end() {
+				o.logger(ctx).Printf("Function %s returned %s after being abandoned: it should honour context cancellation", function.Name, time.Since(run.started))
 			}
 		}()
 
-		select {
-		case result := <-resultChan:
-			// Store the result in memoization cache
-			o.Logger.Printf("Function %s executed", function.Name)
-			return result, nil
-		case err := <-errChan:
-			o.Logger.Printf("Error executing function %s: %v", function.Name, err)
-			return nil, err
-		case <-execCtx.Done():
-			o.Logger.Printf("Function %s timed out", function.Name)
-			return nil, &Error{FuncName: function.Name, Err: fmt.Errorf("function execution timed out")}
+		noticeAfter := time.Duration(float64(o.Timeout) * slowCallNotice)
+		noticedAt := run.started.Add(noticeAfter)
+		notice := time.NewTimer(noticeAfter)
+		defer notice.Stop()
+		expire := time.NewTimer(o.Timeout)
+		defer expire.Stop()
+		extended := false
+
+		for {
+			select {
+			case out := <-done:
+				o.recordHealth(function.Name, out.err)
+				if out.err != nil {
+					o.logger(ctx).Printf("Error executing function %s: %v", function.Name, out.err)
+					return nil, &Error{FuncName: function.Name, Args: processedArgs, Err: out.err}
+				}
+				o.logger(ctx).Printf("Function %s executed", function.Name)
+				result, err := o.spillResult(ctx, function.Name, out.result)
+				if err != nil {
+					return nil, &Error{FuncName: function.Name, Err: err}
+				}
+				out.result = result
+				if memoizable {
+					o.storeMemo(ctx, function.Name, fingerprint, processedArgs, out.result)
+				}
+				return out.result, nil
+			case <-notice.C:
+				progress.Send(fmt.Sprintf("Still working on %s...", function.Name))
+			case <-expire.C:
+				// Executors proving to be alive get more time, once
+				if o.TimeoutExtension > 0 && !extended && live.beatSince(noticedAt) {
+					extended = true
+					expire.Reset(o.TimeoutExtension)
+					o.logger(ctx).Printf("Function %s is alive, extending its deadline by %s", function.Name, o.TimeoutExtension)
+					progress.Send(fmt.Sprintf("%s is taking longer than expected, waiting up to %s more...", function.Name, o.TimeoutExtension))
+					continue
+				}
+				run.abandon()
+				o.logger(ctx).Printf("Function %s timed out", function.Name)
+				o.recordHealth(function.Name, errTimeout)
+				return nil, &Error{FuncName: function.Name, Args: processedArgs, Err: errTimeout}
+			case <-execCtx.Done():
+				run.abandon()
+				o.logger(ctx).Printf("Function %s timed out", function.Name)
+				// A cancelled request says nothing about the health of the function
+				if ctx.Err() == nil {
+					o.recordHealth(function.Name, errTimeout)
+				}
+				return nil, &Error{FuncName: function.Name, Args: processedArgs, Err: errTimeout}
+			}
 		}
-	})
+	}
+	var result interface{}
+	if volatile || receipt != nil {
+		result, err = call()
+	} else {
+		result, err, _ = o.inFlight.Do(fingerprint, call)
+	}
+
+	if receipt != nil {
+		if err != nil {
+			receipt.Outcome = ReceiptFailed
+			receipt.Error = err.Error()
+		} else {
+			receipt.Outcome = ReceiptPerformed
+			receipt.Details = result.(FuncResult).Metadata
+		}
+		o.recordReceipt(ctx, receipt)
+	}
 
 	if err != nil {
 		return nil, err
@@ -222,13 +526,52 @@ func (o *Orchestrator) executeFunc(ctx context.Context, function parser.PlannedF
 	funcResult := result.(FuncResult)
 
 	return &ExecutedFuncCall{
-		Name:    function.Name,
-		Purpose: function.Purpose,
-		Args:    argsExecution,
-		Result:  funcResult,
+		Name:     function.Name,
+		Purpose:  function.Purpose,
+		Args:     argsExecution,
+		Result:   funcResult,
+		Receipt:  receipt,
+		Duration: time.Since(started),
+		Cached:   !ran,
+		Render:   o.renderHint(function.Name),
 	}, nil
 }
 
+func (o *Orchestrator) renderHint(name string) string {
+	ts := o.ToolSet()
+	if def, ok := ts.FindTool(name); ok {
+		return ts.RenderHint(def)
+	}
+	return ""
+}
+
+func (o *Orchestrator) isSideEffect(name string) bool {
+	def, ok := o.ToolSet().FindTool(name)
+	return ok && def.SideEffect
+}
+
+func (o *Orchestrator) isVolatile(name string) bool {
+	def, ok := o.ToolSet().FindTool(name)
+	return ok && def.Volatile
+}
+
+func (o *Orchestrator) approve(ctx context.Context, name string, args map[string]any) (bool, error) {
+	if o.Approver == nil {
+		return true, nil
+	}
+	return o.Approver(ctx, name, args)
+}
+
+func (o *Orchestrator) recordReceipt(ctx context.Context, receipt *Receipt) {
+	collectReceipt(ctx, *receipt)
+	if o.AuditLog == nil {
+		return
+	}
+	if err := o.AuditLog.Record(ctx, *receipt); err != nil {
+		o.logger(ctx).Printf("Failed to record receipt for %s: %v", receipt.FuncName, err)
+	}
+}
+
 func handleMissingRequiredArgsError(err error, function parser.PlannedFuncCall, argsExecution map[string]Arg) (*ExecutedFuncCall, error) {
 	fe, ok := AsFormattableError(err)
 	if !ok {
@@ -249,26 +592,33 @@ func handleMissingRequiredArgsError(err error, function parser.PlannedFuncCall,
 
 // processArgs processes the arguments, executing nested functions if necessary
 func (o *Orchestrator) processArgs(ctx context.Context, function parser.PlannedFuncCall, progress progress.Stream) (map[string]Arg, error) {
-	args := make(map[string]Arg)
+	args := make(map[string]Arg, len(function.Args))
 
+	done := prefetchedFromContext(ctx)
 	for key, value := range function.Args {
 		switch v := value.(type) {
 		case *parser.PlannedFuncCall:
-			o.Logger.Printf("Processing nested function for argument '%s' in function '%s'", key, function.Name)
-			funcExe, err := o.executeFunc(ctx, *v, progress)
-			if err != nil {
+			funcExe, ok := done[v]
+			if !ok {
+				o.logger(ctx).Printf("Processing nested function for argument '%s' in function '%s'", key, function.Name)
+				var err error
+				if funcExe, err = o.executeFunc(ctx, *v, progress); err != nil {
+					return nil, &Error{FuncName: function.Name, ArgName: key, Err: err}
+				}
+			}
+			if err := o.choose(ctx, function.Name, key, funcExe, progress); err != nil {
 				return nil, &Error{FuncName: function.Name, ArgName: key, Err: err}
 			}
 			args[key] = NewFuncArg(funcExe)
 		default:
-			args[key] = NewValueArg(v)
+			args[key] = NewValueArg(normalizeNumbers(v, o.NumberMode))
 		}
 	}
 	return args, nil
 }
 
 func createProcessedArgs(argsExecution map[string]Arg) map[string]any {
-	processedArgs := make(map[string]any)
+	processedArgs := make(map[string]any, len(argsExecution))
 
 	for key, arg := range argsExecution {
 		switch v := arg.(type) {
@@ -285,7 +635,7 @@ func createProcessedArgs(argsExecution map[string]Arg) map[string]any {
 
 // checkRequiredArgs checks if all required arguments are present
 func (o *Orchestrator) checkRequiredArgs(function parser.PlannedFuncCall, args map[string]Arg) error {
-	functionSchema, ok := o.ToolSet.FindTool(function.Name)
+	functionSchema, ok := o.ToolSet().FindTool(function.Name)
 	if !ok {
 		return fmt.Errorf("function schema not found for %s", function.Name)
 	}
@@ -324,25 +674,63 @@ func (o *Orchestrator) checkRequiredArg(paramName string, args map[string]Arg) e
 	return fmt.Errorf("missing argument for required parameter %s: func call result is blank and has no FormatFunc", paramName)
 }
 
+// fingerprintBufferPool holds the buffers used to build the fingerprints,
+// which are generated for every function call.
+var fingerprintBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
 // generateFingerprint creates a unique fingerprint for a function call
 func generateFingerprint(functionName string, args map[string]interface{}) string {
-	keys := make([]string, 0, len(args))
+	buf := fingerprintBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer fingerprintBufferPool.Put(buf)
+
+	// Functions rarely have more than a few arguments: keep the keys on the stack
+	var keysBuf [8]string
+	keys := keysBuf[:0]
 	for k := range args {
 		keys = append(keys, k)
 	}
-	sort.Strings(keys)
+	slices.Sort(keys)
 
-	var builder strings.Builder
-	builder.WriteString(functionName)
-	builder.WriteByte('|')
+	buf.WriteString(functionName)
+	buf.WriteByte('|')
 
 	for i, k := range keys {
 		if i > 0 {
-			builder.WriteByte(',')
+			buf.WriteByte(',')
 		}
-		v, _ := json.Marshal(args[k])
-		fmt.Fprintf(&builder, "%s:%s", k, v)
+		buf.WriteString(k)
+		buf.WriteByte(':')
+		writeFingerprintValue(buf, args[k])
 	}
 
-	return fmt.Sprintf("%x", sha256.Sum256([]byte(builder.String())))
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFingerprintValue writes an unambiguous representation of v, encoding
+// scalars directly and falling back to JSON for composite values.
+func writeFingerprintValue(buf *bytes.Buffer, v interface{}) {
+	var scratch [64]byte
+	switch x := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		buf.Write(strconv.AppendQuote(scratch[:0], x))
+	case bool:
+		buf.Write(strconv.AppendBool(scratch[:0], x))
+	case float64:
+		buf.Write(strconv.AppendFloat(scratch[:0], x, 'g', -1, 64))
+	case int:
+		buf.Write(strconv.AppendInt(scratch[:0], int64(x), 10))
+	case int64:
+		buf.Write(strconv.AppendInt(scratch[:0], x, 10))
+	case json.Number:
+		buf.WriteString(string(x))
+	default:
+		data, _ := json.Marshal(x)
+		buf.Write(data)
+	}
 }
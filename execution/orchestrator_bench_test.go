@@ -0,0 +1,118 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// newBenchmarkOrchestrator returns an orchestrator with instantaneous executors,
+// so that the benchmarks measure the orchestration overhead only.
+func newBenchmarkOrchestrator(concurrent bool) *Orchestrator {
+	ts := &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name: "get_coordinates",
+				Parameters: tools.TypeInfo{
+					Type:       "object",
+					Properties: map[string]tools.TypeInfo{"city": {Type: "string"}},
+					Required:   []string{"city"},
+				},
+				Returns: tools.TypeInfo{Type: "coordinates_value"},
+			},
+			{
+				Name: "get_weather_forecast",
+				Parameters: tools.TypeInfo{
+					Type:       "object",
+					Properties: map[string]tools.TypeInfo{"coordinates": {Type: "coordinates_value"}},
+					Required:   []string{"coordinates"},
+				},
+				Returns: tools.TypeInfo{Type: "weather_forecast_value"},
+			},
+		},
+	}
+
+	o := NewOrchestrator(log.New(io.Discard, "", 0), time.Minute, concurrent, ts)
+	o.RegisterFunction("get_coordinates", func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		return FuncResult{Present: true, Value: map[string]float64{"lat": 45.07, "lon": 7.69}}, nil
+	})
+	o.RegisterFunction("get_weather_forecast", func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		return FuncResult{Present: true, Value: []float64{12.5, 13.1}}, nil
+	})
+	return o
+}
+
+func benchmarkPlan(cities ...string) []parser.PlannedFuncCall {
+	plan := make([]parser.PlannedFuncCall, len(cities))
+	for i, city := range cities {
+		plan[i] = parser.PlannedFuncCall{
+			Name:    "get_weather_forecast",
+			Purpose: "Retrieve the weather forecast",
+			Args: map[string]interface{}{
+				"coordinates": &parser.PlannedFuncCall{
+					Name:    "get_coordinates",
+					Purpose: "Retrieve the coordinates",
+					Args:    map[string]interface{}{"city": city},
+				},
+			},
+		}
+	}
+	return plan
+}
+
+func BenchmarkExecute(b *testing.B) {
+	cases := []struct {
+		name       string
+		plan       []parser.PlannedFuncCall
+		concurrent bool
+	}{
+		{"single", []parser.PlannedFuncCall{{Name: "get_coordinates", Args: map[string]interface{}{"city": "Turin"}}}, false},
+		{"nested", benchmarkPlan("Turin"), false},
+		{"nested-3", benchmarkPlan("Turin", "Rome", "Milan"), false},
+		{"nested-3-concurrent", benchmarkPlan("Turin", "Rome", "Milan"), true},
+	}
+
+	for _, c := range cases {
+		o := newBenchmarkOrchestrator(c.concurrent)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := o.Execute(context.Background(), c.plan, &progress.NoOp{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateFingerprint(b *testing.B) {
+	args := map[string]interface{}{
+		"city":        "Turin",
+		"coordinates": map[string]interface{}{"lat": 45.07, "lon": 7.69},
+		"days":        3,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		generateFingerprint("get_weather_forecast", args)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+func newTestOrchestrator(timeout time.Duration, executor FuncExecutor) *Orchestrator {
+	ts := &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{Name: "slow", Parameters: tools.TypeInfo{Type: "object"}, Returns: tools.TypeInfo{Type: "string"}},
+		},
+	}
+	o := NewOrchestrator(log.New(io.Discard, "", 0), timeout, false, ts)
+	o.RegisterFunction("slow", executor)
+	return o
+}
+
+// checkNoLeaks fails the test if executor goroutines are still running shortly after the execution.
+func checkNoLeaks(t *testing.T, o *Orchestrator) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := o.Drain(ctx); err != nil {
+		t.Fatalf("leaked executors: %d in flight, %d abandoned", o.InFlight(), o.Abandoned())
+	}
+	if n := o.Abandoned(); n != 0 {
+		t.Fatalf("abandoned gauge is %d after drain", n)
+	}
+}
+
+func TestExecuteTimeoutCancelsExecutor(t *testing.T) {
+	o := newTestOrchestrator(20*time.Millisecond, func(ctx context.Context, _ map[string]interface{}, _ progress.Stream) (FuncResult, error) {
+		<-ctx.Done()
+		return FuncResult{}, ctx.Err()
+	})
+
+	_, err := o.Execute(context.Background(), []parser.PlannedFuncCall{{Name: "slow"}}, &progress.NoOp{})
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	checkNoLeaks(t, o)
+}
+
+func TestExecuteTracksAbandonedExecutor(t *testing.T) {
+	release := make(chan struct{})
+	o := newTestOrchestrator(20*time.Millisecond, func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		<-release // ignores the context
+		return FuncResult{Present: true}, nil
+	})
+
+	_, err := o.Execute(context.Background(), []parser.PlannedFuncCall{{Name: "slow"}}, &progress.NoOp{})
+	var execErr *Error
+	if !errors.As(err, &execErr) {
+		t.Fatalf("expected an execution error, got %v", err)
+	}
+	if got := o.InFlight(); got != 1 {
+		t.Errorf("InFlight() = %d, want 1", got)
+	}
+	if got := o.Abandoned(); got != 1 {
+		t.Errorf("Abandoned() = %d, want 1", got)
+	}
+
+	close(release)
+	checkNoLeaks(t, o)
+}
+
+func TestExecuteCompletedExecutorIsNotAbandoned(t *testing.T) {
+	o := newTestOrchestrator(time.Second, func(context.Context, map[string]interface{}, progress.Stream) (FuncResult, error) {
+		return FuncResult{Present: true, Value: "done"}, nil
+	})
+
+	result, err := o.Execute(context.Background(), []parser.PlannedFuncCall{{Name: "slow"}}, &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := result.FuncCalls[0].Result.Value; v != "done" {
+		t.Errorf("got %v, want done", v)
+	}
+	checkNoLeaks(t, o)
+}
+
+// TestConcurrentRegistrationAndExecution is meant to be run with -race.
+func TestConcurrentRegistrationAndExecution(t *testing.T) {
+	echo := func(_ context.Context, args map[string]interface{}, _ progress.Stream) (FuncResult, error) {
+		return FuncResult{Present: true, Value: args["text"]}, nil
+	}
+	definition := func(name string) tools.FuncDefinition {
+		return tools.FuncDefinition{
+			Name: name,
+			Parameters: tools.TypeInfo{
+				Type:       "object",
+				Properties: map[string]tools.TypeInfo{"text": {Type: "string"}},
+				Required:   []string{"text"},
+			},
+			Returns: tools.TypeInfo{Type: "string"},
+		}
+	}
+
+	o := NewOrchestrator(log.New(io.Discard, "", 0), time.Second, true, &tools.ToolSet{})
+	o.RegisterTool(definition("echo"), echo)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				name := fmt.Sprintf("dynamic_%d_%d", i, j)
+				o.RegisterTool(definition(name), echo)
+				if j%2 == 0 {
+					o.UnregisterFunction(name)
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				plan := []parser.PlannedFuncCall{
+					{Name: "echo", Args: map[string]interface{}{"text": fmt.Sprintf("%d-%d", i, j)}},
+					{Name: "echo", Args: map[string]interface{}{"text": "shared"}},
+				}
+				result, err := o.Execute(context.Background(), plan, &progress.NoOp{})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if got, want := result.FuncCalls[0].Result.Value, fmt.Sprintf("%d-%d", i, j); got != want {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, ok := o.ToolSet().FindTool("dynamic_3_49"); !ok {
+		t.Error("expected dynamic_3_49 to be registered")
+	}
+	if _, ok := o.lookupFunction("dynamic_3_48"); ok {
+		t.Error("expected dynamic_3_48 to be unregistered")
+	}
+	checkNoLeaks(t, o)
+}
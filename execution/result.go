@@ -17,6 +17,7 @@ package execution
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 type Result struct {
@@ -36,6 +37,16 @@ type ExecutedFuncCall struct {
 	Purpose string         `json:"purpose"`
 	Args    map[string]Arg `json:"args"`
 	Result  FuncResult     `json:"-"`
+	// Receipt is set for side-effect functions only.
+	Receipt *Receipt `json:"receipt,omitempty"`
+	// Duration is the time spent on the call, excluding its nested calls.
+	Duration time.Duration `json:"duration,omitempty"`
+	// Cached reports whether the result was reused from the memo or from an
+	// identical call in flight rather than computed.
+	Cached bool `json:"cached,omitempty"`
+	// Render is the presentation hint of the result, from the definition of
+	// the function (see tools.TypeInfo.RenderAs).
+	Render string `json:"render,omitempty"`
 }
 
 type Arg interface{}
@@ -96,7 +107,6 @@ func (r FuncResults) Format(separator string) (string, error) {
 	}
 
 	// Remove duplicates
-	// TODO: Use a "Formatter" type to encapsulate this and the "separator" logic?
 	seen := make(map[string]struct{})
 	var unique []string
 	for _, s := range formatted {
@@ -129,4 +139,9 @@ type FuncResult struct {
 
 	// Metadata optionally provided by the function's implementation.
 	Metadata any
+
+	// Candidates, when more than one, are the alternative values of an
+	// ambiguous result, Value being the default one. When the result is bound
+	// to an argument, Orchestrator.Chooser selects the one to use.
+	Candidates []Candidate
 }
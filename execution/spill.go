@@ -0,0 +1,109 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/blob"
+)
+
+// SetMaxResultSize sets the maximum size in bytes of the values returned by a function,
+// measured on their JSON encoding, overriding MaxResultSize. A negative size removes the limit.
+func (o *Orchestrator) SetMaxResultSize(name string, size int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.resultSizeLimits[name] = size
+}
+
+func (o *Orchestrator) maxResultSize(name string) int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if size, ok := o.resultSizeLimits[name]; ok {
+		return size
+	}
+	return o.MaxResultSize
+}
+
+// spillResult replaces a value exceeding the size limit of the function with a
+// blob.Artifact referencing its JSON encoding in Blobs. Without Blobs, oversized
+// results are errors.
+func (o *Orchestrator) spillResult(ctx context.Context, name string, result FuncResult) (FuncResult, error) {
+	limit := o.maxResultSize(name)
+	if limit <= 0 || result.Value == nil {
+		return result, nil
+	}
+	if _, ok := result.Value.(blob.Artifact); ok {
+		return result, nil
+	}
+
+	data, err := json.Marshal(result.Value)
+	if err != nil {
+		return FuncResult{}, fmt.Errorf("error marshalling result to check its size: %w", err)
+	}
+	if len(data) <= limit {
+		return result, nil
+	}
+	if o.Blobs == nil {
+		return FuncResult{}, fmt.Errorf("result of %d bytes exceeds the limit of %d bytes", len(data), limit)
+	}
+
+	artifact, err := o.Blobs.Put(ctx, "application/json", data)
+	if err != nil {
+		return FuncResult{}, fmt.Errorf("error spilling result to the blob store: %w", err)
+	}
+	o.logger(ctx).Printf("Result of %s (%d bytes) spilled to artifact %s", name, len(data), artifact.ID)
+
+	// The formatted result is kept only if it fits, releasing the original value
+	formatted := fmt.Sprintf("The result of %s is too large to be shown (%d bytes) and has been stored as artifact %s.", name, len(data), artifact.ID)
+	if result.FormatFunc != nil {
+		if s, err := result.FormatFunc(); err == nil && len(s) <= limit {
+			formatted = s
+		}
+	}
+
+	result.Value = artifact
+	result.FormatFunc = func() (string, error) { return formatted, nil }
+	return result, nil
+}
+
+// resolveArtifacts loads back the spilled values used as arguments, so that
+// executors receive the decoded JSON instead of the Artifact.
+func (o *Orchestrator) resolveArtifacts(ctx context.Context, processedArgs map[string]any) error {
+	for key, value := range processedArgs {
+		artifact, ok := value.(blob.Artifact)
+		if !ok {
+			continue
+		}
+		if o.Blobs == nil {
+			return fmt.Errorf("no blob store to resolve artifact %s of argument %s", artifact.ID, key)
+		}
+		data, err := o.Blobs.Get(ctx, artifact.ID)
+		if err != nil {
+			return fmt.Errorf("error loading artifact %s of argument %s: %w", artifact.ID, key, err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var v any
+		if err := decoder.Decode(&v); err != nil {
+			return fmt.Errorf("error decoding artifact %s of argument %s: %w", artifact.ID, key, err)
+		}
+		processedArgs[key] = normalizeNumbers(v, o.NumberMode)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// executorTracker counts the executor goroutines, so that executors which keep
+// running after their call timed out (ignoring the cancellation of their
+// context) are visible instead of leaking silently.
+type executorTracker struct {
+	inFlight  atomic.Int64
+	abandoned atomic.Int64
+}
+
+const (
+	runRunning int32 = iota
+	runFinished
+	runAbandoned
+)
+
+// executorRun tracks a single executor goroutine.
+type executorRun struct {
+	tracker *executorTracker
+	state   atomic.Int32
+	started time.Time
+}
+
+func (t *executorTracker) begin() *executorRun {
+	t.inFlight.Add(1)
+	return &executorRun{tracker: t, started: time.Now()}
+}
+
+// abandon marks the run as no longer awaited. It has no effect if the executor already returned.
+func (r *executorRun) abandon() {
+	if r.state.CompareAndSwap(runRunning, runAbandoned) {
+		r.tracker.abandoned.Add(1)
+	}
+}
+
+// end marks the executor as returned and reports whether it had been abandoned.
+func (r *executorRun) end() bool {
+	abandoned := !r.state.CompareAndSwap(runRunning, runFinished)
+	if abandoned {
+		r.tracker.abandoned.Add(-1)
+	}
+	r.tracker.inFlight.Add(-1)
+	return abandoned
+}
+
+// InFlight returns the number of executors currently running, including the abandoned ones.
+func (o *Orchestrator) InFlight() int64 {
+	return o.executors.inFlight.Load()
+}
+
+// Abandoned returns the number of executors still running after their call
+// timed out or was cancelled. A value that does not return to zero reveals
+// executors ignoring the cancellation of their context.
+func (o *Orchestrator) Abandoned() int64 {
+	return o.executors.abandoned.Load()
+}
+
+// Drain waits until no executor is running, or until ctx is done.
+// It is meant for graceful shutdowns and tests, once no new execution is started.
+func (o *Orchestrator) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for o.InFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
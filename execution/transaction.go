@@ -0,0 +1,170 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"golang.org/x/sync/errgroup"
+)
+
+type prefetchedContextKey struct{}
+
+// prefetched holds the results of the read-only calls executed in the first
+// phase of a transaction, by planned call.
+type prefetched map[*parser.PlannedFuncCall]*ExecutedFuncCall
+
+func prefetchedFromContext(ctx context.Context) prefetched {
+	p, _ := ctx.Value(prefetchedContextKey{}).(prefetched)
+	return p
+}
+
+// ExecuteTransaction executes the calls grouping their side effects into an
+// all-or-nothing unit. The read-only calls, including the arguments of the
+// side-effect calls, are executed first; the side effects are performed only
+// if all of them succeeded and found their result, and are compensated (see
+// RegisterCompensator) if one of them fails.
+func (o *Orchestrator) ExecuteTransaction(ctx context.Context, functions []parser.PlannedFuncCall, progress progress.Stream) (*Result, error) {
+	results := make([]*ExecutedFuncCall, len(functions))
+
+	// Phase 1: the read-only calls and the read-only subtrees of the side-effect calls
+	var readOnly, withSideEffects []int
+	var prerequisites []*parser.PlannedFuncCall
+	for i := range functions {
+		if o.hasSideEffects(&functions[i]) {
+			withSideEffects = append(withSideEffects, i)
+			prerequisites = o.appendPrerequisites(prerequisites, &functions[i])
+		} else {
+			readOnly = append(readOnly, i)
+		}
+	}
+
+	roots := make([]*parser.PlannedFuncCall, 0, len(readOnly)+len(prerequisites))
+	for _, i := range readOnly {
+		roots = append(roots, &functions[i])
+	}
+	roots = append(roots, prerequisites...)
+
+	executed, err := o.executeAll(ctx, roots, progress)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range readOnly {
+		results[i] = executed[j]
+	}
+	done := make(prefetched, len(prerequisites))
+	for j, p := range prerequisites {
+		done[p] = executed[len(readOnly)+j]
+	}
+
+	// The side effects are performed only if every prerequisite is available
+	for _, p := range prerequisites {
+		if call := done[p]; !call.Result.Present {
+			o.logger(ctx).Printf("Prerequisite %s not found, the side effects are not performed", p.Name)
+			for _, i := range withSideEffects {
+				results[i] = notPerformed(functions[i], call)
+			}
+			return &Result{FuncCalls: results}, nil
+		}
+	}
+
+	// Phase 2: the side effects, in order, using the prefetched results
+	ctx = context.WithValue(ctx, prefetchedContextKey{}, done)
+	ctx, receipts := CollectReceipts(ctx)
+	for _, i := range withSideEffects {
+		o.logger(ctx).Printf("Executing function: %s", functions[i].Name)
+		call, err := o.executeFunc(ctx, functions[i], progress)
+		if err != nil {
+			if cerr := o.Compensate(ctx, receipts()); cerr != nil {
+				o.logger(ctx).Printf("Some side effects could not be compensated: %v", cerr)
+			}
+			return nil, &Error{FuncName: functions[i].Name, Err: err}
+		}
+		results[i] = call
+	}
+	return &Result{FuncCalls: results}, nil
+}
+
+// hasSideEffects reports whether the call or one of its nested calls is a side-effect function.
+func (o *Orchestrator) hasSideEffects(call *parser.PlannedFuncCall) bool {
+	for _, name := range call.CollectAllNestedFuncCalls() {
+		if o.isSideEffect(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendPrerequisites appends the largest read-only subtrees nested in call.
+func (o *Orchestrator) appendPrerequisites(prerequisites []*parser.PlannedFuncCall, call *parser.PlannedFuncCall) []*parser.PlannedFuncCall {
+	for _, arg := range call.Args {
+		nested, ok := arg.(*parser.PlannedFuncCall)
+		if !ok {
+			continue
+		}
+		if o.hasSideEffects(nested) {
+			prerequisites = o.appendPrerequisites(prerequisites, nested)
+		} else {
+			prerequisites = append(prerequisites, nested)
+		}
+	}
+	return prerequisites
+}
+
+// executeAll executes the calls, concurrently if enabled.
+func (o *Orchestrator) executeAll(ctx context.Context, calls []*parser.PlannedFuncCall, progress progress.Stream) ([]*ExecutedFuncCall, error) {
+	executed := make([]*ExecutedFuncCall, len(calls))
+	group, groupCtx := errgroup.WithContext(ctx)
+	if !o.EnableConcurrentExec {
+		group.SetLimit(1)
+	}
+	for i, call := range calls {
+		group.Go(func() error {
+			o.logger(ctx).Printf("Executing function: %s", call.Name)
+			funcExe, err := o.executeFunc(groupCtx, *call, progress)
+			if err != nil {
+				return &Error{FuncName: call.Name, Err: err}
+			}
+			executed[i] = funcExe
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return executed, nil
+}
+
+func notPerformed(function parser.PlannedFuncCall, missing *ExecutedFuncCall) *ExecutedFuncCall {
+	return &ExecutedFuncCall{
+		Name:    function.Name,
+		Purpose: function.Purpose,
+		Result: FuncResult{
+			Present: false,
+			FormatFunc: func() (string, error) {
+				reason := fmt.Sprintf("%s found nothing", missing.Name)
+				if missing.Result.FormatFunc != nil {
+					if s, err := missing.Result.FormatFunc(); err == nil {
+						reason = s
+					}
+				}
+				return fmt.Sprintf("The action %s has not been performed because a prerequisite is missing: %s", function.Name, reason), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+func ticketFor(key string) parser.PlannedFuncCall {
+	return parser.PlannedFuncCall{Name: "create_ticket", Args: map[string]any{
+		"title": &parser.PlannedFuncCall{Name: "lookup", Args: map[string]any{"key": key}},
+	}}
+}
+
+func TestTransactionUsesThePrefetchedPrerequisites(t *testing.T) {
+	desk := &ticketDesk{}
+	o := newTicketOrchestrator(desk)
+
+	result, err := o.ExecuteTransaction(context.Background(), []parser.PlannedFuncCall{ticketFor("a"), ticketFor("b")}, &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := desk.snapshot(&desk.titles); !slices.Equal(got, []string{"title of a", "title of b"}) {
+		t.Errorf("created tickets %v, want them in plan order", got)
+	}
+	if desk.lookups != 2 {
+		t.Errorf("lookup ran %d times, want 2 (once per prerequisite)", desk.lookups)
+	}
+	if v := result.FuncCalls[1].Result.Value; v != "T2" {
+		t.Errorf("second result %v, want T2", v)
+	}
+}
+
+func TestTransactionSkipsTheSideEffectsOnAMissingPrerequisite(t *testing.T) {
+	desk := &ticketDesk{}
+	o := newTicketOrchestrator(desk)
+
+	result, err := o.ExecuteTransaction(context.Background(), []parser.PlannedFuncCall{ticketFor("a"), ticketFor("missing")}, &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := desk.snapshot(&desk.titles); len(got) != 0 {
+		t.Fatalf("created tickets %v, want none", got)
+	}
+	for _, call := range result.FuncCalls {
+		if call.Result.Present {
+			t.Fatalf("%s reported as performed", call.Name)
+		}
+		s, err := call.Result.FormatFunc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(s, "not been performed") || !strings.Contains(s, "No such key.") {
+			t.Errorf("unexpected explanation %q", s)
+		}
+	}
+}
+
+func TestTransactionCompensatesOnFailure(t *testing.T) {
+	desk := &ticketDesk{}
+	o := newTicketOrchestrator(desk)
+	plan := []parser.PlannedFuncCall{
+		ticketFor("a"),
+		{Name: "page_oncall", Args: map[string]any{"team": "ops"}},
+		ticketFor("b"),
+	}
+
+	if _, err := o.ExecuteTransaction(context.Background(), plan, &progress.NoOp{}); err == nil {
+		t.Fatal("expected the error of page_oncall")
+	}
+
+	if got := desk.snapshot(&desk.titles); !slices.Equal(got, []string{"title of a"}) {
+		t.Errorf("created tickets %v, want only the one before the failure", got)
+	}
+	if got := desk.snapshot(&desk.cancelled); !slices.Equal(got, []string{"T1"}) {
+		t.Errorf("cancelled tickets %v, want [T1]", got)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nlpodyssey/funcallarchitect/analytics"
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// countTools increments, through count, the statistics of every tool called by the calls.
+func (r *report) countTools(names []string, count func(s *analytics.ToolStats)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tools == nil {
+		r.tools = make(map[string]*analytics.ToolStats)
+	}
+	for _, name := range names {
+		s, ok := r.tools[name]
+		if !ok {
+			s = &analytics.ToolStats{Tool: name}
+			r.tools[name] = s
+		}
+		count(s)
+	}
+}
+
+// countExecution counts the successful calls of the planned tools, and the
+// ones contributing to the answer: those finding a result, if the calls
+// they are passed to do too.
+func (r *report) countExecution(funcCalls []*execution.ExecutedFuncCall, contributing bool) {
+	for _, f := range funcCalls {
+		if _, planned := r.tools[f.Name]; !planned {
+			continue
+		}
+		contributed := contributing && f.Result.Present
+		s := r.tools[f.Name]
+		s.Succeeded++
+		if contributed {
+			s.Contributed++
+		}
+		var nested []*execution.ExecutedFuncCall
+		for _, arg := range f.Args {
+			if call, ok := execution.GetFuncCall(arg); ok {
+				nested = append(nested, call)
+			}
+		}
+		r.countExecution(nested, contributed)
+	}
+}
+
+func (r *report) toolStats() []analytics.ToolStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make([]analytics.ToolStats, 0, len(r.tools))
+	for _, s := range r.tools {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Tool < stats[j].Tool })
+	return stats
+}
+
+// recordAnalytics adds the statistics of the request to the analytics, if configured.
+func (a *RequestHandler) recordAnalytics(ctx context.Context, rep *report) {
+	if a.config.Analytics == nil {
+		return
+	}
+	if err := a.config.Analytics.Add(ctx, rep.record.CreatedAt, rep.toolStats()); err != nil {
+		a.logger(ctx).Printf("Failed to record the planner analytics: %v", err)
+	}
+}
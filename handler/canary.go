@@ -0,0 +1,223 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// DefaultCanaryComparisons is the default number of comparisons kept by a Canary.
+const DefaultCanaryComparisons = 1000
+
+// Canary runs a new version of the tools in shadow on a share of the requests,
+// to assess it against the live version before its promotion: the requests
+// are planned with its definitions as well, in the background, and the plans
+// validated and compared with the live ones. The users always get the live
+// results.
+type Canary struct {
+	// Tools is the new version of RequestHandlerConfig.Tools. The built-in
+	// tools are added as for the live version.
+	Tools Tools
+	// Percentage is the share of the requests planned with Tools, from 0 to 100.
+	Percentage float64
+	// Execute executes the valid plans with the executors of Tools, comparing
+	// the results with the live ones. The executors must be staging ones,
+	// since the side effects are performed. By default the plans are only
+	// validated.
+	Execute bool
+	// MaxComparisons is the number of comparisons kept; the oldest ones are
+	// dropped first. DefaultCanaryComparisons if zero.
+	MaxComparisons int
+
+	toolset      *tools.ToolSet
+	orchestrator *execution.Orchestrator
+
+	mu          sync.Mutex
+	comparisons []CanaryComparison
+}
+
+// CanaryComparison compares the processing of a request by the two versions.
+type CanaryComparison struct {
+	RequestID  string          `json:"request_id,omitempty"`
+	Request    string          `json:"request"`
+	CreatedAt  time.Time       `json:"created_at"`
+	LivePlan   json.RawMessage `json:"live_plan,omitempty"`
+	CanaryPlan json.RawMessage `json:"canary_plan,omitempty"`
+	// SamePlan reports whether the plans call the same functions with the
+	// same arguments.
+	SamePlan bool `json:"same_plan"`
+	// Invalid is the validation error of the canary plan.
+	Invalid     string `json:"invalid,omitempty"`
+	LiveError   string `json:"live_error,omitempty"`
+	CanaryError string `json:"canary_error,omitempty"`
+	// Executed reports whether the canary plan was executed, and SameResults
+	// whether its formatted main results match the live ones.
+	Executed    bool `json:"executed,omitempty"`
+	SameResults bool `json:"same_results,omitempty"`
+}
+
+// agrees reports whether the canary did as well as the live version.
+func (c *CanaryComparison) agrees() bool {
+	return c.SamePlan && c.Invalid == "" && c.CanaryError == "" && (!c.Executed || c.SameResults)
+}
+
+// CanaryReport summarizes the comparisons of a Canary.
+type CanaryReport struct {
+	Requests     int `json:"requests"`
+	SamePlans    int `json:"same_plans"`
+	InvalidPlans int `json:"invalid_plans"`
+	LiveErrors   int `json:"live_errors"`
+	CanaryErrors int `json:"canary_errors"`
+	Executed     int `json:"executed"`
+	SameResults  int `json:"same_results"`
+	// AgreementRate is the share of the requests planned the same way by the
+	// two versions.
+	AgreementRate float64 `json:"agreement_rate"`
+	// Disagreements are the comparisons where the canary did differently,
+	// most recent first, for review.
+	Disagreements []CanaryComparison `json:"disagreements,omitempty"`
+}
+
+// Report summarizes the comparisons kept.
+func (c *Canary) Report() CanaryReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var r CanaryReport
+	for i := len(c.comparisons) - 1; i >= 0; i-- {
+		comparison := c.comparisons[i]
+		r.Requests++
+		if comparison.SamePlan {
+			r.SamePlans++
+		}
+		if comparison.Invalid != "" {
+			r.InvalidPlans++
+		}
+		if comparison.LiveError != "" {
+			r.LiveErrors++
+		}
+		if comparison.CanaryError != "" {
+			r.CanaryErrors++
+		}
+		if comparison.Executed {
+			r.Executed++
+			if comparison.SameResults {
+				r.SameResults++
+			}
+		}
+		if !comparison.agrees() {
+			r.Disagreements = append(r.Disagreements, comparison)
+		}
+	}
+	if r.Requests > 0 {
+		r.AgreementRate = float64(r.SamePlans) / float64(r.Requests)
+	}
+	return r
+}
+
+// init prepares the tools of the canary, and their orchestrator when the
+// plans are executed.
+func (c *Canary) init(config RequestHandlerConfig) error {
+	ts := c.Tools
+	if !config.DisableBuiltinTools {
+		ts = withBuiltinTools(config, ts)
+	}
+	c.toolset = ts.AvailableTools()
+	if !c.Execute {
+		return nil
+	}
+	// Approvals, audit and memoization are left to the live version
+	c.orchestrator = execution.NewOrchestrator(config.Logger, config.Timeout, config.EnableConcurrentExec, c.toolset)
+	c.orchestrator.NumberMode = config.NumberMode
+	c.orchestrator.TimeoutExtension = config.TimeoutExtension
+	c.orchestrator.MaxResultSize = config.MaxResultSize
+	c.orchestrator.Blobs = config.BlobStore
+	return ts.RegisterWith(c.orchestrator)
+}
+
+// sample reports whether the request is to be shadowed. It can be called on a
+// nil Canary.
+func (c *Canary) sample() bool {
+	return c != nil && rand.Float64()*100 < c.Percentage
+}
+
+func (c *Canary) add(comparison CanaryComparison) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.comparisons = append(c.comparisons, comparison)
+	maxComparisons := c.MaxComparisons
+	if maxComparisons == 0 {
+		maxComparisons = DefaultCanaryComparisons
+	}
+	if excess := len(c.comparisons) - maxComparisons; excess > 0 {
+		c.comparisons = append(c.comparisons[:0:0], c.comparisons[excess:]...)
+	}
+}
+
+// shadowRequest plans the request with the canary tools in the background,
+// comparing the outcome with the live one.
+func (a *RequestHandler) shadowRequest(ctx context.Context, message string, liveCalls []parser.PlannedFuncCall, liveExec *execution.Result, liveErr error) {
+	c := a.config.Canary
+	comparison := CanaryComparison{RequestID: requestID(ctx), Request: message, CreatedAt: time.Now()}
+	if liveErr != nil {
+		comparison.LiveError = liveErr.Error()
+	}
+	if liveCalls != nil {
+		comparison.LivePlan, _ = json.Marshal(liveCalls)
+	}
+	// The canary must not outlive the request handling, nor show up in its report
+	ctx = context.WithValue(context.WithoutCancel(ctx), reportContextKey{}, (*report)(nil))
+	go func() {
+		defer func() { c.add(comparison) }()
+		funcCalls, _, _, err := a.generateFunctionCalls(ctx, message, c.toolset, nil, &progress.NoOp{})
+		if err != nil {
+			comparison.CanaryError = err.Error()
+			return
+		}
+		comparison.CanaryPlan, _ = json.Marshal(funcCalls)
+		comparison.SamePlan = samePlan(funcCalls, liveCalls)
+		for _, call := range funcCalls {
+			if err := c.toolset.ValidateCall(call); err != nil {
+				comparison.Invalid = err.Error()
+				return
+			}
+		}
+		if c.orchestrator == nil || len(funcCalls) == 0 {
+			return
+		}
+		exec, err := c.orchestrator.Execute(ctx, funcCalls, &progress.NoOp{})
+		comparison.Executed = true
+		if err != nil {
+			comparison.CanaryError = err.Error()
+			return
+		}
+		comparison.SameResults = liveExec != nil && sameResults(exec, liveExec)
+	}()
+}
+
+// sameResults compares the formatted main results of the executions.
+func sameResults(a, b *execution.Result) bool {
+	resultsA, errA := a.MainFuncResults().Format("")
+	resultsB, errB := b.MainFuncResults().Format("")
+	return errA == nil && errB == nil && resultsA == resultsB
+}
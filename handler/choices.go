@@ -0,0 +1,116 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// ErrChoiceNotFound is returned by Choose when no request is waiting for the
+// choice, e.g. because it timed out.
+var ErrChoiceNotFound = errors.New("choice not found")
+
+// pendingChoices are the choices the executions are waiting for, by ID.
+type pendingChoices struct {
+	mu      sync.Mutex
+	waiting map[string]pendingChoice
+}
+
+type pendingChoice struct {
+	options int
+	answer  chan int
+}
+
+// Choose answers the choice with the given ID, sent over the progress stream
+// of a request (see RequestHandlerConfig.ChoiceTimeout), with the index of the
+// chosen option. The execution of the request resumes with it.
+func (a *RequestHandler) Choose(id string, option int) error {
+	a.choices.mu.Lock()
+	defer a.choices.mu.Unlock()
+	p, ok := a.choices.waiting[id]
+	if !ok {
+		return ErrChoiceNotFound
+	}
+	if option < 0 || option >= p.options {
+		return fmt.Errorf("invalid option %d of %d", option, p.options)
+	}
+	delete(a.choices.waiting, id)
+	p.answer <- option
+	return nil
+}
+
+// choose is the execution.Chooser asking the user through the stream of the
+// request. The default candidate is kept for the streams not delivering
+// choices, and when no answer comes in time.
+func (a *RequestHandler) choose(ctx context.Context, choice execution.Choice, stream progress.Stream) (int, error) {
+	choices, ok := stream.(progress.ChoiceStream)
+	if !ok {
+		return -1, nil
+	}
+	options := make([]string, len(choice.Candidates))
+	for i, c := range choice.Candidates {
+		options[i] = c.Label
+	}
+	id := newChoiceID()
+	answer := make(chan int, 1)
+	a.choices.mu.Lock()
+	if a.choices.waiting == nil {
+		a.choices.waiting = make(map[string]pendingChoice)
+	}
+	a.choices.waiting[id] = pendingChoice{options: len(options), answer: answer}
+	a.choices.mu.Unlock()
+	defer func() {
+		a.choices.mu.Lock()
+		delete(a.choices.waiting, id)
+		a.choices.mu.Unlock()
+	}()
+
+	a.logger(ctx).Printf("Waiting for the choice %s among %d candidates of %s", id, len(options), choice.FuncName)
+	choices.SendChoice(progress.Choice{
+		ID:       id,
+		Question: fmt.Sprintf("Which %s did you mean?", strings.ReplaceAll(choice.ArgName, "_", " ")),
+		Options:  options,
+	})
+	timer := time.NewTimer(a.config.ChoiceTimeout)
+	defer timer.Stop()
+	select {
+	case i := <-answer:
+		return i, nil
+	case <-timer.C:
+		a.logger(ctx).Printf("No answer to the choice %s, using the default candidate", id)
+		choices.Send("No choice made, using the default candidate")
+		return -1, nil
+	case <-ctx.Done():
+		return -1, context.Cause(ctx)
+	}
+}
+
+func newChoiceID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/testkit"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// placeTools has find_place returning the candidates of an ambiguous name,
+// and get_forecast taking the place.
+type placeTools struct{}
+
+func (placeTools) AvailableTools() *tools.ToolSet {
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "find_place",
+				Description: "Find a place by name.",
+				Parameters: tools.TypeInfo{
+					Type:       "object",
+					Properties: map[string]tools.TypeInfo{"name": {Type: "string"}},
+					Required:   []string{"name"},
+				},
+				Returns: tools.TypeInfo{Type: "place"},
+			},
+			{
+				Name:        "get_forecast",
+				Description: "Return the forecast for the place.",
+				Parameters: tools.TypeInfo{
+					Type:       "object",
+					Properties: map[string]tools.TypeInfo{"place": {Type: "place"}},
+					Required:   []string{"place"},
+				},
+				Returns: tools.TypeInfo{Type: "string"},
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"place": {Type: "string", Description: "The name of a place, with its region"},
+		},
+	}
+}
+
+func (placeTools) RegisterWith(o *execution.Orchestrator) error {
+	o.RegisterFunction("find_place", func(context.Context, map[string]any, progress.Stream) (execution.FuncResult, error) {
+		return execution.FuncResult{
+			Present:    true,
+			Value:      "Springfield, Illinois",
+			FormatFunc: func() (string, error) { return "Springfield, Illinois", nil },
+			Candidates: []execution.Candidate{
+				{Label: "Springfield, Illinois", Value: "Springfield, Illinois"},
+				{Label: "Springfield, Missouri", Value: "Springfield, Missouri"},
+			},
+		}, nil
+	})
+	o.RegisterFunction("get_forecast", func(_ context.Context, args map[string]any, _ progress.Stream) (execution.FuncResult, error) {
+		text := "sunny in " + args["place"].(string)
+		return execution.FuncResult{Present: true, Value: text, FormatFunc: func() (string, error) { return text, nil }}, nil
+	})
+	return nil
+}
+
+// choiceStream answers the choices it receives through answer.
+type choiceStream struct {
+	progress.NoOp
+	answer  func(progress.Choice)
+	choices []progress.Choice
+}
+
+func (s *choiceStream) SendChoice(choice progress.Choice) {
+	s.choices = append(s.choices, choice)
+	if s.answer != nil {
+		go s.answer(choice)
+	}
+}
+
+func newChoiceHandler(t *testing.T, timeout time.Duration) *handler.RequestHandler {
+	t.Helper()
+	plan := testkit.Plan(testkit.Call("get_forecast", map[string]any{
+		"place": testkit.Call("find_place", map[string]any{"name": "Springfield"}),
+	}))
+	h, err := handler.NewRequestHandler(handler.RequestHandlerConfig{
+		Logger:              log.New(io.Discard, "", 0),
+		LLMClient:           testkit.NewCompleter(plan),
+		Tools:               placeTools{},
+		Timeout:             5 * time.Second,
+		DisableBuiltinTools: true,
+		ChoiceTimeout:       timeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestChoiceResumesThePlanWithTheAnswer(t *testing.T) {
+	h := newChoiceHandler(t, 5*time.Second)
+	stream := &choiceStream{answer: func(c progress.Choice) {
+		if err := h.Choose(c.ID, 1); err != nil {
+			t.Errorf("choose: %v", err)
+		}
+	}}
+
+	result, err := h.ProcessUserRequest(context.Background(), "Weather in Springfield?", stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.choices) != 1 || len(stream.choices[0].Options) != 2 {
+		t.Fatalf("got the choices %+v, want one among 2 options", stream.choices)
+	}
+	if want := "sunny in Springfield, Missouri"; result.Output != want {
+		t.Fatalf("output %q, want %q", result.Output, want)
+	}
+	if err := h.Choose(stream.choices[0].ID, 0); !errors.Is(err, handler.ErrChoiceNotFound) {
+		t.Fatalf("answering again: got %v, want %v", err, handler.ErrChoiceNotFound)
+	}
+}
+
+func TestChoiceKeepsTheDefaultCandidate(t *testing.T) {
+	want := "sunny in Springfield, Illinois"
+
+	// Without an answer in time
+	h := newChoiceHandler(t, 50*time.Millisecond)
+	stream := &choiceStream{}
+	result, err := h.ProcessUserRequest(context.Background(), "Weather in Springfield?", stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.choices) != 1 || result.Output != want {
+		t.Fatalf("got %d choices and output %q, want 1 and %q", len(stream.choices), result.Output, want)
+	}
+
+	// With a stream not delivering choices
+	result, err = newChoiceHandler(t, 5*time.Second).ProcessUserRequest(context.Background(), "Weather in Springfield?", &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Output != want {
+		t.Fatalf("output %q, want %q", result.Output, want)
+	}
+}
+
+func TestChooseRejectsInvalidOptions(t *testing.T) {
+	h := newChoiceHandler(t, 5*time.Second)
+	stream := &choiceStream{}
+	stream.answer = func(c progress.Choice) {
+		if err := h.Choose(c.ID, 2); err == nil {
+			t.Error("an option out of range was accepted")
+		}
+		if err := h.Choose(c.ID, 0); err != nil {
+			t.Errorf("choose: %v", err)
+		}
+	}
+	result, err := h.ProcessUserRequest(context.Background(), "Weather in Springfield?", stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "sunny in Springfield, Illinois"; result.Output != want {
+		t.Fatalf("output %q, want %q", result.Output, want)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// DefaultContextRetries is the default maximum number of retries of a
+// planning prompt exceeding the context length.
+const DefaultContextRetries = 3
+
+// ContextRetry configures the retries of the planning prompts exceeding the
+// context length of the model (see llm.IsContextLengthError). At each retry
+// the prompt is shrunk further: first the session failure hints are dropped,
+// then the previous plan of a replanning is summarized, and finally the tool
+// list is halved, keeping the tools most relevant to the request.
+type ContextRetry struct {
+	// MaxRetries is the maximum number of retries. DefaultContextRetries if zero.
+	MaxRetries int
+	// Embedder, if set, ranks the tools by embedding similarity to the request.
+	// They are ranked by word overlap otherwise.
+	Embedder llm.Embedder
+}
+
+// completePlan requests the planning completion to the client, shrinking the
+// prompt and retrying, if configured, while it exceeds the context length.
+// The warnings report what was dropped from the prompt.
+func (a *RequestHandler) completePlan(ctx context.Context, client llm.Completer, message string, ts *tools.ToolSet, feedback []llm.Message, progress progress.Stream) (string, []string, error) {
+	hints := true
+	var warnings []string
+	for retry := 0; ; retry++ {
+		messages, jsonSchema, err := a.planningRequest(ctx, message, ts, feedback, hints, progress)
+		if err != nil {
+			return "", warnings, err
+		}
+		progress.Send("Generating function calls plan...")
+		completion, err := a.completeStreamed(ctx, client, messages, string(jsonSchema), "Planning", progress)
+		cfg := a.config.ContextRetry
+		if err == nil || cfg == nil || !llm.IsContextLengthError(err) {
+			return completion, warnings, err
+		}
+		maxRetries := cfg.MaxRetries
+		if maxRetries == 0 {
+			maxRetries = DefaultContextRetries
+		}
+		if retry >= maxRetries {
+			return "", warnings, err
+		}
+
+		a.logger(ctx).Printf("The planning prompt exceeds the context length, shrinking it: %v", err)
+		progress.Send("Shrinking the prompt exceeding the context length...")
+		switch {
+		case hints && len(a.failureHints(ctx)) > 0:
+			hints = false
+			warnings = append(warnings, "the planning prompt exceeded the context length: dropped the session failure hints")
+		case len(feedback) > 0 && feedback[0][0] == "assistant" && !strings.HasPrefix(feedback[0][1], summarizedPlanPrefix):
+			feedback = append([]llm.Message{{"assistant", a.summarizePlan(feedback[0][1])}}, feedback[1:]...)
+			warnings = append(warnings, "the planning prompt exceeded the context length: summarized the previous plan")
+		case len(ts.Functions) > 1:
+			var dropped []string
+			if ts, dropped, err = cfg.halveTools(message, ts); err != nil {
+				return "", warnings, fmt.Errorf("error ranking tools: %w", err)
+			}
+			warnings = append(warnings, fmt.Sprintf("the planning prompt exceeded the context length: dropped the tools %s", strings.Join(dropped, ", ")))
+		default:
+			return "", warnings, err
+		}
+	}
+}
+
+// summarizedPlanPrefix starts the summaries of the previous plans.
+const summarizedPlanPrefix = "Previous plan, summarized:"
+
+// summarizePlan replaces a previous plan with the list of the functions it called.
+func (a *RequestHandler) summarizePlan(completion string) string {
+	funcCalls, err := a.config.PlanFormat.parse(completion)
+	if err != nil || len(funcCalls) == 0 {
+		return summarizedPlanPrefix + " no valid function calls."
+	}
+	var names []string
+	for _, f := range funcCalls {
+		names = append(names, f.CollectAllNestedFuncCalls()...)
+	}
+	return fmt.Sprintf("%s called %s.", summarizedPlanPrefix, strings.Join(names, ", "))
+}
+
+// halveTools keeps the half of the tools most relevant to the request,
+// returning the names of the dropped ones.
+func (c *ContextRetry) halveTools(message string, ts *tools.ToolSet) (*tools.ToolSet, []string, error) {
+	scores, err := c.rankTools(message, ts.Functions)
+	if err != nil {
+		return nil, nil, err
+	}
+	order := make([]int, len(ts.Functions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+
+	keep := make([]bool, len(ts.Functions))
+	for _, i := range order[:(len(order)+1)/2] {
+		keep[i] = true
+	}
+	// The kept tools preserve their order in the prompt
+	shrunk := &tools.ToolSet{TypeDefinitions: ts.TypeDefinitions}
+	var dropped []string
+	for i, f := range ts.Functions {
+		if keep[i] {
+			shrunk.Functions = append(shrunk.Functions, f)
+		} else {
+			dropped = append(dropped, f.Name)
+		}
+	}
+	return shrunk, dropped, nil
+}
+
+// rankTools scores the relevance of each function to the request.
+func (c *ContextRetry) rankTools(message string, functions []tools.FuncDefinition) ([]float64, error) {
+	scores := make([]float64, len(functions))
+	if c.Embedder != nil {
+		request, err := c.Embedder.CreateEmbedding(message)
+		if err != nil {
+			return nil, err
+		}
+		for i, f := range functions {
+			vector, err := c.Embedder.CreateEmbedding(f.Name + ": " + f.Description)
+			if err != nil {
+				return nil, err
+			}
+			scores[i] = cosineSimilarity(request, vector)
+		}
+		return scores, nil
+	}
+
+	words := make(map[string]bool)
+	for _, w := range splitWords(message) {
+		words[w] = true
+	}
+	for i, f := range functions {
+		for _, w := range splitWords(f.Name + " " + f.Description) {
+			if words[w] {
+				scores[i]++
+			}
+		}
+	}
+	return scores, nil
+}
+
+// splitWords returns the lowercase words of the text, splitting identifiers
+// like get_weather too.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
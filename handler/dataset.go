@@ -0,0 +1,200 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/history"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// DatasetFormat is the format of the examples of a fine-tuning dataset.
+type DatasetFormat string
+
+const (
+	// DatasetChat writes {"messages": [{"role": ..., "content": ...}, ...]},
+	// as in the OpenAI fine-tuning API.
+	DatasetChat DatasetFormat = "chat"
+	// DatasetPromptCompletion writes {"prompt": ..., "completion": ...}.
+	DatasetPromptCompletion DatasetFormat = "prompt-completion"
+	// DatasetText writes {"text": ...} with the messages in the ChatML
+	// template, as used by llama.cpp LoRA fine-tuning.
+	DatasetText DatasetFormat = "text"
+)
+
+// DatasetOptions configures ExportDataset.
+type DatasetOptions struct {
+	Format DatasetFormat
+	// Since excludes the executions before the given time, if set.
+	Since time.Time
+	// Evaluations also exports an example for each evaluation verdict.
+	Evaluations bool
+	// IncludeDisliked includes the executions with negative feedback.
+	IncludeDisliked bool
+	// RequireLiked includes only the executions with positive feedback.
+	RequireLiked bool
+}
+
+// ExportDataset writes to w, one JSON object per line, the fine-tuning examples
+// built from the execution history: the plans of the successful executions
+// whose calls all passed evaluation, and optionally the evaluation verdicts.
+// The prompts are rebuilt from the current tools and templates.
+// It returns the number of examples written.
+func (a *RequestHandler) ExportDataset(ctx context.Context, w io.Writer, options DatasetOptions) (int, error) {
+	if a.config.History == nil {
+		return 0, fmt.Errorf("no execution history configured")
+	}
+	if options.Format == "" {
+		options.Format = DatasetChat
+	}
+	records, err := a.config.History.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error listing the execution history: %w", err)
+	}
+
+	ts := a.config.Tools.AvailableTools()
+	systemPrompt, err := a.config.PlanFormat.systemPrompt(a.config.PromptVersions, ts, a.config.DefinitionFormat)
+	if err != nil {
+		return 0, fmt.Errorf("error generating system prompt: %w", err)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	n := 0
+	write := func(messages []llm.Message) error {
+		example, err := datasetExample(options.Format, messages)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(example); err != nil {
+			return fmt.Errorf("error writing dataset: %w", err)
+		}
+		n++
+		return nil
+	}
+
+	for _, r := range records {
+		if !options.Since.IsZero() && r.CreatedAt.Before(options.Since) {
+			continue
+		}
+		if !options.includes(r) {
+			continue
+		}
+		if plan, ok := acceptedPlan(r); ok {
+			if err := write([]llm.Message{{"system", systemPrompt}, {"user", r.Request}, {"assistant", plan}}); err != nil {
+				return n, err
+			}
+		}
+		if !options.Evaluations {
+			continue
+		}
+		for _, v := range r.Evaluations {
+			userPrompt, err := a.verdictPrompt(r.Request, v, ts)
+			if err != nil {
+				a.logger(ctx).Printf("Skipping a verdict of %s: %v", r.ID, err)
+				continue
+			}
+			answer := fmt.Sprintf(`{"success": %t}`, v.Consistent)
+			if err := write([]llm.Message{{"user", userPrompt}, {"assistant", answer}}); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// includes applies the feedback filters to the record.
+func (o DatasetOptions) includes(r *history.Record) bool {
+	liked, disliked := false, false
+	for _, f := range r.Feedback {
+		liked = liked || f.Rating == history.ThumbsUp
+		disliked = disliked || f.Rating == history.ThumbsDown
+	}
+	if disliked && !o.IncludeDisliked {
+		return false
+	}
+	return liked || !o.RequireLiked
+}
+
+// acceptedPlan returns the plan executed successfully, if every call passed evaluation.
+func acceptedPlan(r *history.Record) (string, bool) {
+	if r.Error != "" || len(r.Plans) == 0 || len(r.Evaluations) == 0 {
+		return "", false
+	}
+	for _, v := range r.Evaluations {
+		if !v.Consistent {
+			return "", false
+		}
+	}
+	return r.Plans[len(r.Plans)-1].Completion, true
+}
+
+// verdictPrompt rebuilds the evaluation prompt of the verdict.
+func (a *RequestHandler) verdictPrompt(message string, v history.Verdict, ts *tools.ToolSet) (string, error) {
+	usedTools := &tools.ToolSet{TypeDefinitions: ts.TypeDefinitions}
+	for _, name := range v.Tools {
+		tool, ok := ts.FindTool(name)
+		if !ok {
+			return "", fmt.Errorf("tool %s not found", name)
+		}
+		usedTools.Functions = append(usedTools.Functions, *tool)
+	}
+	var call bytes.Buffer
+	if err := json.Indent(&call, v.Call, "", "  "); err != nil {
+		return "", fmt.Errorf("error indenting call: %w", err)
+	}
+	return a.evaluationPrompt(message, call.Bytes(), usedTools)
+}
+
+func datasetExample(format DatasetFormat, messages []llm.Message) (any, error) {
+	switch format {
+	case DatasetChat:
+		type message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}
+		example := struct {
+			Messages []message `json:"messages"`
+		}{}
+		for _, m := range messages {
+			example.Messages = append(example.Messages, message{Role: m[0], Content: m[1]})
+		}
+		return example, nil
+	case DatasetPromptCompletion:
+		last := len(messages) - 1
+		var prompt strings.Builder
+		for _, m := range messages[:last] {
+			prompt.WriteString(m[1])
+			prompt.WriteString("\n\n")
+		}
+		return map[string]string{"prompt": prompt.String(), "completion": messages[last][1]}, nil
+	case DatasetText:
+		var text strings.Builder
+		for _, m := range messages {
+			fmt.Fprintf(&text, "<|im_start|>%s\n%s<|im_end|>\n", m[0], m[1])
+		}
+		return map[string]string{"text": text.String()}, nil
+	default:
+		return nil, fmt.Errorf("unknown dataset format %q", format)
+	}
+}
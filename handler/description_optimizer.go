@@ -0,0 +1,482 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/history"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Defaults of DescriptionOptimization.
+const (
+	DefaultDescriptionCandidates = 3
+	DefaultOptimizationCases     = 5
+)
+
+// DescriptionOptimization configures OptimizeDescriptions.
+type DescriptionOptimization struct {
+	// Optimizer proposes the descriptions. LLMClient by default; a larger
+	// model than the planner helps.
+	Optimizer llm.Completer
+	// Candidates is the number of descriptions proposed per function.
+	Candidates int
+	// MaxFailures bounds the failed requests replayed per function, and
+	// MaxRegressions the successful ones checked for regressions.
+	MaxFailures    int
+	MaxRegressions int
+	// Since excludes the executions before the given time, if set.
+	Since time.Time
+}
+
+// DescriptionChange is a description changed by the optimizer.
+type DescriptionChange struct {
+	Function string `json:"function"`
+	// Parameter is empty for the description of the function.
+	Parameter string `json:"parameter,omitempty"`
+	Old       string `json:"old"`
+	New       string `json:"new"`
+}
+
+// DescriptionProposal is the best candidate descriptions of a function.
+type DescriptionProposal struct {
+	Function string              `json:"function"`
+	Changes  []DescriptionChange `json:"changes"`
+	// Cases is the number of requests replayed: the failed ones, and the
+	// successful ones checked for regressions.
+	Cases int `json:"cases"`
+	// Baseline and Score are the shares of the cases planned correctly with
+	// the current and the proposed descriptions.
+	Baseline float64 `json:"baseline"`
+	Score    float64 `json:"score"`
+}
+
+// DescriptionOptimizationResult holds the proposals improving on the current
+// descriptions, to be reviewed before adoption.
+type DescriptionOptimizationResult struct {
+	// ToolSet is the input ToolSet with the proposals applied.
+	ToolSet   *tools.ToolSet        `json:"toolset"`
+	Proposals []DescriptionProposal `json:"proposals"`
+	// Diff is the unified diff of the ToolSet, encoded as indented JSON.
+	Diff string `json:"diff"`
+}
+
+// optimizationCase is a request replayed to score the descriptions.
+type optimizationCase struct {
+	request string
+	// failure is set for the failed requests, expected for the successful ones.
+	failure  *prompt.PlanningFailure
+	expected []parser.PlannedFuncCall
+}
+
+// OptimizeDescriptions proposes improved descriptions for the functions of ts
+// the planner misused, according to the execution history: the calls evaluated
+// as inconsistent, and the executions with negative feedback. The candidates
+// of the optimizer are scored by replaying the failed requests, whose plans
+// must pass validation and evaluation, and the successful ones using the same
+// function, whose plans must not change. The evaluation uses the current
+// descriptions, so that the candidates can't talk the evaluator into
+// accepting wrong plans. Nothing is executed.
+//
+// ts is usually the content of a ToolSet file defining some of the tools of
+// the handler; its definitions take precedence over the handler ones.
+func (a *RequestHandler) OptimizeDescriptions(ctx context.Context, ts *tools.ToolSet, options DescriptionOptimization, progress progress.Stream) (*DescriptionOptimizationResult, error) {
+	if a.config.History == nil {
+		return nil, fmt.Errorf("no execution history configured")
+	}
+	if options.Optimizer == nil {
+		options.Optimizer = a.config.LLMClient
+	}
+	if options.Candidates <= 0 {
+		options.Candidates = DefaultDescriptionCandidates
+	}
+	if options.MaxFailures <= 0 {
+		options.MaxFailures = DefaultOptimizationCases
+	}
+	if options.MaxRegressions <= 0 {
+		options.MaxRegressions = DefaultOptimizationCases
+	}
+	records, err := a.config.History.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the execution history: %w", err)
+	}
+
+	original := tools.Merge(ts, a.config.Tools.AvailableTools())
+	failures, regressions := a.optimizationCases(records, ts, options)
+	names := make([]string, 0, len(failures))
+	for name := range failures {
+		names = append(names, name)
+	}
+	// The most misused functions first
+	sort.Slice(names, func(i, j int) bool {
+		if len(failures[names[i]]) != len(failures[names[j]]) {
+			return len(failures[names[i]]) > len(failures[names[j]])
+		}
+		return names[i] < names[j]
+	})
+
+	result := &DescriptionOptimizationResult{ToolSet: cloneToolSet(ts)}
+	for _, name := range names {
+		progress.Send(fmt.Sprintf("Optimizing the descriptions of %s...", name))
+		cases := append(failures[name][:len(failures[name]):len(failures[name])], regressions[name]...)
+		proposal, candidate, err := a.optimizeFunction(ctx, name, result.ToolSet, original, cases, options)
+		if err != nil {
+			return nil, fmt.Errorf("error optimizing %s: %w", name, err)
+		}
+		if proposal == nil {
+			a.logger(ctx).Printf("Description optimization: no candidate improves on %s", name)
+			continue
+		}
+		result.ToolSet = candidate
+		result.Proposals = append(result.Proposals, *proposal)
+	}
+
+	before, err := json.MarshalIndent(ts, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding toolset: %w", err)
+	}
+	after, err := json.MarshalIndent(result.ToolSet, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding toolset: %w", err)
+	}
+	result.Diff = unifiedDiff("a/toolset.json", "b/toolset.json", string(before), string(after))
+	return result, nil
+}
+
+// optimizationCases returns the failed and the successful requests, by the
+// functions of ts they used.
+func (a *RequestHandler) optimizationCases(records []*history.Record, ts *tools.ToolSet, options DescriptionOptimization) (failures, regressions map[string][]optimizationCase) {
+	failures = make(map[string][]optimizationCase)
+	regressions = make(map[string][]optimizationCase)
+	addFailure := func(names []string, c optimizationCase) {
+		seen := make(map[string]bool)
+		for _, name := range names {
+			if _, ok := ts.FindTool(name); ok && !seen[name] && len(failures[name]) < options.MaxFailures {
+				seen[name] = true
+				failures[name] = append(failures[name], c)
+			}
+		}
+	}
+
+	// The most recent records first
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if !options.Since.IsZero() && r.CreatedAt.Before(options.Since) {
+			continue
+		}
+		for _, v := range r.Evaluations {
+			if !v.Consistent {
+				addFailure(v.Tools, optimizationCase{request: r.Request, failure: &prompt.PlanningFailure{
+					Request: r.Request,
+					Plan:    string(v.Call),
+					Problem: "the call was evaluated as inconsistent with the request",
+				}})
+			}
+		}
+		if f, ok := negativeFeedback(r); ok && len(r.Plans) > 0 {
+			problem := "the user was not satisfied with the result"
+			if f.Comment != "" {
+				problem += ": " + f.Comment
+			}
+			addFailure(calledFunctions(r.Calls), optimizationCase{request: r.Request, failure: &prompt.PlanningFailure{
+				Request: r.Request,
+				Plan:    r.Plans[len(r.Plans)-1].Completion,
+				Problem: problem,
+			}})
+			continue
+		}
+		plan, ok := acceptedPlan(r)
+		if !ok {
+			continue
+		}
+		expected, err := a.config.PlanFormat.parse(plan)
+		if err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, call := range expected {
+			for _, name := range call.CollectAllNestedFuncCalls() {
+				if !seen[name] && len(regressions[name]) < options.MaxRegressions {
+					seen[name] = true
+					regressions[name] = append(regressions[name], optimizationCase{request: r.Request, expected: expected})
+				}
+			}
+		}
+	}
+	return failures, regressions
+}
+
+func negativeFeedback(r *history.Record) (history.Feedback, bool) {
+	for _, f := range r.Feedback {
+		if f.Rating == history.ThumbsDown {
+			return f, true
+		}
+	}
+	return history.Feedback{}, false
+}
+
+func calledFunctions(calls []history.Call) []string {
+	var names []string
+	for _, c := range calls {
+		names = append(names, c.Name)
+		names = append(names, calledFunctions(c.Calls)...)
+	}
+	return names
+}
+
+// optimizeFunction returns the best candidate descriptions of the function,
+// and ts with them applied, or nil if none improves on the current ones.
+func (a *RequestHandler) optimizeFunction(ctx context.Context, name string, ts, original *tools.ToolSet, cases []optimizationCase, options DescriptionOptimization) (*DescriptionProposal, *tools.ToolSet, error) {
+	function, _ := ts.FindTool(name)
+	definition, err := json.MarshalIndent(function, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshalling function: %w", err)
+	}
+	var planningFailures []prompt.PlanningFailure
+	for _, c := range cases {
+		if c.failure != nil {
+			planningFailures = append(planningFailures, *c.failure)
+		}
+	}
+	userPrompt, err := a.config.PromptVersions.CreatePromptForDescriptionOptimization(string(definition), planningFailures, options.Candidates)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating prompt for description optimization: %w", err)
+	}
+	jsonSchema, err := json.Marshal(prompt.DescriptionOptimizationResponseSchema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshalling schema: %w", err)
+	}
+	body, err := completeWith(ctx, options.Optimizer, []llm.Message{{"user", userPrompt}}, string(jsonSchema))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating descriptions: %w", err)
+	}
+	var response struct {
+		Candidates []struct {
+			Description string `json:"description"`
+			Parameters  []struct {
+				Name        string `json:"name"`
+				Description string `json:"description"`
+			} `json:"parameters"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return nil, nil, fmt.Errorf("error unmarshaling JSON: %w", err)
+	}
+
+	baseline, err := a.scoreDescriptions(ctx, tools.Merge(ts, original), original, cases)
+	if err != nil {
+		return nil, nil, err
+	}
+	var best *DescriptionProposal
+	var bestToolSet *tools.ToolSet
+	for _, c := range response.Candidates {
+		candidate := cloneToolSet(ts)
+		var changes []DescriptionChange
+		for i := range candidate.Functions {
+			f := &candidate.Functions[i]
+			if f.Name != name {
+				continue
+			}
+			if c.Description != "" && c.Description != f.Description {
+				changes = append(changes, DescriptionChange{Function: name, Old: f.Description, New: c.Description})
+				f.Description = c.Description
+			}
+			for _, p := range c.Parameters {
+				param, ok := f.Parameters.Properties[p.Name]
+				if !ok || p.Description == "" || p.Description == param.Description {
+					continue
+				}
+				changes = append(changes, DescriptionChange{Function: name, Parameter: p.Name, Old: param.Description, New: p.Description})
+				param.Description = p.Description
+				f.Parameters.Properties[p.Name] = param
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		score, err := a.scoreDescriptions(ctx, tools.Merge(candidate, original), original, cases)
+		if err != nil {
+			return nil, nil, err
+		}
+		a.logger(ctx).Printf("Description optimization: %s scores %.2f (baseline %.2f)", name, score, baseline)
+		if score > baseline && (best == nil || score > best.Score) {
+			best = &DescriptionProposal{Function: name, Changes: changes, Cases: len(cases), Baseline: baseline, Score: score}
+			bestToolSet = candidate
+		}
+	}
+	return best, bestToolSet, nil
+}
+
+// scoreDescriptions returns the share of the cases planned correctly with ts.
+// The plans are evaluated against the original definitions.
+func (a *RequestHandler) scoreDescriptions(ctx context.Context, ts, original *tools.ToolSet, cases []optimizationCase) (float64, error) {
+	if len(cases) == 0 {
+		return 0, nil
+	}
+	jsonSchema, err := json.Marshal(prompt.FuncCallsEvaluationResponseSchema)
+	if err != nil {
+		return 0, fmt.Errorf("error marshalling schema: %w", err)
+	}
+	passed := 0
+	for _, c := range cases {
+		funcCalls, _, _, err := a.generateFunctionCalls(ctx, c.request, ts, nil, &progress.NoOp{})
+		if err != nil || len(funcCalls) == 0 {
+			continue
+		}
+		if c.failure == nil {
+			if samePlan(funcCalls, c.expected) {
+				passed++
+			}
+			continue
+		}
+		ok := true
+		for _, call := range funcCalls {
+			if err := ts.ValidateCall(call); err != nil {
+				ok = false
+				break
+			}
+			usedTools := &tools.ToolSet{TypeDefinitions: original.TypeDefinitions}
+			for _, name := range call.CollectAllNestedFuncCalls() {
+				if tool, found := original.FindTool(name); found {
+					usedTools.Functions = append(usedTools.Functions, *tool)
+				}
+			}
+			consistent, err := a.evaluateSingleFunctionCall(ctx, c.request, call, jsonSchema, usedTools, &progress.NoOp{})
+			if err != nil {
+				return 0, err
+			}
+			if !consistent {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			passed++
+		}
+	}
+	return float64(passed) / float64(len(cases)), nil
+}
+
+// cloneToolSet returns a deep copy of ts.
+func cloneToolSet(ts *tools.ToolSet) *tools.ToolSet {
+	data, err := json.Marshal(ts)
+	if err != nil {
+		panic(err)
+	}
+	var clone tools.ToolSet
+	if err := json.Unmarshal(data, &clone); err != nil {
+		panic(err)
+	}
+	return &clone
+}
+
+// unifiedDiff returns the line diff of the texts in the unified format, with
+// three lines of context, or "" if they are equal.
+func unifiedDiff(nameA, nameB, a, b string) string {
+	if a == b {
+		return ""
+	}
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// Longest common subsequence, by suffix
+	lcs := make([][]int, len(linesA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(linesB)+1)
+	}
+	for i := len(linesA) - 1; i >= 0; i-- {
+		for j := len(linesB) - 1; j >= 0; j-- {
+			if linesA[i] == linesB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	type edit struct {
+		op   byte
+		line string
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(linesA) || j < len(linesB) {
+		switch {
+		case i < len(linesA) && j < len(linesB) && linesA[i] == linesB[j]:
+			edits = append(edits, edit{' ', linesA[i]})
+			i++
+			j++
+		case i < len(linesA) && (j == len(linesB) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', linesA[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', linesB[j]})
+			j++
+		}
+	}
+
+	const contextLines = 3
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk while the changes are closer than twice the context
+		from := max(start-contextLines, 0)
+		end := start
+		for k := start; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				end = k + 1
+			} else if k-end >= 2*contextLines {
+				break
+			}
+		}
+		to := min(end+contextLines, len(edits))
+		lineA, lineB := 1, 1
+		for _, e := range edits[:from] {
+			if e.op != '+' {
+				lineA++
+			}
+			if e.op != '-' {
+				lineB++
+			}
+		}
+		countA, countB := 0, 0
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				countA++
+			}
+			if e.op != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", lineA, countA, lineB, countB)
+		for _, e := range edits[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", e.op, e.line)
+		}
+		start = to
+	}
+	return out.String()
+}
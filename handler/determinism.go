@@ -0,0 +1,80 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+)
+
+// Determinism configures the handler so that repeated runs of the same request
+// produce the same results, for documentation and tests.
+type Determinism struct {
+	// Seed is the seed of the completions of the LLM clients implementing
+	// llm.SeededCompleter, which then sample greedily. Other clients are
+	// used as they are.
+	Seed int
+	// Clock, if set, is the clock of the executors (see execution.Now),
+	// typically frozen.
+	Clock func() time.Time
+	// HTTP, if set, wraps the HTTP transports of the executors (see
+	// execution.HTTPClient), typically with an httprecord.Recorder.
+	HTTP execution.HTTPMiddleware
+}
+
+// FrozenClock returns a clock always reporting t.
+func FrozenClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+// seeded replaces the LLM clients of config with their seeded copies.
+func (d *Determinism) seeded(config RequestHandlerConfig) RequestHandlerConfig {
+	config.LLMClient = d.seed(config.Logger, config.LLMClient)
+	if config.Ensemble != nil {
+		ensemble := *config.Ensemble
+		ensemble.Planner = d.seed(config.Logger, ensemble.Planner)
+		ensemble.Arbiter = d.seed(config.Logger, ensemble.Arbiter)
+		config.Ensemble = &ensemble
+	}
+	return config
+}
+
+func (d *Determinism) seed(logger *log.Logger, client llm.Completer) llm.Completer {
+	if client == nil {
+		return nil
+	}
+	seeded, ok := client.(llm.SeededCompleter)
+	if !ok {
+		logger.Printf("Deterministic mode: the LLM client %T can't be seeded", client)
+		return client
+	}
+	return seeded.WithSeed(d.Seed)
+}
+
+// environment returns a copy of ctx with the clock and the HTTP middleware of
+// the executors.
+func (d *Determinism) environment(ctx context.Context) context.Context {
+	if d.Clock != nil {
+		ctx = execution.WithClock(ctx, d.Clock)
+	}
+	if d.HTTP != nil {
+		ctx = execution.WithHTTPMiddleware(ctx, d.HTTP)
+	}
+	return ctx
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Ensemble configures the planning with two models, for deployments where
+// planning mistakes are costly: LLMClient and Planner plan the request
+// independently, and when their plans disagree, Arbiter chooses between them,
+// or rejects both.
+type Ensemble struct {
+	// Planner is the second model.
+	Planner llm.Completer
+	// Arbiter chooses between diverging plans. LLMClient by default.
+	Arbiter llm.Completer
+}
+
+// generateWithEnsemble plans the request with both models and returns the
+// agreeing plan, or the one chosen by the arbiter. The plan is empty, and the
+// request unprocessable, when the arbiter rejects both.
+func (a *RequestHandler) generateWithEnsemble(ctx context.Context, message string, ts *tools.ToolSet, feedback []llm.Message, progress progress.Stream) ([]parser.PlannedFuncCall, string, []string, error) {
+	type plan struct {
+		completion string
+		funcCalls  []parser.PlannedFuncCall
+		warnings   []string
+		err        error
+	}
+	var plans [2]plan
+	progress.Send("Generating function calls plans with two models...")
+	var wg sync.WaitGroup
+	for i, client := range []llm.Completer{a.config.LLMClient, a.config.Ensemble.Planner} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := &plans[i]
+			if p.completion, p.warnings, p.err = a.completePlan(ctx, client, message, ts, feedback, progress); p.err != nil {
+				p.err = fmt.Errorf("error calling LLM: %w", p.err)
+				return
+			}
+			p.funcCalls, p.err = a.config.PlanFormat.parse(p.completion)
+		}()
+	}
+	wg.Wait()
+
+	progress.Send("Synthesizing function calls...")
+	for _, p := range plans {
+		if p.completion != "" {
+			reportFromContext(ctx).addPlan(p.completion, p.funcCalls, "")
+		}
+	}
+	primary, secondary := plans[0], plans[1]
+	warnings := appendWarnings(primary.warnings, secondary.warnings...)
+	switch {
+	case primary.err != nil && secondary.err != nil:
+		return nil, "", warnings, primary.err
+	case primary.err != nil:
+		a.logger(ctx).Printf("Ensemble: using the plan of the second model: %v", primary.err)
+		return secondary.funcCalls, secondary.completion, warnings, nil
+	case secondary.err != nil:
+		a.logger(ctx).Printf("Ensemble: using the plan of the first model: %v", secondary.err)
+		return primary.funcCalls, primary.completion, warnings, nil
+	case samePlan(primary.funcCalls, secondary.funcCalls):
+		a.logger(ctx).Printf("Ensemble: the plans agree")
+		return primary.funcCalls, primary.completion, warnings, nil
+	}
+
+	a.logger(ctx).Printf("Ensemble: the plans disagree, asking the arbiter")
+	progress.Send("Arbitrating between diverging plans...")
+	choice, err := a.arbitrate(ctx, message, ts, primary.funcCalls, secondary.funcCalls)
+	if err != nil {
+		return nil, "", warnings, err
+	}
+	a.logger(ctx).Printf("Ensemble: the arbiter chose %s", choice)
+	switch choice {
+	case "A":
+		return primary.funcCalls, primary.completion, warnings, nil
+	case "B":
+		return secondary.funcCalls, secondary.completion, warnings, nil
+	default:
+		return nil, primary.completion, warnings, nil
+	}
+}
+
+// arbitrate asks the arbiter to choose between the plans: "A", "B" or "neither".
+func (a *RequestHandler) arbitrate(ctx context.Context, message string, ts *tools.ToolSet, planA, planB []parser.PlannedFuncCall) (string, error) {
+	usedTools := &tools.ToolSet{TypeDefinitions: ts.TypeDefinitions}
+	seen := make(map[string]bool)
+	for _, f := range append(planA[:len(planA):len(planA)], planB...) {
+		for _, name := range f.CollectAllNestedFuncCalls() {
+			if tool, ok := ts.FindTool(name); ok && !seen[name] {
+				seen[name] = true
+				usedTools.Functions = append(usedTools.Functions, *tool)
+			}
+		}
+	}
+	definitions, err := usedTools.Definitions(a.config.DefinitionFormat)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling functions to JSON: %w", err)
+	}
+	dataA, err := json.MarshalIndent(planA, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshalling plan: %w", err)
+	}
+	dataB, err := json.MarshalIndent(planB, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshalling plan: %w", err)
+	}
+	userPrompt, err := a.config.PromptVersions.CreatePromptForPlanArbitration(message, definitions, string(dataA), string(dataB))
+	if err != nil {
+		return "", fmt.Errorf("error generating prompt for arbitration: %w", err)
+	}
+	jsonSchema, err := json.Marshal(prompt.PlanArbitrationResponseSchema)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling schema: %w", err)
+	}
+
+	arbiter := a.config.Ensemble.Arbiter
+	if arbiter == nil {
+		arbiter = a.config.LLMClient
+	}
+	body, err := completeWith(ctx, arbiter, []llm.Message{{"user", userPrompt}}, string(jsonSchema))
+	if err != nil {
+		return "", fmt.Errorf("error generating response for arbitration: %w", err)
+	}
+	var response struct {
+		Choice string `json:"choice"`
+	}
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return "", fmt.Errorf("error unmarshaling JSON: %w", err)
+	}
+	return response.Choice, nil
+}
+
+// samePlan reports whether the plans call the same functions with the same
+// nesting and arguments. The purposes, free text, are ignored.
+func samePlan(a, b []parser.PlannedFuncCall) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if callKey(&a[i]) != callKey(&b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// callKey encodes the name and the arguments of the call, recursively.
+func callKey(call *parser.PlannedFuncCall) string {
+	args := make(map[string]any, len(call.Args))
+	for name, arg := range call.Args {
+		if f, ok := arg.(*parser.PlannedFuncCall); ok {
+			args[name] = callKey(f)
+		} else {
+			args[name] = arg
+		}
+	}
+	data, _ := json.Marshal(struct {
+		Name string         `json:"name"`
+		Args map[string]any `json:"args"`
+	}{call.Name, args})
+	return string(data)
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/testkit"
+)
+
+// failingEvaluations fails the first consistency evaluation, and delays the others.
+type failingEvaluations struct {
+	*testkit.Completer
+	schema      string
+	evaluations atomic.Int32
+}
+
+func (c *failingEvaluations) Complete(messages []llm.Message, jsonSchema string) (string, error) {
+	if jsonSchema == c.schema {
+		if c.evaluations.Add(1) == 1 {
+			return "", errors.New("backend down")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return c.Completer.Complete(messages, jsonSchema)
+}
+
+func TestEvaluationErrorCancelsThePendingEvaluations(t *testing.T) {
+	const calls = 12
+	planned := make([]parser.PlannedFuncCall, calls)
+	for i := range planned {
+		planned[i] = testkit.Call("get_weather", map[string]any{"location": fmt.Sprintf("%d,%d", i, i)})
+	}
+	schema, err := json.Marshal(prompt.FuncCallsEvaluationResponseSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	completer := &failingEvaluations{Completer: testkit.NewCompleter(testkit.Plan(planned...)), schema: string(schema)}
+	h, err := handler.NewRequestHandler(handler.RequestHandlerConfig{
+		Logger:              log.New(io.Discard, "", 0),
+		LLMClient:           completer,
+		Tools:               weatherTools{},
+		Timeout:             time.Second,
+		DisableBuiltinTools: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.ProcessUserRequest(context.Background(), "What's the weather?", &progress.NoOp{}); err == nil {
+		t.Fatal("the failed evaluation did not fail the request")
+	}
+	// Give the evaluations not cancelled the time to reach the LLM
+	time.Sleep(300 * time.Millisecond)
+	// The evaluations in flight complete, and one may start before the cancellation
+	if n := completer.evaluations.Load(); n > 5 {
+		t.Fatalf("%d of %d calls were evaluated, although the first evaluation failed", n, calls)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// maxReplans is the number of new plans requested after failed executions
+// when FlagReplanning is enabled.
+const maxReplans = 2
+
+// replanningFeedback returns the messages asking the LLM to correct the plan
+// it produced, given the error of its execution.
+func (a *RequestHandler) replanningFeedback(completion string, executionError error) ([]llm.Message, error) {
+	feedback, err := a.config.PromptVersions.CreatePromptForReplanning(executionError.Error())
+	if err != nil {
+		return nil, fmt.Errorf("error generating re-planning prompt: %w", err)
+	}
+	return []llm.Message{
+		{"assistant", completion},
+		{"user", feedback},
+	}, nil
+}
+
+// speculativeExecution is an execution started before the evaluation of the plan completes.
+type speculativeExecution struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	result *execution.Result
+	err    error
+}
+
+// startSpeculativeExecution starts executing the plan in the background, unless
+// it involves side-effect functions, in which case it returns nil.
+func (a *RequestHandler) startSpeculativeExecution(ctx context.Context, funcCalls []parser.PlannedFuncCall) *speculativeExecution {
+	ts := a.config.Tools.AvailableTools()
+	for _, f := range funcCalls {
+		if hasSideEffects(ts, f) {
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &speculativeExecution{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		// Progress is not reported, since the results may be discarded
+		s.result, s.err = a.orchestrator.Execute(ctx, funcCalls, &progress.NoOp{})
+	}()
+	return s
+}
+
+// wait returns the result of the speculative execution.
+func (s *speculativeExecution) wait() (*execution.Result, error) {
+	<-s.done
+	s.cancel()
+	return s.result, s.err
+}
+
+// discard stops the speculative execution and waits for it to return.
+// It can be called on a nil speculativeExecution.
+func (s *speculativeExecution) discard() {
+	if s == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// synthesizeAnswer composes a natural-language answer to the request from the main results.
+func (a *RequestHandler) synthesizeAnswer(ctx context.Context, message string, exec *execution.Result, progress progress.Stream) (string, error) {
+	progress.Send("Synthesizing answer...")
+
+	results, err := exec.MainFuncResults().Format("")
+	if err != nil {
+		return "", fmt.Errorf("error formatting results: %w", err)
+	}
+
+	userPrompt, err := a.config.PromptVersions.CreatePromptForSynthesis(message, results, a.config.OutputLanguage)
+	if err != nil {
+		return "", fmt.Errorf("error generating synthesis prompt: %w", err)
+	}
+
+	answer, err := a.completeStreamed(ctx, a.config.LLMClient, []llm.Message{{"user", userPrompt}}, "", "Synthesizing answer", progress)
+	if err != nil {
+		return "", fmt.Errorf("error calling LLM: %w", err)
+	}
+	a.logger(ctx).Printf("Synthesized answer: %s", answer)
+	return answer, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/session"
+)
+
+const (
+	// failureHintsTTL is how long a failure is reported to the planner.
+	failureHintsTTL = 30 * time.Minute
+	// maxFailureReasonLength truncates the reasons reported to the planner.
+	maxFailureReasonLength = 200
+)
+
+// collectFailures returns the calls of an execution that failed or found nothing.
+func collectFailures(exec *execution.Result, execErr error) []session.Failure {
+	now := time.Now()
+	var failures []session.Failure
+
+	// The innermost error is the one of the executor
+	var failed *execution.Error
+	for err := execErr; errors.As(err, &failed); err = failed.Err {
+		if failed.Args != nil {
+			failures = append(failures, session.Failure{
+				FuncName: failed.FuncName,
+				Args:     failed.Args,
+				Reason:   failed.Err.Error(),
+				At:       now,
+			})
+			break
+		}
+	}
+
+	if exec != nil {
+		for _, call := range exec.FuncCalls {
+			failures = appendNotFound(failures, call, now)
+		}
+	}
+	return failures
+}
+
+// appendNotFound appends the calls of the tree rooted at call whose result is not present.
+func appendNotFound(failures []session.Failure, call *execution.ExecutedFuncCall, at time.Time) []session.Failure {
+	args := make(map[string]any, len(call.Args))
+	nestedMissing := false
+	for name, arg := range call.Args {
+		if nested, ok := execution.GetFuncCall(arg); ok {
+			failures = appendNotFound(failures, nested, at)
+			nestedMissing = nestedMissing || !nested.Result.Present
+			continue
+		}
+		args[name], _ = execution.GetValue(arg)
+	}
+
+	// Builtin results and not approved actions are not failures of the tools, and
+	// the calls missing a nested result are reported through the nested call
+	if call.Result.Present || call.Receipt != nil || strings.HasPrefix(call.Name, "__builtin__") || nestedMissing {
+		return failures
+	}
+
+	reason := "nothing found"
+	if call.Result.FormatFunc != nil {
+		if s, err := call.Result.FormatFunc(); err == nil && s != "" {
+			reason = s
+		}
+	}
+	return append(failures, session.Failure{FuncName: call.Name, Args: args, Reason: reason, At: at})
+}
+
+// recordFailures stores the failures in the session of the request, if any.
+func (a *RequestHandler) recordFailures(ctx context.Context, failures []session.Failure) {
+	if a.config.SessionStore == nil || len(failures) == 0 {
+		return
+	}
+	id, ok := session.IDFromContext(ctx)
+	if !ok {
+		return
+	}
+	err := a.config.SessionStore.Update(ctx, id, func(s *session.Session) error {
+		s.RecordFailures(failures...)
+		return nil
+	})
+	if err != nil {
+		a.logger(ctx).Printf("Failed to record failures in the session: %v", err)
+	}
+}
+
+// failureHints describes the recent failures of the session of the request, if any.
+func (a *RequestHandler) failureHints(ctx context.Context) []string {
+	if a.config.SessionStore == nil {
+		return nil
+	}
+	if _, ok := session.IDFromContext(ctx); !ok {
+		return nil
+	}
+	s, err := session.FromContext(ctx, a.config.SessionStore)
+	if err != nil {
+		a.logger(ctx).Printf("Failed to load the session: %v", err)
+		return nil
+	}
+
+	var hints []string
+	for _, f := range s.Failures {
+		if time.Since(f.At) > failureHintsTTL {
+			continue
+		}
+		hints = append(hints, formatFailureHint(f))
+	}
+	return hints
+}
+
+func formatFailureHint(f session.Failure) string {
+	reason := strings.Join(strings.Fields(f.Reason), " ")
+	if r := []rune(reason); len(r) > maxFailureReasonLength {
+		reason = string(r[:maxFailureReasonLength]) + "..."
+	}
+
+	args, err := json.Marshal(f.Args)
+	if err != nil || len(f.Args) == 0 {
+		return fmt.Sprintf("%s failed: %s", f.FuncName, reason)
+	}
+	return fmt.Sprintf("%s failed for %s: %s", f.FuncName, args, reason)
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Flag names an experimental pipeline stage, disabled unless explicitly enabled.
+type Flag string
+
+const (
+	// FlagReplanning re-plans the request, with the error as feedback,
+	// when the execution of a plan fails.
+	FlagReplanning Flag = "replanning"
+	// FlagSpeculativeExecution executes plans without side-effect functions
+	// while they are being evaluated, discarding the results if some call is rejected.
+	FlagSpeculativeExecution Flag = "speculative_execution"
+	// FlagAnswerSynthesis composes a natural-language answer from the results,
+	// stored in ProcessingResult.Answer.
+	FlagAnswerSynthesis Flag = "answer_synthesis"
+)
+
+// knownFlags lists the flags accepted by ParseFlags.
+var knownFlags = []Flag{FlagReplanning, FlagSpeculativeExecution, FlagAnswerSynthesis}
+
+// Flags holds the state of the feature flags. Missing flags are disabled.
+type Flags map[Flag]bool
+
+// Enabled reports whether the flag is enabled.
+func (f Flags) Enabled(flag Flag) bool {
+	return f[flag]
+}
+
+// String returns the flags as accepted by ParseFlags, sorted by name.
+func (f Flags) String() string {
+	items := make([]string, 0, len(f))
+	for flag, enabled := range f {
+		if enabled {
+			items = append(items, string(flag))
+		} else {
+			items = append(items, "-"+string(flag))
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// ParseFlags parses a comma-separated list of flag names: a name enables the
+// flag, a name prefixed with "-" disables it, e.g. "replanning,-answer_synthesis".
+func ParseFlags(s string) (Flags, error) {
+	flags := make(Flags)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		enabled := !strings.HasPrefix(item, "-")
+		flag := Flag(strings.TrimPrefix(item, "-"))
+		if !isKnownFlag(flag) {
+			return nil, fmt.Errorf("unknown feature flag %q", flag)
+		}
+		flags[flag] = enabled
+	}
+	return flags, nil
+}
+
+func isKnownFlag(flag Flag) bool {
+	for _, f := range knownFlags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+type flagsContextKey struct{}
+
+// WithFlags returns a copy of ctx carrying per-request overrides of the configured flags.
+func WithFlags(ctx context.Context, overrides Flags) context.Context {
+	return context.WithValue(ctx, flagsContextKey{}, overrides)
+}
+
+// flags returns the effective flags of the request: the configured ones,
+// updated with the overrides carried by ctx.
+func (a *RequestHandler) flags(ctx context.Context) Flags {
+	flags := make(Flags, len(a.config.Flags))
+	for flag, enabled := range a.config.Flags {
+		flags[flag] = enabled
+	}
+	overrides, _ := ctx.Value(flagsContextKey{}).(Flags)
+	for flag, enabled := range overrides {
+		flags[flag] = enabled
+	}
+	return flags
+}
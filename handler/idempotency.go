@@ -0,0 +1,174 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/session"
+)
+
+const (
+	// DefaultIdempotencyTTL is the default time the results are kept by an IdempotencyCache.
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyCacheSize is the default number of results kept by an IdempotencyCache.
+	DefaultIdempotencyCacheSize = 10000
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is reused for a
+// different request.
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused for a different request")
+
+// errProcessingAborted releases the entries whose processing panicked.
+var errProcessingAborted = errors.New("processing aborted")
+
+// IdempotencyCache keeps the results of the requests carrying an idempotency
+// key (see WithIdempotencyKey), so that the retries of a client, or the
+// redeliveries of a webhook, get the stored result instead of planning and
+// executing the request again. The requests with a key still being processed
+// wait for its result. Failed requests, and the partial results of the requests
+// whose budget expired, are not kept, so that they can be retried.
+// Keys are scoped by session: the keys of the requests without a session are
+// shared by all of them, so their clients must choose unique keys, e.g. UUIDs.
+type IdempotencyCache struct {
+	// TTL is how long the results are kept.
+	TTL time.Duration
+	// MaxEntries is the number of results kept; the oldest ones are evicted first.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[idempotencyKey]*idempotencyEntry
+}
+
+// idempotencyKey is the idempotency key chosen by the client within its session.
+type idempotencyKey struct {
+	session string
+	key     string
+}
+
+type idempotencyEntry struct {
+	request string
+	// done is closed when the processing ends, setting result on success.
+	done    chan struct{}
+	result  *ProcessingResult
+	expires time.Time
+}
+
+// NewIdempotencyCache creates an IdempotencyCache with the default TTL and size.
+func NewIdempotencyCache() *IdempotencyCache {
+	return &IdempotencyCache{TTL: DefaultIdempotencyTTL, MaxEntries: DefaultIdempotencyCacheSize}
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx carrying the idempotency key
+// chosen by the client for the request.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// acquire returns the entry of the key, reporting whether the caller owns it
+// and must process the request.
+func (c *IdempotencyCache) acquire(key idempotencyKey, request string) (*idempotencyEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[idempotencyKey]*idempotencyEntry)
+	}
+	now := time.Now()
+	if e, ok := c.entries[key]; ok && (e.expires.IsZero() || now.Before(e.expires)) {
+		if e.request != request {
+			return nil, false, ErrIdempotencyKeyReused
+		}
+		return e, false, nil
+	}
+	e := &idempotencyEntry{request: request, done: make(chan struct{})}
+	c.entries[key] = e
+	return e, true, nil
+}
+
+// release stores the result of the owned entry, or drops the entry when the
+// processing failed or was cut short by the budget of the request.
+func (c *IdempotencyCache) release(key idempotencyKey, e *idempotencyEntry, result *ProcessingResult, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(e.done)
+	if err != nil || result == nil || result.Partial {
+		delete(c.entries, key)
+		return
+	}
+	now := time.Now()
+	e.result, e.expires = result, now.Add(c.TTL)
+	for k, other := range c.entries {
+		if !other.expires.IsZero() && !now.Before(other.expires) {
+			delete(c.entries, k)
+		}
+	}
+	for c.MaxEntries > 0 && len(c.entries) > c.MaxEntries {
+		var oldest *idempotencyKey
+		for k, other := range c.entries {
+			if !other.expires.IsZero() && (oldest == nil || other.expires.Before(c.entries[*oldest].expires)) {
+				oldest = &k
+			}
+		}
+		if oldest == nil {
+			break
+		}
+		delete(c.entries, *oldest)
+	}
+}
+
+// run processes the request of the owned entry, releasing it even if the
+// processing panics, so that the requests waiting for it do not hang.
+func (c *IdempotencyCache) run(key idempotencyKey, e *idempotencyEntry, process func() (*ProcessingResult, error)) (result *ProcessingResult, err error) {
+	err = errProcessingAborted
+	defer func() { c.release(key, e, result, err) }()
+	return process()
+}
+
+// idempotent processes the request, unless it carries the idempotency key of
+// a previous one, whose stored result is returned instead with Replayed set.
+func (a *RequestHandler) idempotent(ctx context.Context, request string, process func() (*ProcessingResult, error)) (*ProcessingResult, error) {
+	var key idempotencyKey
+	key.key, _ = ctx.Value(idempotencyKeyContextKey{}).(string)
+	if key.key == "" || a.config.Idempotency == nil {
+		return process()
+	}
+	key.session, _ = session.IDFromContext(ctx)
+	for {
+		e, owner, err := a.config.Idempotency.acquire(key, request)
+		if err != nil {
+			return nil, err
+		}
+		if owner {
+			return a.config.Idempotency.run(key, e, process)
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.result != nil {
+			a.logger(ctx).Printf("Idempotency: replaying the result of key %s", key.key)
+			replay := *e.result
+			replay.Replayed = true
+			return &replay, nil
+		}
+		// The request being processed failed: process this one instead
+	}
+}
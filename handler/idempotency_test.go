@@ -0,0 +1,207 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/session"
+)
+
+func newIdempotentHandler() *RequestHandler {
+	return &RequestHandler{config: RequestHandlerConfig{
+		Logger:      log.New(io.Discard, "", 0),
+		Idempotency: NewIdempotencyCache(),
+	}}
+}
+
+func TestIdempotentWaitersReplayTheResult(t *testing.T) {
+	a := newIdempotentHandler()
+	ctx := WithIdempotencyKey(context.Background(), "k1")
+	started, finish := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	process := func() (*ProcessingResult, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-finish
+		}
+		return &ProcessingResult{}, nil
+	}
+
+	var owner *ProcessingResult
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		owner, _ = a.idempotent(ctx, "request", process)
+	}()
+	<-started
+
+	const waiters = 5
+	results := make([]*ProcessingResult, waiters)
+	errs := make([]error, waiters)
+	for i := range waiters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = a.idempotent(ctx, "request", process)
+		}()
+	}
+	close(finish)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("processed %d times, want 1", n)
+	}
+	if owner == nil || owner.Replayed {
+		t.Fatalf("the first request got %+v, want a result not replayed", owner)
+	}
+	for i, result := range results {
+		if errs[i] != nil || result == nil || !result.Replayed {
+			t.Errorf("waiter %d got %+v, %v, want the replayed result", i, result, errs[i])
+		}
+	}
+}
+
+func TestIdempotentRetriesFailedRequests(t *testing.T) {
+	a := newIdempotentHandler()
+	ctx := WithIdempotencyKey(context.Background(), "k1")
+	started, finish := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	process := func() (*ProcessingResult, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-finish
+			return nil, errors.New("failed")
+		}
+		return &ProcessingResult{}, nil
+	}
+
+	var ownerErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, ownerErr = a.idempotent(ctx, "request", process)
+	}()
+	<-started
+	waited := make(chan *ProcessingResult)
+	go func() {
+		result, err := a.idempotent(ctx, "request", process)
+		if err != nil {
+			t.Errorf("the waiting request failed: %v", err)
+		}
+		waited <- result
+	}()
+	close(finish)
+	<-done
+
+	if ownerErr == nil {
+		t.Fatal("the first request did not fail")
+	}
+	// The waiting request processes again, instead of replaying the failure
+	if result := <-waited; result == nil || result.Replayed {
+		t.Fatalf("the waiting request got %+v, want a result not replayed", result)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("processed %d times, want 2", n)
+	}
+	result, err := a.idempotent(ctx, "request", process)
+	if err != nil || !result.Replayed {
+		t.Fatalf("the retry got %+v, %v, want the replayed result", result, err)
+	}
+}
+
+func TestIdempotentReleasesPanickingRequests(t *testing.T) {
+	a := newIdempotentHandler()
+	ctx := WithIdempotencyKey(context.Background(), "k1")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("the panic was not propagated")
+			}
+		}()
+		_, _ = a.idempotent(ctx, "request", func() (*ProcessingResult, error) { panic("boom") })
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	result, err := a.idempotent(ctx, "request", func() (*ProcessingResult, error) { return &ProcessingResult{}, nil })
+	if err != nil {
+		t.Fatalf("the retry after the panic failed: %v", err)
+	}
+	if result.Replayed {
+		t.Fatal("the retry after the panic was replayed")
+	}
+}
+
+func TestIdempotentDoesNotKeepPartialResults(t *testing.T) {
+	a := newIdempotentHandler()
+	ctx := WithIdempotencyKey(context.Background(), "k1")
+	var calls atomic.Int32
+	process := func() (*ProcessingResult, error) {
+		return &ProcessingResult{Partial: calls.Add(1) == 1}, nil
+	}
+
+	if result, err := a.idempotent(ctx, "request", process); err != nil || !result.Partial {
+		t.Fatalf("got %+v, %v, want the partial result", result, err)
+	}
+	result, err := a.idempotent(ctx, "request", process)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Replayed || result.Partial {
+		t.Fatalf("the retry got %+v, want a complete result not replayed", result)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("processed %d times, want 2", n)
+	}
+}
+
+func TestIdempotentScopesTheKeysBySession(t *testing.T) {
+	a := newIdempotentHandler()
+	var calls atomic.Int32
+	process := func() (*ProcessingResult, error) {
+		calls.Add(1)
+		return &ProcessingResult{}, nil
+	}
+
+	// The same key in different sessions, and keys that would join into the same string
+	for _, ctx := range []context.Context{
+		session.WithID(WithIdempotencyKey(context.Background(), "k1"), "alice"),
+		session.WithID(WithIdempotencyKey(context.Background(), "k1"), "bob"),
+		session.WithID(WithIdempotencyKey(context.Background(), "b/c"), "a"),
+		session.WithID(WithIdempotencyKey(context.Background(), "c"), "a/b"),
+		WithIdempotencyKey(context.Background(), "a/b/c"),
+	} {
+		result, err := a.idempotent(ctx, fmt.Sprint("request ", calls.Load()), process)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Replayed {
+			t.Fatalf("request %d replayed the result of another session", calls.Load())
+		}
+	}
+	if n := calls.Load(); n != 5 {
+		t.Fatalf("processed %d times, want 5", n)
+	}
+}
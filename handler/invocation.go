@@ -0,0 +1,55 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// InvokeTool executes a single call of the function with the given literal
+// arguments, bypassing the LLM, for testing the tools and for deterministic
+// client features. The arguments are validated against the definition of the
+// function, and the call goes through the same execution as the plans
+// (caching, middleware, approval, audit). Answer synthesis is skipped.
+// Invalid calls are rejected with ErrInvalidPlan.
+func (a *RequestHandler) InvokeTool(ctx context.Context, name string, args map[string]any, progress progress.Stream) (*ProcessingResult, error) {
+	call := parser.PlannedFuncCall{Name: name, Purpose: "Direct invocation", Args: args}
+	request := invocationRequest(call)
+	return a.idempotent(ctx, request, func() (*ProcessingResult, error) {
+		return a.run(ctx, request, func(ctx context.Context) (*ProcessingResult, error) {
+			if err := a.config.Tools.AvailableTools().ValidateCall(call); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidPlan, err)
+			}
+			flags := a.flags(ctx)
+			flags[FlagAnswerSynthesis] = false
+			return a.executeSubmitted(ctx, "", []parser.PlannedFuncCall{call}, flags, nil, progress)
+		})
+	})
+}
+
+// invocationRequest describes the direct invocation in the report, in place
+// of the request of the user.
+func invocationRequest(call parser.PlannedFuncCall) string {
+	args, err := json.Marshal(call.Args)
+	if err != nil {
+		return call.Name + "(...)"
+	}
+	return fmt.Sprintf("%s(%s)", call.Name, args)
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/session"
+)
+
+// DefaultMaintenanceInterval is the default time between the maintenance rounds.
+const DefaultMaintenanceInterval = time.Minute
+
+// Maintenance configures the background work keeping a steady-state
+// deployment fast between requests (see RunMaintenance).
+type Maintenance struct {
+	// Interval is the time between the rounds. DefaultMaintenanceInterval if zero.
+	Interval time.Duration
+	// RefreshWithin, if positive, executes again the calls whose persisted
+	// results expire within it (see RequestHandlerConfig.MemoTTL) and were
+	// used at least RefreshMinHits times (1 if zero) since they were stored.
+	RefreshWithin  time.Duration
+	RefreshMinHits int
+	// KeepWarm probes the LLM clients implementing llm.HealthChecker at every
+	// round, keeping their connections open.
+	KeepWarm bool
+	// SessionTTL, if positive, deletes the sessions not updated for longer,
+	// from the session stores implementing session.Pruner.
+	SessionTTL time.Duration
+}
+
+// RunMaintenance runs the maintenance rounds configured by
+// RequestHandlerConfig.Maintenance until ctx is done. It returns immediately
+// without one.
+func (a *RequestHandler) RunMaintenance(ctx context.Context) {
+	m := a.config.Maintenance
+	if m == nil {
+		return
+	}
+	interval := m.Interval
+	if interval <= 0 {
+		interval = DefaultMaintenanceInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		a.Maintain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Maintain runs a maintenance round. Failures are logged.
+func (a *RequestHandler) Maintain(ctx context.Context) {
+	m := a.config.Maintenance
+	if m == nil {
+		return
+	}
+	if m.RefreshWithin > 0 {
+		if n := a.orchestrator.RefreshMemo(ctx, m.RefreshWithin, m.RefreshMinHits); n > 0 {
+			a.config.Logger.Printf("Maintenance: refreshed %d memoized results", n)
+		}
+	}
+	if m.KeepWarm {
+		a.keepWarm(ctx)
+	}
+	if pruner, ok := a.config.SessionStore.(session.Pruner); ok && m.SessionTTL > 0 {
+		n, err := pruner.Prune(ctx, time.Now().Add(-m.SessionTTL))
+		if err != nil {
+			a.config.Logger.Printf("Maintenance: failed to prune the sessions: %v", err)
+		} else if n > 0 {
+			a.config.Logger.Printf("Maintenance: pruned %d sessions", n)
+		}
+	}
+}
+
+// keepWarm probes the LLM clients of the handler.
+func (a *RequestHandler) keepWarm(ctx context.Context) {
+	clients := []llm.Completer{a.config.LLMClient}
+	if e := a.config.Ensemble; e != nil {
+		clients = append(clients, e.Planner, e.Arbiter)
+	}
+	for _, client := range clients {
+		checker, ok := client.(llm.HealthChecker)
+		if !ok {
+			continue
+		}
+		if err := checker.Health(ctx); err != nil {
+			a.config.Logger.Printf("Maintenance: LLM health check failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// builtinFormatters are the formatters selectable with WithOutputFormat, the
+// default first.
+var builtinFormatters = []execution.Formatter{
+	execution.TextFormatter{},
+	execution.JSONFormatter{},
+	execution.MarkdownFormatter{},
+	execution.HTMLFormatter{},
+}
+
+// formatAliases are the short names accepted in place of the content types.
+var formatAliases = map[string]string{
+	"text":     execution.ContentTypeText,
+	"json":     execution.ContentTypeJSON,
+	"markdown": execution.ContentTypeMarkdown,
+	"md":       execution.ContentTypeMarkdown,
+	"html":     execution.ContentTypeHTML,
+}
+
+type outputFormatContextKey struct{}
+
+// WithOutputFormat returns a copy of ctx requesting the representation of the
+// output of the request, in the format of the HTTP Accept header, such as
+// "text/html, text/markdown;q=0.5". The short names text, json, markdown and
+// html are accepted too. Unsupported formats fall back to plain text.
+func WithOutputFormat(ctx context.Context, accept string) context.Context {
+	return context.WithValue(ctx, outputFormatContextKey{}, accept)
+}
+
+// formatOutput sets the output of the result, formatted with the formatter
+// negotiated for the request, or the synthesized answer, in plain text.
+func (a *RequestHandler) formatOutput(ctx context.Context, result *ProcessingResult) error {
+	if result.Answer != "" {
+		result.Output, result.ContentType = result.Answer, execution.ContentTypeText
+		return nil
+	}
+	accept, _ := ctx.Value(outputFormatContextKey{}).(string)
+	formatter := negotiateFormatter(accept, append(a.config.Formatters[:len(a.config.Formatters):len(a.config.Formatters)], builtinFormatters...))
+	// The formatted results are reused by the report
+	for _, f := range result.Execution.FuncCalls {
+		if _, err := formatOnce(f); err != nil {
+			return fmt.Errorf("error formatting result: %w", err)
+		}
+	}
+	output, err := formatter.Format(result.Execution)
+	if err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+	result.Output, result.ContentType = output, formatter.ContentType()
+	return nil
+}
+
+// negotiateFormatter returns the first of the formatters matching the most
+// preferred media type accepted, or the default one.
+func negotiateFormatter(accept string, formatters []execution.Formatter) execution.Formatter {
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(item, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if alias, ok := formatAliases[mediaType]; ok {
+			mediaType = alias
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if name, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(name) == "q" {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if mediaType != "" && q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	for _, r := range ranges {
+		for _, f := range formatters {
+			if mediaTypeMatches(r.mediaType, f.ContentType()) {
+				return f
+			}
+		}
+	}
+	return execution.TextFormatter{}
+}
+
+// mediaTypeMatches reports whether the media range, possibly with wildcards
+// such as "text/*", matches the content type.
+func mediaTypeMatches(mediaRange, contentType string) bool {
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(contentType, prefix+"/")
+}
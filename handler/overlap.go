@@ -0,0 +1,141 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// evaluateWhileExecuting starts executing every call as soon as its verdict arrives.
+// It returns a nil result without errors when some call is rejected, after
+// cancelling the calls started and rolling back the side effects performed.
+// With TransactionalSideEffects, the calls involving side effects are held back
+// until every verdict has arrived, and then executed as a single transaction.
+func (a *RequestHandler) evaluateWhileExecuting(ctx context.Context, message string, funcCalls []parser.PlannedFuncCall, _ Flags, progress progress.Stream) (exec *execution.Result, evalErr, execErr error) {
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	execCtx, receipts := execution.CollectReceipts(execCtx)
+
+	calls := make([]*execution.ExecutedFuncCall, len(funcCalls))
+	errs := make([]error, len(funcCalls))
+	var wg sync.WaitGroup
+	rejected := false
+	var held []int
+	ts := a.config.Tools.AvailableTools()
+
+	stage(ctx, "evaluation", func(ctx context.Context) {
+		evalErr = a.evaluateEach(ctx, message, funcCalls, progress, func(i int, isConsistent bool) {
+			if rejected {
+				return
+			}
+			if !isConsistent {
+				a.logger(ctx).Printf("Function %s rejected, cancelling the plan", funcCalls[i].Name)
+				rejected = true
+				cancel()
+				return
+			}
+			if a.config.TransactionalSideEffects && hasSideEffects(ts, funcCalls[i]) {
+				held = append(held, i)
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				stage(execCtx, "execution", func(execCtx context.Context) {
+					result, err := a.orchestrator.Execute(execCtx, funcCalls[i:i+1], progress)
+					if err != nil {
+						errs[i] = err
+						return
+					}
+					calls[i] = result.FuncCalls[0]
+				})
+			}()
+		})
+	})
+	if evalErr != nil || rejected {
+		cancel()
+		wg.Wait()
+		a.rollback(ctx, receipts())
+		return nil, evalErr, nil
+	}
+
+	if len(held) > 0 {
+		// The verdicts arrive in any order: the transaction follows the plan
+		slices.Sort(held)
+		transaction := make([]parser.PlannedFuncCall, len(held))
+		for j, i := range held {
+			transaction[j] = funcCalls[i]
+		}
+		stage(execCtx, "execution", func(execCtx context.Context) {
+			result, err := a.orchestrator.Execute(execCtx, transaction, progress)
+			if err != nil {
+				errs[held[0]] = err
+				return
+			}
+			for j, i := range held {
+				calls[i] = result.FuncCalls[j]
+			}
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return &execution.Result{FuncCalls: calls}, nil, nil
+}
+
+// hasSideEffects reports whether the call or one of its nested calls is a side-effect function.
+func hasSideEffects(ts *tools.ToolSet, call parser.PlannedFuncCall) bool {
+	for _, name := range call.CollectAllNestedFuncCalls() {
+		if def, ok := ts.FindTool(name); ok && def.SideEffect {
+			return true
+		}
+	}
+	return false
+}
+
+// rollback undoes the side effects performed, most recent first, through the
+// Rollback hook or the compensators registered with the orchestrator.
+func (a *RequestHandler) rollback(ctx context.Context, receipts []execution.Receipt) {
+	var performed []execution.Receipt
+	for i := len(receipts) - 1; i >= 0; i-- {
+		if receipts[i].Outcome == execution.ReceiptPerformed {
+			performed = append(performed, receipts[i])
+		}
+	}
+	if len(performed) == 0 {
+		return
+	}
+	a.logger(ctx).Printf("Rolling back %d side effects", len(performed))
+	if a.config.Rollback == nil {
+		// Compensate expects the receipts in the order they were recorded
+		if err := a.orchestrator.Compensate(ctx, receipts); err != nil {
+			a.logger(ctx).Printf("Rollback failed: %v", err)
+		}
+		return
+	}
+	if err := a.config.Rollback(ctx, performed); err != nil {
+		a.logger(ctx).Printf("Rollback failed: %v", err)
+	}
+}
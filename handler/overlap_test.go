@@ -0,0 +1,208 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/testkit"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// ticketTools provides the side-effect functions create_ticket, compensated
+// by cancelling the ticket, and close_all_tickets, failing if closeFails is set.
+type ticketTools struct {
+	closeFails bool
+
+	mu        sync.Mutex
+	created   []string
+	cancelled []string
+}
+
+func (t *ticketTools) AvailableTools() *tools.ToolSet {
+	params := tools.TypeInfo{
+		Type:       "object",
+		Properties: map[string]tools.TypeInfo{"title": {Type: "string"}},
+		Required:   []string{"title"},
+	}
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{Name: "create_ticket", Description: "Open a ticket.", Parameters: params, Returns: tools.TypeInfo{Type: "string"}, SideEffect: true},
+			{Name: "close_all_tickets", Description: "Close every ticket.", Parameters: params, Returns: tools.TypeInfo{Type: "string"}, SideEffect: true},
+		},
+	}
+}
+
+func (t *ticketTools) RegisterWith(o *execution.Orchestrator) error {
+	o.RegisterFunction("create_ticket", func(context.Context, map[string]any, progress.Stream) (execution.FuncResult, error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		id := fmt.Sprintf("T%d", len(t.created)+1)
+		t.created = append(t.created, id)
+		return execution.FuncResult{Present: true, Value: id, Metadata: id}, nil
+	})
+	o.RegisterFunction("close_all_tickets", func(context.Context, map[string]any, progress.Stream) (execution.FuncResult, error) {
+		if t.closeFails {
+			return execution.FuncResult{}, errors.New("tracker unavailable")
+		}
+		return execution.FuncResult{Present: true, Value: "closed"}, nil
+	})
+	o.RegisterCompensator("create_ticket", func(_ context.Context, receipt execution.Receipt) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.cancelled = append(t.cancelled, fmt.Sprint(receipt.Details))
+		return nil
+	})
+	return nil
+}
+
+// lateRejection delays the consistency evaluations of the rejected functions,
+// so that the other calls are performed before the plan is rejected.
+type lateRejection struct {
+	*testkit.Completer
+	schema string
+}
+
+func (c *lateRejection) Complete(messages []llm.Message, jsonSchema string) (string, error) {
+	if jsonSchema == c.schema && len(messages) > 0 {
+		for _, name := range c.Reject {
+			if strings.Contains(messages[len(messages)-1][1], fmt.Sprintf("%q", name)) {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+	}
+	return c.Completer.Complete(messages, jsonSchema)
+}
+
+// newOverlapHandler returns a handler in overlap mode planning two tickets
+// and the closing of all the tickets, evaluating the reject functions last.
+func newOverlapHandler(t *testing.T, ts *ticketTools, reject []string, configure func(*handler.RequestHandlerConfig)) *handler.RequestHandler {
+	t.Helper()
+	schema, err := json.Marshal(prompt.FuncCallsEvaluationResponseSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	completer := testkit.NewCompleter(testkit.Plan(
+		testkit.Call("create_ticket", map[string]any{"title": "printer"}),
+		testkit.Call("create_ticket", map[string]any{"title": "network"}),
+		testkit.Call("close_all_tickets", map[string]any{"title": "all"}),
+	))
+	completer.Reject = reject
+	config := handler.RequestHandlerConfig{
+		Logger:              log.New(io.Discard, "", 0),
+		LLMClient:           &lateRejection{Completer: completer, schema: string(schema)},
+		Tools:               ts,
+		Timeout:             time.Second,
+		DisableBuiltinTools: true,
+		OverlapExecution:    true,
+	}
+	if configure != nil {
+		configure(&config)
+	}
+	h, err := handler.NewRequestHandler(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestOverlapRollsBackThroughTheHook(t *testing.T) {
+	ts := &ticketTools{}
+	var rolledBack []string
+	h := newOverlapHandler(t, ts, []string{"close_all_tickets"}, func(config *handler.RequestHandlerConfig) {
+		config.Rollback = func(_ context.Context, performed []execution.Receipt) error {
+			for _, r := range performed {
+				rolledBack = append(rolledBack, fmt.Sprintf("%s %v", r.FuncName, r.Details))
+			}
+			return nil
+		}
+	})
+
+	if _, err := h.ProcessUserRequest(context.Background(), "Open two tickets and close them all", &progress.NoOp{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ts.created) != 2 {
+		t.Fatalf("created %v, want both tickets before the rejection", ts.created)
+	}
+	// The tickets are created concurrently, in any order
+	slices.Sort(rolledBack)
+	if want := []string{"create_ticket T1", "create_ticket T2"}; !slices.Equal(rolledBack, want) {
+		t.Errorf("rolled back %v, want %v", rolledBack, want)
+	}
+	if len(ts.cancelled) != 0 {
+		t.Errorf("the compensators ran despite the hook: %v", ts.cancelled)
+	}
+}
+
+func TestOverlapRollsBackThroughTheCompensators(t *testing.T) {
+	ts := &ticketTools{}
+	h := newOverlapHandler(t, ts, []string{"close_all_tickets"}, nil)
+
+	if _, err := h.ProcessUserRequest(context.Background(), "Open two tickets and close them all", &progress.NoOp{}); err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(ts.cancelled)
+	if !slices.Equal(ts.cancelled, []string{"T1", "T2"}) {
+		t.Errorf("cancelled %v, want [T1 T2]", ts.cancelled)
+	}
+}
+
+func transactional(config *handler.RequestHandlerConfig) {
+	config.TransactionalSideEffects = true
+}
+
+func TestOverlapHoldsTheTransactionalSideEffects(t *testing.T) {
+	ts := &ticketTools{}
+	h := newOverlapHandler(t, ts, []string{"close_all_tickets"}, transactional)
+
+	if _, err := h.ProcessUserRequest(context.Background(), "Open two tickets and close them all", &progress.NoOp{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ts.created) != 0 {
+		t.Errorf("created %v before the plan was accepted", ts.created)
+	}
+}
+
+func TestOverlapExecutesThePlanAsOneTransaction(t *testing.T) {
+	ts := &ticketTools{closeFails: true}
+	h := newOverlapHandler(t, ts, nil, transactional)
+
+	if _, err := h.ProcessUserRequest(context.Background(), "Open two tickets and close them all", &progress.NoOp{}); err == nil {
+		t.Fatal("expected the error of close_all_tickets")
+	}
+
+	slices.Sort(ts.cancelled)
+	if len(ts.created) == 0 || !slices.Equal(ts.created, ts.cancelled) {
+		t.Fatalf("created %v, cancelled %v, want every ticket cancelled", ts.created, ts.cancelled)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+const (
+	// DefaultPlanCacheThreshold is the default minimum cosine similarity for reusing a plan.
+	DefaultPlanCacheThreshold = 0.92
+	// DefaultPlanCacheSize is the default number of plans kept by a PlanCache.
+	DefaultPlanCacheSize = 1000
+)
+
+// PlanCache keeps the plans of the requests executed successfully with every
+// call accepted, to reuse them for semantically similar requests: instead of
+// planning from scratch, the LLM only re-extracts the literal arguments of the
+// cached plan from the new request, which needs a much smaller prompt.
+// Reused plans are evaluated and executed as usual.
+type PlanCache struct {
+	Embedder llm.Embedder
+	// Threshold is the minimum cosine similarity between the embeddings of
+	// the requests for reusing a plan.
+	Threshold float64
+	// MaxEntries is the number of plans kept; the oldest ones are evicted first.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries []planCacheEntry
+}
+
+type planCacheEntry struct {
+	request    string
+	vector     []float32
+	completion string
+	funcCalls  []parser.PlannedFuncCall
+}
+
+// NewPlanCache creates a PlanCache with the default threshold and size.
+func NewPlanCache(embedder llm.Embedder) *PlanCache {
+	return &PlanCache{
+		Embedder:   embedder,
+		Threshold:  DefaultPlanCacheThreshold,
+		MaxEntries: DefaultPlanCacheSize,
+	}
+}
+
+// lookup returns the entry most similar to vector, if similar enough.
+func (c *PlanCache) lookup(vector []float32) (planCacheEntry, float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	best, bestScore := -1, 0.0
+	for i, e := range c.entries {
+		if score := cosineSimilarity(vector, e.vector); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 || bestScore < c.Threshold {
+		return planCacheEntry{}, bestScore, false
+	}
+	return c.entries[best], bestScore, true
+}
+
+func (c *PlanCache) add(entry planCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+	if excess := len(c.entries) - c.MaxEntries; c.MaxEntries > 0 && excess > 0 {
+		c.entries = append(c.entries[:0:0], c.entries[excess:]...)
+	}
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// planReuse is the outcome of a plan cache lookup. The plan is nil on misses.
+type planReuse struct {
+	vector     []float32
+	completion string
+	funcCalls  []parser.PlannedFuncCall
+}
+
+// reusePlan looks for the plan of a similar request and adapts it to message.
+// Failures are logged and treated as misses, falling back to planning.
+func (a *RequestHandler) reusePlan(ctx context.Context, message string, ts *tools.ToolSet, progress progress.Stream) *planReuse {
+	vector, err := a.config.PlanCache.Embedder.CreateEmbedding(message)
+	if err != nil {
+		a.logger(ctx).Printf("Plan cache: error embedding the request: %v", err)
+		return nil
+	}
+	reuse := &planReuse{vector: vector}
+	entry, score, ok := a.config.PlanCache.lookup(vector)
+	if !ok {
+		return reuse
+	}
+
+	// The tools of the plan must still be available
+	usedTools := &tools.ToolSet{TypeDefinitions: ts.TypeDefinitions}
+	seen := make(map[string]bool)
+	for _, f := range entry.funcCalls {
+		for _, name := range f.CollectAllNestedFuncCalls() {
+			tool, ok := ts.FindTool(name)
+			if !ok {
+				a.logger(ctx).Printf("Plan cache: %s is not available, planning from scratch", name)
+				return reuse
+			}
+			if !seen[name] {
+				seen[name] = true
+				usedTools.Functions = append(usedTools.Functions, *tool)
+			}
+		}
+	}
+
+	a.logger(ctx).Printf("Plan cache: reusing the plan of %q (similarity %.3f)", entry.request, score)
+	progress.Send("Adapting a previous plan...")
+	userPrompt, err := a.config.PromptVersions.CreatePromptForPlanReuse(entry.request, entry.completion, message)
+	if err != nil {
+		a.logger(ctx).Printf("Plan cache: %v", err)
+		return reuse
+	}
+	jsonSchema, err := a.config.PlanFormat.jsonSchema(usedTools)
+	if err != nil {
+		a.logger(ctx).Printf("Plan cache: failed to generate JSON schema: %v", err)
+		return reuse
+	}
+	completion, err := a.complete(ctx, []llm.Message{{"user", userPrompt}}, string(jsonSchema))
+	if err != nil {
+		a.logger(ctx).Printf("Plan cache: error calling LLM: %v", err)
+		return reuse
+	}
+	funcCalls, err := a.config.PlanFormat.parse(completion)
+	if err != nil || !sameStructure(entry.funcCalls, funcCalls) {
+		a.logger(ctx).Printf("Plan cache: the adapted plan differs from the cached one, planning from scratch")
+		return reuse
+	}
+
+	reportFromContext(ctx).addPlan(completion, funcCalls, entry.request)
+	reuse.completion, reuse.funcCalls = completion, funcCalls
+	return reuse
+}
+
+// cachePlan adds the plan to the cache if it was planned from scratch and
+// every call was accepted and executed.
+func (a *RequestHandler) cachePlan(reuse *planReuse, message, completion string, funcCalls []parser.PlannedFuncCall, exec *execution.Result) {
+	if reuse == nil || reuse.funcCalls != nil || len(funcCalls) == 0 || len(exec.FuncCalls) != len(funcCalls) {
+		return
+	}
+	a.config.PlanCache.add(planCacheEntry{
+		request:    message,
+		vector:     reuse.vector,
+		completion: completion,
+		funcCalls:  funcCalls,
+	})
+}
+
+// sameStructure reports whether the plans call the same functions with the
+// same nesting, regardless of the literal arguments.
+func sameStructure(a, b []parser.PlannedFuncCall) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if shape(&a[i]) != shape(&b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// shape encodes the names of the call and of its nested calls, by argument.
+func shape(call *parser.PlannedFuncCall) string {
+	nested := make(map[string]string)
+	for name, arg := range call.Args {
+		if f, ok := arg.(*parser.PlannedFuncCall); ok {
+			nested[name] = shape(f)
+		}
+	}
+	data, _ := json.Marshal(struct {
+		Name   string            `json:"name"`
+		Nested map[string]string `json:"nested"`
+	}{call.Name, nested})
+	return string(data)
+}
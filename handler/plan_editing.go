@@ -0,0 +1,118 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// ErrInvalidPlan is returned by ExecutePlan for plans not conforming to the
+// definitions of the tools.
+var ErrInvalidPlan = errors.New("invalid plan")
+
+// PlanProposal is a plan proposed for a request, for an operator to review
+// and possibly edit (change arguments, remove calls, swap tools) before
+// submitting it to ExecutePlan.
+type PlanProposal struct {
+	// Request is the request as planned, after AlterUserRequest.
+	Request string                   `json:"request"`
+	Calls   []parser.PlannedFuncCall `json:"calls"`
+	// Consistent holds the verdicts of the evaluation, by call. It is empty
+	// for the plans of the rules, which are not evaluated.
+	Consistent []bool   `json:"consistent,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// ProposePlan plans and evaluates the request without executing it.
+func (a *RequestHandler) ProposePlan(ctx context.Context, message string, progress progress.Stream) (*PlanProposal, error) {
+	progress.Send("Processing user request...")
+	if a.config.AlterUserRequest != nil {
+		message = a.config.AlterUserRequest(message)
+	}
+
+	ts, warnings := a.planningTools()
+	planned, err := a.plan(ctx, message, ts, nil, true, progress)
+	if err != nil {
+		return nil, fmt.Errorf("error generating function calls: %w", err)
+	}
+	proposal := &PlanProposal{Request: message, Calls: planned.funcCalls, Warnings: appendWarnings(warnings, planned.warnings...)}
+	if planned.ruled {
+		return proposal, nil
+	}
+	proposal.Consistent = make([]bool, len(planned.funcCalls))
+	err = a.evaluateEach(ctx, message, planned.funcCalls, progress, func(i int, isConsistent bool) {
+		proposal.Consistent[i] = isConsistent
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating function calls consistency: %w", err)
+	}
+	return proposal, nil
+}
+
+// ExecutePlan executes a plan submitted for the request, typically a
+// PlanProposal edited by an operator. The plan is validated against the
+// tools instead of being evaluated by the LLM; the execution and its result
+// are the same as for ProcessUserRequest.
+func (a *RequestHandler) ExecutePlan(ctx context.Context, message string, funcCalls []parser.PlannedFuncCall, progress progress.Stream) (*ProcessingResult, error) {
+	plan, _ := json.Marshal(funcCalls)
+	return a.idempotent(ctx, message+"\n"+string(plan), func() (*ProcessingResult, error) {
+		return a.run(ctx, message, func(ctx context.Context) (*ProcessingResult, error) {
+			ts, warnings := a.planningTools()
+			if len(funcCalls) == 0 {
+				return nil, fmt.Errorf("%w: no function calls", ErrInvalidPlan)
+			}
+			for i, f := range funcCalls {
+				if err := ts.ValidateCall(f); err != nil {
+					return nil, fmt.Errorf("%w: call %d: %w", ErrInvalidPlan, i+1, err)
+				}
+			}
+			return a.executeSubmitted(ctx, message, funcCalls, a.flags(ctx), warnings, progress)
+		})
+	})
+}
+
+// executeSubmitted executes the validated calls submitted by a client rather
+// than planned.
+func (a *RequestHandler) executeSubmitted(ctx context.Context, message string, funcCalls []parser.PlannedFuncCall, flags Flags, warnings []string, progress progress.Stream) (*ProcessingResult, error) {
+	reportFromContext(ctx).addEditedPlan(funcCalls)
+
+	var exec *execution.Result
+	var err error
+	stage(ctx, "execution", func(ctx context.Context) {
+		exec, err = a.executeFunctionCalls(ctx, funcCalls, progress)
+	})
+	a.recordFailures(ctx, collectFailures(exec, err))
+	if err != nil && budgetExpired(ctx) {
+		return a.partialResult(ctx, "execution", err, flags, warnings)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error executing functions: %w", err)
+	}
+	return a.completeResult(ctx, message, exec, flags, warnings, progress)
+}
+
+// Autocomplete returns the metadata of the tools for a call being filled by
+// an operator (see tools.ToolSet.Autocomplete).
+func (a *RequestHandler) Autocomplete(call tools.PartialCall) *tools.Suggestions {
+	return a.config.Tools.AvailableTools().Autocomplete(call)
+}
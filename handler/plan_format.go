@@ -0,0 +1,62 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// PlanFormat selects the structure of the plans requested to the LLM.
+type PlanFormat int
+
+const (
+	// PlanFormatNested requests nested function calls (the default).
+	PlanFormatNested PlanFormat = iota
+	// PlanFormatFlat requests a flat list of calls referencing each other by id,
+	// which is easier to produce for small models.
+	PlanFormatFlat
+)
+
+func (f PlanFormat) String() string {
+	if f == PlanFormatFlat {
+		return "flat"
+	}
+	return "nested"
+}
+
+func (f PlanFormat) systemPrompt(pins prompt.Pins, ts *tools.ToolSet, definitions tools.DefinitionFormat) (string, error) {
+	if f == PlanFormatFlat {
+		return pins.CreatePromptForFlatFuncCallsWith(ts, definitions)
+	}
+	return pins.CreatePromptForFuncCallsWith(ts, definitions)
+}
+
+func (f PlanFormat) jsonSchema(ts *tools.ToolSet) (json.RawMessage, error) {
+	if f == PlanFormatFlat {
+		return ts.ToFlatJSONSchema()
+	}
+	return ts.ToJSONSchema()
+}
+
+func (f PlanFormat) parse(completion string) ([]parser.PlannedFuncCall, error) {
+	if f == PlanFormatFlat {
+		return parser.ParseFlatFunctions([]byte(completion))
+	}
+	return parser.ParseJsonFunctions([]byte(completion))
+}
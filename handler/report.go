@@ -0,0 +1,251 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/analytics"
+	"github.com/nlpodyssey/funcallarchitect/codec"
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/history"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+)
+
+type reportContextKey struct{}
+
+// report collects the execution report of a request as it is processed.
+type report struct {
+	mu      sync.Mutex
+	started time.Time
+	record  *history.Record
+	// tools collects the planner analytics.
+	tools map[string]*analytics.ToolStats
+}
+
+func withReport(ctx context.Context, record *history.Record) (context.Context, *report) {
+	r := &report{started: time.Now(), record: record}
+	return context.WithValue(ctx, reportContextKey{}, r), r
+}
+
+// reportFromContext returns the report of the request, or nil. The methods
+// of a nil report do nothing.
+func reportFromContext(ctx context.Context) *report {
+	r, _ := ctx.Value(reportContextKey{}).(*report)
+	return r
+}
+
+func (r *report) addStage(name string, d time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.Stages = append(r.record.Stages, history.Stage{Name: name, Duration: d})
+}
+
+func (r *report) addCompletion(d time.Duration, usage llm.TokenUsage) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.LLM.Completions++
+	r.record.LLM.Duration += d
+	r.record.LLM.PromptTokens += usage.PromptTokens
+	r.record.LLM.CompletionTokens += usage.CompletionTokens
+}
+
+// addReasoning records the reasoning segments of a completion, if any.
+func (r *report) addReasoning(reasoning string) {
+	if r == nil || reasoning == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.Reasoning = append(r.record.Reasoning, reasoning)
+}
+
+// addPlan records a plan; reusedFrom is the request whose cached plan was adapted, if any.
+func (r *report) addPlan(completion string, funcCalls []parser.PlannedFuncCall, reusedFrom string) {
+	r.appendPlan(history.Plan{Completion: completion, ReusedFrom: reusedFrom}, funcCalls)
+}
+
+// addRulePlan records a plan of the rules planner.
+func (r *report) addRulePlan(rule, completion string, funcCalls []parser.PlannedFuncCall) {
+	r.appendPlan(history.Plan{Completion: completion, Rule: rule}, funcCalls)
+}
+
+// addEditedPlan records a plan submitted by an operator.
+func (r *report) addEditedPlan(funcCalls []parser.PlannedFuncCall) {
+	r.appendPlan(history.Plan{Edited: true}, funcCalls)
+}
+
+func (r *report) appendPlan(plan history.Plan, funcCalls []parser.PlannedFuncCall) {
+	if r == nil {
+		return
+	}
+	for _, f := range funcCalls {
+		r.countTools(f.CollectAllNestedFuncCalls(), func(s *analytics.ToolStats) { s.Planned++ })
+	}
+	plan.Calls, _ = json.Marshal(funcCalls)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.Plans = append(r.record.Plans, plan)
+}
+
+func (r *report) addVerdict(function parser.PlannedFuncCall, call []byte, consistent bool) {
+	if r == nil {
+		return
+	}
+	if consistent {
+		r.countTools(function.CollectAllNestedFuncCalls(), func(s *analytics.ToolStats) { s.Accepted++ })
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.Evaluations = append(r.record.Evaluations, history.Verdict{
+		Call:       json.RawMessage(call),
+		Tools:      function.CollectAllNestedFuncCalls(),
+		Consistent: consistent,
+	})
+}
+
+// finish completes the record with the outcome of the request. The main
+// results are formatted once, and their FormatFunc return the same text
+// afterwards, so that formatting them again costs nothing.
+func (r *report) finish(result *ProcessingResult, err error) *history.Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record := r.record
+	record.Duration = time.Since(r.started)
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+
+	record.Flags = result.Flags.String()
+	record.Warnings = result.Warnings
+	record.Calls = reportCalls(result.Execution.FuncCalls)
+	r.countExecution(result.Execution.FuncCalls, true)
+	for _, f := range result.Execution.FuncCalls {
+		formatOnce(f)
+	}
+	record.Output = result.Answer
+	if record.Output == "" {
+		record.Output, _ = result.Execution.MainFuncResults().Format("")
+	}
+	return record
+}
+
+func reportCalls(funcCalls []*execution.ExecutedFuncCall) []history.Call {
+	calls := make([]history.Call, 0, len(funcCalls))
+	for _, f := range funcCalls {
+		calls = append(calls, reportCall(f, ""))
+	}
+	return calls
+}
+
+func reportCall(f *execution.ExecutedFuncCall, arg string) history.Call {
+	call := history.Call{
+		Name:     f.Name,
+		Purpose:  f.Purpose,
+		Arg:      arg,
+		Duration: f.Duration,
+		Cached:   f.Cached,
+		Present:  f.Result.Present,
+		Render:   f.Render,
+	}
+	if f.Result.FormatFunc != nil {
+		if text, err := f.Result.FormatFunc(); err == nil {
+			call.Result = text
+		}
+	}
+	if f.Result.Present {
+		if tv, err := codec.EncodeValue(f.Result.Value); err == nil {
+			call.Value = &tv
+		}
+	}
+	if f.Receipt != nil {
+		call.Receipt = f.Receipt.ID
+	}
+	names := make([]string, 0, len(f.Args))
+	for name := range f.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v, ok := execution.GetValue(f.Args[name]); ok {
+			if call.Args == nil {
+				call.Args = make(map[string]any)
+			}
+			call.Args[name] = v
+		} else if nested, ok := execution.GetFuncCall(f.Args[name]); ok {
+			call.Calls = append(call.Calls, reportCall(nested, name))
+		}
+	}
+	return call
+}
+
+// complete requests a completion to the LLM, accounting for it in the report.
+func (a *RequestHandler) complete(ctx context.Context, messages []llm.Message, jsonSchema string) (string, error) {
+	return completeWith(ctx, a.config.LLMClient, messages, jsonSchema)
+}
+
+// completeWith requests a completion to the given model, accounting for it
+// in the report. It fails with the cause of the cancellation of ctx, and
+// gives up on the completion when the budget of the request expires.
+func completeWith(ctx context.Context, client llm.Completer, messages []llm.Message, jsonSchema string) (string, error) {
+	if ctx.Err() != nil {
+		return "", context.Cause(ctx)
+	}
+	if hasBudget(ctx) {
+		return completeWithinBudget(ctx, client, messages, jsonSchema)
+	}
+	return completeAccounted(ctx, client, messages, jsonSchema)
+}
+
+// completeAccounted requests the completion, accounting it in the report.
+func completeAccounted(ctx context.Context, client llm.Completer, messages []llm.Message, jsonSchema string) (string, error) {
+	started := time.Now()
+	if reasoner, ok := client.(llm.ReasoningReporter); ok {
+		content, reasoning, usage, err := reasoner.CompleteWithReasoning(messages, jsonSchema)
+		reportFromContext(ctx).addCompletion(time.Since(started), usage)
+		reportFromContext(ctx).addReasoning(reasoning)
+		return content, err
+	}
+	reporter, ok := client.(llm.UsageReporter)
+	if !ok {
+		defer func() { reportFromContext(ctx).addCompletion(time.Since(started), llm.TokenUsage{}) }()
+		return client.Complete(messages, jsonSchema)
+	}
+	content, usage, err := reporter.CompleteWithUsage(messages, jsonSchema)
+	reportFromContext(ctx).addCompletion(time.Since(started), usage)
+	return content, err
+}
+
+// storeReport persists the record in the history, if configured.
+func (a *RequestHandler) storeReport(ctx context.Context, record *history.Record) {
+	if a.config.History == nil {
+		return
+	}
+	if err := a.config.History.Put(ctx, record); err != nil {
+		a.logger(ctx).Printf("Failed to store the execution report: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+)
+
+// errBudgetExpired is the cause of the cancellation of the requests
+// exceeding their budget.
+var errBudgetExpired = errors.New("request budget expired")
+
+// DeadlineExceededPrompt is the output of the requests whose budget expired
+// before any result was produced.
+const DeadlineExceededPrompt = "The request could not be completed in time. Please try again or simplify the request."
+
+type requestTimeoutContextKey struct{}
+
+type budgetContextKey struct{}
+
+// WithRequestTimeout returns a copy of ctx overriding the configured
+// RequestTimeout for the request.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutContextKey{}, timeout)
+}
+
+// withBudget returns a copy of ctx cancelled when the budget of the request expires.
+func (a *RequestHandler) withBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := a.config.RequestTimeout
+	if t, ok := ctx.Value(requestTimeoutContextKey{}).(time.Duration); ok {
+		timeout = t
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(context.WithValue(ctx, budgetContextKey{}, true), timeout, errBudgetExpired)
+}
+
+// hasBudget reports whether the request has a budget.
+func hasBudget(ctx context.Context) bool {
+	_, ok := ctx.Value(budgetContextKey{}).(bool)
+	return ok
+}
+
+// completeWithinBudget is completeWith for the requests with a budget. As the
+// LLM clients cannot be interrupted, the completion is abandoned when the
+// budget expires, and its outcome discarded.
+func completeWithinBudget(ctx context.Context, client llm.Completer, messages []llm.Message, jsonSchema string) (string, error) {
+	type completion struct {
+		content string
+		err     error
+	}
+	done := make(chan completion, 1)
+	go func() {
+		content, err := completeAccounted(ctx, client, messages, jsonSchema)
+		done <- completion{content, err}
+	}()
+	select {
+	case c := <-done:
+		return c.content, c.err
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	}
+}
+
+// budgetExpired reports whether the budget of the request expired, as opposed
+// to the request being cancelled by its caller.
+func budgetExpired(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errBudgetExpired)
+}
+
+// partialResult returns the best result produced before the budget of the
+// request expired during the stage: the main calls completed, if err is an
+// execution.PartialError, or DeadlineExceededPrompt.
+func (a *RequestHandler) partialResult(ctx context.Context, stage string, err error, flags Flags, warnings []string) (*ProcessingResult, error) {
+	a.logger(ctx).Printf("Request budget expired during %s: %v", stage, err)
+	exec := &execution.Result{
+		FuncCalls: []*execution.ExecutedFuncCall{
+			{
+				Name:    "__builtin__.deadline_exceeded",
+				Purpose: "Return a response for a request not completed in time",
+				Result: execution.FuncResult{
+					FormatFunc: func() (string, error) {
+						return DeadlineExceededPrompt, nil
+					},
+				},
+			},
+		},
+	}
+	var partial *execution.PartialError
+	if errors.As(err, &partial) {
+		exec = partial.Completed
+	}
+	result := &ProcessingResult{
+		Execution: exec,
+		Flags:     flags,
+		Warnings:  appendWarnings(warnings, fmt.Sprintf("the request budget expired during %s: the result is partial", stage)),
+		Partial:   true,
+	}
+	if err := a.formatOutput(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -19,15 +19,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"runtime/pprof"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/nlpodyssey/funcallarchitect/analytics"
+	"github.com/nlpodyssey/funcallarchitect/blob"
+	"github.com/nlpodyssey/funcallarchitect/builtin"
+	"github.com/nlpodyssey/funcallarchitect/codec"
 	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/history"
+	"github.com/nlpodyssey/funcallarchitect/judge"
 	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/logging"
 	"github.com/nlpodyssey/funcallarchitect/parser"
 	"github.com/nlpodyssey/funcallarchitect/progress"
 	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/session"
 	"github.com/nlpodyssey/funcallarchitect/tools"
+	"github.com/nlpodyssey/funcallarchitect/version"
 )
 
 const UnprocessableRequestPrompt = "Unable to process this request. Please rephrase or provide a different query."
@@ -39,6 +52,33 @@ type Tools interface {
 
 type ProcessingResult struct {
 	Execution *execution.Result
+	// Flags are the feature flags in effect for the request.
+	Flags Flags
+	// Answer is the natural-language answer composed from the results,
+	// when FlagAnswerSynthesis is enabled.
+	Answer string
+	// Warnings report conditions that degraded the processing, such as
+	// unhealthy tools excluded from planning.
+	Warnings []string
+	// Report is the execution report of the request.
+	Report *history.Record
+	// Values are the structured results of the main calls, when
+	// StructuredOutput is enabled.
+	Values []StructuredResult
+	// Reasoning are the reasoning segments of the completions, for the LLM
+	// clients capturing them (see llm.ReasoningReporter), for debugging.
+	Reasoning []string
+	// Output is the output of the request in the representation requested
+	// with WithOutputFormat, of type ContentType: the synthesized answer, if
+	// any, or the formatted main results.
+	Output      string
+	ContentType string
+	// Partial reports whether the budget of the request expired (see
+	// RequestTimeout) before the result was complete.
+	Partial bool
+	// Replayed reports whether the result is the stored one of a previous
+	// request with the same idempotency key (see IdempotencyCache).
+	Replayed bool
 }
 
 func UnprocessableRequestExecutions() *execution.Result {
@@ -67,6 +107,170 @@ type RequestHandlerConfig struct {
 	Timeout              time.Duration
 	EnableConcurrentExec bool
 
+	// RequestTimeout, if positive, is the budget of the whole request, across
+	// planning, evaluation, execution and synthesis, distinct from the Timeout
+	// of the functions and from those of the LLM clients. When it expires, the
+	// request returns the best result produced so far, marked as Partial,
+	// instead of failing. It can be overridden per request with WithRequestTimeout.
+	RequestTimeout time.Duration
+
+	// TimeoutExtension, if positive, extends once the deadline of the
+	// functions reporting their liveness through execution.Heartbeat.
+	TimeoutExtension time.Duration
+
+	// PlanFormat selects the structure of the plans generated by the LLM.
+	// Execution is the same for every format.
+	PlanFormat PlanFormat
+
+	// DefinitionFormat selects the rendering of the tool definitions in the
+	// prompts. tools.DefinitionsCompact saves prompt tokens with large
+	// toolsets, tools.DefinitionsTypeScript may suit smaller models better;
+	// the verbose default is what the prompts were tested with.
+	DefinitionFormat tools.DefinitionFormat
+
+	// NumberMode controls how numeric arguments are passed to executors.
+	// The default converts them to float64; use execution.NumbersPreserved
+	// to keep integers (and large IDs) exact.
+	NumberMode execution.NumberMode
+
+	// DisableBuiltinTools prevents the built-in tools (calculator, unit conversion
+	// and, when a SessionStore is set, memory) from being advertised alongside Tools.
+	DisableBuiltinTools bool
+
+	// EnableLLMTools advertises the built-in tools backed by LLMClient
+	// (structured extraction, summarization, translation), which issue additional LLM requests when executed.
+	EnableLLMTools bool
+
+	// OutputLanguage, if set, forces the formatted results into the given language
+	// (e.g. "Italian"), translating them through LLMClient.
+	OutputLanguage string
+
+	// SessionStore, if set, enables the built-in remember and recall tools.
+	// Requests are bound to a session through session.WithID.
+	SessionStore session.Store
+
+	// Approver, if set, gates the execution of side-effect functions.
+	Approver execution.Approver
+	// ChoiceTimeout, if positive, pauses the execution when a nested call
+	// returns several candidates (see execution.FuncResult.Candidates) to ask
+	// the user which one they meant, through the progress streams implementing
+	// progress.ChoiceStream. The answers are submitted with Choose; without
+	// one within ChoiceTimeout, the default candidate is used.
+	ChoiceTimeout time.Duration
+	// AuditLog, if set, records a receipt for every side-effect function call.
+	AuditLog execution.AuditLog
+
+	// MemoStore, if set, persists function results across requests,
+	// encoded with MemoCodec (codec.JSON by default; codec.CBOR and
+	// codec.MessagePack are more compact for large results).
+	MemoStore execution.MemoStore
+	MemoCodec codec.Codec
+	// MemoTTL, if positive, is how long the persisted results are valid.
+	MemoTTL time.Duration
+
+	// Maintenance, if set, configures the background maintenance run by
+	// RunMaintenance.
+	Maintenance *Maintenance
+
+	// MaxResultSize, if positive, limits the size of the values returned by functions.
+	// Oversized values are spilled to BlobStore, or fail the call when it is not set.
+	MaxResultSize int
+	BlobStore     blob.Store
+
+	// OverlapExecution starts executing each call as soon as it is evaluated as
+	// consistent, instead of waiting for all the verdicts, to reduce latency.
+	// The plan must then be accepted as a whole: when a call is rejected, the
+	// calls already started are cancelled, the side effects already performed
+	// are rolled back, and the request is answered as unprocessable. With
+	// TransactionalSideEffects, the calls involving side effects still wait
+	// for all the verdicts.
+	OverlapExecution bool
+	// Rollback, if set, undoes the side effects performed by a plan rejected in
+	// overlap mode. The receipts are passed most recent first. By default the
+	// compensators registered with the orchestrator are run.
+	Rollback func(ctx context.Context, performed []execution.Receipt) error
+
+	// CompensateOnFailure runs the registered compensators of the side effects
+	// performed by a plan whose execution fails.
+	CompensateOnFailure bool
+
+	// TransactionalSideEffects executes the plans involving side effects as
+	// all-or-nothing units: the read-only calls run first, and the side effects
+	// are performed only if all of them succeeded, and compensated if one fails.
+	TransactionalSideEffects bool
+
+	// ToolHealth, if set, tracks the failures of the functions: unhealthy
+	// functions fail immediately and are excluded from planning.
+	ToolHealth *execution.HealthTracker
+
+	// History, if set, stores the execution report of every request.
+	History history.Store
+
+	// StructuredOutput exposes the values of the main results, encoded in JSON,
+	// as ProcessingResult.Values, with the formatted text as secondary output.
+	StructuredOutput bool
+
+	// PlanCache, if set, reuses the plans of previous requests for
+	// semantically similar ones.
+	PlanCache *PlanCache
+
+	// Formatters are the formatters of the output selectable with
+	// WithOutputFormat besides the built-in ones (plain text, JSON, Markdown
+	// and HTML), which they take precedence over for the same content type.
+	Formatters []execution.Formatter
+
+	// Idempotency, if set, returns the stored results of the requests
+	// repeating the idempotency key of a previous one (see WithIdempotencyKey).
+	Idempotency *IdempotencyCache
+
+	// Rules, if set, plans the requests matching its rules without the LLM,
+	// either before trying the LLM or when it fails.
+	Rules *RulesPlanner
+
+	// ContextRetry, if set, shrinks the planning prompts exceeding the context
+	// length of the model and retries, reporting what was dropped in Warnings.
+	ContextRetry *ContextRetry
+
+	// StreamTokens streams the planning, evaluation and synthesis completions
+	// of the LLM clients implementing llm.StreamingCompleter, reporting the
+	// tokens generated as progress events, so that long requests show
+	// continuous activity.
+	StreamTokens bool
+
+	// Ensemble, if set, plans with a second model as well, resorting to an
+	// arbiter when the two plans disagree.
+	Ensemble *Ensemble
+
+	// EvaluationRubric, if set, evaluates the planned calls against its
+	// criteria (see judge.PlanRubric) instead of the built-in evaluation
+	// prompt: a call is consistent when its criteria that are not advisory pass.
+	EvaluationRubric *judge.Rubric
+	// AnswerRubric, if set, judges the synthesized answers against its
+	// criteria (see judge.AnswerRubric), reporting the ones not met in Warnings.
+	AnswerRubric *judge.Rubric
+
+	// Analytics, if set, accumulates per tool how the planned calls pass
+	// evaluation, succeed and contribute to the answers.
+	Analytics analytics.Store
+
+	// Canary, if set, plans a share of the requests with a new version of the
+	// tools as well, in the background, comparing the plans with the live ones.
+	Canary *Canary
+
+	// PromptVersions pins the versions of the built-in prompt templates by
+	// name, so that upgrades don't change the prompts of the deployment
+	// unnoticed.
+	PromptVersions prompt.Pins
+
+	// Deterministic, if set, seeds the completions, and freezes the clock and
+	// records the HTTP interactions of the executors as configured, so that
+	// repeated runs of a request produce the same results.
+	Deterministic *Determinism
+
+	// Flags enables experimental pipeline stages. Requests can override
+	// them through WithFlags.
+	Flags Flags
+
 	AlterUserRequest func(string) string
 	AlterResult      func(result *ProcessingResult) error
 }
@@ -75,6 +279,7 @@ type RequestHandlerConfig struct {
 type RequestHandler struct {
 	config       RequestHandlerConfig
 	orchestrator *execution.Orchestrator
+	choices      pendingChoices
 }
 
 // NewRequestHandler creates a new RequestHandler instance
@@ -83,79 +288,415 @@ func NewRequestHandler(config RequestHandlerConfig) (*RequestHandler, error) {
 		config.Logger = log.New(log.Writer(), "", log.Ldate|log.Ltime|log.Lshortfile)
 	}
 
+	// The prompts were tested as they are: modified ones are rejected
+	if err := prompt.Verify(); err != nil {
+		return nil, err
+	}
+	for _, rubric := range []*judge.Rubric{config.EvaluationRubric, config.AnswerRubric} {
+		if rubric == nil {
+			continue
+		}
+		if err := rubric.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid rubric: %w", err)
+		}
+	}
+	if err := config.PromptVersions.Validate(); err != nil {
+		return nil, fmt.Errorf("error pinning prompt version: %w", err)
+	}
+
+	if config.Deterministic != nil {
+		config = config.Deterministic.seeded(config)
+	}
+	if !config.DisableBuiltinTools {
+		config.Tools = withBuiltinTools(config, config.Tools)
+	}
+
 	ec := execution.NewOrchestrator(config.Logger, config.Timeout, config.EnableConcurrentExec, config.Tools.AvailableTools())
+	ec.NumberMode = config.NumberMode
+	ec.TimeoutExtension = config.TimeoutExtension
+	ec.Health = config.ToolHealth
+	ec.Approver = config.Approver
+	ec.AuditLog = config.AuditLog
+	ec.CompensateOnFailure = config.CompensateOnFailure
+	ec.Transactional = config.TransactionalSideEffects
+	ec.Memo = config.MemoStore
+	ec.MemoCodec = config.MemoCodec
+	ec.MemoTTL = config.MemoTTL
+	ec.MaxResultSize = config.MaxResultSize
+	ec.Blobs = config.BlobStore
 
 	agent := &RequestHandler{
 		config:       config,
 		orchestrator: ec,
 	}
 
+	if config.ChoiceTimeout > 0 {
+		ec.Chooser = agent.choose
+	}
+
 	if err := config.Tools.RegisterWith(ec); err != nil {
 		agent.config.Logger.Printf("Failed to register tools: %v", err)
 		return nil, fmt.Errorf("failed to register tools: %w", err)
 	}
+	if config.Canary != nil {
+		if err := config.Canary.init(config); err != nil {
+			return nil, fmt.Errorf("failed to set up the canary: %w", err)
+		}
+	}
 
 	return agent, nil
 }
 
-// ProcessUserRequest handles the user's request and returns the processing result
+// withBuiltinTools composes ts with the built-in tools enabled by config.
+func withBuiltinTools(config RequestHandlerConfig, ts Tools) Tools {
+	toolsets := []Tools{ts, &builtin.Calculator{}}
+	if config.SessionStore != nil {
+		toolsets = append(toolsets, &builtin.Memory{Store: config.SessionStore}, &builtin.Variables{})
+	}
+	if config.EnableLLMTools {
+		toolsets = append(toolsets,
+			&builtin.Extraction{
+				Completer:       config.LLMClient,
+				TypeDefinitions: ts.AvailableTools().TypeDefinitions,
+				Prompts:         config.PromptVersions,
+			},
+			&builtin.Summarization{Completer: config.LLMClient, Prompts: config.PromptVersions},
+			&builtin.Translation{Completer: config.LLMClient, Prompts: config.PromptVersions},
+		)
+	}
+	return ComposeTools(toolsets...)
+}
+
+// ProcessUserRequest handles the user's request and returns the processing result.
+// The work is tagged with pprof labels identifying the request and the pipeline stage.
 func (a *RequestHandler) ProcessUserRequest(ctx context.Context, message string, progress progress.Stream) (*ProcessingResult, error) {
+	return a.idempotent(ctx, message, func() (*ProcessingResult, error) {
+		return a.run(ctx, message, func(ctx context.Context) (*ProcessingResult, error) {
+			return a.processUserRequest(ctx, message, progress)
+		})
+	})
+}
+
+// run runs process for the request, recording its execution report.
+func (a *RequestHandler) run(ctx context.Context, message string, process func(ctx context.Context) (*ProcessingResult, error)) (result *ProcessingResult, err error) {
+	id := requestID(ctx)
+	labels := []string{"request", id}
+	if sessionID, ok := session.IDFromContext(ctx); ok {
+		labels = append(labels, "session", sessionID)
+	}
+
+	// The derived logger is used throughout the request, executors included
+	ctx = logging.WithLogger(ctx, logging.With(logging.FromContext(ctx, a.config.Logger), labels...))
+	if a.config.Deterministic != nil {
+		ctx = a.config.Deterministic.environment(ctx)
+	}
+	ctx = a.withVariables(ctx)
+
+	record := &history.Record{
+		ID:        history.NewID(),
+		RequestID: id,
+		CreatedAt: time.Now(),
+		Request:   message,
+		Build:     version.Current(),
+		Prompts:   a.config.PromptVersions.All(),
+	}
+	record.SessionID, _ = session.IDFromContext(ctx)
+	ctx, rep := withReport(ctx, record)
+
+	pprof.Do(ctx, requestLabels(labels), func(ctx context.Context) {
+		ctx, cancel := a.withBudget(ctx)
+		defer cancel()
+		result, err = process(ctx)
+	})
+
+	rep.finish(result, err)
+	if result != nil {
+		result.Report = record
+		result.Reasoning = record.Reasoning
+	}
+	a.storeReport(ctx, record)
+	a.recordAnalytics(ctx, rep)
+	return result, err
+}
+
+// logger returns the request-scoped logger carried by ctx.
+func (a *RequestHandler) logger(ctx context.Context) *log.Logger {
+	return logging.FromContext(ctx, a.config.Logger)
+}
+
+func (a *RequestHandler) processUserRequest(ctx context.Context, message string, progress progress.Stream) (_ *ProcessingResult, err error) {
 	progress.Send("Processing user request...")
 
+	flags := a.flags(ctx)
+	if len(flags) > 0 {
+		a.logger(ctx).Printf("Feature flags: %s", flags)
+	}
+
 	if a.config.AlterUserRequest != nil {
-		a.config.Logger.Printf("Original message: %s", message)
+		a.logger(ctx).Printf("Original message: %s", message)
 		message = a.config.AlterUserRequest(message)
-		a.config.Logger.Printf("Altered message: %s", message)
+		a.logger(ctx).Printf("Altered message: %s", message)
 	}
 
-	funcCalls, err := a.generateFunctionCalls(ctx, message, progress)
-	if err != nil {
-		return nil, fmt.Errorf("error generating function calls: %w", err)
+	var exec *execution.Result
+	var funcCalls []parser.PlannedFuncCall
+	var completion string
+	var reuse *planReuse
+	var ruled bool
+	var feedback []llm.Message
+	var warnings []string
+	if a.config.Canary.sample() {
+		// The plan of the new version of the tools is compared with the final live one
+		defer func() { a.shadowRequest(ctx, message, funcCalls, exec, err) }()
 	}
+	for attempt := 0; ; attempt++ {
+		ts, toolWarnings := a.planningTools()
+		for _, w := range toolWarnings {
+			a.logger(ctx).Printf("Warning: %s", w)
+		}
+		warnings = appendWarnings(warnings, toolWarnings...)
+
+		var planned plannedRequest
+		var err error
+		stage(ctx, "planning", func(ctx context.Context) {
+			planned, err = a.plan(ctx, message, ts, feedback, attempt == 0, progress)
+		})
+		if err != nil && budgetExpired(ctx) {
+			return a.partialResult(ctx, "planning", err, flags, warnings)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error generating function calls: %w", err)
+		}
+		if attempt == 0 {
+			reuse = planned.reuse
+		}
+		for _, w := range planned.warnings {
+			a.logger(ctx).Printf("Warning: %s", w)
+		}
+		warnings = appendWarnings(warnings, planned.warnings...)
+		funcCalls, completion, ruled = planned.funcCalls, planned.completion, planned.ruled
 
-	funcCalls, err = a.evaluateFuncCallsConsistency(message, funcCalls, progress)
-	if err != nil {
-		return nil, fmt.Errorf("error evaluating function calls consistency: %w", err)
-	}
+		evaluateAndExecute := a.evaluateThenExecute
+		if a.config.OverlapExecution {
+			evaluateAndExecute = a.evaluateWhileExecuting
+		}
+		var evalErr error
+		if ruled {
+			// The plans of the rules are trusted, and need no LLM
+			stage(ctx, "execution", func(ctx context.Context) {
+				exec, err = a.executeFunctionCalls(ctx, funcCalls, progress)
+			})
+		} else {
+			exec, evalErr, err = evaluateAndExecute(ctx, message, funcCalls, flags, progress)
+		}
+		if evalErr != nil && budgetExpired(ctx) {
+			return a.partialResult(ctx, "evaluation", evalErr, flags, warnings)
+		}
+		if evalErr != nil {
+			return nil, fmt.Errorf("error evaluating function calls consistency: %w", evalErr)
+		}
 
-	if len(funcCalls) == 0 {
-		return &ProcessingResult{
-			Execution: UnprocessableRequestExecutions(),
-		}, nil
+		if exec == nil && err == nil {
+			exec := UnprocessableRequestExecutions()
+			a.translateResults(ctx, exec)
+			result := &ProcessingResult{
+				Execution: exec,
+				Flags:     flags,
+				Warnings:  warnings,
+			}
+			if err := a.formatOutput(ctx, result); err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+
+		a.recordFailures(ctx, collectFailures(exec, err))
+		if err == nil {
+			break
+		}
+		if budgetExpired(ctx) {
+			return a.partialResult(ctx, "execution", err, flags, warnings)
+		}
+		if !flags.Enabled(FlagReplanning) || attempt == maxReplans {
+			return nil, fmt.Errorf("error executing functions: %w", err)
+		}
+
+		a.logger(ctx).Printf("Re-planning after failed execution: %v", err)
+		progress.Send("Re-planning after a failed execution...")
+		if feedback, err = a.replanningFeedback(completion, err); err != nil {
+			return nil, err
+		}
+	}
+	if a.config.PlanCache != nil && !ruled {
+		a.cachePlan(reuse, message, completion, funcCalls, exec)
 	}
+	return a.completeResult(ctx, message, exec, flags, warnings, progress)
+}
 
-	exec, err := a.executeFunctionCalls(ctx, funcCalls, progress)
-	if err != nil {
-		return nil, fmt.Errorf("error executing functions: %w", err)
+// plannedRequest is the outcome of the planning stage.
+type plannedRequest struct {
+	funcCalls  []parser.PlannedFuncCall
+	completion string
+	// reuse is the lookup in the plan cache, on the first attempt.
+	reuse *planReuse
+	// ruled reports whether the rules planned the request.
+	ruled bool
+	// warnings report what was dropped from the planning prompt.
+	warnings []string
+}
+
+// plan plans the request with the rules, the plan cache or the LLM. The rules
+// and the plan cache are consulted on the first attempt only.
+func (a *RequestHandler) plan(ctx context.Context, message string, ts *tools.ToolSet, feedback []llm.Message, first bool, progress progress.Stream) (p plannedRequest, err error) {
+	if first && a.config.Rules != nil && !a.config.Rules.FallbackOnly {
+		if p.funcCalls, p.completion, p.ruled = a.planWithRules(ctx, message, ts); p.ruled {
+			return p, nil
+		}
+	}
+	if first && a.config.PlanCache != nil {
+		p.reuse = a.reusePlan(ctx, message, ts, progress)
+		if p.reuse != nil && p.reuse.funcCalls != nil {
+			p.funcCalls, p.completion = p.reuse.funcCalls, p.reuse.completion
+			return p, nil
+		}
+	}
+	if a.config.Ensemble != nil {
+		p.funcCalls, p.completion, p.warnings, err = a.generateWithEnsemble(ctx, message, ts, feedback, progress)
+	} else {
+		p.funcCalls, p.completion, p.warnings, err = a.generateFunctionCalls(ctx, message, ts, feedback, progress)
+	}
+	if err != nil && first && a.config.Rules != nil {
+		if calls, completion, ok := a.planWithRules(ctx, message, ts); ok {
+			a.logger(ctx).Printf("Planning with the rules after the LLM failed: %v", err)
+			p.funcCalls, p.completion, p.ruled = calls, completion, true
+			return p, nil
+		}
 	}
+	return p, err
+}
+
+// completeResult turns a successful execution into the result of the request.
+func (a *RequestHandler) completeResult(ctx context.Context, message string, exec *execution.Result, flags Flags, warnings []string, progress progress.Stream) (*ProcessingResult, error) {
+	a.translateResults(ctx, exec)
 
 	if a.config.AlterResult != nil {
-		if err := a.config.AlterResult(&ProcessingResult{Execution: exec}); err != nil {
+		if err := a.config.AlterResult(&ProcessingResult{Execution: exec, Flags: flags, Warnings: warnings}); err != nil {
 			return nil, fmt.Errorf("error on altering result: %w", err)
 		}
 	}
 
-	return &ProcessingResult{
+	result := &ProcessingResult{
 		Execution: exec,
-	}, nil
+		Flags:     flags,
+		Warnings:  warnings,
+	}
+
+	if a.config.StructuredOutput {
+		var valueWarnings []string
+		result.Values, valueWarnings = a.structuredResults(ctx, exec)
+		result.Warnings = appendWarnings(result.Warnings, valueWarnings...)
+	}
+
+	if flags.Enabled(FlagAnswerSynthesis) {
+		var err error
+		stage(ctx, "synthesis", func(ctx context.Context) {
+			result.Answer, err = a.synthesizeAnswer(ctx, message, exec, progress)
+		})
+		switch {
+		case err != nil && budgetExpired(ctx):
+			// The results are complete, only the answer is missing
+			a.logger(ctx).Printf("Request budget expired during synthesis: %v", err)
+			result.Answer, result.Partial = "", true
+			result.Warnings = appendWarnings(result.Warnings, "the request budget expired during synthesis: the answer is missing")
+		case err != nil:
+			return nil, fmt.Errorf("error synthesizing answer: %w", err)
+		}
+		if a.config.AnswerRubric != nil && !result.Partial {
+			judgeWarnings, err := a.judgeAnswer(ctx, message, exec, result.Answer, progress)
+			if err != nil {
+				return nil, fmt.Errorf("error judging answer: %w", err)
+			}
+			result.Warnings = appendWarnings(result.Warnings, judgeWarnings...)
+		}
+	}
+
+	if err := a.formatOutput(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-func (a *RequestHandler) generateFunctionCalls(_ context.Context, message string, progress progress.Stream) ([]parser.PlannedFuncCall, error) {
+// evaluateThenExecute evaluates the planned calls and executes the consistent ones.
+// It returns a nil result without errors when every call is rejected.
+func (a *RequestHandler) evaluateThenExecute(ctx context.Context, message string, funcCalls []parser.PlannedFuncCall, flags Flags, progress progress.Stream) (exec *execution.Result, evalErr, execErr error) {
+	var speculative *speculativeExecution
+	if flags.Enabled(FlagSpeculativeExecution) && len(funcCalls) > 0 {
+		speculative = a.startSpeculativeExecution(ctx, funcCalls)
+	}
+
+	var consistent []parser.PlannedFuncCall
+	stage(ctx, "evaluation", func(ctx context.Context) {
+		consistent, evalErr = a.evaluateFuncCallsConsistency(ctx, message, funcCalls, progress)
+	})
+	if evalErr != nil || len(consistent) == 0 {
+		speculative.discard()
+		return nil, evalErr, nil
+	}
+
+	stage(ctx, "execution", func(ctx context.Context) {
+		// The speculative results are valid only if no call was rejected
+		if speculative != nil && len(consistent) == len(funcCalls) {
+			a.logger(ctx).Printf("Using the speculative execution")
+			exec, execErr = speculative.wait()
+			return
+		}
+		speculative.discard()
+		exec, execErr = a.executeFunctionCalls(ctx, consistent, progress)
+	})
+	return exec, nil, execErr
+}
+
+// generateFunctionCalls asks the LLM for a plan using the given tools, returning the parsed
+// calls, the raw completion, and warnings about what was dropped from a prompt
+// exceeding the context length. The feedback messages, if any, follow the user request.
+func (a *RequestHandler) generateFunctionCalls(ctx context.Context, message string, ts *tools.ToolSet, feedback []llm.Message, progress progress.Stream) ([]parser.PlannedFuncCall, string, []string, error) {
+	funcCallsCompletion, warnings, err := a.completePlan(ctx, a.config.LLMClient, message, ts, feedback, progress)
+	if err != nil {
+		return nil, "", warnings, fmt.Errorf("error calling LLM: %w", err)
+	}
+
+	progress.Send("Synthesizing function calls...")
+	funcCalls, err := a.config.PlanFormat.parse(funcCallsCompletion)
+	reportFromContext(ctx).addPlan(funcCallsCompletion, funcCalls, "")
+	return funcCalls, funcCallsCompletion, warnings, err
+}
+
+// planningRequest returns the messages and the JSON schema of the planning
+// completion. The feedback messages, if any, follow the user request; hints
+// adds the recent failures of the session to the system prompt. The session
+// variables are always listed.
+func (a *RequestHandler) planningRequest(ctx context.Context, message string, ts *tools.ToolSet, feedback []llm.Message, hints bool, progress progress.Stream) ([]llm.Message, []byte, error) {
 	progress.Send("Generating system prompt...")
-	systemPrompt, err := prompt.CreatePromptForFuncCalls(a.config.Tools.AvailableTools())
+	systemPrompt, err := a.config.PlanFormat.systemPrompt(a.config.PromptVersions, ts, a.config.DefinitionFormat)
 	if err != nil {
-		return nil, fmt.Errorf("error generating system prompt: %w", err)
+		return nil, nil, fmt.Errorf("error generating system prompt: %w", err)
+	}
+	if hints {
+		// Recent failures in the session help the planner avoid repeating them
+		systemPrompt += prompt.CreatePlanningHints(a.failureHints(ctx))
 	}
+	systemPrompt += prompt.CreatePlanningVariables(a.sessionVariables(ctx))
 
 	messages := []llm.Message{
 		{"system", systemPrompt},
 		{"user", message},
 	}
+	messages = append(messages, feedback...)
 
 	progress.Send("Generating schema for constrained generation...")
-	jsonSchema, err := a.config.Tools.AvailableTools().ToJSONSchema()
+	jsonSchema, err := a.config.PlanFormat.jsonSchema(ts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate JSON schema: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate JSON schema: %w", err)
 	}
 
 	/*
@@ -169,44 +710,59 @@ func (a *RequestHandler) generateFunctionCalls(_ context.Context, message string
 		a.config.Logger.Printf("JSON schema:\n%s\n", prettyJSON.String())
 	*/
 
-	progress.Send("Generating function calls plan...")
-	funcCallsCompletion, err := a.config.LLMClient.Complete(messages, string(jsonSchema))
+	return messages, jsonSchema, nil
+}
+
+func (a *RequestHandler) evaluateFuncCallsConsistency(ctx context.Context, message string, funcCalls []parser.PlannedFuncCall, progress progress.Stream) ([]parser.PlannedFuncCall, error) {
+	var consistent []parser.PlannedFuncCall
+	err := a.evaluateEach(ctx, message, funcCalls, progress, func(i int, isConsistent bool) {
+		if isConsistent {
+			consistent = append(consistent, funcCalls[i])
+		}
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error calling LLM: %w", err)
+		return nil, err
 	}
-
-	progress.Send("Synthesizing function calls...")
-	return parser.ParseJsonFunctions([]byte(funcCallsCompletion))
+	return consistent, nil
 }
 
-func (a *RequestHandler) evaluateFuncCallsConsistency(message string, funcCalls []parser.PlannedFuncCall, progress progress.Stream) ([]parser.PlannedFuncCall, error) {
+// evaluateEach evaluates the planned calls concurrently, passing each verdict
+// to report as soon as it arrives. report is called by the calling goroutine only.
+// The first error cancels the evaluations still pending.
+func (a *RequestHandler) evaluateEach(ctx context.Context, message string, funcCalls []parser.PlannedFuncCall, progress progress.Stream, report func(i int, isConsistent bool)) error {
 	if len(funcCalls) == 0 {
-		return nil, nil
+		return nil
 	}
 
 	progress.Send("Evaluating function calls consistency...")
 
 	jsonSchema, err := json.Marshal(prompt.FuncCallsEvaluationResponseSchema)
 	if err != nil {
-		return nil, fmt.Errorf("error marshalling schema: %w", err)
+		return fmt.Errorf("error marshalling schema: %w", err)
 	}
 
 	at := a.config.Tools.AvailableTools()
 
 	type result struct {
-		funcCall parser.PlannedFuncCall
-		err      error
+		index        int
+		isConsistent bool
+		err          error
 	}
 
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
 	resultChan := make(chan result, len(funcCalls)) // Buffered channel to prevent blocking
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, 4) // Limit to 4 concurrent operations
-
-	for _, function := range funcCalls {
-		wg.Add(1)
-		go func(f parser.PlannedFuncCall) {
-			defer wg.Done()
-			sem <- struct{}{}
+	sem := make(chan struct{}, 4)                   // Limit to 4 concurrent operations
+
+	for i, function := range funcCalls {
+		go func(i int, f parser.PlannedFuncCall) {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				resultChan <- result{index: i, err: context.Cause(ctx)}
+				return
+			}
 			defer func() { <-sem }()
 
 			usedTools := make([]tools.FuncDefinition, 0)
@@ -214,60 +770,53 @@ func (a *RequestHandler) evaluateFuncCallsConsistency(message string, funcCalls
 				if tool, ok := at.FindTool(toolName); ok {
 					usedTools = append(usedTools, *tool)
 				} else {
-					resultChan <- result{err: fmt.Errorf("tool %s not found", toolName)}
+					resultChan <- result{index: i, err: fmt.Errorf("tool %s not found", toolName)}
 					return
 				}
 			}
 
-			isConsistent, err := a.evaluateSingleFunctionCall(message, f, jsonSchema, &tools.ToolSet{
+			isConsistent, err := a.evaluateSingleFunctionCall(ctx, message, f, jsonSchema, &tools.ToolSet{
 				Functions:       usedTools,
 				TypeDefinitions: at.TypeDefinitions,
-			})
-
-			if err != nil {
-				resultChan <- result{err: err}
-				return
-			}
-
-			if isConsistent {
-				resultChan <- result{funcCall: f}
-			}
-		}(function)
+			}, progress)
+			resultChan <- result{index: i, isConsistent: isConsistent, err: err}
+		}(i, function)
 	}
 
-	wg.Wait()
-	close(resultChan)
-
-	var consistent []parser.PlannedFuncCall
-	for r := range resultChan {
+	for range funcCalls {
+		r := <-resultChan
 		if r.err != nil {
-			return nil, r.err
-		}
-		if r.funcCall.Name != "" {
-			consistent = append(consistent, r.funcCall)
+			cancel(r.err)
+			return r.err
 		}
+		report(r.index, r.isConsistent)
 	}
-
-	return consistent, nil
+	return nil
 }
 
-func (a *RequestHandler) evaluateSingleFunctionCall(message string, function parser.PlannedFuncCall, jsonSchema []byte, usedTools *tools.ToolSet) (bool, error) {
+func (a *RequestHandler) evaluateSingleFunctionCall(ctx context.Context, message string, function parser.PlannedFuncCall, jsonSchema []byte, usedTools *tools.ToolSet, progress progress.Stream) (bool, error) {
 	data, err := json.MarshalIndent(function, "", "  ")
 	if err != nil {
 		return false, fmt.Errorf("error marshalling function: %w", err)
 	}
 
-	usedFunctionsJSON, err := usedTools.ToJSONDefinitions()
-	if err != nil {
-		return false, fmt.Errorf("error marshaling functions to JSON: %w", err)
+	stage := fmt.Sprintf("Evaluating %s", function.Name)
+	if a.config.EvaluationRubric != nil {
+		consistent, err := a.judgeFunctionCall(ctx, message, data, usedTools, stage, progress)
+		if err != nil {
+			return false, err
+		}
+		a.logger(ctx).Printf("Function %s -> %v", function.Name, consistent)
+		reportFromContext(ctx).addVerdict(function, data, consistent)
+		return consistent, nil
 	}
 
-	userPrompt, err := prompt.CreatePromptForFuncCallsEvaluation(message, string(data), string(usedFunctionsJSON))
+	userPrompt, err := a.evaluationPrompt(message, data, usedTools)
 	if err != nil {
-		return false, fmt.Errorf("error generating userPrompt for self-validation: %w", err)
+		return false, err
 	}
 
-	body, err := a.config.LLMClient.Complete([]llm.Message{{"user", userPrompt}}, string(jsonSchema))
+	body, err := a.completeStreamed(ctx, a.config.LLMClient, []llm.Message{{"user", userPrompt}}, string(jsonSchema), stage, progress)
 	if err != nil {
 		return false, fmt.Errorf("error generating response for self-validation: %w", err)
 	}
@@ -280,10 +829,26 @@ func (a *RequestHandler) evaluateSingleFunctionCall(message string, function par
 		return false, fmt.Errorf("error unmarshaling JSON: %w", err)
 	}
 
-	a.config.Logger.Printf("Function %s -> %v", function.Name, evaluation.Success)
+	a.logger(ctx).Printf("Function %s -> %v", function.Name, evaluation.Success)
+	reportFromContext(ctx).addVerdict(function, data, evaluation.Success)
 	return evaluation.Success, nil
 }
 
+// evaluationPrompt returns the prompt asking whether the planned call, in
+// indented JSON, is consistent with the request.
+func (a *RequestHandler) evaluationPrompt(message string, call []byte, usedTools *tools.ToolSet) (string, error) {
+	usedFunctions, err := usedTools.Definitions(a.config.DefinitionFormat)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling functions to JSON: %w", err)
+	}
+
+	userPrompt, err := a.config.PromptVersions.CreatePromptForFuncCallsEvaluation(message, string(call), usedFunctions)
+	if err != nil {
+		return "", fmt.Errorf("error generating userPrompt for self-validation: %w", err)
+	}
+	return userPrompt, nil
+}
+
 func (a *RequestHandler) executeFunctionCalls(ctx context.Context, funcCalls []parser.PlannedFuncCall, progress progress.Stream) (*execution.Result, error) {
 	if len(funcCalls) == 0 {
 		return nil, fmt.Errorf("no function calls to execute")
@@ -291,3 +856,71 @@ func (a *RequestHandler) executeFunctionCalls(ctx context.Context, funcCalls []p
 	progress.Send("Executing function calls...")
 	return a.orchestrator.Execute(ctx, funcCalls, progress)
 }
+
+// translateResults wraps the format functions of the main results so that the
+// formatted output is translated into the configured output language. Each
+// result is translated once, however many times it is formatted.
+func (a *RequestHandler) translateResults(ctx context.Context, exec *execution.Result) {
+	if a.config.OutputLanguage == "" {
+		return
+	}
+	for _, f := range exec.FuncCalls {
+		format := f.Result.FormatFunc
+		if format == nil {
+			continue
+		}
+		f.Result.FormatFunc = sync.OnceValues(func() (string, error) {
+			text, err := format()
+			if err != nil {
+				return "", err
+			}
+			translated, err := a.translate(ctx, text)
+			if err != nil {
+				return "", fmt.Errorf("error translating result into %s: %w", a.config.OutputLanguage, err)
+			}
+			return translated, nil
+		})
+	}
+}
+
+// translate translates text into the output language, within the request.
+func (a *RequestHandler) translate(ctx context.Context, text string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+	userPrompt, err := a.config.PromptVersions.CreatePromptForTranslation(text, a.config.OutputLanguage, "")
+	if err != nil {
+		return "", fmt.Errorf("error generating translation prompt: %w", err)
+	}
+	translation, err := a.complete(ctx, []llm.Message{{"user", userPrompt}}, "")
+	if err != nil {
+		return "", fmt.Errorf("error calling LLM: %w", err)
+	}
+	return strings.TrimSpace(translation), nil
+}
+
+// requestCounter numbers the requests without an ID.
+var requestCounter atomic.Uint64
+
+func requestID(ctx context.Context) string {
+	if id, ok := logging.RequestIDFromContext(ctx); ok {
+		return id
+	}
+	return strconv.FormatUint(requestCounter.Add(1), 10)
+}
+
+// requestLabels converts the key-value pairs identifying the request into pprof labels.
+func requestLabels(keyvals []string) pprof.LabelSet {
+	labels := make([]string, 0, len(keyvals))
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		labels = append(labels, "funcall_"+keyvals[i], keyvals[i+1])
+	}
+	return pprof.Labels(labels...)
+}
+
+// stage runs fn with the pprof label of the given pipeline stage, timing it in the report.
+func stage(ctx context.Context, name string, fn func(ctx context.Context)) {
+	started := time.Now()
+	pprof.Do(ctx, pprof.Labels("funcall_stage", name), fn)
+	reportFromContext(ctx).addStage(name, time.Since(started))
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/judge"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// judgeCompleter lets a judge.Judge complete through the handler, so that its
+// completions are streamed and accounted in the report of the request.
+type judgeCompleter struct {
+	a        *RequestHandler
+	ctx      context.Context
+	stage    string
+	progress progress.Stream
+}
+
+func (c *judgeCompleter) Complete(messages []llm.Message, jsonSchema string) (string, error) {
+	return c.a.completeStreamed(c.ctx, c.a.config.LLMClient, messages, jsonSchema, c.stage, c.progress)
+}
+
+func (a *RequestHandler) judge(ctx context.Context, rubric *judge.Rubric, stage string, progress progress.Stream) *judge.Judge {
+	return &judge.Judge{
+		Completer: &judgeCompleter{a: a, ctx: ctx, stage: stage, progress: progress},
+		Rubric:    *rubric,
+		Prompts:   a.config.PromptVersions,
+	}
+}
+
+// judgeFunctionCall evaluates the planned call, in indented JSON, against
+// EvaluationRubric.
+func (a *RequestHandler) judgeFunctionCall(ctx context.Context, message string, call []byte, usedTools *tools.ToolSet, stage string, progress progress.Stream) (bool, error) {
+	definitions, err := usedTools.Definitions(a.config.DefinitionFormat)
+	if err != nil {
+		return false, fmt.Errorf("error marshaling functions to JSON: %w", err)
+	}
+	verdict, err := a.judge(ctx, a.config.EvaluationRubric, stage, progress).Judge(judge.Input{
+		Request: message,
+		Content: string(call),
+		Context: "Function definitions:\n" + definitions,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error judging function call: %w", err)
+	}
+	for _, c := range verdict.Failed() {
+		a.logger(ctx).Printf("Criterion %s not met: %s", c.Criterion, c.Reason)
+	}
+	return verdict.Pass, nil
+}
+
+// judgeAnswer judges the synthesized answer against AnswerRubric, returning
+// the criteria not met as warnings.
+func (a *RequestHandler) judgeAnswer(ctx context.Context, message string, exec *execution.Result, answer string, progress progress.Stream) ([]string, error) {
+	progress.Send("Judging answer...")
+	results, err := exec.MainFuncResults().Format("")
+	if err != nil {
+		return nil, fmt.Errorf("error formatting results: %w", err)
+	}
+	verdict, err := a.judge(ctx, a.config.AnswerRubric, "Judging answer", progress).Judge(judge.Input{
+		Request: message,
+		Content: answer,
+		Context: "Results of the function calls:\n" + results,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	for _, c := range verdict.Failed() {
+		warnings = append(warnings, fmt.Sprintf("the answer does not meet the %s criterion: %s", c.Criterion, strings.TrimSpace(c.Reason)))
+	}
+	return warnings, nil
+}
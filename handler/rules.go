@@ -0,0 +1,198 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Rule maps the requests matching Pattern to a plan, deterministically.
+type Rule struct {
+	Name string
+	// Pattern is matched against the whole request, trimmed. Its named
+	// groups are the slots filling the parameters of Plan.
+	Pattern *regexp.Regexp
+	// Plan is the template of the plan, with parser.Param placeholders
+	// named after the slots.
+	Plan []parser.PlannedFuncCall
+}
+
+// RulesPlanner plans requests with rules instead of the LLM, for a known set
+// of high-frequency queries, or when the LLM is unavailable. The plans are
+// executed by the same pipeline, but are not evaluated by the LLM.
+type RulesPlanner struct {
+	// Rules are tried in order; the first one matching plans the request.
+	Rules []Rule
+	// FallbackOnly uses the rules only when the LLM fails to plan, instead
+	// of trying them first.
+	FallbackOnly bool
+}
+
+// rulesFile is the configuration read by LoadRules.
+type rulesFile struct {
+	FallbackOnly bool `json:"fallback_only"`
+	Rules        []struct {
+		Name    string            `json:"name"`
+		Pattern string            `json:"pattern"`
+		Plan    []json.RawMessage `json:"plan"`
+	} `json:"rules"`
+}
+
+// LoadRules reads a RulesPlanner configured in JSON:
+//
+//	{"rules": [{
+//	  "name": "weather",
+//	  "pattern": "(?i)^what is the weather in (?P<city>[^?]+)\\??$",
+//	  "plan": [{"name": "get_weather", "purpose": "Get the weather",
+//	            "args": {"city": {"$param": "city"}}}]
+//	}]}
+func LoadRules(r io.Reader) (*RulesPlanner, error) {
+	var file rulesFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("error decoding rules: %w", err)
+	}
+	p := &RulesPlanner{FallbackOnly: file.FallbackOnly}
+	for _, fr := range file.Rules {
+		pattern, err := regexp.Compile(fr.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error compiling the pattern of rule %q: %w", fr.Name, err)
+		}
+		rule := Rule{Name: fr.Name, Pattern: pattern}
+		for _, data := range fr.Plan {
+			call, err := parser.DecodeCall(data)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding the plan of rule %q: %w", fr.Name, err)
+			}
+			rule.Plan = append(rule.Plan, call)
+		}
+		if err := rule.Validate(nil); err != nil {
+			return nil, err
+		}
+		p.Rules = append(p.Rules, rule)
+	}
+	return p, nil
+}
+
+// Validate checks that the parameters of the plan are slots of the pattern
+// and, if ts is not nil, that the planned functions are defined in it.
+func (r *Rule) Validate(ts *tools.ToolSet) error {
+	if len(r.Plan) == 0 {
+		return fmt.Errorf("rule %q has an empty plan", r.Name)
+	}
+	slots := make(map[string]bool)
+	for _, name := range r.Pattern.SubexpNames() {
+		slots[name] = name != ""
+	}
+	for _, call := range r.Plan {
+		for _, param := range call.Params() {
+			if !slots[param] {
+				return fmt.Errorf("rule %q: parameter %q is not a slot of the pattern", r.Name, param)
+			}
+		}
+		if ts == nil {
+			continue
+		}
+		for _, name := range call.CollectAllNestedFuncCalls() {
+			if _, ok := ts.FindTool(name); !ok {
+				return fmt.Errorf("rule %q: function %s is not defined", r.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Plan returns the plan of the first rule matching the request, if any.
+// The slots are strings, converted to numbers and booleans where the
+// parameters of the functions in ts require so.
+func (p *RulesPlanner) Plan(request string, ts *tools.ToolSet) ([]parser.PlannedFuncCall, *Rule, bool) {
+	request = strings.TrimSpace(request)
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		match := rule.Pattern.FindStringSubmatch(request)
+		if match == nil {
+			continue
+		}
+		slots := make(map[string]interface{})
+		for j, name := range rule.Pattern.SubexpNames() {
+			if name != "" && match[j] != "" {
+				slots[name] = strings.TrimSpace(match[j])
+			}
+		}
+		plan := make([]parser.PlannedFuncCall, len(rule.Plan))
+		for j, template := range rule.Plan {
+			plan[j] = parser.Instantiate(template, slots)
+			coerceArgs(&plan[j], ts)
+		}
+		return plan, rule, true
+	}
+	return nil, nil, false
+}
+
+// coerceArgs converts the string arguments of the call, nested calls
+// included, to the scalar types of the parameters.
+func coerceArgs(call *parser.PlannedFuncCall, ts *tools.ToolSet) {
+	var params map[string]tools.TypeInfo
+	if ts != nil {
+		if def, ok := ts.FindTool(call.Name); ok {
+			params = def.Parameters.Properties
+		}
+	}
+	for name, arg := range call.Args {
+		switch v := arg.(type) {
+		case *parser.PlannedFuncCall:
+			coerceArgs(v, ts)
+		case string:
+			switch params[name].Type {
+			case "number", "integer":
+				if _, err := strconv.ParseFloat(v, 64); err == nil {
+					call.Args[name] = json.Number(v)
+				}
+			case "boolean":
+				if b, err := strconv.ParseBool(v); err == nil {
+					call.Args[name] = b
+				}
+			}
+		}
+	}
+}
+
+// planWithRules plans the request with the rules, reporting whether one matched.
+func (a *RequestHandler) planWithRules(ctx context.Context, message string, ts *tools.ToolSet) ([]parser.PlannedFuncCall, string, bool) {
+	funcCalls, rule, ok := a.config.Rules.Plan(message, ts)
+	if !ok {
+		return nil, "", false
+	}
+	if err := rule.Validate(ts); err != nil {
+		a.logger(ctx).Printf("Rules: %v", err)
+		return nil, "", false
+	}
+	completion, err := json.Marshal(funcCalls)
+	if err != nil {
+		a.logger(ctx).Printf("Rules: error marshalling plan: %v", err)
+		return nil, "", false
+	}
+	a.logger(ctx).Printf("Rules: planned with rule %q", rule.Name)
+	reportFromContext(ctx).addRulePlan(rule.Name, string(completion), funcCalls)
+	return funcCalls, string(completion), true
+}
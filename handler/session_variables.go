@@ -0,0 +1,48 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+
+	"github.com/nlpodyssey/funcallarchitect/session"
+)
+
+// withVariables binds the variables of the session of the request, if any,
+// to ctx, for the tools and the planning prompt.
+func (a *RequestHandler) withVariables(ctx context.Context) context.Context {
+	if a.config.SessionStore == nil {
+		return ctx
+	}
+	id, ok := session.IDFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return session.WithVariables(ctx, session.NewVariables(a.config.SessionStore, id))
+}
+
+// sessionVariables returns the variables of the session of the request, if any.
+func (a *RequestHandler) sessionVariables(ctx context.Context) map[string]any {
+	vars, ok := session.VariablesFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	all, err := vars.All(ctx)
+	if err != nil {
+		a.logger(ctx).Printf("Failed to load the session variables: %v", err)
+		return nil
+	}
+	return all
+}
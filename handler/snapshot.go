@@ -0,0 +1,414 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/codec"
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/judge"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// modulePath identifies this module in the build information.
+const modulePath = "github.com/nlpodyssey/funcallarchitect"
+
+// Snapshot is the effective configuration of a RequestHandler: everything that
+// determines how a request is planned and executed, so that production
+// incidents can be reproduced exactly.
+type Snapshot struct {
+	CreatedAt time.Time `json:"created_at"`
+	// Versions holds the Go version and the versions of the main module and of this module.
+	Versions map[string]string `json:"versions"`
+	ToolSet  *tools.ToolSet    `json:"toolset"`
+	Prompts  SnapshotPrompts   `json:"prompts"`
+	// Model holds the settings reported by the LLM client, if it implements llm.SettingsReporter.
+	Model   map[string]any  `json:"model,omitempty"`
+	Options SnapshotOptions `json:"options"`
+}
+
+// SnapshotPrompts holds the prompts and schemas sent to the LLM.
+type SnapshotPrompts struct {
+	Planning         string          `json:"planning"`
+	PlanningSchema   json.RawMessage `json:"planning_schema"`
+	EvaluationSchema json.RawMessage `json:"evaluation_schema"`
+	// Versions are the versions of the prompt templates in use, by name.
+	Versions map[string]int `json:"versions"`
+}
+
+// SnapshotOptions holds the options of the handler and of its orchestrator.
+type SnapshotOptions struct {
+	Timeout              string `json:"timeout"`
+	TimeoutExtension     string `json:"timeout_extension"`
+	RequestTimeout       string `json:"request_timeout,omitempty"`
+	ChoiceTimeout        string `json:"choice_timeout,omitempty"`
+	EnableConcurrentExec bool   `json:"enable_concurrent_exec"`
+	OverlapExecution     bool   `json:"overlap_execution"`
+	PlanFormat           string `json:"plan_format"`
+	DefinitionFormat     string `json:"definition_format"`
+	NumberMode           string `json:"number_mode"`
+	DisableBuiltinTools  bool   `json:"disable_builtin_tools"`
+	EnableLLMTools       bool   `json:"enable_llm_tools"`
+	OutputLanguage       string `json:"output_language,omitempty"`
+	SessionMemory        bool   `json:"session_memory"`
+	Approver             bool   `json:"approver"`
+	AuditLog             bool   `json:"audit_log"`
+	History              bool   `json:"history"`
+	Analytics            bool   `json:"analytics"`
+	PlanCache            bool   `json:"plan_cache"`
+	Idempotency          bool   `json:"idempotency"`
+	Ensemble             bool   `json:"ensemble"`
+	Rules                bool   `json:"rules"`
+	ContextRetry         bool   `json:"context_retry"`
+	Canary               bool   `json:"canary"`
+	StreamTokens         bool   `json:"stream_tokens"`
+	StructuredOutput     bool   `json:"structured_output"`
+	CompensateOnFailure  bool   `json:"compensate_on_failure"`
+	Transactional        bool   `json:"transactional"`
+	MemoCodec            string `json:"memo_codec,omitempty"`
+	MemoTTL              string `json:"memo_ttl,omitempty"`
+	Maintenance          bool   `json:"maintenance"`
+	Flags                string `json:"flags,omitempty"`
+	Deterministic        bool   `json:"deterministic"`
+	Seed                 int    `json:"seed,omitempty"`
+	// The rubrics are recorded in full, as their criteria drive the evaluation.
+	EvaluationRubric *judge.Rubric `json:"evaluation_rubric,omitempty"`
+	AnswerRubric     *judge.Rubric `json:"answer_rubric,omitempty"`
+}
+
+// signedSnapshot is the document produced by SignSnapshot. The signature covers
+// the exact bytes of the snapshot.
+type signedSnapshot struct {
+	Snapshot  json.RawMessage `json:"snapshot"`
+	Algorithm string          `json:"algorithm"`
+	Signature []byte          `json:"signature"`
+}
+
+// Snapshot exports the effective configuration of the handler.
+func (a *RequestHandler) Snapshot() (*Snapshot, error) {
+	ts := a.config.Tools.AvailableTools()
+
+	planning, err := a.config.PlanFormat.systemPrompt(a.config.PromptVersions, ts, a.config.DefinitionFormat)
+	if err != nil {
+		return nil, fmt.Errorf("error generating system prompt: %w", err)
+	}
+	planningSchema, err := a.config.PlanFormat.jsonSchema(ts)
+	if err != nil {
+		return nil, fmt.Errorf("error generating JSON schema: %w", err)
+	}
+	evaluationSchema, err := json.Marshal(prompt.FuncCallsEvaluationResponseSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling evaluation schema: %w", err)
+	}
+
+	s := &Snapshot{
+		CreatedAt: time.Now().UTC(),
+		Versions:  buildVersions(),
+		ToolSet:   ts,
+		Prompts: SnapshotPrompts{
+			Planning:         planning,
+			PlanningSchema:   planningSchema,
+			EvaluationSchema: evaluationSchema,
+			Versions:         a.config.PromptVersions.All(),
+		},
+		Options: SnapshotOptions{
+			Timeout:              a.config.Timeout.String(),
+			TimeoutExtension:     a.config.TimeoutExtension.String(),
+			EnableConcurrentExec: a.config.EnableConcurrentExec,
+			OverlapExecution:     a.config.OverlapExecution,
+			PlanFormat:           a.config.PlanFormat.String(),
+			DefinitionFormat:     a.config.DefinitionFormat.String(),
+			NumberMode:           a.config.NumberMode.String(),
+			DisableBuiltinTools:  a.config.DisableBuiltinTools,
+			EnableLLMTools:       a.config.EnableLLMTools,
+			OutputLanguage:       a.config.OutputLanguage,
+			SessionMemory:        a.config.SessionStore != nil,
+			Approver:             a.config.Approver != nil,
+			AuditLog:             a.config.AuditLog != nil,
+			History:              a.config.History != nil,
+			Analytics:            a.config.Analytics != nil,
+			PlanCache:            a.config.PlanCache != nil,
+			Idempotency:          a.config.Idempotency != nil,
+			Ensemble:             a.config.Ensemble != nil,
+			Rules:                a.config.Rules != nil,
+			ContextRetry:         a.config.ContextRetry != nil,
+			Canary:               a.config.Canary != nil,
+			StreamTokens:         a.config.StreamTokens,
+			StructuredOutput:     a.config.StructuredOutput,
+			CompensateOnFailure:  a.config.CompensateOnFailure,
+			Transactional:        a.config.TransactionalSideEffects,
+			Maintenance:          a.config.Maintenance != nil,
+			Flags:                a.config.Flags.String(),
+			EvaluationRubric:     a.config.EvaluationRubric,
+			AnswerRubric:         a.config.AnswerRubric,
+		},
+	}
+	if a.config.RequestTimeout > 0 {
+		s.Options.RequestTimeout = a.config.RequestTimeout.String()
+	}
+	if a.config.ChoiceTimeout > 0 {
+		s.Options.ChoiceTimeout = a.config.ChoiceTimeout.String()
+	}
+	if a.config.MemoTTL > 0 {
+		s.Options.MemoTTL = a.config.MemoTTL.String()
+	}
+	if a.config.Deterministic != nil {
+		s.Options.Deterministic = true
+		s.Options.Seed = a.config.Deterministic.Seed
+	}
+	if a.config.MemoStore != nil {
+		s.Options.MemoCodec = codec.JSON.Name()
+		if a.config.MemoCodec != nil {
+			s.Options.MemoCodec = a.config.MemoCodec.Name()
+		}
+	}
+	if r, ok := a.config.LLMClient.(llm.SettingsReporter); ok {
+		s.Model = r.Settings()
+	}
+	return s, nil
+}
+
+func buildVersions() map[string]string {
+	versions := map[string]string{"go": runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return versions
+	}
+	versions[info.Main.Path] = info.Main.Version
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			versions[dep.Path] = dep.Version
+		}
+	}
+	return versions
+}
+
+// SignSnapshot encodes the snapshot as a JSON document signed with the given key.
+func SignSnapshot(s *Snapshot, key ed25519.PrivateKey) ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling snapshot: %w", err)
+	}
+	return json.MarshalIndent(signedSnapshot{
+		Snapshot:  data,
+		Algorithm: "ed25519",
+		Signature: ed25519.Sign(key, data),
+	}, "", "  ")
+}
+
+// VerifySnapshot checks the signature of a document produced by SignSnapshot
+// and returns the snapshot it contains.
+func VerifySnapshot(document []byte, key ed25519.PublicKey) (*Snapshot, error) {
+	var signed signedSnapshot
+	if err := json.Unmarshal(document, &signed); err != nil {
+		return nil, fmt.Errorf("error unmarshalling snapshot document: %w", err)
+	}
+	if signed.Algorithm != "ed25519" {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", signed.Algorithm)
+	}
+	// The signature covers the compact encoding, which indentation does not preserve
+	var data bytes.Buffer
+	if err := json.Compact(&data, signed.Snapshot); err != nil {
+		return nil, fmt.Errorf("error compacting snapshot: %w", err)
+	}
+	if !ed25519.Verify(key, data.Bytes(), signed.Signature) {
+		return nil, fmt.Errorf("invalid snapshot signature")
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data.Bytes(), &s); err != nil {
+		return nil, fmt.Errorf("error unmarshalling snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+// ApplyTo sets the options recorded in the snapshot on config. Resources
+// (LLM client, tools, stores) are not part of the snapshot and must be provided
+// by the caller; use Diff to check that they match.
+func (s *Snapshot) ApplyTo(config *RequestHandlerConfig) error {
+	timeout, err := time.ParseDuration(s.Options.Timeout)
+	if err != nil {
+		return fmt.Errorf("error parsing timeout: %w", err)
+	}
+	timeoutExtension, err := time.ParseDuration(s.Options.TimeoutExtension)
+	if err != nil {
+		return fmt.Errorf("error parsing timeout extension: %w", err)
+	}
+	if s.Options.RequestTimeout != "" {
+		if config.RequestTimeout, err = time.ParseDuration(s.Options.RequestTimeout); err != nil {
+			return fmt.Errorf("error parsing request timeout: %w", err)
+		}
+	}
+	if s.Options.ChoiceTimeout != "" {
+		if config.ChoiceTimeout, err = time.ParseDuration(s.Options.ChoiceTimeout); err != nil {
+			return fmt.Errorf("error parsing choice timeout: %w", err)
+		}
+	}
+	if s.Options.MemoTTL != "" {
+		if config.MemoTTL, err = time.ParseDuration(s.Options.MemoTTL); err != nil {
+			return fmt.Errorf("error parsing memo TTL: %w", err)
+		}
+	}
+	switch s.Options.PlanFormat {
+	case PlanFormatNested.String():
+		config.PlanFormat = PlanFormatNested
+	case PlanFormatFlat.String():
+		config.PlanFormat = PlanFormatFlat
+	default:
+		return fmt.Errorf("unknown plan format %q", s.Options.PlanFormat)
+	}
+	if s.Options.DefinitionFormat != "" {
+		if config.DefinitionFormat, err = tools.ParseDefinitionFormat(s.Options.DefinitionFormat); err != nil {
+			return err
+		}
+	}
+	switch s.Options.NumberMode {
+	case execution.NumbersAsFloat64.String():
+		config.NumberMode = execution.NumbersAsFloat64
+	case execution.NumbersPreserved.String():
+		config.NumberMode = execution.NumbersPreserved
+	default:
+		return fmt.Errorf("unknown number mode %q", s.Options.NumberMode)
+	}
+	if s.Options.MemoCodec != "" {
+		c, err := codec.ByName(s.Options.MemoCodec)
+		if err != nil {
+			return err
+		}
+		config.MemoCodec = c
+	}
+	flags, err := ParseFlags(s.Options.Flags)
+	if err != nil {
+		return err
+	}
+	config.Flags = flags
+	config.Timeout = timeout
+	config.TimeoutExtension = timeoutExtension
+	config.EnableConcurrentExec = s.Options.EnableConcurrentExec
+	config.OverlapExecution = s.Options.OverlapExecution
+	config.StructuredOutput = s.Options.StructuredOutput
+	config.CompensateOnFailure = s.Options.CompensateOnFailure
+	config.TransactionalSideEffects = s.Options.Transactional
+	config.DisableBuiltinTools = s.Options.DisableBuiltinTools
+	config.EnableLLMTools = s.Options.EnableLLMTools
+	config.OutputLanguage = s.Options.OutputLanguage
+	config.EvaluationRubric = s.Options.EvaluationRubric
+	config.AnswerRubric = s.Options.AnswerRubric
+	config.PromptVersions = s.Prompts.Versions
+	if s.Options.Deterministic {
+		// The clock and the HTTP middleware are provided by the caller
+		var d Determinism
+		if config.Deterministic != nil {
+			d = *config.Deterministic
+		}
+		d.Seed = s.Options.Seed
+		config.Deterministic = &d
+	}
+	return nil
+}
+
+// Diff lists the differences between the snapshot and other, ignoring the creation time.
+// An empty result means that both describe the same configuration.
+func (s *Snapshot) Diff(other *Snapshot) []string {
+	var diffs []string
+	for name, v := range s.Versions {
+		if other.Versions[name] != v {
+			diffs = append(diffs, fmt.Sprintf("version of %s: %q != %q", name, v, other.Versions[name]))
+		}
+	}
+	for name, v := range other.Versions {
+		if _, ok := s.Versions[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("version of %s: %q != %q", name, "", v))
+		}
+	}
+	diffs = append(diffs, diffToolSets(s.ToolSet, other.ToolSet)...)
+	if s.Prompts.Planning != other.Prompts.Planning {
+		diffs = append(diffs, "planning prompt differs")
+	}
+	if !bytes.Equal(s.Prompts.PlanningSchema, other.Prompts.PlanningSchema) {
+		diffs = append(diffs, "planning schema differs")
+	}
+	if !bytes.Equal(s.Prompts.EvaluationSchema, other.Prompts.EvaluationSchema) {
+		diffs = append(diffs, "evaluation schema differs")
+	}
+	if !maps.Equal(s.Prompts.Versions, other.Prompts.Versions) {
+		diffs = append(diffs, fmt.Sprintf("prompt versions: %v != %v", s.Prompts.Versions, other.Prompts.Versions))
+	}
+	if !jsonEqual(s.Model, other.Model) {
+		diffs = append(diffs, "model settings differ")
+	}
+	if s.Options != other.Options {
+		diffs = append(diffs, fmt.Sprintf("options: %+v != %+v", s.Options, other.Options))
+	}
+	return diffs
+}
+
+func diffToolSets(a, b *tools.ToolSet) []string {
+	if a == nil || b == nil {
+		if a != b {
+			return []string{"toolset differs"}
+		}
+		return nil
+	}
+	var diffs []string
+	for _, f := range a.Functions {
+		g, ok := b.FindTool(f.Name)
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("function %s removed", f.Name))
+		} else if !jsonEqual(f, *g) {
+			diffs = append(diffs, fmt.Sprintf("function %s differs", f.Name))
+		}
+	}
+	for _, g := range b.Functions {
+		if _, ok := a.FindTool(g.Name); !ok {
+			diffs = append(diffs, fmt.Sprintf("function %s added", g.Name))
+		}
+	}
+	if !jsonEqual(a.TypeDefinitions, b.TypeDefinitions) {
+		diffs = append(diffs, "type definitions differ")
+	}
+	return diffs
+}
+
+// jsonEqual compares the JSON representations of a and b, so that values
+// decoded from a snapshot compare equal to the original ones.
+func jsonEqual(a, b any) bool {
+	var x, y any
+	if err := roundTrip(a, &x); err != nil {
+		return false
+	}
+	if err := roundTrip(b, &y); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func roundTrip(v any, dst *any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"io"
+	"log"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/testkit"
+)
+
+func TestSnapshotRestoresThePromptVersionsOfTheHandler(t *testing.T) {
+	config := handler.RequestHandlerConfig{
+		Logger:              log.New(io.Discard, "", 0),
+		LLMClient:           testkit.NewCompleter(),
+		Tools:               weatherTools{},
+		Timeout:             time.Second,
+		DisableBuiltinTools: true,
+		PromptVersions:      prompt.Pins{"translation": 1},
+	}
+	h, err := handler.NewRequestHandler(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := h.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (prompt.Pins{"translation": 1}).All(); !maps.Equal(snapshot.Prompts.Versions, want) {
+		t.Fatalf("snapshot prompt versions are %v, want %v", snapshot.Prompts.Versions, want)
+	}
+
+	restored := handler.RequestHandlerConfig{
+		Logger:    config.Logger,
+		LLMClient: config.LLMClient,
+		Tools:     config.Tools,
+	}
+	if err := snapshot.ApplyTo(&restored); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(restored.PromptVersions, snapshot.Prompts.Versions) {
+		t.Fatalf("restored prompt versions are %v, want %v", restored.PromptVersions, snapshot.Prompts.Versions)
+	}
+
+	// Pins are validated per handler, without affecting the others
+	restored.PromptVersions = prompt.Pins{"translation": 99}
+	if _, err := handler.NewRequestHandler(restored); err == nil {
+		t.Fatal("a handler pinning an unknown prompt version was created")
+	}
+	again, err := h.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := snapshot.Diff(again); len(diffs) > 0 {
+		t.Fatalf("the snapshot of the first handler changed: %v", diffs)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// streamProgressInterval is the number of tokens between the progress events
+// of a streamed completion.
+const streamProgressInterval = 32
+
+// completeStreamed is like completeWith. When StreamTokens is set and the
+// client implements llm.StreamingCompleter, the completion is streamed and the
+// tokens generated are reported as progress events of the stage.
+func (a *RequestHandler) completeStreamed(ctx context.Context, client llm.Completer, messages []llm.Message, jsonSchema string, stage string, progress progress.Stream) (string, error) {
+	if queued, ok := client.(llm.QueueReporter); ok {
+		// The requests waiting for a busy backend show their position in the queue
+		client = queued.WithQueueProgress(func(position int) {
+			progress.Send(fmt.Sprintf("%s: waiting for the LLM (position %d in queue)...", stage, position))
+		})
+	}
+	streamer, ok := client.(llm.StreamingCompleter)
+	if !a.config.StreamTokens || !ok {
+		return completeWith(ctx, client, messages, jsonSchema)
+	}
+	if ctx.Err() != nil {
+		return "", context.Cause(ctx)
+	}
+	started := time.Now()
+	tokens := 0
+	content, usage, err := streamer.CompleteStream(messages, jsonSchema, func(string) {
+		if tokens++; tokens%streamProgressInterval == 0 {
+			progress.Send(fmt.Sprintf("%s: %d tokens generated...", stage, tokens))
+		}
+	})
+	reportFromContext(ctx).addCompletion(time.Since(started), usage)
+	if err == nil && tokens > 0 {
+		progress.Send(fmt.Sprintf("%s: %d tokens generated", stage, tokens))
+	}
+	return content, err
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/blob"
+	"github.com/nlpodyssey/funcallarchitect/codec"
+	"github.com/nlpodyssey/funcallarchitect/execution"
+)
+
+// StructuredResult is the machine-consumable result of a main call.
+type StructuredResult struct {
+	Name    string `json:"name"`
+	Purpose string `json:"purpose,omitempty"`
+	Present bool   `json:"present"`
+	// Type is the return type in the definition of the function.
+	Type string `json:"type,omitempty"`
+	// Value is the JSON encoding of the value, set when Present.
+	Value json.RawMessage `json:"value,omitempty"`
+	// ValueType is the registered name of the Go type of the value, if any
+	// (see codec.RegisterValue).
+	ValueType string `json:"value_type,omitempty"`
+	Render    string `json:"render,omitempty"`
+	// Text is the formatted result, secondary to Value.
+	Text string `json:"text,omitempty"`
+}
+
+// structuredResults returns the structured results of the main calls, and
+// warnings about the values not conforming to the return types.
+func (a *RequestHandler) structuredResults(ctx context.Context, exec *execution.Result) ([]StructuredResult, []string) {
+	ts := a.orchestrator.ToolSet()
+	results := make([]StructuredResult, 0, len(exec.FuncCalls))
+	var warnings []string
+	for _, f := range exec.FuncCalls {
+		r := StructuredResult{
+			Name:    f.Name,
+			Purpose: f.Purpose,
+			Present: f.Result.Present,
+			Render:  f.Render,
+		}
+		if text, err := formatOnce(f); err == nil {
+			r.Text = text
+		}
+		def, defined := ts.FindTool(f.Name)
+		if defined {
+			r.Type = def.Returns.Type
+		}
+		if f.Result.Present {
+			tv, err := codec.EncodeValue(f.Result.Value)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("the result of %s cannot be encoded: %v", f.Name, err))
+			} else {
+				r.Value, r.ValueType = tv.Data, tv.Type
+			}
+			_, spilled := f.Result.Value.(blob.Artifact)
+			if err == nil && defined && !spilled {
+				if err := ts.ValidateValue(def.Returns, f.Result.Value); err != nil {
+					warnings = append(warnings, fmt.Sprintf("the result of %s does not conform to its return type: %v", f.Name, err))
+				}
+			}
+		}
+		results = append(results, r)
+	}
+	for _, w := range warnings {
+		a.logger(ctx).Printf("Warning: %s", w)
+	}
+	return results, warnings
+}
+
+// formatOnce formats the result of the call, replacing its FormatFunc with
+// one returning the same text, so that formatting it again costs nothing.
+func formatOnce(f *execution.ExecutedFuncCall) (string, error) {
+	if f.Result.FormatFunc == nil {
+		return "", nil
+	}
+	text, err := f.Result.FormatFunc()
+	if err != nil {
+		return "", err
+	}
+	f.Result.FormatFunc = func() (string, error) { return text, nil }
+	return text, nil
+}
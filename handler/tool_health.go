@@ -0,0 +1,56 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// planningTools returns the tools offered to the planner, omitting the functions
+// that ToolHealth reports as unhealthy, together with a warning for each of them.
+func (a *RequestHandler) planningTools() (*tools.ToolSet, []string) {
+	ts := a.config.Tools.AvailableTools()
+	if a.config.ToolHealth == nil {
+		return ts, nil
+	}
+	unhealthy := a.config.ToolHealth.Unhealthy()
+	if len(unhealthy) == 0 {
+		return ts, nil
+	}
+
+	var warnings []string
+	filtered := &tools.ToolSet{TypeDefinitions: ts.TypeDefinitions}
+	for _, f := range ts.Functions {
+		if slices.Contains(unhealthy, f.Name) {
+			warnings = append(warnings, fmt.Sprintf("%s is temporarily unavailable and was excluded from planning", f.Name))
+			continue
+		}
+		filtered.Functions = append(filtered.Functions, f)
+	}
+	return filtered, warnings
+}
+
+// appendWarnings appends the warnings not already present.
+func appendWarnings(warnings []string, more ...string) []string {
+	for _, w := range more {
+		if !slices.Contains(warnings, w) {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
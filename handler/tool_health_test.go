@@ -0,0 +1,126 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/testkit"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// weatherTools has get_weather taking the coordinates produced by get_coordinates.
+type weatherTools struct{}
+
+func (weatherTools) AvailableTools() *tools.ToolSet {
+	return &tools.ToolSet{
+		Functions: []tools.FuncDefinition{
+			{
+				Name:        "get_coordinates",
+				Description: "Return the coordinates of a place.",
+				Parameters: tools.TypeInfo{
+					Type:       "object",
+					Properties: map[string]tools.TypeInfo{"place": {Type: "string"}},
+					Required:   []string{"place"},
+				},
+				Returns: tools.TypeInfo{Type: "coordinates"},
+			},
+			{
+				Name:        "get_weather",
+				Description: "Return the weather at the coordinates.",
+				Parameters: tools.TypeInfo{
+					Type:       "object",
+					Properties: map[string]tools.TypeInfo{"location": {Type: "coordinates"}},
+					Required:   []string{"location"},
+				},
+				Returns: tools.TypeInfo{Type: "string"},
+			},
+		},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"coordinates": {Type: "string", Description: "Latitude and longitude"},
+		},
+	}
+}
+
+func (weatherTools) RegisterWith(o *execution.Orchestrator) error {
+	o.RegisterFunction("get_coordinates", func(context.Context, map[string]any, progress.Stream) (execution.FuncResult, error) {
+		return execution.FuncResult{}, errors.New("geocoder down")
+	})
+	o.RegisterFunction("get_weather", func(_ context.Context, args map[string]any, _ progress.Stream) (execution.FuncResult, error) {
+		text := "sunny at " + args["location"].(string)
+		return execution.FuncResult{Present: true, Value: text, FormatFunc: func() (string, error) { return text, nil }}, nil
+	})
+	return nil
+}
+
+var refPattern = regexp.MustCompile(`"\$ref":"#/\$defs/([^"]+)"`)
+
+func TestUnhealthyProducerIsExcludedFromPlanning(t *testing.T) {
+	health := execution.NewHealthTracker()
+	for i := 0; i < health.FailureThreshold; i++ {
+		health.Record("get_coordinates", errors.New("geocoder down"))
+	}
+	completer := testkit.NewCompleter(testkit.Plan(testkit.Call("get_weather", map[string]any{"location": "45.07,7.69"})))
+	h, err := handler.NewRequestHandler(handler.RequestHandlerConfig{
+		Logger:              log.New(io.Discard, "", 0),
+		LLMClient:           completer,
+		Tools:               weatherTools{},
+		Timeout:             time.Second,
+		DisableBuiltinTools: true,
+		ToolHealth:          health,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := h.ProcessUserRequest(context.Background(), "weather at 45.07,7.69", &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Output != "sunny at 45.07,7.69" {
+		t.Errorf("output is %q", result.Output)
+	}
+	if want := "get_coordinates is temporarily unavailable and was excluded from planning"; !slices.Contains(result.Warnings, want) {
+		t.Errorf("warnings %v lack %q", result.Warnings, want)
+	}
+
+	// The planning schema must be valid without the producer of the coordinates
+	schema := completer.Requests()[0].JSONSchema
+	var doc struct {
+		Defs map[string]json.RawMessage `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(schema), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Defs["get_coordinates"]; ok {
+		t.Error("the unhealthy function is offered to the planner")
+	}
+	for _, m := range refPattern.FindAllStringSubmatch(schema, -1) {
+		if _, ok := doc.Defs[m[1]]; !ok {
+			t.Errorf("dangling reference to %s", m[1])
+		}
+	}
+}
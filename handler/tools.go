@@ -0,0 +1,75 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// ComposeTools combines several Tools into one. When the same function is
+// provided more than once, the Tools coming first win.
+func ComposeTools(ts ...Tools) Tools {
+	return composedTools(ts)
+}
+
+type composedTools []Tools
+
+func (c composedTools) AvailableTools() *tools.ToolSet {
+	sets := make([]*tools.ToolSet, len(c))
+	for i, t := range c {
+		sets[i] = t.AvailableTools()
+	}
+	return tools.Merge(sets...)
+}
+
+func (c composedTools) RegisterWith(ec *execution.Orchestrator) error {
+	// Register in reverse order, so that the executors of the first Tools
+	// overwrite the ones registered later under the same name.
+	for i := len(c) - 1; i >= 0; i-- {
+		if err := c[i].RegisterWith(ec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Macros returns the Tools defining the given macros. The functions of their
+// templates must be provided by other Tools.
+func Macros(macros ...tools.Macro) (Tools, error) {
+	for _, m := range macros {
+		if err := m.Validate(nil); err != nil {
+			return nil, err
+		}
+	}
+	return macroTools(macros), nil
+}
+
+type macroTools []tools.Macro
+
+func (m macroTools) AvailableTools() *tools.ToolSet {
+	ts := &tools.ToolSet{TypeDefinitions: make(map[string]tools.TypeInfo)}
+	for _, macro := range m {
+		ts.Functions = append(ts.Functions, macro.FuncDefinition)
+	}
+	return ts
+}
+
+func (m macroTools) RegisterWith(ec *execution.Orchestrator) error {
+	for _, macro := range m {
+		ec.RegisterMacro(macro)
+	}
+	return nil
+}
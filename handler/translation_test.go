@@ -0,0 +1,66 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/testkit"
+)
+
+func TestOutputLanguageTranslatesEachResultOnce(t *testing.T) {
+	var translations atomic.Int32
+	completer := testkit.NewCompleter(testkit.Plan(testkit.Call("get_weather", map[string]any{"location": "45.07,7.69"})))
+	completer.Fallback = func(messages []llm.Message, _ string) (string, error) {
+		translations.Add(1)
+		return "soleggiato", nil
+	}
+	h, err := handler.NewRequestHandler(handler.RequestHandlerConfig{
+		Logger:              log.New(io.Discard, "", 0),
+		LLMClient:           completer,
+		Tools:               weatherTools{},
+		Timeout:             time.Second,
+		DisableBuiltinTools: true,
+		OutputLanguage:      "Italian",
+		// The results are formatted before the output too
+		AlterResult: func(result *handler.ProcessingResult) error {
+			_, err := result.Execution.MainFuncResults().Format("")
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := h.ProcessUserRequest(context.Background(), "What's the weather at 45.07,7.69?", &progress.NoOp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Output, "soleggiato") {
+		t.Fatalf("output %q is not translated", result.Output)
+	}
+	if n := translations.Load(); n != 1 {
+		t.Fatalf("translated %d times, want 1", n)
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Analysis is the concurrency analysis of the execution of a record: where
+// the latency went, and how much of it the execution features not in use
+// would have saved.
+type Analysis struct {
+	// Latency is the duration of the execution stage, or the sequential
+	// execution time of the calls when the stage was not timed apart
+	// (e.g. with overlapping evaluation and execution).
+	Latency time.Duration `json:"latency"`
+	// Measured reports whether Latency is the duration of the execution stage.
+	Measured bool `json:"measured"`
+	// Work is the total time spent in the calls.
+	Work  time.Duration `json:"work"`
+	Calls int           `json:"calls"`
+	// Cached is the number of calls whose result was reused.
+	Cached int `json:"cached"`
+	// CriticalPath is the longest chain of calls waiting for each other,
+	// from a main call down to the nested call it waited for the most.
+	CriticalPath         []string      `json:"critical_path"`
+	CriticalPathDuration time.Duration `json:"critical_path_duration"`
+	// SequentialIdle is the time the calls waited for independent nested
+	// calls resolved one after the other.
+	SequentialIdle time.Duration `json:"sequential_idle"`
+	// Bottleneck is the call taking the most time.
+	Bottleneck         string        `json:"bottleneck,omitempty"`
+	BottleneckDuration time.Duration `json:"bottleneck_duration,omitempty"`
+	// Estimates are the latencies the execution would have had with other
+	// execution features.
+	Estimates []Estimate `json:"estimates,omitempty"`
+	// Timeline is the tree of the calls, depth first.
+	Timeline []TimelineEntry `json:"timeline,omitempty"`
+}
+
+// Estimate is the latency estimated for an execution feature.
+type Estimate struct {
+	Feature string        `json:"feature"`
+	Latency time.Duration `json:"latency"`
+	// Saving is the reduction of the latency, zero when the feature would
+	// not have helped, or was in use already.
+	Saving time.Duration `json:"saving"`
+	Note   string        `json:"note,omitempty"`
+}
+
+// TimelineEntry is a call of the Timeline.
+type TimelineEntry struct {
+	// Path is the name of the call, prefixed with the argument of the parent
+	// call receiving its result, for nested calls.
+	Path  string `json:"path"`
+	Depth int    `json:"depth"`
+	// Duration is the time spent in the call itself; Subtree includes its
+	// nested calls, resolved sequentially.
+	Duration time.Duration `json:"duration"`
+	Subtree  time.Duration `json:"subtree"`
+	Cached   bool          `json:"cached,omitempty"`
+	Critical bool          `json:"critical,omitempty"`
+}
+
+// Analyze computes the concurrency analysis of the record.
+func (r *Record) Analyze() *Analysis {
+	a := &Analysis{}
+	var sequential, concurrent, dag time.Duration
+	var criticalRoot *Call
+	for i := range r.Calls {
+		c := &r.Calls[i]
+		seq, crit := serialTime(c), criticalTime(c)
+		sequential += seq
+		concurrent = max(concurrent, seq)
+		if crit > dag || criticalRoot == nil {
+			dag, criticalRoot = crit, c
+		}
+		a.SequentialIdle += seq - crit
+		a.walk(c, 0)
+	}
+	if criticalRoot != nil {
+		a.CriticalPathDuration = dag
+		critical := make(map[*Call]bool)
+		for c := criticalRoot; c != nil; c = slowestNested(c) {
+			a.CriticalPath = append(a.CriticalPath, c.Name)
+			critical[c] = true
+		}
+		a.markCritical(r.Calls, critical)
+	}
+
+	a.Latency = sequential
+	if d, ok := r.stageDuration("execution"); ok {
+		a.Latency, a.Measured = d, true
+	}
+	if len(r.Calls) > 1 {
+		a.addEstimate("concurrent_execution", concurrent, "main calls executed concurrently, nested calls still sequential")
+	}
+	a.addEstimate("dag_scheduling", dag, "independent calls executed concurrently at every level")
+	if evaluation, ok := r.stageDuration("evaluation"); ok && a.Measured {
+		note := "execution started during the evaluation; only for plans without side effects"
+		if strings.Contains(","+r.Flags+",", ",speculative_execution,") {
+			note = "in use"
+			evaluation = 0
+		}
+		// The execution overlaps the evaluation: the longest of the two remains
+		speculative := a.Latency - min(evaluation, a.Latency)
+		a.addEstimate("speculative_execution", speculative, note)
+	}
+	return a
+}
+
+func (a *Analysis) addEstimate(feature string, latency time.Duration, note string) {
+	a.Estimates = append(a.Estimates, Estimate{
+		Feature: feature,
+		Latency: latency,
+		Saving:  max(a.Latency-latency, 0),
+		Note:    note,
+	})
+}
+
+func (a *Analysis) walk(c *Call, depth int) {
+	path := c.Name
+	if c.Arg != "" {
+		path = c.Arg + ": " + c.Name
+	}
+	a.Calls++
+	a.Work += c.Duration
+	if c.Cached {
+		a.Cached++
+	}
+	if c.Duration > a.BottleneckDuration {
+		a.Bottleneck, a.BottleneckDuration = path, c.Duration
+	}
+	a.Timeline = append(a.Timeline, TimelineEntry{
+		Path:     path,
+		Depth:    depth,
+		Duration: c.Duration,
+		Subtree:  serialTime(c),
+		Cached:   c.Cached,
+	})
+	for i := range c.Calls {
+		a.walk(&c.Calls[i], depth+1)
+	}
+}
+
+// markCritical flags the timeline entries of the calls on the critical path,
+// relying on the timeline following the depth-first order of the calls.
+func (a *Analysis) markCritical(calls []Call, critical map[*Call]bool) {
+	i := 0
+	var visit func(c *Call)
+	visit = func(c *Call) {
+		a.Timeline[i].Critical = critical[c]
+		i++
+		for j := range c.Calls {
+			visit(&c.Calls[j])
+		}
+	}
+	for j := range calls {
+		visit(&calls[j])
+	}
+}
+
+func (r *Record) stageDuration(name string) (time.Duration, bool) {
+	for i := len(r.Stages) - 1; i >= 0; i-- {
+		if r.Stages[i].Name == name {
+			return r.Stages[i].Duration, true
+		}
+	}
+	return 0, false
+}
+
+// serialTime is the duration of the call with its nested calls resolved
+// sequentially, as the orchestrator does.
+func serialTime(c *Call) time.Duration {
+	d := c.Duration
+	for i := range c.Calls {
+		d += serialTime(&c.Calls[i])
+	}
+	return d
+}
+
+// criticalTime is the duration of the call with its nested calls resolved
+// concurrently.
+func criticalTime(c *Call) time.Duration {
+	var nested time.Duration
+	for i := range c.Calls {
+		nested = max(nested, criticalTime(&c.Calls[i]))
+	}
+	return c.Duration + nested
+}
+
+func slowestNested(c *Call) *Call {
+	var slowest *Call
+	var longest time.Duration
+	for i := range c.Calls {
+		if d := criticalTime(&c.Calls[i]); slowest == nil || d > longest {
+			slowest, longest = &c.Calls[i], d
+		}
+	}
+	return slowest
+}
+
+// Text renders the analysis as a human-readable report, with the timeline
+// of the calls drawn as bars.
+func (a *Analysis) Text() string {
+	var sb strings.Builder
+	latency := a.Latency.Round(time.Millisecond).String()
+	if !a.Measured {
+		latency += " (estimated, sequential)"
+	}
+	fmt.Fprintf(&sb, "Latency:         %s\n", latency)
+	fmt.Fprintf(&sb, "Work:            %s in %d calls (%d cached)\n", a.Work.Round(time.Millisecond), a.Calls, a.Cached)
+	if len(a.CriticalPath) > 0 {
+		fmt.Fprintf(&sb, "Critical path:   %s: %s\n", a.CriticalPathDuration.Round(time.Millisecond), strings.Join(a.CriticalPath, " > "))
+	}
+	fmt.Fprintf(&sb, "Sequential idle: %s\n", a.SequentialIdle.Round(time.Millisecond))
+	if a.Bottleneck != "" {
+		share := 0.0
+		if a.Work > 0 {
+			share = 100 * float64(a.BottleneckDuration) / float64(a.Work)
+		}
+		fmt.Fprintf(&sb, "Bottleneck:      %s (%s, %.0f%% of the work)\n", a.Bottleneck, a.BottleneckDuration.Round(time.Millisecond), share)
+	}
+
+	if len(a.Estimates) > 0 {
+		sb.WriteString("\nWhat if:\n")
+		for _, e := range a.Estimates {
+			fmt.Fprintf(&sb, "  %-22s %10s  -%s", e.Feature, e.Latency.Round(time.Millisecond), e.Saving.Round(time.Millisecond))
+			if e.Note != "" {
+				fmt.Fprintf(&sb, "  (%s)", e.Note)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(a.Timeline) > 0 {
+		const width = 40
+		var longest time.Duration
+		names := 0
+		for _, e := range a.Timeline {
+			longest = max(longest, e.Subtree)
+			names = max(names, 2*e.Depth+len(e.Path))
+		}
+		sb.WriteString("\nCalls (# own time, = nested calls, * critical path):\n")
+		for _, e := range a.Timeline {
+			own, nested := 0, 0
+			if longest > 0 {
+				own = int(width * e.Duration / longest)
+				nested = int(width*e.Subtree/longest) - own
+			}
+			marker := " "
+			if e.Critical {
+				marker = "*"
+			}
+			name := strings.Repeat("  ", e.Depth) + e.Path
+			bar := strings.Repeat("=", nested) + strings.Repeat("#", own)
+			fmt.Fprintf(&sb, "%s %-*s  %-*s  %s", marker, names, name, width, bar, e.Duration.Round(time.Millisecond))
+			if e.Cached {
+				sb.WriteString(" (cached)")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,305 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// The sections of a Difference.
+const (
+	DiffPlan   = "plan"
+	DiffCalls  = "calls"
+	DiffOutput = "output"
+	DiffError  = "error"
+)
+
+// Difference is a difference between two records.
+type Difference struct {
+	Section string `json:"section"`
+	// Path locates the difference in the section, e.g. "[0].args.city".
+	Path string `json:"path,omitempty"`
+	// A and B are the values in the two records, nil where missing.
+	A any `json:"a,omitempty"`
+	B any `json:"b,omitempty"`
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s%s: %s -> %s", d.Section, d.Path, diffValue(d.A), diffValue(d.B))
+}
+
+func diffValue(v any) string {
+	if v == nil {
+		return "(missing)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// RecordDiff is the structural comparison of two executions, typically of the
+// same request with different models, prompts or versions of the tools.
+type RecordDiff struct {
+	A           string `json:"a"`
+	B           string `json:"b"`
+	SameRequest bool   `json:"same_request"`
+	// SamePlanShape reports whether the last plans call the same functions
+	// with the same nesting; SamePlan whether the arguments match as well.
+	// The purposes of the calls, free text, are ignored.
+	SamePlanShape bool `json:"same_plan_shape"`
+	SamePlan      bool `json:"same_plan"`
+	// SameResults reports whether the executed calls returned the same
+	// values, and SameOutput whether the outputs are equal.
+	SameResults bool         `json:"same_results"`
+	SameOutput  bool         `json:"same_output"`
+	Differences []Difference `json:"differences,omitempty"`
+}
+
+// Diff compares the plans, the executed calls with their arguments and
+// results, the outputs and the errors of the records.
+func Diff(a, b *Record) *RecordDiff {
+	d := &RecordDiff{
+		A:           a.ID,
+		B:           b.ID,
+		SameRequest: a.Request == b.Request,
+		SameOutput:  a.Output == b.Output,
+	}
+
+	planA, planB := lastPlan(a), lastPlan(b)
+	var shape []Difference
+	diffValues(DiffPlan, "", planShape(planA), planShape(planB), &shape)
+	d.SamePlanShape = len(shape) == 0
+	n := len(d.Differences)
+	diffValues(DiffPlan, "", planA, planB, &d.Differences)
+	d.SamePlan = len(d.Differences) == n
+
+	var results []Difference
+	diffValues(DiffCalls, "", callResults(a.Calls), callResults(b.Calls), &results)
+	d.SameResults = len(results) == 0
+	diffValues(DiffCalls, "", calls(a.Calls), calls(b.Calls), &d.Differences)
+
+	if !d.SameOutput {
+		d.Differences = append(d.Differences, Difference{Section: DiffOutput, A: a.Output, B: b.Output})
+	}
+	if a.Error != b.Error {
+		d.Differences = append(d.Differences, Difference{Section: DiffError, A: nonEmpty(a.Error), B: nonEmpty(b.Error)})
+	}
+	return d
+}
+
+func nonEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// lastPlan returns the calls of the last plan, without the purposes.
+func lastPlan(r *Record) any {
+	if len(r.Plans) == 0 {
+		return nil
+	}
+	return withoutPurposes(decodeGeneric(r.Plans[len(r.Plans)-1].Calls))
+}
+
+func withoutPurposes(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		delete(v, "purpose")
+		for _, value := range v {
+			withoutPurposes(value)
+		}
+	case []any:
+		for _, item := range v {
+			withoutPurposes(item)
+		}
+	}
+	return v
+}
+
+// planShape keeps the names of the planned calls and the arguments receiving
+// nested calls.
+func planShape(v any) any {
+	switch v := v.(type) {
+	case []any:
+		shape := make([]any, len(v))
+		for i, item := range v {
+			shape[i] = planShape(item)
+		}
+		return shape
+	case map[string]any:
+		args, _ := v["args"].(map[string]any)
+		nested := make(map[string]any)
+		for name, arg := range args {
+			if isPlannedCall(arg) {
+				nested[name] = planShape(arg)
+			}
+		}
+		return map[string]any{"name": v["name"], "args": nested}
+	default:
+		return v
+	}
+}
+
+func isPlannedCall(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, hasName := m["name"]
+	_, hasArgs := m["args"]
+	return hasName && hasArgs
+}
+
+// calls returns the comparable parts of the executed calls: not the timings,
+// the cache hits or the receipts.
+func calls(executed []Call) any {
+	items := make([]map[string]any, len(executed))
+	for i, c := range executed {
+		items[i] = map[string]any{
+			"name":    c.Name,
+			"arg":     c.Arg,
+			"args":    c.Args,
+			"present": c.Present,
+			"result":  callResult(c),
+			"calls":   calls(c.Calls),
+		}
+	}
+	return encodeGeneric(items)
+}
+
+// callResults keeps the names and the results of the executed calls.
+func callResults(executed []Call) any {
+	items := make([]map[string]any, len(executed))
+	for i, c := range executed {
+		items[i] = map[string]any{
+			"name":   c.Name,
+			"result": callResult(c),
+			"calls":  callResults(c.Calls),
+		}
+	}
+	return encodeGeneric(items)
+}
+
+// callResult is the typed value of the result when recorded, its text otherwise.
+func callResult(c Call) any {
+	if c.Value != nil {
+		return c.Value.Data
+	}
+	return c.Result
+}
+
+// encodeGeneric converts v to the generic values of its JSON encoding.
+func encodeGeneric(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return decodeGeneric(data)
+}
+
+// decodeGeneric decodes the JSON data, keeping the numbers exact.
+func decodeGeneric(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return string(data)
+	}
+	return v
+}
+
+// diffValues appends to out the differences between the generic values.
+func diffValues(section, path string, a, b any, out *[]Difference) {
+	switch va := a.(type) {
+	case map[string]any:
+		vb, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(va)+len(vb))
+		for key := range va {
+			keys = append(keys, key)
+		}
+		for key := range vb {
+			if _, ok := va[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			diffValues(section, path+"."+key, va[key], vb[key], out)
+		}
+		return
+	case []any:
+		vb, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(va), len(vb)); i++ {
+			var itemA, itemB any
+			if i < len(va) {
+				itemA = va[i]
+			}
+			if i < len(vb) {
+				itemB = vb[i]
+			}
+			diffValues(section, fmt.Sprintf("%s[%d]", path, i), itemA, itemB, out)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*out = append(*out, Difference{Section: section, Path: path, A: a, B: b})
+	}
+}
+
+// Markdown renders the comparison for review.
+func (d *RecordDiff) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Comparison of %s and %s\n\n", d.A, d.B)
+	for _, check := range []struct {
+		name string
+		same bool
+	}{
+		{"Request", d.SameRequest},
+		{"Plan shape", d.SamePlanShape},
+		{"Plan", d.SamePlan},
+		{"Results", d.SameResults},
+		{"Output", d.SameOutput},
+	} {
+		verdict := "same"
+		if !check.same {
+			verdict = "different"
+		}
+		fmt.Fprintf(&b, "- **%s:** %s\n", check.name, verdict)
+	}
+	if len(d.Differences) > 0 {
+		b.WriteString("\n## Differences\n\n")
+		for _, difference := range d.Differences {
+			fmt.Fprintf(&b, "- `%s`\n", difference)
+		}
+	}
+	return b.String()
+}
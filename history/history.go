@@ -0,0 +1,376 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history stores the execution reports of the processed requests,
+// for support and debugging.
+package history
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/codec"
+	"github.com/nlpodyssey/funcallarchitect/version"
+)
+
+// ErrNotFound is returned when a record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// Record is the self-contained report of the processing of a request.
+type Record struct {
+	ID        string    `json:"id"`
+	RequestID string    `json:"request_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Request   string    `json:"request"`
+	Flags     string    `json:"flags,omitempty"`
+	// Build identifies the code processing the request.
+	Build version.Build `json:"build"`
+	// Prompts are the versions of the prompt templates in use, by name.
+	Prompts map[string]int `json:"prompts,omitempty"`
+	// Plans are the plans generated by the planner, one per attempt.
+	Plans       []Plan    `json:"plans,omitempty"`
+	Evaluations []Verdict `json:"evaluations,omitempty"`
+	// Calls are the main calls executed, with their nested calls.
+	Calls    []Call        `json:"calls,omitempty"`
+	Stages   []Stage       `json:"stages,omitempty"`
+	Duration time.Duration `json:"duration"`
+	LLM      Usage         `json:"llm"`
+	Warnings []string      `json:"warnings,omitempty"`
+	// Reasoning are the reasoning segments of the completions, for the LLM
+	// clients capturing them (see llm.ReasoningReporter).
+	Reasoning []string `json:"reasoning,omitempty"`
+	// Output is the answer or the formatted results returned to the user.
+	Output string `json:"output,omitempty"`
+	// Error is set when the processing failed.
+	Error string `json:"error,omitempty"`
+	// Feedback is the feedback of the users on the execution.
+	Feedback []Feedback `json:"feedback,omitempty"`
+}
+
+// Plan is a plan generated by the planner.
+type Plan struct {
+	Completion string          `json:"completion"`
+	Calls      json.RawMessage `json:"calls,omitempty"`
+	// ReusedFrom is the previous request whose cached plan was adapted, if any.
+	ReusedFrom string `json:"reused_from,omitempty"`
+	// Rule is the rule of the rules planner producing the plan, if any.
+	Rule string `json:"rule,omitempty"`
+	// Edited reports whether the plan was submitted by an operator,
+	// rather than generated.
+	Edited bool `json:"edited,omitempty"`
+}
+
+// Verdict is the evaluation of a planned call.
+type Verdict struct {
+	Call json.RawMessage `json:"call"`
+	// Tools are the functions used by the call, nested ones included.
+	Tools      []string `json:"tools,omitempty"`
+	Consistent bool     `json:"consistent"`
+}
+
+// Call is an executed function call.
+type Call struct {
+	Name    string `json:"name"`
+	Purpose string `json:"purpose,omitempty"`
+	// Arg is the argument of the parent call receiving the result, for nested calls.
+	Arg string `json:"arg,omitempty"`
+	// Args are the literal arguments.
+	Args     map[string]any `json:"args,omitempty"`
+	Duration time.Duration  `json:"duration"`
+	Cached   bool           `json:"cached,omitempty"`
+	Present  bool           `json:"present"`
+	Result   string         `json:"result,omitempty"`
+	// Value is the result value, typed when its type is registered with
+	// codec.RegisterValue.
+	Value   *codec.TypedValue `json:"value,omitempty"`
+	Render  string            `json:"render,omitempty"`
+	Receipt string            `json:"receipt,omitempty"`
+	Calls   []Call            `json:"calls,omitempty"`
+}
+
+// Stage is the timing of a pipeline stage.
+type Stage struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Usage is the cost of the LLM completions requested by the pipeline.
+type Usage struct {
+	Completions int           `json:"completions"`
+	Duration    time.Duration `json:"duration"`
+	// The tokens are counted for the LLM clients reporting them
+	// (see llm.UsageReporter).
+	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+}
+
+// Rating is the judgement of a user on an execution.
+type Rating string
+
+const (
+	ThumbsUp   Rating = "up"
+	ThumbsDown Rating = "down"
+)
+
+// Feedback is the feedback of a user on an execution.
+type Feedback struct {
+	Rating    Rating    `json:"rating"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddFeedback attaches the feedback to the record with the given ID,
+// setting its creation time if missing.
+func AddFeedback(ctx context.Context, store Store, id string, feedback Feedback) error {
+	if feedback.Rating != ThumbsUp && feedback.Rating != ThumbsDown {
+		return fmt.Errorf("invalid rating %q", feedback.Rating)
+	}
+	if feedback.CreatedAt.IsZero() {
+		feedback.CreatedAt = time.Now()
+	}
+	return store.Update(ctx, id, func(r *Record) error {
+		r.Feedback = append(r.Feedback, feedback)
+		return nil
+	})
+}
+
+// NewID returns a new random record ID.
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Store persists the records.
+type Store interface {
+	Put(ctx context.Context, record *Record) error
+	// Get returns the record with the given ID, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Record, error)
+	// List returns all the records, oldest first.
+	List(ctx context.Context) ([]*Record, error)
+	// Update applies fn to the record with the given ID and stores the result
+	// atomically, or returns ErrNotFound.
+	Update(ctx context.Context, id string, fn func(r *Record) error) error
+}
+
+// MemoryStore is an in-memory Store, mostly useful for testing.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string][]byte
+}
+
+// NewMemoryStore creates a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string][]byte)}
+}
+
+func (m *MemoryStore) Put(_ context.Context, record *Record) error {
+	// Records are stored encoded, so that callers can't modify them
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error encoding record: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[record.ID] = data
+	return nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, id string) (*Record, error) {
+	m.mu.RLock()
+	data, ok := m.records[id]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return decode(data)
+}
+
+func (m *MemoryStore) Update(ctx context.Context, id string, fn func(r *Record) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	record, err := decode(data)
+	if err != nil {
+		return err
+	}
+	if err := fn(record); err != nil {
+		return err
+	}
+	record.ID = id
+	if data, err = json.Marshal(record); err != nil {
+		return fmt.Errorf("error encoding record: %w", err)
+	}
+	m.records[id] = data
+	return nil
+}
+
+func (m *MemoryStore) List(_ context.Context) ([]*Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := make([]*Record, 0, len(m.records))
+	for _, data := range m.records {
+		record, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// DirStore is a Store keeping one JSON file per record in a directory.
+// Updates are atomic within the process only.
+type DirStore struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// NewDirStore creates the directory if needed and returns a DirStore using it.
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating history directory: %w", err)
+	}
+	return &DirStore{Dir: dir}, nil
+}
+
+func (d *DirStore) Put(_ context.Context, record *Record) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.put(record)
+}
+
+func (d *DirStore) put(record *Record) error {
+	if !isValidID(record.ID) {
+		return fmt.Errorf("invalid record ID %q", record.ID)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error encoding record: %w", err)
+	}
+
+	// Write to a temporary file first, so that readers never see partial records
+	tmp, err := os.CreateTemp(d.Dir, record.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), d.path(record.ID))
+}
+
+func (d *DirStore) Get(_ context.Context, id string) (*Record, error) {
+	return d.get(id)
+}
+
+func (d *DirStore) Update(_ context.Context, id string, fn func(r *Record) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	record, err := d.get(id)
+	if err != nil {
+		return err
+	}
+	if err := fn(record); err != nil {
+		return err
+	}
+	record.ID = id
+	return d.put(record)
+}
+
+func (d *DirStore) get(id string) (*Record, error) {
+	if !isValidID(id) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(d.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+func (d *DirStore) List(_ context.Context) ([]*Record, error) {
+	paths, err := filepath.Glob(filepath.Join(d.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		record, err := decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		records = append(records, record)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+func (d *DirStore) path(id string) string {
+	return filepath.Join(d.Dir, id+".json")
+}
+
+func decode(data []byte) (*Record, error) {
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("error decoding record: %w", err)
+	}
+	return &record, nil
+}
+
+func sortRecords(records []*Record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+}
+
+// isValidID reports whether id has the format of NewID, which also prevents
+// path traversal through crafted IDs.
+func isValidID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Markdown renders the record as a human-readable report.
+func (r *Record) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Execution %s\n\n", r.ID)
+	fmt.Fprintf(&sb, "- **Date:** %s\n", r.CreatedAt.Format(time.RFC3339))
+	if r.RequestID != "" {
+		fmt.Fprintf(&sb, "- **Request ID:** %s\n", r.RequestID)
+	}
+	if r.SessionID != "" {
+		fmt.Fprintf(&sb, "- **Session:** %s\n", r.SessionID)
+	}
+	if r.Flags != "" {
+		fmt.Fprintf(&sb, "- **Flags:** %s\n", r.Flags)
+	}
+	if r.Build.Version != "" {
+		build := r.Build.Version
+		if r.Build.Commit != "" {
+			build += " " + r.Build.Commit
+		}
+		if r.Build.Modified {
+			build += " (modified)"
+		}
+		fmt.Fprintf(&sb, "- **Build:** %s, schema %d\n", build, r.Build.Schema)
+	}
+	if len(r.Prompts) > 0 {
+		names := make([]string, 0, len(r.Prompts))
+		for name := range r.Prompts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			names[i] = fmt.Sprintf("%s v%d", name, r.Prompts[name])
+		}
+		fmt.Fprintf(&sb, "- **Prompts:** %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&sb, "- **Duration:** %s\n", r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&sb, "- **LLM completions:** %d (%s)\n", r.LLM.Completions, r.LLM.Duration.Round(time.Millisecond))
+	if r.LLM.PromptTokens > 0 || r.LLM.CompletionTokens > 0 {
+		fmt.Fprintf(&sb, "- **LLM tokens:** %d prompt, %d completion\n", r.LLM.PromptTokens, r.LLM.CompletionTokens)
+	}
+	if r.Error != "" {
+		fmt.Fprintf(&sb, "- **Error:** %s\n", r.Error)
+	}
+
+	fmt.Fprintf(&sb, "\n## Request\n\n%s\n", quote(r.Request))
+
+	if len(r.Warnings) > 0 {
+		sb.WriteString("\n## Warnings\n\n")
+		for _, w := range r.Warnings {
+			fmt.Fprintf(&sb, "- %s\n", w)
+		}
+	}
+
+	for i, plan := range r.Plans {
+		if len(r.Plans) == 1 {
+			sb.WriteString("\n## Plan\n\n")
+		} else {
+			fmt.Fprintf(&sb, "\n## Plan (attempt %d)\n\n", i+1)
+		}
+		if plan.Edited {
+			sb.WriteString("Edited by an operator.\n\n")
+		}
+		if plan.Rule != "" {
+			fmt.Fprintf(&sb, "Planned by rule %s.\n\n", plan.Rule)
+		}
+		if plan.ReusedFrom != "" {
+			fmt.Fprintf(&sb, "Adapted from the plan of:\n\n%s\n\n", quote(plan.ReusedFrom))
+		}
+		code(&sb, "json", indent(plan.Calls, plan.Completion))
+	}
+
+	if len(r.Evaluations) > 0 {
+		sb.WriteString("\n## Evaluation\n\n| Call | Consistent |\n| --- | --- |\n")
+		for _, v := range r.Evaluations {
+			var call struct {
+				Name string `json:"name"`
+			}
+			_ = json.Unmarshal(v.Call, &call)
+			fmt.Fprintf(&sb, "| `%s` | %t |\n", call.Name, v.Consistent)
+		}
+	}
+
+	if len(r.Reasoning) > 0 {
+		sb.WriteString("\n## Reasoning\n")
+		for i, reasoning := range r.Reasoning {
+			fmt.Fprintf(&sb, "\n### Completion %d\n\n%s\n", i+1, quote(reasoning))
+		}
+	}
+
+	if len(r.Calls) > 0 {
+		sb.WriteString("\n## Calls\n\n| Call | Duration | Cached | Found |\n| --- | --- | --- | --- |\n")
+		walkCalls(r.Calls, 0, func(c Call, depth int) {
+			name := strings.Repeat("↳ ", depth) + "`" + c.Name + "`"
+			if c.Arg != "" {
+				name += " (" + c.Arg + ")"
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %t | %t |\n", name, c.Duration.Round(time.Microsecond), c.Cached, c.Present)
+		})
+
+		sb.WriteString("\n## Results\n")
+		for _, c := range r.Calls {
+			fmt.Fprintf(&sb, "\n### %s\n\n", c.Name)
+			if c.Purpose != "" {
+				fmt.Fprintf(&sb, "_%s_\n\n", c.Purpose)
+			}
+			sb.WriteString(c.Result)
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(r.Stages) > 0 {
+		sb.WriteString("\n## Stages\n\n| Stage | Duration |\n| --- | --- |\n")
+		for _, s := range r.Stages {
+			fmt.Fprintf(&sb, "| %s | %s |\n", s.Name, s.Duration.Round(time.Millisecond))
+		}
+	}
+
+	if r.Output != "" {
+		fmt.Fprintf(&sb, "\n## Output\n\n%s\n", r.Output)
+	}
+
+	if len(r.Feedback) > 0 {
+		sb.WriteString("\n## Feedback\n\n")
+		for _, f := range r.Feedback {
+			icon := "👍"
+			if f.Rating == ThumbsDown {
+				icon = "👎"
+			}
+			fmt.Fprintf(&sb, "- %s %s", icon, f.CreatedAt.Format(time.RFC3339))
+			if f.Comment != "" {
+				fmt.Fprintf(&sb, ": %s", f.Comment)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func walkCalls(calls []Call, depth int, fn func(c Call, depth int)) {
+	for _, c := range calls {
+		fn(c, depth)
+		walkCalls(c.Calls, depth+1, fn)
+	}
+}
+
+func quote(s string) string {
+	return "> " + strings.ReplaceAll(s, "\n", "\n> ")
+}
+
+func code(sb *strings.Builder, lang, s string) {
+	fmt.Fprintf(sb, "```%s\n%s\n```\n", lang, strings.TrimRight(s, "\n"))
+}
+
+// indent returns data indented, or fallback if it is empty or invalid.
+func indent(data json.RawMessage, fallback string) string {
+	if len(data) == 0 {
+		return fallback
+	}
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fallback
+	}
+	return string(b)
+}
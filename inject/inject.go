@@ -0,0 +1,305 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inject builds the Tools of an application, and their
+// dependencies, from constructors declaring what they need as parameters.
+//
+// Large toolsets tend to share configurations, HTTP clients, stores and
+// embedders among dozens of providers. Registering their constructors in a
+// Container spares wiring them by hand:
+//
+//	c := inject.New()
+//	c.Supply(webtool.FetcherConfig{UserAgent: "my-agent"}, myEmbedder)
+//	c.Provide(webtool.NewFetcher)
+//	c.Provide(func() webtool.SearchProvider { return webtool.NewSearXNG(endpoint) })
+//	c.Provide(webtool.New)
+//	c.Provide(ragtool.NewMemoryStore)
+//	c.Provide(ragtool.New)
+//	ts, err := c.Tools()
+//
+// Each type is built at most once, on first use, and shared by all the
+// constructors depending on it. In tests, the dependencies of a provider can
+// be supplied as fakes in their place.
+package inject
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/nlpodyssey/funcallarchitect/handler"
+)
+
+var (
+	errorType = reflect.TypeFor[error]()
+	toolsType = reflect.TypeFor[handler.Tools]()
+)
+
+// Container holds the constructors and the values built from them.
+// It is safe for concurrent use.
+type Container struct {
+	mu sync.Mutex
+	// providers are in registration order, which is the order of Tools.
+	providers []*provider
+	byType    map[reflect.Type]*provider
+}
+
+type provider struct {
+	typ         reflect.Type
+	constructor reflect.Value // invalid for supplied values
+	value       reflect.Value
+	built       bool
+}
+
+// New returns an empty Container.
+func New() *Container {
+	return &Container{byType: make(map[reflect.Type]*provider)}
+}
+
+// Provide registers constructors. A constructor is a function returning the
+// value it provides, optionally followed by an error; its parameters are the
+// dependencies, resolved within the Container. Interface parameters accept
+// the only provided type implementing them, when not provided themselves.
+func (c *Container) Provide(constructors ...any) error {
+	for _, constructor := range constructors {
+		fn := reflect.ValueOf(constructor)
+		if fn.Kind() != reflect.Func || fn.IsNil() {
+			return fmt.Errorf("constructor %T is not a function", constructor)
+		}
+		t := fn.Type()
+		if t.IsVariadic() {
+			return fmt.Errorf("constructor %v cannot be variadic", t)
+		}
+		if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+			return fmt.Errorf("constructor %v must return a value, optionally followed by an error", t)
+		}
+		if err := c.add(&provider{typ: t.Out(0), constructor: fn}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Supply registers ready values, provided as their dynamic type.
+func (c *Container) Supply(values ...any) error {
+	for _, v := range values {
+		if v == nil {
+			return errors.New("cannot supply a nil value")
+		}
+		value := reflect.ValueOf(v)
+		if err := c.add(&provider{typ: value.Type(), value: value, built: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SupplyAs registers a ready value as the type T, typically an interface.
+func SupplyAs[T any](c *Container, v T) error {
+	return c.add(&provider{typ: reflect.TypeFor[T](), value: reflect.ValueOf(&v).Elem(), built: true})
+}
+
+func (c *Container) add(p *provider) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.byType[p.typ]; ok {
+		return fmt.Errorf("type %v provided twice", p.typ)
+	}
+	c.byType[p.typ] = p
+	c.providers = append(c.providers, p)
+	return nil
+}
+
+// Resolve returns the value of type T, building it and its dependencies
+// if needed.
+func Resolve[T any](c *Container) (T, error) {
+	var zero T
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, err := c.resolve(reflect.TypeFor[T](), nil)
+	if err != nil {
+		return zero, err
+	}
+	return v.Interface().(T), nil
+}
+
+// Invoke calls fn with its parameters resolved within the Container.
+// If the last result of fn is an error, it is returned.
+func (c *Container) Invoke(fn any) error {
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func || f.IsNil() {
+		return fmt.Errorf("%T is not a function", fn)
+	}
+	c.mu.Lock()
+	args, err := c.arguments(f.Type(), nil)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	out := f.Call(args)
+	if n := len(out); n > 0 && f.Type().Out(n-1) == errorType && !out[n-1].IsNil() {
+		return out[n-1].Interface().(error)
+	}
+	return nil
+}
+
+// Tools builds every provided value implementing handler.Tools and composes
+// them in registration order, so that the first provided wins on conflicts.
+func (c *Container) Tools() (handler.Tools, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ts []handler.Tools
+	for _, p := range c.providers {
+		if !p.typ.Implements(toolsType) {
+			continue
+		}
+		v, err := c.build(p, nil)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, v.Interface().(handler.Tools))
+	}
+	return handler.ComposeTools(ts...), nil
+}
+
+// Validate checks that the dependencies of every constructor can be
+// resolved, without building anything.
+func (c *Container) Validate() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for _, p := range c.providers {
+		if err := c.check(p, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Container) check(p *provider, path []reflect.Type) error {
+	if p.built {
+		return nil
+	}
+	if err := cycle(p.typ, path); err != nil {
+		return err
+	}
+	t := p.constructor.Type()
+	for i := 0; i < t.NumIn(); i++ {
+		dep, err := c.lookup(t.In(i), p.typ)
+		if err != nil {
+			return err
+		}
+		if err := c.check(dep, append(path, p.typ)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Container) resolve(t reflect.Type, path []reflect.Type) (reflect.Value, error) {
+	var dependent reflect.Type
+	if len(path) > 0 {
+		dependent = path[len(path)-1]
+	}
+	p, err := c.lookup(t, dependent)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v, err := c.build(p, path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if p.typ != t {
+		v = v.Convert(t)
+	}
+	return v, nil
+}
+
+// lookup returns the provider of t: the one of exactly t, or the only one
+// implementing the interface t.
+func (c *Container) lookup(t, dependent reflect.Type) (*provider, error) {
+	if p, ok := c.byType[t]; ok {
+		return p, nil
+	}
+	var found []*provider
+	if t.Kind() == reflect.Interface {
+		for _, p := range c.providers {
+			if p.typ.Implements(t) {
+				found = append(found, p)
+			}
+		}
+	}
+	switch len(found) {
+	case 1:
+		return found[0], nil
+	case 0:
+		if dependent == nil {
+			return nil, fmt.Errorf("no provider of %v", t)
+		}
+		return nil, fmt.Errorf("no provider of %v, needed by %v", t, dependent)
+	default:
+		names := make([]string, len(found))
+		for i, p := range found {
+			names[i] = p.typ.String()
+		}
+		return nil, fmt.Errorf("ambiguous providers of %v: %s", t, strings.Join(names, ", "))
+	}
+}
+
+func (c *Container) build(p *provider, path []reflect.Type) (reflect.Value, error) {
+	if p.built {
+		return p.value, nil
+	}
+	if err := cycle(p.typ, path); err != nil {
+		return reflect.Value{}, err
+	}
+	args, err := c.arguments(p.constructor.Type(), append(path, p.typ))
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	out := p.constructor.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("error constructing %v: %w", p.typ, out[1].Interface().(error))
+	}
+	p.value, p.built = out[0], true
+	return p.value, nil
+}
+
+func (c *Container) arguments(t reflect.Type, path []reflect.Type) ([]reflect.Value, error) {
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		v, err := c.resolve(t.In(i), path)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+func cycle(t reflect.Type, path []reflect.Type) error {
+	for i, dep := range path {
+		if dep != t {
+			continue
+		}
+		names := make([]string, 0, len(path)-i+1)
+		for _, d := range path[i:] {
+			names = append(names, d.String())
+		}
+		return fmt.Errorf("dependency cycle: %s -> %v", strings.Join(names, " -> "), t)
+	}
+	return nil
+}
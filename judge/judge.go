@@ -0,0 +1,201 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package judge evaluates contents produced for a user request, such as planned
+// function calls, final answers and tool outputs, against rubrics of criteria,
+// through a language model.
+package judge
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+)
+
+// Criterion is a criterion of a Rubric.
+type Criterion struct {
+	// Name identifies the criterion in the verdicts, e.g. "grounded".
+	Name string `json:"name"`
+	// Description is what the judge checks, e.g. "Every fact of the answer
+	// comes from the results."
+	Description string `json:"description"`
+	// Advisory criteria are reported without failing the verdict.
+	Advisory bool `json:"advisory,omitempty"`
+}
+
+// Rubric is the set of criteria a content is judged against.
+type Rubric struct {
+	// Subject names what is judged in the prompt, e.g. "answer".
+	Subject  string      `json:"subject"`
+	Criteria []Criterion `json:"criteria"`
+}
+
+// The built-in rubrics.
+var (
+	// PlanRubric judges a planned function call, with the definitions of the
+	// functions it uses as context.
+	PlanRubric = Rubric{
+		Subject: "function call",
+		Criteria: []Criterion{
+			{Name: "relevant", Description: "The function, and its nested calls, serve what the user asked for."},
+			{Name: "arguments", Description: "The arguments match the request and the definitions, with no invented values."},
+			{Name: "complete", Description: "Nothing the call needs from the request is missing."},
+		},
+	}
+	// AnswerRubric judges a final answer, with the results of the function
+	// calls as context.
+	AnswerRubric = Rubric{
+		Subject: "answer",
+		Criteria: []Criterion{
+			{Name: "grounded", Description: "Every fact of the answer comes from the context."},
+			{Name: "responsive", Description: "The answer addresses what the user asked for."},
+			{Name: "concise", Description: "The answer has no irrelevant or repeated content.", Advisory: true},
+		},
+	}
+	// ToolOutputRubric judges the output of a tool, with the call producing
+	// it as context.
+	ToolOutputRubric = Rubric{
+		Subject: "tool output",
+		Criteria: []Criterion{
+			{Name: "valid", Description: "The output is a meaningful result, not an error message or a placeholder."},
+			{Name: "relevant", Description: "The output is about what the call asked for."},
+		},
+	}
+)
+
+var criterionName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Validate checks that the rubric has criteria with distinct names made of
+// lowercase letters, digits and underscores, usable as JSON schema properties.
+func (r Rubric) Validate() error {
+	if r.Subject == "" {
+		return fmt.Errorf("rubric without subject")
+	}
+	if len(r.Criteria) == 0 {
+		return fmt.Errorf("rubric without criteria")
+	}
+	seen := make(map[string]bool, len(r.Criteria))
+	for _, c := range r.Criteria {
+		if !criterionName.MatchString(c.Name) {
+			return fmt.Errorf("invalid criterion name %q", c.Name)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("duplicate criterion %q", c.Name)
+		}
+		seen[c.Name] = true
+	}
+	return nil
+}
+
+// Input is what the judge is given.
+type Input struct {
+	// Request is the user request the content was produced for.
+	Request string
+	// Content is what is judged.
+	Content string
+	// Context, optional, is the material the content was produced from, such
+	// as the function definitions of a plan or the results of an answer.
+	Context string
+}
+
+// CriterionVerdict is the verdict on a criterion.
+type CriterionVerdict struct {
+	Criterion string `json:"criterion"`
+	Pass      bool   `json:"pass"`
+	Reason    string `json:"reason"`
+	Advisory  bool   `json:"advisory,omitempty"`
+}
+
+// Verdict is the judgement of a content.
+type Verdict struct {
+	// Pass reports whether every criterion that is not advisory passed.
+	Pass bool `json:"pass"`
+	// Criteria are the verdicts in the order of the rubric.
+	Criteria []CriterionVerdict `json:"criteria"`
+}
+
+// Failed returns the verdicts of the criteria not met, advisory ones included.
+func (v *Verdict) Failed() []CriterionVerdict {
+	var failed []CriterionVerdict
+	for _, c := range v.Criteria {
+		if !c.Pass {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// Judge judges contents against a rubric.
+type Judge struct {
+	Completer llm.Completer
+	Rubric    Rubric
+	// Prompts pins the version of the judge prompt. Defaults to the latest.
+	Prompts prompt.Pins
+}
+
+// New creates a Judge after validating the rubric.
+func New(completer llm.Completer, rubric Rubric) (*Judge, error) {
+	if err := rubric.Validate(); err != nil {
+		return nil, err
+	}
+	return &Judge{Completer: completer, Rubric: rubric}, nil
+}
+
+// Judge asks the model for a verdict on each criterion of the rubric.
+func (j *Judge) Judge(input Input) (*Verdict, error) {
+	criteria := make([]prompt.JudgeCriterion, len(j.Rubric.Criteria))
+	names := make([]string, len(j.Rubric.Criteria))
+	for i, c := range j.Rubric.Criteria {
+		criteria[i] = prompt.JudgeCriterion{Name: c.Name, Description: c.Description}
+		names[i] = c.Name
+	}
+	userPrompt, err := j.Prompts.CreatePromptForJudge(j.Rubric.Subject, input.Request, input.Context, input.Content, criteria)
+	if err != nil {
+		return nil, fmt.Errorf("error generating prompt for judge: %w", err)
+	}
+	jsonSchema, err := json.Marshal(prompt.JudgeResponseSchema(names))
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling schema: %w", err)
+	}
+	body, err := j.Completer.Complete([]llm.Message{{"user", userPrompt}}, string(jsonSchema))
+	if err != nil {
+		return nil, fmt.Errorf("error generating verdict: %w", err)
+	}
+
+	var response struct {
+		Criteria map[string]struct {
+			Reason string `json:"reason"`
+			Pass   bool   `json:"pass"`
+		} `json:"criteria"`
+	}
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling JSON: %w", err)
+	}
+	verdict := &Verdict{Pass: true}
+	for _, c := range j.Rubric.Criteria {
+		r, ok := response.Criteria[c.Name]
+		if !ok {
+			// Without structured output, the model may skip criteria
+			r.Reason = "no verdict given"
+		}
+		verdict.Criteria = append(verdict.Criteria, CriterionVerdict{Criterion: c.Name, Pass: r.Pass, Reason: r.Reason, Advisory: c.Advisory})
+		if !r.Pass && !c.Advisory {
+			verdict.Pass = false
+		}
+	}
+	return verdict, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llamacpp
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/nlpodyssey/funcallarchitect/builtin"
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+func benchmarkJSONSchema(b *testing.B) string {
+	b.Helper()
+	ts := tools.Merge(
+		(&builtin.Calculator{}).AvailableTools(),
+		(&builtin.DateTime{}).AvailableTools(),
+		geo.New().AvailableTools(),
+	)
+	schema, err := ts.ToJSONSchema()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return string(schema)
+}
+
+func silenceLog(b *testing.B) {
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// BenchmarkJSONSchemaToGrammar measures the conversion, which runs a Python subprocess.
+func BenchmarkJSONSchemaToGrammar(b *testing.B) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		b.Skip("python3 is not available")
+	}
+	schema := benchmarkJSONSchema(b)
+	silenceLog(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grammarCache = &GrammarCache{m: make(map[string]string)}
+		if _, err := jsonSchemaToGrammar(schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkJSONSchemaToGrammarCached measures the cache lookup, dominated by the schema fingerprint.
+func BenchmarkJSONSchemaToGrammarCached(b *testing.B) {
+	schema := benchmarkJSONSchema(b)
+	silenceLog(b)
+
+	hash, err := calculateFingerprint(schema)
+	if err != nil {
+		b.Fatal(err)
+	}
+	grammarCache.Set(hash, "root ::= \"{}\"")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := jsonSchemaToGrammar(schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -15,14 +15,20 @@
 package llamacpp
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"regexp"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
 )
 
 // Config represents the configuration for the LLM endpoint
@@ -35,6 +41,21 @@ type Config struct {
 	MaxTokens   int
 	UseGrammar  bool
 	Timeout     time.Duration
+	// ModelFamily selects the control tokens stripped from the completions,
+	// among llm.ModelFamilies. The <|…|> tokens are stripped by default.
+	ModelFamily string
+	// Stripping, if set, overrides the stripping of ModelFamily.
+	Stripping *llm.Stripping
+	// Extractors post-process the completions after the stripping of the
+	// control tokens, in order. llm.DefaultExtractors if nil.
+	Extractors []llm.Extractor
+	// HealthEndpoint is probed by Health. By default it is the /health path
+	// of the host of Endpoint, served by llama.cpp.
+	HealthEndpoint string
+	// CaptureReasoning keeps the reasoning segments stripped from the
+	// completions, reported by CompleteWithReasoning, instead of discarding
+	// them. Streamed completions don't capture them.
+	CaptureReasoning bool
 }
 
 // CompletionRequest represents a request to the LLM endpoint
@@ -47,6 +68,14 @@ type CompletionRequest struct {
 	JsonSchema  interface{} `json:"json_schema,omitempty"`
 	Grammar     string      `json:"grammar,omitempty"`
 	Seed        int         `json:"seed"`
+	Stream      bool        `json:"stream,omitempty"`
+	// StreamOptions requests the usage in the last chunk of a stream.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions configures a streamed completion.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // Message represents a chat message
@@ -62,61 +91,211 @@ type CompletionResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage llm.TokenUsage `json:"usage"`
+}
+
+// CompletionChunk represents a chunk of a streamed response.
+type CompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *llm.TokenUsage `json:"usage"`
 }
 
+// defaultSeed makes completions reproducible across runs.
+const defaultSeed = 42
+
 type Client struct {
-	config Config
-	client *http.Client
+	config   Config
+	client   *http.Client
+	stripper func() (*llm.Stripper, error)
+	seed     int
 }
 
 func NewClient(c Config) *Client {
 	return &Client{
 		config: c,
+		seed:   defaultSeed,
 		client: &http.Client{
 			Timeout: c.Timeout,
 		},
+		stripper: sync.OnceValues(func() (*llm.Stripper, error) {
+			if c.Stripping != nil {
+				return c.Stripping.Compile()
+			}
+			s, err := llm.StrippingFor(c.ModelFamily)
+			if err != nil {
+				return nil, err
+			}
+			return s.Compile()
+		}),
+	}
+}
+
+// Settings reports the model settings, excluding the API key.
+func (c *Client) Settings() map[string]any {
+	return map[string]any{
+		"model":        c.config.Model,
+		"endpoint":     c.config.Endpoint,
+		"temperature":  c.config.Temperature,
+		"top_p":        c.config.TopP,
+		"max_tokens":   c.config.MaxTokens,
+		"use_grammar":  c.config.UseGrammar,
+		"model_family": c.config.ModelFamily,
+		"timeout":      c.config.Timeout.String(),
+		"seed":         c.seed,
 	}
 }
 
+// WithSeed returns a copy of the client sampling greedily with the seed
+// (see llm.SeededCompleter).
+func (c *Client) WithSeed(seed int) llm.Completer {
+	clone := *c
+	clone.config.Temperature, clone.config.TopP = 0, 1
+	clone.seed = seed
+	return &clone
+}
+
 func (c *Client) Complete(messages [][2]string, jsonSchema string) (string, error) {
+	content, _, err := c.CompleteWithUsage(messages, jsonSchema)
+	return content, err
+}
+
+// CompleteWithUsage is like Complete, also returning the token usage reported
+// by the server.
+func (c *Client) CompleteWithUsage(messages [][2]string, jsonSchema string) (string, llm.TokenUsage, error) {
+	content, _, usage, err := c.CompleteWithReasoning(messages, jsonSchema)
+	return content, usage, err
+}
+
+// CompleteWithReasoning is like CompleteWithUsage, also returning the
+// reasoning segments of the completion when CaptureReasoning is set.
+func (c *Client) CompleteWithReasoning(messages [][2]string, jsonSchema string) (string, string, llm.TokenUsage, error) {
 	conversation := make([]Message, len(messages))
 	for i, m := range messages {
 		conversation[i] = Message{Role: m[0], Content: m[1]}
 	}
 
 	if jsonSchema == "" || !c.config.UseGrammar {
-		return c.complete(conversation, "")
+		return c.complete(conversation, "", jsonSchema)
 	}
 
 	grammar, err := jsonSchemaToGrammar(jsonSchema)
 	if err != nil {
-		return "", fmt.Errorf("error converting JSON schema to grammar: %w", err)
+		return "", "", llm.TokenUsage{}, fmt.Errorf("error converting JSON schema to grammar: %w", err)
 	}
-	return c.complete(conversation, grammar)
+	return c.complete(conversation, grammar, jsonSchema)
 }
 
-func (c *Client) complete(messages []Message, grammar string) (string, error) {
+// CompleteStream is like CompleteWithUsage, streaming the response and
+// passing each generated token to onToken.
+func (c *Client) CompleteStream(messages [][2]string, jsonSchema string, onToken func(token string)) (string, llm.TokenUsage, error) {
+	conversation := make([]Message, len(messages))
+	for i, m := range messages {
+		conversation[i] = Message{Role: m[0], Content: m[1]}
+	}
+
+	var grammar string
+	if jsonSchema != "" && c.config.UseGrammar {
+		var err error
+		if grammar, err = jsonSchemaToGrammar(jsonSchema); err != nil {
+			return "", llm.TokenUsage{}, fmt.Errorf("error converting JSON schema to grammar: %w", err)
+		}
+	}
+
+	requestBody := c.completionRequest(conversation, grammar)
+	requestBody.Stream = true
+	requestBody.StreamOptions = &StreamOptions{IncludeUsage: true}
+	resp, err := c.post(requestBody)
+	if err != nil {
+		return "", llm.TokenUsage{}, fmt.Errorf("error getting completion response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage llm.TokenUsage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+		var chunk CompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", usage, fmt.Errorf("error unmarshalling JSON: %w", err)
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+			onToken(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", usage, fmt.Errorf("error reading response stream: %w", err)
+	}
+	stripped, _, err := c.postProcess(content.String(), jsonSchema)
+	return stripped, usage, err
+}
+
+func (c *Client) complete(messages []Message, grammar, jsonSchema string) (string, string, llm.TokenUsage, error) {
 	response, err := c.getCompletionResponse(messages, grammar)
 	if err != nil {
-		log.Fatalf("Error getting completion response: %v", err)
+		return "", "", llm.TokenUsage{}, fmt.Errorf("error getting completion response: %w", err)
 	}
-	return removeControlTokens(response.Choices[0].Message.Content), nil
+	if len(response.Choices) == 0 {
+		return "", "", response.Usage, fmt.Errorf("empty completion response")
+	}
+	content, reasoning, err := c.postProcess(response.Choices[0].Message.Content, jsonSchema)
+	return content, reasoning, response.Usage, err
 }
 
 func (c *Client) getCompletionResponse(messages []Message, grammar string) (*CompletionResponse, error) {
+	resp, err := c.post(c.completionRequest(messages, grammar))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	var completionResponse CompletionResponse
+	err = json.Unmarshal(body, &completionResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+
+	return &completionResponse, nil
+}
+
+func (c *Client) completionRequest(messages []Message, grammar string) CompletionRequest {
 	requestBody := CompletionRequest{
 		Model:       c.config.Model,
 		Messages:    messages,
 		Temperature: c.config.Temperature,
 		TopP:        c.config.TopP,
 		MaxTokens:   c.config.MaxTokens,
-		Seed:        42,
+		Seed:        c.seed,
 	}
 
 	if c.config.UseGrammar && grammar != "" {
 		requestBody.Grammar = grammar
 	}
+	return requestBody
+}
 
+// post sends the completion request, returning the response if successful.
+func (c *Client) post(requestBody CompletionRequest) (*http.Response, error) {
 	jsonBody, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("error marshalling JSON: %w", err)
@@ -134,29 +313,67 @@ func (c *Client) getCompletionResponse(messages []Message, grammar string) (*Com
 	if err != nil {
 		return nil, fmt.Errorf("error making API request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
-	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.Fatalf("Error response: %s", body)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response body: %w", err)
+		}
+		// Context-overflow errors are recognized by llm.IsContextLengthError
+		return nil, fmt.Errorf("error response (%s): %s", resp.Status, body)
 	}
+	return resp, nil
+}
 
-	var completionResponse CompletionResponse
-	err = json.Unmarshal(body, &completionResponse)
+// postProcess strips the control tokens and the reasoning segments of the
+// model family, then applies the extractors.
+func (c *Client) postProcess(content, jsonSchema string) (answer, reasoning string, err error) {
+	stripper, err := c.stripper()
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+		return "", "", fmt.Errorf("error configuring control tokens stripping: %w", err)
 	}
-
-	return &completionResponse, nil
+	answer, reasoning = stripper.StripReasoning(content)
+	extractors := c.config.Extractors
+	if extractors == nil {
+		extractors = llm.DefaultExtractors
+	}
+	if answer, err = llm.Chain(extractors...).Extract(answer, jsonSchema); err != nil {
+		return "", "", fmt.Errorf("error extracting completion: %w", err)
+	}
+	if !c.config.CaptureReasoning {
+		reasoning = ""
+	}
+	return answer, reasoning, nil
 }
 
-func removeControlTokens(content string) string {
-	re := regexp.MustCompile(`<\|.*?\|>`)
-	return re.ReplaceAllString(content, "")
+// Health probes the health endpoint of the server, which fails while the
+// model is loading.
+func (c *Client) Health(ctx context.Context) error {
+	endpoint := c.config.HealthEndpoint
+	if endpoint == "" {
+		u, err := url.Parse(c.config.Endpoint)
+		if err != nil {
+			return fmt.Errorf("error parsing endpoint: %w", err)
+		}
+		u.Path, u.RawQuery = "/health", ""
+		endpoint = u.String()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making API request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("error response (%s): %s", resp.Status, body)
+	}
+	return nil
 }
 
 func (c *Client) CreateEmbedding(text string) ([]float32, error) {
@@ -0,0 +1,85 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Extractor post-processes the raw completions of a model, returning the
+// relevant part. jsonSchema is the schema constraining the completion, empty
+// for free-text completions.
+type Extractor interface {
+	Extract(content, jsonSchema string) (string, error)
+}
+
+// ExtractorFunc adapts a function to the Extractor interface.
+type ExtractorFunc func(content, jsonSchema string) (string, error)
+
+func (f ExtractorFunc) Extract(content, jsonSchema string) (string, error) {
+	return f(content, jsonSchema)
+}
+
+// Chain composes the extractors, applied in order.
+func Chain(extractors ...Extractor) Extractor {
+	return ExtractorFunc(func(content, jsonSchema string) (string, error) {
+		for _, e := range extractors {
+			var err error
+			if content, err = e.Extract(content, jsonSchema); err != nil {
+				return "", err
+			}
+		}
+		return content, nil
+	})
+}
+
+// Extract strips the content, implementing Extractor.
+func (s *Stripper) Extract(content, _ string) (string, error) {
+	return s.Strip(content), nil
+}
+
+var fencedCodeBlock = regexp.MustCompile("(?s)```[\\w-]*\\n(.*?)```")
+
+// FencedCodeBlock extracts the last fenced code block of the structured
+// completions, for the models wrapping their JSON in Markdown. The completions
+// without code blocks are unchanged.
+var FencedCodeBlock Extractor = ExtractorFunc(func(content, jsonSchema string) (string, error) {
+	if jsonSchema == "" {
+		return content, nil
+	}
+	blocks := fencedCodeBlock.FindAllStringSubmatch(content, -1)
+	if len(blocks) == 0 {
+		return content, nil
+	}
+	return strings.TrimSpace(blocks[len(blocks)-1][1]), nil
+})
+
+// LastJSON extracts the last JSON object or array of the structured
+// completions (see FinalJSON). The completions without JSON are unchanged, to
+// be reported by the parser.
+var LastJSON Extractor = ExtractorFunc(func(content, jsonSchema string) (string, error) {
+	if jsonSchema == "" {
+		return content, nil
+	}
+	if final, ok := FinalJSON(content); ok {
+		return final, nil
+	}
+	return content, nil
+})
+
+// DefaultExtractors are applied to the completions after the control tokens
+// are stripped, unless configured otherwise.
+var DefaultExtractors = []Extractor{FencedCodeBlock, LastJSON}
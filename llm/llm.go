@@ -14,6 +14,11 @@
 
 package llm
 
+import (
+	"errors"
+	"strings"
+)
+
 // Completer represents the interface for text completion
 type Completer interface {
 	Complete(messages [][2]string, jsonSchema string) (string, error)
@@ -24,3 +29,105 @@ type Completer interface {
 //	0: role
 //	1: content
 type Message = [2]string
+
+// Embedder represents the interface for text embedding
+type Embedder interface {
+	CreateEmbedding(text string) ([]float32, error)
+}
+
+// ErrContextLength is returned, possibly wrapped, by the Completers detecting
+// that the prompt exceeds the context length of the model.
+var ErrContextLength = errors.New("the prompt exceeds the context length of the model")
+
+// contextLengthMessages are fragments of the context-overflow error messages
+// of common backends (llama.cpp, vLLM, TGI, OpenAI-compatible servers).
+var contextLengthMessages = []string{
+	"context length",
+	"context_length_exceeded",
+	"context size",
+	"context window",
+	"maximum context",
+	"too many tokens",
+	"prompt is too long",
+	"input is too long",
+	"input validation error: `inputs` tokens",
+}
+
+// IsContextLengthError reports whether err reports a prompt exceeding the
+// context length of the model: ErrContextLength, or an error of a backend
+// recognized by its message.
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrContextLength) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range contextLengthMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// overloadedMessages are fragments of the error messages of backends
+// rejecting requests because they are overloaded.
+var overloadedMessages = []string{
+	"429",
+	"503",
+	"too many requests",
+	"service unavailable",
+	"no slot available",
+	"server is busy",
+	"overloaded",
+}
+
+// IsOverloadedError reports whether err reports a backend rejecting the
+// request because it is overloaded, in which case it can be retried later.
+func IsOverloadedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range overloadedMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenUsage is the number of tokens processed by a completion.
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// UsageReporter is implemented by the Completers that can report the token
+// usage of their completions, e.g. for cost accounting.
+type UsageReporter interface {
+	CompleteWithUsage(messages []Message, jsonSchema string) (string, TokenUsage, error)
+}
+
+// StreamingCompleter is implemented by the Completers that can stream their
+// completions, passing each generated token to onToken as it arrives, e.g. for
+// reporting progress. The complete content is returned as well.
+type StreamingCompleter interface {
+	CompleteStream(messages []Message, jsonSchema string, onToken func(token string)) (string, TokenUsage, error)
+}
+
+// SeededCompleter is implemented by the Completers that can sample greedily
+// with a fixed seed, for reproducible completions.
+type SeededCompleter interface {
+	// WithSeed returns a copy of the Completer sampling greedily with the seed.
+	WithSeed(seed int) Completer
+}
+
+// SettingsReporter is implemented by the Completers that can describe their
+// model settings (model name, sampling parameters, ...), e.g. for configuration
+// snapshots. Secrets such as API keys must not be reported.
+type SettingsReporter interface {
+	Settings() map[string]any
+}
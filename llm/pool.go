@@ -0,0 +1,297 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// DefaultPoolCooldown is the default time a failing backend is excluded from a Pool.
+const DefaultPoolCooldown = 30 * time.Second
+
+// HealthChecker is implemented by the Completers that can probe the health
+// of their backend.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// PoolBackend is a backend of a Pool.
+type PoolBackend struct {
+	// Name identifies the backend in the status and in the errors.
+	Name      string
+	Completer Completer
+	// Slots is the number of parallel slots of the backend, 1 if zero.
+	Slots int
+}
+
+// PoolStatus is the status of a backend of a Pool.
+type PoolStatus struct {
+	Name     string `json:"name"`
+	Healthy  bool   `json:"healthy"`
+	InFlight int    `json:"in_flight"`
+	Slots    int    `json:"slots"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Pool is a Completer spreading the requests across several backends, e.g.
+// llama.cpp instances, so that deployments can scale horizontally.
+//
+// The requests of the same conversation, identified by their first two
+// messages, are routed to the same backend while it has free slots, to reuse
+// its prompt cache. A request failing on a backend, for reasons other than the
+// length of the prompt, is retried on another one, and the failing backend is
+// excluded for Cooldown, or until a health check (see Run) succeeds.
+type Pool struct {
+	// Cooldown is how long a failing backend is excluded. DefaultPoolCooldown if zero.
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	backends []*poolBackend
+}
+
+type poolBackend struct {
+	PoolBackend
+	inFlight       int
+	unhealthyUntil time.Time
+	err            error
+}
+
+// NewPool creates a Pool of the given backends.
+func NewPool(backends ...PoolBackend) *Pool {
+	p := &Pool{}
+	for i, b := range backends {
+		if b.Name == "" {
+			b.Name = fmt.Sprintf("backend-%d", i+1)
+		}
+		b.Slots = max(b.Slots, 1)
+		p.backends = append(p.backends, &poolBackend{PoolBackend: b})
+	}
+	return p
+}
+
+// CheckHealth probes the backends implementing HealthChecker, updating their status.
+func (p *Pool) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, b := range p.backends {
+		checker, ok := b.Completer.(HealthChecker)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := checker.Health(ctx)
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if err != nil {
+				b.unhealthyUntil = time.Now().Add(p.cooldown())
+				b.err = fmt.Errorf("health check failed: %w", err)
+			} else {
+				b.unhealthyUntil = time.Time{}
+				b.err = nil
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Run checks the health of the backends at every interval, until ctx is done.
+func (p *Pool) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.CheckHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the status of the backends.
+func (p *Pool) Status() []PoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	status := make([]PoolStatus, len(p.backends))
+	for i, b := range p.backends {
+		status[i] = PoolStatus{
+			Name:     b.Name,
+			Healthy:  !now.Before(b.unhealthyUntil),
+			InFlight: b.inFlight,
+			Slots:    b.Slots,
+		}
+		if b.err != nil && !status[i].Healthy {
+			status[i].Error = b.err.Error()
+		}
+	}
+	return status
+}
+
+func (p *Pool) cooldown() time.Duration {
+	if p.Cooldown > 0 {
+		return p.Cooldown
+	}
+	return DefaultPoolCooldown
+}
+
+// do runs fn on the backends, in the order of preference, until it succeeds
+// or fails because of the prompt.
+func (p *Pool) do(messages []Message, fn func(c Completer) error) error {
+	if len(p.backends) == 0 {
+		return fmt.Errorf("no backends in the pool")
+	}
+	key := conversationKey(messages)
+	tried := make(map[*poolBackend]bool)
+	var err error
+	for range p.backends {
+		b := p.pick(key, tried)
+		err = fn(b.Completer)
+		p.done(b, err)
+		if err == nil || IsContextLengthError(err) {
+			return err
+		}
+		tried[b] = true
+		err = fmt.Errorf("%s: %w", b.Name, err)
+	}
+	return err
+}
+
+// pick reserves a slot of the preferred backend not tried yet: the healthy
+// ones first, then the ones with free slots, by rendezvous hashing of the
+// conversation, and finally the least loaded.
+func (p *Pool) pick(key string, tried map[*poolBackend]bool) *poolBackend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	var candidates []*poolBackend
+	for _, b := range p.backends {
+		if !tried[b] && !now.Before(b.unhealthyUntil) {
+			candidates = append(candidates, b)
+		}
+	}
+	if len(candidates) == 0 {
+		// With no healthy backend left, the unhealthy ones are tried anyway
+		for _, b := range p.backends {
+			if !tried[b] {
+				candidates = append(candidates, b)
+			}
+		}
+	}
+
+	var best *poolBackend
+	var bestFree bool
+	var bestWeight uint64
+	for _, b := range candidates {
+		free := b.inFlight < b.Slots
+		weight := rendezvousWeight(key, b.Name)
+		switch {
+		case best == nil,
+			free && !bestFree,
+			free && bestFree && weight > bestWeight,
+			!free && !bestFree && b.inFlight*best.Slots < best.inFlight*b.Slots:
+			best, bestFree, bestWeight = b, free, weight
+		}
+	}
+	best.inFlight++
+	return best
+}
+
+func (p *Pool) done(b *poolBackend, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b.inFlight--
+	if err != nil && !IsContextLengthError(err) {
+		b.unhealthyUntil = time.Now().Add(p.cooldown())
+		b.err = err
+	}
+}
+
+// conversationKey identifies the conversation of the messages by the first two.
+func conversationKey(messages []Message) string {
+	h := fnv.New64a()
+	for _, m := range messages[:min(len(messages), 2)] {
+		h.Write([]byte(m[0]))
+		h.Write([]byte{0})
+		h.Write([]byte(m[1]))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+func rendezvousWeight(key, name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+	return h.Sum64()
+}
+
+func (p *Pool) Complete(messages []Message, jsonSchema string) (content string, err error) {
+	err = p.do(messages, func(c Completer) error {
+		content, err = c.Complete(messages, jsonSchema)
+		return err
+	})
+	return content, err
+}
+
+func (p *Pool) CompleteWithUsage(messages []Message, jsonSchema string) (content string, usage TokenUsage, err error) {
+	err = p.do(messages, func(c Completer) error {
+		if reporter, ok := c.(UsageReporter); ok {
+			content, usage, err = reporter.CompleteWithUsage(messages, jsonSchema)
+		} else {
+			content, err = c.Complete(messages, jsonSchema)
+		}
+		return err
+	})
+	return content, usage, err
+}
+
+func (p *Pool) CompleteWithReasoning(messages []Message, jsonSchema string) (content, reasoning string, usage TokenUsage, err error) {
+	err = p.do(messages, func(c Completer) error {
+		switch c := c.(type) {
+		case ReasoningReporter:
+			content, reasoning, usage, err = c.CompleteWithReasoning(messages, jsonSchema)
+		case UsageReporter:
+			content, usage, err = c.CompleteWithUsage(messages, jsonSchema)
+		default:
+			content, err = c.Complete(messages, jsonSchema)
+		}
+		return err
+	})
+	return content, reasoning, usage, err
+}
+
+// CompleteStream streams the completion on the backends that can; onToken
+// is not called by the others.
+func (p *Pool) CompleteStream(messages []Message, jsonSchema string, onToken func(token string)) (content string, usage TokenUsage, err error) {
+	err = p.do(messages, func(c Completer) error {
+		switch c := c.(type) {
+		case StreamingCompleter:
+			content, usage, err = c.CompleteStream(messages, jsonSchema, onToken)
+		case UsageReporter:
+			content, usage, err = c.CompleteWithUsage(messages, jsonSchema)
+		default:
+			content, err = c.Complete(messages, jsonSchema)
+		}
+		return err
+	})
+	return content, usage, err
+}
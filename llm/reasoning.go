@@ -0,0 +1,52 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ReasoningReporter is implemented by the Completers of reasoning models that
+// can report the reasoning segments emitted before the answer (e.g. the
+// <think>…</think> blocks of DeepSeek-R1), e.g. for debugging. The reasoning is
+// empty when not captured.
+type ReasoningReporter interface {
+	CompleteWithReasoning(messages []Message, jsonSchema string) (content, reasoning string, usage TokenUsage, err error)
+}
+
+// FinalJSON returns the last JSON object or array of the content, for the
+// models writing prose or reasoning before the JSON answer. The content is
+// returned as is if it is valid JSON, and ok is false if it contains no JSON.
+func FinalJSON(content string) (final string, ok bool) {
+	if json.Valid([]byte(content)) {
+		return content, true
+	}
+	for i := 0; i < len(content); i++ {
+		if content[i] != '{' && content[i] != '[' {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(content[i:]))
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			continue
+		}
+		// Values nested in the decoded one are skipped
+		n := int(dec.InputOffset())
+		final, ok = content[i:i+n], true
+		i += n - 1
+	}
+	return final, ok
+}
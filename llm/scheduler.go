@@ -0,0 +1,209 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSchedulerRetries is the default maximum number of retries of the
+	// requests rejected by an overloaded backend.
+	DefaultSchedulerRetries = 3
+	// DefaultSchedulerBackoff is the default delay before the first retry.
+	DefaultSchedulerBackoff = time.Second
+)
+
+// Scheduler limits the concurrent requests to a backend with few parallel
+// slots, such as a single llama.cpp instance, queuing the others in arrival
+// order instead of letting them time out. Share one Scheduler among all the
+// clients of the same backend.
+//
+// The requests rejected because the backend is overloaded (see
+// IsOverloadedError) are retried with exponential back-off. The slot is held
+// meanwhile, holding back the queued requests as well.
+type Scheduler struct {
+	// Slots is the maximum number of concurrent requests, 1 if zero.
+	Slots int
+	// MaxRetries is the maximum number of retries of a rejected request.
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubled at each retry.
+	Backoff time.Duration
+
+	mu      sync.Mutex
+	running int
+	queue   []*waiter
+}
+
+type waiter struct {
+	ready    chan struct{}
+	onQueued func(position int)
+}
+
+// NewScheduler creates a Scheduler with the given slots and the default back-off.
+func NewScheduler(slots int) *Scheduler {
+	return &Scheduler{
+		Slots:      slots,
+		MaxRetries: DefaultSchedulerRetries,
+		Backoff:    DefaultSchedulerBackoff,
+	}
+}
+
+// Do runs fn in a slot, waiting in the queue if none is free. onQueued, if
+// set, is called with the position of the request in the queue, 1 being the
+// next, whenever it changes.
+func (s *Scheduler) Do(onQueued func(position int), fn func() error) error {
+	s.acquire(onQueued)
+	defer s.release()
+	for retry := 0; ; retry++ {
+		err := fn()
+		if retry >= s.MaxRetries || !IsOverloadedError(err) {
+			return err
+		}
+		time.Sleep(s.Backoff << retry)
+	}
+}
+
+func (s *Scheduler) acquire(onQueued func(position int)) {
+	s.mu.Lock()
+	if len(s.queue) == 0 && s.running < max(s.Slots, 1) {
+		s.running++
+		s.mu.Unlock()
+		return
+	}
+	w := &waiter{ready: make(chan struct{}), onQueued: onQueued}
+	s.queue = append(s.queue, w)
+	position := len(s.queue)
+	s.mu.Unlock()
+
+	if onQueued != nil {
+		onQueued(position)
+	}
+	<-w.ready
+}
+
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	if len(s.queue) == 0 {
+		s.running--
+		s.mu.Unlock()
+		return
+	}
+	// The slot passes to the first waiter
+	next := s.queue[0]
+	s.queue = s.queue[1:]
+	waiting := slices.Clone(s.queue)
+	s.mu.Unlock()
+
+	close(next.ready)
+	for i, w := range waiting {
+		if w.onQueued != nil {
+			w.onQueued(i + 1)
+		}
+	}
+}
+
+// Queued returns the number of requests waiting for a slot.
+func (s *Scheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// Completer returns a Completer scheduling the requests to c.
+func (s *Scheduler) Completer(c Completer) *ScheduledCompleter {
+	return &ScheduledCompleter{scheduler: s, completer: c}
+}
+
+// QueueReporter is implemented by the Completers queuing their requests,
+// e.g. for reporting progress while waiting.
+type QueueReporter interface {
+	// WithQueueProgress returns a Completer reporting to fn the position in
+	// the queue of its requests.
+	WithQueueProgress(fn func(position int)) Completer
+}
+
+// ScheduledCompleter is a Completer whose requests are scheduled by a
+// Scheduler. The token usage and the reasoning are reported if the scheduled
+// Completer reports them.
+type ScheduledCompleter struct {
+	scheduler *Scheduler
+	completer Completer
+	onQueued  func(position int)
+}
+
+func (c *ScheduledCompleter) Complete(messages []Message, jsonSchema string) (content string, err error) {
+	err = c.scheduler.Do(c.onQueued, func() error {
+		content, err = c.completer.Complete(messages, jsonSchema)
+		return err
+	})
+	return content, err
+}
+
+func (c *ScheduledCompleter) CompleteWithUsage(messages []Message, jsonSchema string) (content string, usage TokenUsage, err error) {
+	reporter, ok := c.completer.(UsageReporter)
+	if !ok {
+		content, err = c.Complete(messages, jsonSchema)
+		return content, usage, err
+	}
+	err = c.scheduler.Do(c.onQueued, func() error {
+		content, usage, err = reporter.CompleteWithUsage(messages, jsonSchema)
+		return err
+	})
+	return content, usage, err
+}
+
+func (c *ScheduledCompleter) CompleteWithReasoning(messages []Message, jsonSchema string) (content, reasoning string, usage TokenUsage, err error) {
+	reasoner, ok := c.completer.(ReasoningReporter)
+	if !ok {
+		content, usage, err = c.CompleteWithUsage(messages, jsonSchema)
+		return content, "", usage, err
+	}
+	err = c.scheduler.Do(c.onQueued, func() error {
+		content, reasoning, usage, err = reasoner.CompleteWithReasoning(messages, jsonSchema)
+		return err
+	})
+	return content, reasoning, usage, err
+}
+
+// CompleteStream streams the completion if the scheduled Completer can;
+// otherwise onToken is not called.
+func (c *ScheduledCompleter) CompleteStream(messages []Message, jsonSchema string, onToken func(token string)) (content string, usage TokenUsage, err error) {
+	streamer, ok := c.completer.(StreamingCompleter)
+	if !ok {
+		return c.CompleteWithUsage(messages, jsonSchema)
+	}
+	err = c.scheduler.Do(c.onQueued, func() error {
+		content, usage, err = streamer.CompleteStream(messages, jsonSchema, onToken)
+		return err
+	})
+	return content, usage, err
+}
+
+func (c *ScheduledCompleter) WithQueueProgress(fn func(position int)) Completer {
+	scheduled := *c
+	scheduled.onQueued = fn
+	return &scheduled
+}
+
+// Settings reports the settings of the scheduled Completer, if any.
+func (c *ScheduledCompleter) Settings() map[string]any {
+	if reporter, ok := c.completer.(SettingsReporter); ok {
+		return reporter.Settings()
+	}
+	return nil
+}
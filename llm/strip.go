@@ -0,0 +1,133 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Stripping configures the removal of the wrappers that a model family emits
+// around its completions, which would otherwise leak into the parsed JSON.
+type Stripping struct {
+	// Patterns are regular expressions matching the control tokens to remove.
+	Patterns []string `json:"patterns,omitempty"`
+	// StopSequences truncate the completion at their first occurrence.
+	StopSequences []string `json:"stop_sequences,omitempty"`
+	// ReasoningTags are the names of the tags enclosing reasoning segments,
+	// removed with their content (e.g. "think" for <think>…</think>).
+	ReasoningTags []string `json:"reasoning_tags,omitempty"`
+}
+
+// DefaultStripping removes the <|…|> control tokens.
+var DefaultStripping = Stripping{Patterns: []string{`<\|.*?\|>`}}
+
+// ModelFamilies are the strippings of common model families, by name.
+var ModelFamilies = map[string]Stripping{
+	"llama3": DefaultStripping,
+	"chatml": {
+		Patterns:      []string{`<\|im_start\|>(system|user|assistant)?\n?`, `<\|im_end\|>`, `<\|endoftext\|>`},
+		StopSequences: []string{"<|im_end|>"},
+	},
+	"gemma": {
+		Patterns:      []string{`<start_of_turn>(model|user)?\n?`, `<end_of_turn>`, `<eos>`},
+		StopSequences: []string{"<end_of_turn>"},
+	},
+	"mistral": {
+		Patterns: []string{`\[/?INST\]`, `</?s>`},
+	},
+	"phi3": {
+		Patterns:      []string{`<\|(assistant|user|system|end|endoftext)\|>`},
+		StopSequences: []string{"<|end|>"},
+	},
+	"deepseek-r1": {
+		Patterns:      []string{`<｜.*?｜>`, `<\|.*?\|>`},
+		ReasoningTags: []string{"think"},
+	},
+	"qwen3": {
+		Patterns:      []string{`<\|im_start\|>(assistant)?\n?`, `<\|im_end\|>`, `<\|endoftext\|>`},
+		StopSequences: []string{"<|im_end|>"},
+		ReasoningTags: []string{"think"},
+	},
+}
+
+// StrippingFor returns the stripping of the model family, or DefaultStripping
+// if the family is empty.
+func StrippingFor(family string) (Stripping, error) {
+	if family == "" {
+		return DefaultStripping, nil
+	}
+	s, ok := ModelFamilies[family]
+	if !ok {
+		return Stripping{}, fmt.Errorf("unknown model family %q", family)
+	}
+	return s, nil
+}
+
+// Stripper applies a compiled Stripping.
+type Stripper struct {
+	stops     []string
+	reasoning []*regexp.Regexp
+	patterns  []*regexp.Regexp
+}
+
+// Compile compiles the patterns of the stripping.
+func (s Stripping) Compile() (*Stripper, error) {
+	st := &Stripper{stops: s.StopSequences}
+	for _, tag := range s.ReasoningTags {
+		// An unterminated segment, from a truncated completion, is removed as well
+		q := regexp.QuoteMeta(tag)
+		st.reasoning = append(st.reasoning, regexp.MustCompile(`(?s)<`+q+`>(.*?)(</`+q+`>|$)`))
+	}
+	for _, p := range s.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("error compiling pattern %q: %w", p, err)
+		}
+		st.patterns = append(st.patterns, re)
+	}
+	return st, nil
+}
+
+// Strip truncates the content at the first stop sequence and removes the
+// reasoning segments and the control tokens.
+func (s *Stripper) Strip(content string) string {
+	answer, _ := s.StripReasoning(content)
+	return answer
+}
+
+// StripReasoning is like Strip, also returning the content of the reasoning
+// segments, separated by blank lines.
+func (s *Stripper) StripReasoning(content string) (answer, reasoning string) {
+	for _, stop := range s.stops {
+		if i := strings.Index(content, stop); i >= 0 {
+			content = content[:i]
+		}
+	}
+	var segments []string
+	for _, re := range s.reasoning {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			if segment := strings.TrimSpace(m[1]); segment != "" {
+				segments = append(segments, segment)
+			}
+		}
+		content = re.ReplaceAllString(content, "")
+	}
+	for _, re := range s.patterns {
+		content = re.ReplaceAllString(content, "")
+	}
+	return strings.TrimSpace(content), strings.Join(segments, "\n\n")
+}
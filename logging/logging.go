@@ -0,0 +1,74 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging carries request-scoped loggers through contexts, so that the
+// log lines of concurrent requests can be told apart.
+package logging
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// WithLogger returns a copy of ctx carrying the logger.
+func WithLogger(ctx context.Context, logger *log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or fallback if there is none.
+func FromContext(ctx context.Context, fallback *log.Logger) *log.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*log.Logger); ok && logger != nil {
+		return logger
+	}
+	return fallback
+}
+
+// With derives a logger writing to the same output, whose messages are
+// prefixed by the given key-value pairs, e.g. "[request=42 tenant=acme] ".
+func With(logger *log.Logger, keyvals ...string) *log.Logger {
+	if len(keyvals) == 0 {
+		return logger
+	}
+	var b strings.Builder
+	b.WriteString("[")
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(keyvals[i])
+		b.WriteByte('=')
+		b.WriteString(keyvals[i+1])
+	}
+	b.WriteString("] ")
+
+	// Lmsgprefix keeps the timestamp and file information at the beginning of the line
+	return log.New(logger.Writer(), logger.Prefix()+b.String(), logger.Flags()|log.Lmsgprefix)
+}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request, e.g. taken
+// from an X-Request-ID header. Requests without an ID are numbered by the handler.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the ID of the request carried by ctx.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,304 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openaicompat is an LLM client of the OpenAI-compatible servers
+// with native guided decoding, vLLM and Hugging Face TGI: the JSON schemas of
+// the prompts constrain the completions directly, without the conversion to
+// GBNF grammars of the llamacpp client.
+package openaicompat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+)
+
+// Backend is the server type, selecting the guided decoding parameters.
+type Backend string
+
+const (
+	// VLLM sends the schema as guided_json.
+	VLLM Backend = "vllm"
+	// TGI sends the schema as a json_object response format grammar.
+	TGI Backend = "tgi"
+)
+
+// Config represents the configuration for the LLM endpoint
+type Config struct {
+	Backend Backend
+	APIKey  string
+	Model   string
+	// Endpoint is the URL of the chat completions API, e.g. http://localhost:8000/v1/chat/completions.
+	Endpoint string
+	// EmbeddingEndpoint is the URL of the embeddings API, e.g. of a vLLM
+	// embedding model or of a Text Embeddings Inference server.
+	EmbeddingEndpoint string
+	Temperature       float64
+	TopP              float64
+	MaxTokens         int
+	// GuidedDecoding constrains the completions to the JSON schemas.
+	GuidedDecoding bool
+	// GuidedDecodingBackend selects the guided decoding backend of vLLM
+	// (e.g. "xgrammar", "outlines"), the server default if empty.
+	GuidedDecodingBackend string
+	// ModelFamily and Stripping select the control tokens stripped from the
+	// completions, as in the llamacpp client.
+	ModelFamily string
+	Stripping   *llm.Stripping
+	// Extractors post-process the completions after the stripping of the
+	// control tokens. llm.DefaultExtractors if nil.
+	Extractors []llm.Extractor
+	Timeout    time.Duration
+}
+
+// CompletionRequest represents a request to the chat completions API.
+type CompletionRequest struct {
+	Model       string    `json:"model,omitempty"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	TopP        float64   `json:"top_p,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Seed        int       `json:"seed"`
+	// GuidedJSON and GuidedDecodingBackend are vLLM extensions.
+	GuidedJSON            json.RawMessage `json:"guided_json,omitempty"`
+	GuidedDecodingBackend string          `json:"guided_decoding_backend,omitempty"`
+	// ResponseFormat carries the grammar of TGI.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormat is the TGI grammar constraining the completion.
+type ResponseFormat struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Message represents a chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionResponse represents a response of the chat completions API.
+type CompletionResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage llm.TokenUsage `json:"usage"`
+}
+
+// defaultSeed makes completions reproducible across runs.
+const defaultSeed = 42
+
+type Client struct {
+	config   Config
+	client   *http.Client
+	stripper func() (*llm.Stripper, error)
+	seed     int
+}
+
+func NewClient(c Config) *Client {
+	return &Client{
+		config: c,
+		seed:   defaultSeed,
+		client: &http.Client{
+			Timeout: c.Timeout,
+		},
+		stripper: sync.OnceValues(func() (*llm.Stripper, error) {
+			if c.Stripping != nil {
+				return c.Stripping.Compile()
+			}
+			s, err := llm.StrippingFor(c.ModelFamily)
+			if err != nil {
+				return nil, err
+			}
+			return s.Compile()
+		}),
+	}
+}
+
+// Settings reports the model settings, excluding the API key.
+func (c *Client) Settings() map[string]any {
+	return map[string]any{
+		"backend":                 string(c.config.Backend),
+		"model":                   c.config.Model,
+		"endpoint":                c.config.Endpoint,
+		"temperature":             c.config.Temperature,
+		"top_p":                   c.config.TopP,
+		"max_tokens":              c.config.MaxTokens,
+		"guided_decoding":         c.config.GuidedDecoding,
+		"guided_decoding_backend": c.config.GuidedDecodingBackend,
+		"model_family":            c.config.ModelFamily,
+		"timeout":                 c.config.Timeout.String(),
+		"seed":                    c.seed,
+	}
+}
+
+// WithSeed returns a copy of the client sampling greedily with the seed
+// (see llm.SeededCompleter).
+func (c *Client) WithSeed(seed int) llm.Completer {
+	clone := *c
+	clone.config.Temperature, clone.config.TopP = 0, 1
+	clone.seed = seed
+	return &clone
+}
+
+func (c *Client) Complete(messages [][2]string, jsonSchema string) (string, error) {
+	content, _, err := c.CompleteWithUsage(messages, jsonSchema)
+	return content, err
+}
+
+// CompleteWithUsage is like Complete, also returning the token usage reported
+// by the server.
+func (c *Client) CompleteWithUsage(messages [][2]string, jsonSchema string) (string, llm.TokenUsage, error) {
+	requestBody := CompletionRequest{
+		Model:       c.config.Model,
+		Messages:    make([]Message, len(messages)),
+		Temperature: c.config.Temperature,
+		TopP:        c.config.TopP,
+		MaxTokens:   c.config.MaxTokens,
+		Seed:        c.seed,
+	}
+	for i, m := range messages {
+		requestBody.Messages[i] = Message{Role: m[0], Content: m[1]}
+	}
+	if jsonSchema != "" && c.config.GuidedDecoding {
+		switch c.config.Backend {
+		case VLLM:
+			requestBody.GuidedJSON = json.RawMessage(jsonSchema)
+			requestBody.GuidedDecodingBackend = c.config.GuidedDecodingBackend
+		case TGI:
+			requestBody.ResponseFormat = &ResponseFormat{Type: "json_object", Value: json.RawMessage(jsonSchema)}
+		default:
+			return "", llm.TokenUsage{}, fmt.Errorf("unsupported backend %q", c.config.Backend)
+		}
+	}
+
+	var response CompletionResponse
+	if err := c.post(c.config.Endpoint, requestBody, &response); err != nil {
+		return "", llm.TokenUsage{}, fmt.Errorf("error getting completion response: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return "", response.Usage, fmt.Errorf("empty completion response")
+	}
+
+	stripper, err := c.stripper()
+	if err != nil {
+		return "", response.Usage, fmt.Errorf("error configuring control tokens stripping: %w", err)
+	}
+	extractors := c.config.Extractors
+	if extractors == nil {
+		extractors = llm.DefaultExtractors
+	}
+	content, err := llm.Chain(append([]llm.Extractor{stripper}, extractors...)...).Extract(response.Choices[0].Message.Content, jsonSchema)
+	if err != nil {
+		return "", response.Usage, fmt.Errorf("error extracting completion: %w", err)
+	}
+	return content, response.Usage, nil
+}
+
+func (c *Client) CreateEmbedding(text string) ([]float32, error) {
+	if c.config.EmbeddingEndpoint == "" {
+		return nil, fmt.Errorf("missing embedding endpoint")
+	}
+	var response struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	requestBody := map[string]any{"input": text}
+	if c.config.Model != "" {
+		requestBody["model"] = c.config.Model
+	}
+	if err := c.post(c.config.EmbeddingEndpoint, requestBody, &response); err != nil {
+		return nil, fmt.Errorf("error getting embedding response: %w", err)
+	}
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("empty embedding response")
+	}
+	return response.Data[0].Embedding, nil
+}
+
+// Health probes the /health endpoint served by both vLLM and TGI.
+func (c *Client) Health(ctx context.Context) error {
+	u, err := url.Parse(c.config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("error parsing endpoint: %w", err)
+	}
+	u.Path, u.RawQuery = "/health", ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	c.authorize(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making API request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("error response (%s): %s", resp.Status, body)
+	}
+	return nil
+}
+
+func (c *Client) authorize(req *http.Request) {
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+}
+
+// post sends the request to the endpoint, decoding the response.
+func (c *Client) post(endpoint string, requestBody, response any) error {
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.authorize(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Context-overflow errors are recognized by llm.IsContextLengthError
+		return fmt.Errorf("error response (%s): %s", resp.Status, body)
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+	return nil
+}
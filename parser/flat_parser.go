@@ -0,0 +1,149 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// FlatCall represents a call of the flat plan format, whose arguments
+// reference other calls by id through {"ref": "<id>"} objects.
+type FlatCall struct {
+	ID      string                 `json:"id"`
+	Name    string                 `json:"name"`
+	Purpose string                 `json:"purpose"`
+	Args    map[string]interface{} `json:"args"`
+}
+
+// FlatPlan represents a plan in the flat format.
+type FlatPlan struct {
+	Understanding string     `json:"understanding"`
+	Calls         []FlatCall `json:"calls"`
+	// MainCalls lists the ids of the calls directly answering the request.
+	// If empty, the calls not referenced by any other call are used.
+	MainCalls []string `json:"main_calls"`
+}
+
+// ParseFlatFunctions parses a plan in the flat format and converts it into
+// nested PlannedFuncCall values, like ParseJsonFunctions.
+func ParseFlatFunctions(jsonData []byte) ([]PlannedFuncCall, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+
+	var plan FlatPlan
+	if err := decoder.Decode(&plan); err != nil {
+		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+	return plan.ToPlannedFuncCalls()
+}
+
+// ToPlannedFuncCalls resolves the references between the calls. A call referenced
+// by several arguments is nested into each of them.
+func (p *FlatPlan) ToPlannedFuncCalls() ([]PlannedFuncCall, error) {
+	calls := make(map[string]*FlatCall, len(p.Calls))
+	for i := range p.Calls {
+		c := &p.Calls[i]
+		if c.ID == "" || c.Name == "" {
+			return nil, fmt.Errorf("%w: call without id or name", ErrInvalidJSON)
+		}
+		if _, exists := calls[c.ID]; exists {
+			return nil, fmt.Errorf("%w: duplicate call id %q", ErrInvalidJSON, c.ID)
+		}
+		calls[c.ID] = c
+	}
+
+	mainCalls := p.MainCalls
+	if len(mainCalls) == 0 {
+		mainCalls = p.rootCalls()
+	}
+
+	r := &flatResolver{calls: calls, resolving: make(map[string]bool)}
+	planned := make([]PlannedFuncCall, 0, len(mainCalls))
+	for _, id := range mainCalls {
+		call, err := r.resolve(id)
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, *call)
+	}
+	return planned, nil
+}
+
+// rootCalls returns the ids of the calls not referenced by other calls, in order.
+func (p *FlatPlan) rootCalls() []string {
+	referenced := make(map[string]bool)
+	for _, c := range p.Calls {
+		for _, v := range c.Args {
+			if id, ok := refID(v); ok {
+				referenced[id] = true
+			}
+		}
+	}
+	var roots []string
+	for _, c := range p.Calls {
+		if !referenced[c.ID] {
+			roots = append(roots, c.ID)
+		}
+	}
+	return roots
+}
+
+type flatResolver struct {
+	calls     map[string]*FlatCall
+	resolving map[string]bool
+}
+
+func (r *flatResolver) resolve(id string) (*PlannedFuncCall, error) {
+	c, ok := r.calls[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: reference to unknown call %q", ErrInvalidJSON, id)
+	}
+	if r.resolving[id] {
+		return nil, fmt.Errorf("%w: circular reference to call %q", ErrInvalidJSON, id)
+	}
+	r.resolving[id] = true
+	defer delete(r.resolving, id)
+
+	args := make(map[string]interface{}, len(c.Args))
+	for name, value := range c.Args {
+		if refID, ok := refID(value); ok {
+			nested, err := r.resolve(refID)
+			if err != nil {
+				return nil, fmt.Errorf("error resolving argument '%s' of call %q: %w", name, id, err)
+			}
+			args[name] = nested
+			continue
+		}
+		// Empty strings are dropped, as in the nested format
+		if s, ok := value.(string); ok && s == "" {
+			continue
+		}
+		args[name] = value
+	}
+
+	return &PlannedFuncCall{Name: c.Name, Purpose: c.Purpose, Args: args}, nil
+}
+
+// refID returns the id of the call referenced by an argument value, if it is a reference.
+func refID(v interface{}) (string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	id, ok := m["ref"].(string)
+	return id, ok
+}
@@ -15,10 +15,13 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"slices"
 )
 
 // ErrInvalidJSON is returned when the JSON structure is invalid
@@ -34,8 +37,9 @@ type PlannedFuncCall struct {
 func (t *PlannedFuncCall) CollectAllNestedFuncCalls() []string {
 	var nestedFuncCalls []string
 	nestedFuncCalls = append(nestedFuncCalls, t.Name)
-	for _, arg := range t.Args {
-		switch v := arg.(type) {
+	// The arguments are visited by name, so that the order is stable
+	for _, name := range slices.Sorted(maps.Keys(t.Args)) {
+		switch v := t.Args[name].(type) {
 		case *PlannedFuncCall:
 			nestedFuncCalls = append(nestedFuncCalls, v.CollectAllNestedFuncCalls()...)
 		}
@@ -43,14 +47,19 @@ func (t *PlannedFuncCall) CollectAllNestedFuncCalls() []string {
 	return nestedFuncCalls
 }
 
-// ParseJsonFunctions parses the input JSON data and returns a slice of PlannedFunctionCall
+// ParseJsonFunctions parses the input JSON data and returns a slice of PlannedFunctionCall.
+// Numeric arguments are returned as json.Number.
 func ParseJsonFunctions(jsonData []byte) ([]PlannedFuncCall, error) {
 	var data struct {
 		Understanding string        `json:"understanding"`
 		MainFunctions []interface{} `json:"main_functions"`
 	}
 
-	if err := json.Unmarshal(jsonData, &data); err != nil {
+	// Numbers are decoded as json.Number, so that large integers are not
+	// corrupted; the orchestrator converts them according to its NumberMode.
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
 		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
 	}
 
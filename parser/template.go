@@ -0,0 +1,113 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Param is a placeholder for a parameter in a template of function calls,
+// replaced with the value of the parameter by Instantiate.
+// It is encoded in JSON as {"$param": name}.
+type Param struct {
+	Name string
+}
+
+func (p Param) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"$param": p.Name})
+}
+
+// Params returns the names of the parameters used by the template, nested calls included.
+func (t *PlannedFuncCall) Params() []string {
+	var params []string
+	for _, arg := range t.Args {
+		switch v := arg.(type) {
+		case Param:
+			params = append(params, v.Name)
+		case *PlannedFuncCall:
+			params = append(params, v.Params()...)
+		}
+	}
+	return params
+}
+
+// Instantiate returns a copy of the template with its parameters replaced with
+// the given values. Arguments whose parameter has no value are omitted.
+func Instantiate(template PlannedFuncCall, params map[string]interface{}) PlannedFuncCall {
+	args := make(map[string]interface{}, len(template.Args))
+	for key, value := range template.Args {
+		switch v := value.(type) {
+		case Param:
+			if param, ok := params[v.Name]; ok {
+				args[key] = param
+			}
+		case *PlannedFuncCall:
+			nested := Instantiate(*v, params)
+			args[key] = &nested
+		default:
+			args[key] = v
+		}
+	}
+	return PlannedFuncCall{Name: template.Name, Purpose: template.Purpose, Args: args}
+}
+
+// DecodeCall decodes a function call encoded with encoding/json, such as a
+// template, restoring its nested calls and parameters.
+// Numbers are decoded as json.Number.
+func DecodeCall(data []byte) (PlannedFuncCall, error) {
+	var v map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return PlannedFuncCall{}, fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+	call, ok := decodeCall(v)
+	if !ok {
+		return PlannedFuncCall{}, fmt.Errorf("%w: not a function call", ErrInvalidJSON)
+	}
+	return *call, nil
+}
+
+func decodeCall(v map[string]interface{}) (*PlannedFuncCall, bool) {
+	name, ok := v["name"].(string)
+	if !ok || len(v) > 3 {
+		return nil, false
+	}
+	purpose, _ := v["purpose"].(string)
+	rawArgs, _ := v["args"].(map[string]interface{})
+	args := make(map[string]interface{}, len(rawArgs))
+	for key, value := range rawArgs {
+		args[key] = decodeArg(value)
+	}
+	return &PlannedFuncCall{Name: name, Purpose: purpose, Args: args}, true
+}
+
+func decodeArg(value interface{}) interface{} {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	if name, ok := m["$param"].(string); ok && len(m) == 1 {
+		return Param{Name: name}
+	}
+	if _, hasArgs := m["args"]; hasArgs {
+		if call, ok := decodeCall(m); ok {
+			return call
+		}
+	}
+	return m
+}
@@ -27,3 +27,19 @@ type NoOp struct{}
 func (ne *NoOp) Send(_ string) {
 	// Do nothing
 }
+
+// Choice is a structured prompt asking the user to choose one of the options,
+// e.g. the place meant by an ambiguous name. The answer refers to its ID.
+type Choice struct {
+	ID       string   `json:"id"`
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+}
+
+// ChoiceStream is implemented by the streams able to deliver choice prompts
+// to the client, besides the progress messages.
+type ChoiceStream interface {
+	Stream
+	// SendChoice transmits the choice prompt. Like Send, it should not block.
+	SendChoice(choice Choice)
+}
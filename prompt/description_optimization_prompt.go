@@ -0,0 +1,106 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const descriptionOptimizationPromptTemplate = `A planner chooses functions, and their arguments, from their definitions to fulfill user requests. The definition below led the planner to the mistakes listed after it.
+
+Propose {{.Candidates}} improved versions of the description of the function and of the descriptions of its parameters, so that the planner would not make these mistakes. Say what the function does and does not do, when to prefer it, and what each parameter expects, with an example value when useful. Keep the descriptions short and general: do not mention the requests below. Keep the descriptions that are fine unchanged.
+
+Function definition:
+{{.FuncDefinition}}
+
+Mistakes:
+{{range .Failures}}
+Request: {{.Request}}
+Plan: {{.Plan}}
+Problem: {{.Problem}}
+{{end}}`
+
+// DescriptionOptimizationResponseSchema is the JSON schema of the proposed descriptions.
+var DescriptionOptimizationResponseSchema = map[string]any{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type":    "object",
+	"properties": map[string]any{
+		"candidates": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"description": map[string]any{"type": "string"},
+					"parameters": map[string]any{
+						"type": "array",
+						"items": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"name":        map[string]any{"type": "string"},
+								"description": map[string]any{"type": "string"},
+							},
+							"required":             []string{"name", "description"},
+							"additionalProperties": false,
+						},
+					},
+				},
+				"required":             []string{"description", "parameters"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"candidates"},
+	"additionalProperties": false,
+}
+
+// PlanningFailure is a mistake of the planner shown to the description optimizer.
+type PlanningFailure struct {
+	Request string
+	Plan    string
+	Problem string
+}
+
+// CreatePromptForDescriptionOptimization generates the prompt asking for
+// improved descriptions of a function the planner misused.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForDescriptionOptimization(funcDefinition string, failures []PlanningFailure, candidates int) (string, error) {
+	return Pins(nil).CreatePromptForDescriptionOptimization(funcDefinition, failures, candidates)
+}
+
+// CreatePromptForDescriptionOptimization is like the function CreatePromptForDescriptionOptimization, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForDescriptionOptimization(funcDefinition string, failures []PlanningFailure, candidates int) (string, error) {
+	tmpl, err := template.New("prompt_for_description_optimization").Parse(p.text("description_optimization"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		FuncDefinition string
+		Failures       []PlanningFailure
+		Candidates     int
+	}{
+		FuncDefinition: funcDefinition,
+		Failures:       failures,
+		Candidates:     candidates,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
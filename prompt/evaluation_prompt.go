@@ -29,6 +29,7 @@ Before submitting ANY changes, no matter how minor they may seem:
 1. Run ALL existing test queries against the test Tools
 2. Verify that the results match expected outcomes
 3. Document any deviations or unexpected behaviors
+4. Register the change as a new version in registry.go, with migration notes
 
 Modifications without proper testing may lead to system-wide inconsistencies
 and unpredictable behavior.
@@ -93,8 +94,15 @@ var FuncCallsEvaluationResponseSchema = map[string]any{
 }
 
 // CreatePromptForFuncCallsEvaluation generates a prompt for a second-pass function call validation
+// It uses the latest version of the template (see Pins).
 func CreatePromptForFuncCallsEvaluation(userRequest, plannedFuncCalls, funcDefinitions string) (string, error) {
-	tmpl, err := template.New("prompt_for_func_calls_evaluation").Parse(funcCallsEvaluationPromptTemplate)
+	return Pins(nil).CreatePromptForFuncCallsEvaluation(userRequest, plannedFuncCalls, funcDefinitions)
+}
+
+// CreatePromptForFuncCallsEvaluation is like the function CreatePromptForFuncCallsEvaluation, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForFuncCallsEvaluation(userRequest, plannedFuncCalls, funcDefinitions string) (string, error) {
+	tmpl, err := template.New("prompt_for_func_calls_evaluation").Parse(p.text("evaluation"))
 	if err != nil {
 		return "", fmt.Errorf("error parsing template: %w", err)
 	}
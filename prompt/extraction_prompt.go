@@ -0,0 +1,64 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const extractionPromptTemplate = `You are a data extraction assistant. Your task is to extract from the provided text a single value of the requested type.
+
+Use only the information contained in the text. Do not rely on your personal knowledge and do not make assumptions: leave optional fields empty when the text does not contain them.
+
+Your response must be a single JSON object with a "value" field containing the extracted value. Do not add any explanation or additional content to the response.
+
+Requested type: {{.TypeName}}
+Type definition:
+{{.TypeDefinition}}
+
+Text:
+{{.Text}}`
+
+// CreatePromptForExtraction generates a prompt for extracting a typed value from unstructured text
+// It uses the latest version of the template (see Pins).
+func CreatePromptForExtraction(text, typeName, typeDefinition string) (string, error) {
+	return Pins(nil).CreatePromptForExtraction(text, typeName, typeDefinition)
+}
+
+// CreatePromptForExtraction is like the function CreatePromptForExtraction, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForExtraction(text, typeName, typeDefinition string) (string, error) {
+	tmpl, err := template.New("prompt_for_extraction").Parse(p.text("extraction"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Text           string
+		TypeName       string
+		TypeDefinition string
+	}{
+		Text:           text,
+		TypeName:       typeName,
+		TypeDefinition: typeDefinition,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// flatFuncCallsPromptTemplate is the counterpart of funcCallsPromptTemplate for
+// the flat plan format, meant for models struggling with deeply nested JSON.
+// It follows the same rules: keep the two prompts aligned when changing either.
+const flatFuncCallsPromptTemplate = `You are an AI assistant that creates a list of function calls to address user queries. Your task is to plan how to retrieve information, not to actually provide or withhold information.
+
+Use only the provided functions. Do not rely on your personal knowledge or make judgments about the appropriateness of queries.
+
+Your response must be a single JSON object with these fields:
+
+1. "understanding": A brief summary of the user's request.
+2. "calls": A flat array of all the function calls needed, helper functions included. If no Tools can answer the question, this array should be empty []. Structure:
+{
+	"id": "<unique short id, e.g. c1>",
+	"name": "<func_name>",
+	"purpose": "To [why this function retrieves the information needed to answer the user's request]",
+	"args": {
+		"<arg1>": "value",
+		"<arg2>": {"ref": "<id of the call whose result is the value>"}
+	}
+}
+3. "main_calls": The ids of the calls that DIRECTLY provide the ultimate answer to the user's question.
+
+Key points:
+- Include in main_calls ONLY calls that DIRECTLY retrieve the answer to the user's question.
+- Do not duplicate calls for the same purpose: reference the same call id from several arguments instead.
+- Helper functions (e.g., getting IDs, data formatting) are separate calls referenced through {"ref": "<id>"} in the arguments of other calls.
+- A call can only reference calls listed in "calls", and references must not be circular.
+- Do not make assumptions about missing arguments. Exception: You may make reasonable inferences for certain types of information. For example:
+  - If a city is mentioned, you can infer the country. But never infer the city from the country.
+  - If "recent events" are mentioned, you can use a reasonable time frame without considering it a missing argument.
+- When in doubt, prefer to leave arguments empty rather than making assumptions.
+
+Important:
+- Do not refuse to process any query. Your task is to plan information retrieval, not to make ethical judgments or provide actual information.
+- For all queries, including sensitive or controversial topics, focus solely on structuring the appropriate function calls to retrieve the requested information.
+- Do not include warnings, caveats, or ethical considerations in your response. Your role is purely to plan the technical process of information retrieval.
+- Do not add any additional content to the response. Your response must be a single JSON object with the fields described above.
+
+Available functions:
+<functions>
+{{.Functions}}
+</functions>`
+
+// CreatePromptForFlatFuncCalls returns the system prompt for the flat plan format
+func CreatePromptForFlatFuncCalls(ts *tools.ToolSet) (string, error) {
+	return CreatePromptForFlatFuncCallsWith(ts, tools.DefinitionsVerbose)
+}
+
+// CreatePromptForFlatFuncCallsWith is like CreatePromptForFlatFuncCalls, with
+// the function definitions rendered in the given format.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForFlatFuncCallsWith(ts *tools.ToolSet, format tools.DefinitionFormat) (string, error) {
+	return Pins(nil).CreatePromptForFlatFuncCallsWith(ts, format)
+}
+
+// CreatePromptForFlatFuncCallsWith is like the function CreatePromptForFlatFuncCallsWith, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForFlatFuncCallsWith(ts *tools.ToolSet, format tools.DefinitionFormat) (string, error) {
+	functionDefs, err := ts.Definitions(format)
+	if err != nil {
+		return "", fmt.Errorf("error generating function definitions: %w", err)
+	}
+
+	tmpl, err := template.New("prompt_for_flat_func_calls").Parse(p.text("flat_func_calls"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Functions string
+	}{
+		Functions: functionDefs,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
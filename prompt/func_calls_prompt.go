@@ -33,6 +33,7 @@ Before submitting ANY changes, no matter how minor they may seem:
 1. Run ALL existing test queries against the test Tools
 2. Verify that the results match expected outcomes
 3. Document any deviations or unexpected behaviors
+4. Register the change as a new version in registry.go, with migration notes
 
 Modifications without proper testing may lead to system-wide inconsistencies
 and unpredictable behavior.
@@ -81,14 +82,27 @@ Available functions:
 </functions>`
 
 // CreatePromptForFuncCalls returns the system prompt for nested functions calling
-func CreatePromptForFuncCalls(tools *tools.ToolSet) (string, error) {
-	functionDefs, err := tools.ToJSONDefinitions()
+func CreatePromptForFuncCalls(ts *tools.ToolSet) (string, error) {
+	return CreatePromptForFuncCallsWith(ts, tools.DefinitionsVerbose)
+}
+
+// CreatePromptForFuncCallsWith is like CreatePromptForFuncCalls, with the
+// function definitions rendered in the given format.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForFuncCallsWith(ts *tools.ToolSet, format tools.DefinitionFormat) (string, error) {
+	return Pins(nil).CreatePromptForFuncCallsWith(ts, format)
+}
+
+// CreatePromptForFuncCallsWith is like the function CreatePromptForFuncCallsWith, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForFuncCallsWith(ts *tools.ToolSet, format tools.DefinitionFormat) (string, error) {
+	functionDefs, err := ts.Definitions(format)
 	if err != nil {
 		fmt.Printf("Error generating schema: %v\n", err)
 		return "", err
 	}
 
-	tmpl, err := template.New("prompt_for_func_calls").Parse(funcCallsPromptTemplate)
+	tmpl, err := template.New("prompt_for_func_calls").Parse(p.text("func_calls"))
 	if err != nil {
 		return "", fmt.Errorf("error parsing template: %w", err)
 	}
@@ -97,7 +111,7 @@ func CreatePromptForFuncCalls(tools *tools.ToolSet) (string, error) {
 	if err := tmpl.Execute(&buf, struct {
 		Functions string
 	}{
-		Functions: string(functionDefs),
+		Functions: functionDefs,
 	}); err != nil {
 		return "", fmt.Errorf("error executing template: %w", err)
 	}
@@ -0,0 +1,109 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const judgePromptTemplate = `Judge the {{.Subject}} below, produced for the user request, against each criterion of the rubric. For each criterion, give a short reason, then whether the {{.Subject}} meets it. Be strict: when in doubt, the criterion is not met.
+
+User request:
+{{.Request}}
+{{if .Context}}
+Context:
+{{.Context}}
+{{end}}
+The {{.Subject}} to judge:
+{{.Content}}
+
+Rubric:
+{{range .Criteria}}- {{.Name}}: {{.Description}}
+{{end}}`
+
+// JudgeCriterion is a criterion of the rubric rendered in the judge prompt.
+type JudgeCriterion struct {
+	Name        string
+	Description string
+}
+
+// JudgeResponseSchema returns the JSON schema of the verdicts of the judge,
+// one per criterion.
+func JudgeResponseSchema(criteria []string) map[string]any {
+	properties := make(map[string]any, len(criteria))
+	for _, name := range criteria {
+		properties[name] = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"reason": map[string]any{"type": "string"},
+				"pass":   map[string]any{"type": "boolean"},
+			},
+			"required":             []string{"reason", "pass"},
+			"additionalProperties": false,
+		}
+	}
+	return map[string]any{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type":    "object",
+		"properties": map[string]any{
+			"criteria": map[string]any{
+				"type":                 "object",
+				"properties":           properties,
+				"required":             criteria,
+				"additionalProperties": false,
+			},
+		},
+		"required":             []string{"criteria"},
+		"additionalProperties": false,
+	}
+}
+
+// CreatePromptForJudge generates the prompt asking to judge the content
+// against the criteria. The context, optional, is the material the content
+// was produced from.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForJudge(subject, userRequest, context, content string, criteria []JudgeCriterion) (string, error) {
+	return Pins(nil).CreatePromptForJudge(subject, userRequest, context, content, criteria)
+}
+
+// CreatePromptForJudge is like the function CreatePromptForJudge, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForJudge(subject, userRequest, context, content string, criteria []JudgeCriterion) (string, error) {
+	tmpl, err := template.New("prompt_for_judge").Parse(p.text("judge"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Subject  string
+		Request  string
+		Context  string
+		Content  string
+		Criteria []JudgeCriterion
+	}{
+		Subject:  subject,
+		Request:  userRequest,
+		Context:  context,
+		Content:  content,
+		Criteria: criteria,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
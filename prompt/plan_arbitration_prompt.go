@@ -0,0 +1,84 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const planArbitrationPromptTemplate = `Two plans of function calls were created independently for the same user request. They disagree: choose the one that fulfills the request correctly.
+
+Check that the functions are appropriate, that the arguments match the request, and that nothing requested is missing or added. Answer "neither" if both plans are wrong.
+
+User request:
+{{.Request}}
+
+Function definitions:
+{{.FuncDefinitions}}
+
+Plan A:
+{{.PlanA}}
+
+Plan B:
+{{.PlanB}}`
+
+// PlanArbitrationResponseSchema is the JSON schema of the choice of the arbiter.
+var PlanArbitrationResponseSchema = map[string]any{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type":    "object",
+	"properties": map[string]any{
+		"choice": map[string]any{
+			"type": "string",
+			"enum": []string{"A", "B", "neither"},
+		},
+	},
+	"required":             []string{"choice"},
+	"additionalProperties": false,
+}
+
+// CreatePromptForPlanArbitration generates the prompt asking to choose between
+// two diverging plans for the same request.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForPlanArbitration(userRequest, funcDefinitions, planA, planB string) (string, error) {
+	return Pins(nil).CreatePromptForPlanArbitration(userRequest, funcDefinitions, planA, planB)
+}
+
+// CreatePromptForPlanArbitration is like the function CreatePromptForPlanArbitration, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForPlanArbitration(userRequest, funcDefinitions, planA, planB string) (string, error) {
+	tmpl, err := template.New("prompt_for_plan_arbitration").Parse(p.text("plan_arbitration"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Request         string
+		FuncDefinitions string
+		PlanA           string
+		PlanB           string
+	}{
+		Request:         userRequest,
+		FuncDefinitions: funcDefinitions,
+		PlanA:           planA,
+		PlanB:           planB,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
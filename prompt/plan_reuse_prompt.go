@@ -0,0 +1,65 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const planReusePromptTemplate = `A plan of function calls was created for a previous request. Adapt it to a new, similar request.
+
+Keep exactly the same functions, nesting and purposes: change only the literal argument values, so that they match the new request. Take the values from the new request only; do not carry over values of the previous request that the new one does not mention, unless they still apply.
+
+Previous request:
+{{.PreviousRequest}}
+
+Plan:
+{{.Plan}}
+
+New request:
+{{.Request}}`
+
+// CreatePromptForPlanReuse generates the prompt re-extracting the arguments of
+// the plan of a previous request for a similar new request.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForPlanReuse(previousRequest, plan, request string) (string, error) {
+	return Pins(nil).CreatePromptForPlanReuse(previousRequest, plan, request)
+}
+
+// CreatePromptForPlanReuse is like the function CreatePromptForPlanReuse, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForPlanReuse(previousRequest, plan, request string) (string, error) {
+	tmpl, err := template.New("prompt_for_plan_reuse").Parse(p.text("plan_reuse"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		PreviousRequest string
+		Plan            string
+		Request         string
+	}{
+		PreviousRequest: previousRequest,
+		Plan:            plan,
+		Request:         request,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
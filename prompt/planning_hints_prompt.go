@@ -0,0 +1,36 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"strings"
+)
+
+// CreatePlanningHints generates the notes appended to the planning system prompt
+// to report the calls that failed in previous turns of the conversation.
+func CreatePlanningHints(hints []string) string {
+	if len(hints) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nIn previous turns of this conversation, the following function calls failed or found nothing. ")
+	sb.WriteString("Do not plan them again with the same arguments: use more specific arguments (e.g. add the state or country to an ambiguous place name) or other functions.\n")
+	for _, hint := range hints {
+		sb.WriteString("- ")
+		sb.WriteString(hint)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
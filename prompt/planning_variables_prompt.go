@@ -0,0 +1,45 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// CreatePlanningVariables generates the notes appended to the planning system
+// prompt to report the variables set in the session, in name order.
+func CreatePlanningVariables(vars map[string]any) string {
+	if len(vars) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nThe following variables are set in this conversation. ")
+	sb.WriteString("Use their values where the request refers to them, and set_variable to change them.\n")
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		value, err := json.Marshal(vars[name])
+		if err != nil {
+			continue
+		}
+		sb.WriteString("- ")
+		sb.WriteString(name)
+		sb.WriteString(": ")
+		sb.Write(value)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
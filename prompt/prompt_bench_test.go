@@ -0,0 +1,54 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt_test
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/funcallarchitect/builtin"
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+func BenchmarkCreatePromptForFuncCalls(b *testing.B) {
+	ts := tools.Merge(
+		(&builtin.Calculator{}).AvailableTools(),
+		(&builtin.DateTime{}).AvailableTools(),
+		geo.New().AvailableTools(),
+	)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := prompt.CreatePromptForFuncCalls(ts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreatePromptForFuncCallsEvaluation(b *testing.B) {
+	ts := geo.New().AvailableTools()
+	definitions, err := ts.ToJSONDefinitions()
+	if err != nil {
+		b.Fatal(err)
+	}
+	plan := `{"name": "distance_between", "purpose": "Compute the distance", "args": {"from": {"lat": 45.07, "lon": 7.69}, "to": {"lat": 41.9, "lon": 12.5}}}`
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := prompt.CreatePromptForFuncCallsEvaluation("How far is Turin from Rome?", plan, string(definitions)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt_test
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/funcallarchitect/builtin"
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+func TestPlanningPromptsAreStable(t *testing.T) {
+	newToolSet := func() *tools.ToolSet {
+		return tools.Merge(
+			(&builtin.Calculator{}).AvailableTools(),
+			(&builtin.DateTime{}).AvailableTools(),
+			geo.New().AvailableTools(),
+		)
+	}
+	for name, create := range map[string]func(ts *tools.ToolSet) (string, error){
+		"nested": prompt.CreatePromptForFuncCalls,
+		"flat":   prompt.CreatePromptForFlatFuncCalls,
+	} {
+		t.Run(name, func(t *testing.T) {
+			want, err := create(newToolSet())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 20; i++ {
+				got, err := create(newToolSet())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Fatalf("run %d differs:\n%s\nwant:\n%s", i, got, want)
+				}
+			}
+		})
+	}
+}
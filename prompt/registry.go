@@ -0,0 +1,182 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Template is a version of a built-in prompt template.
+//
+// Changing a template requires registering a new version, with migration
+// notes, and keeping the text of the previous versions for the deployments
+// pinning them (see Pins). Verify detects the templates modified in place.
+type Template struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Text    string `json:"-"`
+	// Fingerprint is the SHA-256 of Text, recorded with the version.
+	Fingerprint string `json:"fingerprint"`
+	// Notes are the migration notes: what changed since the previous version,
+	// and the models it was tested against.
+	Notes string `json:"notes,omitempty"`
+}
+
+var (
+	registryMu sync.RWMutex
+	// registry holds the versions of the templates by name, oldest first.
+	registry = make(map[string][]Template)
+)
+
+// Pins selects the versions of the templates by name, e.g. in the
+// configuration of a deployment; the templates not pinned use the latest
+// version. The nil Pins uses the latest version of every template.
+type Pins map[string]int
+
+func init() {
+	for _, t := range []Template{
+		{"func_calls", 1, funcCallsPromptTemplate, "d8fc0ca9097b856b3e1a2034375d1b8946716fe891594de3c3e73f81f17f3a6a",
+			"Initial version, tested against Meta-Llama-3.1-8B-Instruct-Q5_K_M."},
+		{"flat_func_calls", 1, flatFuncCallsPromptTemplate, "02290ac5ed7237ce142a0eeb8ec1d7d93e99ab8347bfaf0713083a812ffa268d",
+			"Initial version."},
+		{"description_optimization", 1, descriptionOptimizationPromptTemplate, "d99913848ee0c48a31f16871fbef0eff501e0d61db27489de543c7bdc9fa7e77",
+			"Initial version."},
+		{"evaluation", 1, funcCallsEvaluationPromptTemplate, "48e9914d3c1a2f348b228b0740a3c922b588e6cefa2ece39f2953f472be4d157",
+			"Initial version, tested against Meta-Llama-3.1-8B-Instruct-Q5_K_M."},
+		{"extraction", 1, extractionPromptTemplate, "51e18aaf61b0427716552b588698136ecc8b72f0b738cae0b4a8f916fb6b63e4",
+			"Initial version."},
+		{"judge", 1, judgePromptTemplate, "a687c7a956964e52cc0b314817fc59feff9a0bd244d288a455770ebb9c5d463c",
+			"Initial version."},
+		{"plan_arbitration", 1, planArbitrationPromptTemplate, "9d69203a1a2ed37df42fd68bf4d00ab89e53e5ff3e64ade00123a845d8595258",
+			"Initial version."},
+		{"plan_reuse", 1, planReusePromptTemplate, "18b0b8577cf52fef99e2836995ed9453a4bfbe015a8d66f8d0a7a3aa9c65aa2e",
+			"Initial version."},
+		{"replanning", 1, replanningPromptTemplate, "55a7c2d87585eb4781c491cc3a9c18b55c91c3ee960ad32998cb410643d7b977",
+			"Initial version."},
+		{"summarization", 1, summarizationPromptTemplate, "92553e82d8032cd086ddb4fcf299d5f1a00b3144a89d5901c92d8afcdc9aa137",
+			"Initial version."},
+		{"synthesis", 1, synthesisPromptTemplate, "2ade1d1f4a7f2fc89a50594d2a06de99f34e434d2134951cc3e2c40aa5bb6f29",
+			"Initial version."},
+		{"translation", 1, translationPromptTemplate, "bd3d5394bc5b37c7898e3cd2024fec64dec48330012d667bf61965994f50e1c0",
+			"Initial version."},
+	} {
+		registry[t.Name] = append(registry[t.Name], t)
+	}
+}
+
+// Names returns the names of the built-in templates, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Versions returns the versions of the template, oldest first.
+func Versions(name string) []Template {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Template(nil), registry[name]...)
+}
+
+// Validate checks that the pinned templates and versions exist.
+func (p Pins) Validate() error {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for name, version := range p {
+		versions, ok := registry[name]
+		if !ok {
+			return fmt.Errorf("unknown prompt template %q", name)
+		}
+		if !slices.ContainsFunc(versions, func(t Template) bool { return t.Version == version }) {
+			return fmt.Errorf("unknown version %d of prompt template %q (latest is %d)", version, name, versions[len(versions)-1].Version)
+		}
+	}
+	return nil
+}
+
+// Active returns the version of the template in use: the pinned one, or the latest.
+func (p Pins) Active(name string) (Template, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return p.active(name)
+}
+
+func (p Pins) active(name string) (Template, bool) {
+	versions := registry[name]
+	if len(versions) == 0 {
+		return Template{}, false
+	}
+	if pinned, ok := p[name]; ok {
+		for _, t := range versions {
+			if t.Version == pinned {
+				return t, true
+			}
+		}
+	}
+	return versions[len(versions)-1], true
+}
+
+// All returns the version in use of every template, by name.
+func (p Pins) All() map[string]int {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	versions := make(map[string]int, len(registry))
+	for name := range registry {
+		t, _ := p.active(name)
+		versions[name] = t.Version
+	}
+	return versions
+}
+
+// Verify reports the templates whose text does not match the fingerprint of
+// their version, i.e. modified without registering a new version.
+func Verify() error {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var modified []string
+	for name, versions := range registry {
+		for _, t := range versions {
+			sum := sha256.Sum256([]byte(t.Text))
+			if hex.EncodeToString(sum[:]) != t.Fingerprint {
+				modified = append(modified, fmt.Sprintf("%s v%d", name, t.Version))
+			}
+		}
+	}
+	if len(modified) > 0 {
+		sort.Strings(modified)
+		return fmt.Errorf("prompt templates modified without a new version: %s", strings.Join(modified, ", "))
+	}
+	return nil
+}
+
+// text returns the text of the template in use.
+func (p Pins) text(name string) string {
+	t, ok := p.Active(name)
+	if !ok {
+		panic(fmt.Sprintf("prompt: unknown template %q", name))
+	}
+	return t.Text
+}
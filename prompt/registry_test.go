@@ -0,0 +1,75 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+// withVersion registers a new version of the template for the test.
+func withVersion(t *testing.T, name, text string) Template {
+	t.Helper()
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	versions := registry[name]
+	v := Template{Name: name, Version: versions[len(versions)-1].Version + 1, Text: text}
+	registry[name] = append(versions, v)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		registry[name] = versions
+	})
+	return v
+}
+
+func TestPinsSelectTheVersions(t *testing.T) {
+	v2 := withVersion(t, "translation", "Translate into {{.TargetLanguage}}: {{.Text}}")
+
+	latest, err := CreatePromptForTranslation("ciao", "English", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != "Translate into English: ciao" {
+		t.Fatalf("the latest version was not used: %q", latest)
+	}
+
+	pinned := Pins{"translation": 1}
+	first, err := pinned.CreatePromptForTranslation("ciao", "English", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first, "You are a translation assistant.") {
+		t.Fatalf("the pinned version was not used: %q", first)
+	}
+	if got := pinned.All()["translation"]; got != 1 {
+		t.Errorf("pinned version is %d, want 1", got)
+	}
+	if got := Pins(nil).All()["translation"]; got != v2.Version {
+		t.Errorf("latest version is %d, want %d", got, v2.Version)
+	}
+}
+
+func TestPinsValidate(t *testing.T) {
+	if err := (Pins{"translation": 1, "judge": 1}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Pins{"translation": 99}).Validate(); err == nil {
+		t.Error("an unknown version was accepted")
+	}
+	if err := (Pins{"unknown": 1}).Validate(); err == nil {
+		t.Error("an unknown template was accepted")
+	}
+}
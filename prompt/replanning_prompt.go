@@ -0,0 +1,53 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const replanningPromptTemplate = `The execution of your plan failed with the following error:
+{{.Error}}
+
+Produce a corrected plan for the same request, following the same rules. If the error shows that the request cannot be fulfilled with the available functions, return an empty list of main functions.`
+
+// CreatePromptForReplanning generates the feedback asking for a new plan after the execution
+// of the previous one, sent as the preceding assistant message, failed.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForReplanning(executionError string) (string, error) {
+	return Pins(nil).CreatePromptForReplanning(executionError)
+}
+
+// CreatePromptForReplanning is like the function CreatePromptForReplanning, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForReplanning(executionError string) (string, error) {
+	tmpl, err := template.New("prompt_for_replanning").Parse(p.text("replanning"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Error string
+	}{
+		Error: executionError,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
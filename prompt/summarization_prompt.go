@@ -0,0 +1,74 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const summarizationPromptTemplate = `You are a summarization assistant. Your task is to condense the provided text, keeping the information that matters most and never adding information that is not in the text.
+
+Write the summary {{.StyleInstruction}}, using at most {{.MaxWords}} words. Respond with the summary only, without any introduction or additional comment.
+
+Text:
+{{.Text}}`
+
+var summarizationStyleInstructions = map[string]string{
+	"paragraph":     "as a single paragraph of plain prose",
+	"bullet_points": "as a list of bullet points, one per line, each starting with \"- \"",
+	"headline":      "as a single headline-like sentence",
+}
+
+// SummarizationStyles returns the supported summary styles.
+func SummarizationStyles() []string {
+	return []string{"paragraph", "bullet_points", "headline"}
+}
+
+// CreatePromptForSummarization generates a prompt for summarizing a text within a length budget
+// It uses the latest version of the template (see Pins).
+func CreatePromptForSummarization(text, style string, maxWords int) (string, error) {
+	return Pins(nil).CreatePromptForSummarization(text, style, maxWords)
+}
+
+// CreatePromptForSummarization is like the function CreatePromptForSummarization, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForSummarization(text, style string, maxWords int) (string, error) {
+	instruction, ok := summarizationStyleInstructions[style]
+	if !ok {
+		return "", fmt.Errorf("unknown summarization style %q", style)
+	}
+
+	tmpl, err := template.New("prompt_for_summarization").Parse(p.text("summarization"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Text             string
+		StyleInstruction string
+		MaxWords         int
+	}{
+		Text:             text,
+		StyleInstruction: instruction,
+		MaxWords:         maxWords,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
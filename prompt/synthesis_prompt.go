@@ -0,0 +1,62 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const synthesisPromptTemplate = `You are an assistant answering the request of a user. The request has already been processed by calling some functions, whose results are reported below.
+
+Write a concise answer to the request using only the information contained in the results. Do not mention the functions, do not add information that is not in the results, and say so plainly if the results do not answer the request. {{if .Language}}Answer in {{.Language}}.{{else}}Answer in the language of the request.{{end}} Respond with the answer only.
+
+Request:
+{{.Request}}
+
+Results:
+{{.Results}}`
+
+// CreatePromptForSynthesis generates a prompt for composing the answer to a request from the formatted results.
+// The language is optional: when empty, the answer uses the language of the request.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForSynthesis(request, results, language string) (string, error) {
+	return Pins(nil).CreatePromptForSynthesis(request, results, language)
+}
+
+// CreatePromptForSynthesis is like the function CreatePromptForSynthesis, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForSynthesis(request, results, language string) (string, error) {
+	tmpl, err := template.New("prompt_for_synthesis").Parse(p.text("synthesis"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Request  string
+		Results  string
+		Language string
+	}{
+		Request:  request,
+		Results:  results,
+		Language: language,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
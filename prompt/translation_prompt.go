@@ -0,0 +1,59 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const translationPromptTemplate = `You are a translation assistant. Your task is to translate the provided text into {{.TargetLanguage}}{{if .SourceLanguage}} from {{.SourceLanguage}}{{end}}.
+
+Preserve the meaning, the tone and the formatting of the text (line breaks, lists, markdown). Do not translate numbers, units, code, URLs or proper names. If the text is already in {{.TargetLanguage}}, return it unchanged. Respond with the translation only, without any introduction or additional comment.
+
+Text:
+{{.Text}}`
+
+// CreatePromptForTranslation generates a prompt for translating a text into the target language.
+// The source language is optional and is detected by the model when empty.
+// It uses the latest version of the template (see Pins).
+func CreatePromptForTranslation(text, targetLanguage, sourceLanguage string) (string, error) {
+	return Pins(nil).CreatePromptForTranslation(text, targetLanguage, sourceLanguage)
+}
+
+// CreatePromptForTranslation is like the function CreatePromptForTranslation, with the
+// versions of the templates pinned by p.
+func (p Pins) CreatePromptForTranslation(text, targetLanguage, sourceLanguage string) (string, error) {
+	tmpl, err := template.New("prompt_for_translation").Parse(p.text("translation"))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Text           string
+		TargetLanguage string
+		SourceLanguage string
+	}{
+		Text:           text,
+		TargetLanguage: targetLanguage,
+		SourceLanguage: sourceLanguage,
+	}); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	return buf.String(), nil
+}
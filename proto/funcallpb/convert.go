@@ -0,0 +1,327 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package funcallpb defines the protobuf wire format of plans, execution
+// results and tool sets, with converters from and to the Go types.
+//
+// The generated code is produced from funcall.proto, from the parent directory:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative funcallpb/funcall.proto
+package funcallpb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nlpodyssey/funcallarchitect/codec"
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FromPlannedFuncCalls converts the main function calls of a plan.
+func FromPlannedFuncCalls(understanding string, calls []parser.PlannedFuncCall) (*Plan, error) {
+	plan := &Plan{Understanding: understanding}
+	for _, c := range calls {
+		pc, err := fromPlannedFuncCall(c)
+		if err != nil {
+			return nil, err
+		}
+		plan.MainFunctions = append(plan.MainFunctions, pc)
+	}
+	return plan, nil
+}
+
+func fromPlannedFuncCall(c parser.PlannedFuncCall) (*PlannedFuncCall, error) {
+	pc := &PlannedFuncCall{Name: c.Name, Purpose: c.Purpose, Args: make(map[string]*PlannedArg, len(c.Args))}
+	for name, arg := range c.Args {
+		if nested, ok := arg.(*parser.PlannedFuncCall); ok {
+			n, err := fromPlannedFuncCall(*nested)
+			if err != nil {
+				return nil, err
+			}
+			pc.Args[name] = &PlannedArg{Kind: &PlannedArg_FuncCall{FuncCall: n}}
+			continue
+		}
+		v, err := toValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("error converting argument %s of %s: %w", name, c.Name, err)
+		}
+		pc.Args[name] = &PlannedArg{Kind: &PlannedArg_Value{Value: v}}
+	}
+	return pc, nil
+}
+
+// ToPlannedFuncCalls converts a plan back into the main function calls.
+func ToPlannedFuncCalls(plan *Plan) []parser.PlannedFuncCall {
+	calls := make([]parser.PlannedFuncCall, len(plan.GetMainFunctions()))
+	for i, pc := range plan.GetMainFunctions() {
+		calls[i] = *toPlannedFuncCall(pc)
+	}
+	return calls
+}
+
+func toPlannedFuncCall(pc *PlannedFuncCall) *parser.PlannedFuncCall {
+	c := &parser.PlannedFuncCall{Name: pc.GetName(), Purpose: pc.GetPurpose(), Args: make(map[string]interface{}, len(pc.GetArgs()))}
+	for name, arg := range pc.GetArgs() {
+		if nested := arg.GetFuncCall(); nested != nil {
+			c.Args[name] = toPlannedFuncCall(nested)
+		} else {
+			c.Args[name] = arg.GetValue().AsInterface()
+		}
+	}
+	return c
+}
+
+// FromResult converts an execution result. The results are formatted during
+// the conversion, since formatting functions cannot be serialized.
+func FromResult(r *execution.Result) (*Result, error) {
+	result := &Result{}
+	for _, f := range r.FuncCalls {
+		ef, err := fromExecutedFuncCall(f)
+		if err != nil {
+			return nil, err
+		}
+		result.FuncCalls = append(result.FuncCalls, ef)
+	}
+	return result, nil
+}
+
+func fromExecutedFuncCall(f *execution.ExecutedFuncCall) (*ExecutedFuncCall, error) {
+	ef := &ExecutedFuncCall{Name: f.Name, Purpose: f.Purpose, Args: make(map[string]*Arg, len(f.Args))}
+	for name, arg := range f.Args {
+		if nested, ok := execution.GetFuncCall(arg); ok {
+			n, err := fromExecutedFuncCall(nested)
+			if err != nil {
+				return nil, err
+			}
+			ef.Args[name] = &Arg{Kind: &Arg_FuncCall{FuncCall: n}}
+			continue
+		}
+		value, _ := execution.GetValue(arg)
+		v, err := toValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("error converting argument %s of %s: %w", name, f.Name, err)
+		}
+		ef.Args[name] = &Arg{Kind: &Arg_Value{Value: v}}
+	}
+
+	result, err := fromFuncResult(f.Result)
+	if err != nil {
+		return nil, fmt.Errorf("error converting result of %s: %w", f.Name, err)
+	}
+	ef.Result = result
+
+	if f.Receipt != nil {
+		if ef.Receipt, err = fromReceipt(f.Receipt); err != nil {
+			return nil, fmt.Errorf("error converting receipt of %s: %w", f.Name, err)
+		}
+	}
+	return ef, nil
+}
+
+func fromFuncResult(r execution.FuncResult) (*FuncResult, error) {
+	var err error
+	fr := &FuncResult{Present: r.Present}
+	if fr.Value, err = toValue(r.Value); err != nil {
+		return nil, err
+	}
+	if fr.Metadata, err = toValue(r.Metadata); err != nil {
+		return nil, err
+	}
+	if r.FormatFunc != nil {
+		if fr.Formatted, err = r.FormatFunc(); err != nil {
+			return nil, fmt.Errorf("error formatting result: %w", err)
+		}
+	}
+	return fr, nil
+}
+
+func fromReceipt(r *execution.Receipt) (*Receipt, error) {
+	args, err := toValue(r.Args)
+	if err != nil {
+		return nil, err
+	}
+	details, err := toValue(r.Details)
+	if err != nil {
+		return nil, err
+	}
+	return &Receipt{
+		Id:        r.ID,
+		FuncName:  r.FuncName,
+		Args:      args.GetStructValue(),
+		Approved:  r.Approved,
+		Outcome:   string(r.Outcome),
+		Error:     r.Error,
+		Timestamp: timestamppb.New(r.Timestamp),
+		Details:   details,
+	}, nil
+}
+
+// ToResult converts a result back. Values are decoded as generic JSON values
+// (maps, slices, float64...), and the formatting functions return the stored text.
+func ToResult(r *Result) *execution.Result {
+	result := &execution.Result{FuncCalls: make([]*execution.ExecutedFuncCall, len(r.GetFuncCalls()))}
+	for i, f := range r.GetFuncCalls() {
+		result.FuncCalls[i] = toExecutedFuncCall(f)
+	}
+	return result
+}
+
+func toExecutedFuncCall(ef *ExecutedFuncCall) *execution.ExecutedFuncCall {
+	f := &execution.ExecutedFuncCall{Name: ef.GetName(), Purpose: ef.GetPurpose(), Args: make(map[string]execution.Arg, len(ef.GetArgs()))}
+	for name, arg := range ef.GetArgs() {
+		if nested := arg.GetFuncCall(); nested != nil {
+			f.Args[name] = execution.NewFuncArg(toExecutedFuncCall(nested))
+		} else {
+			f.Args[name] = execution.NewValueArg(arg.GetValue().AsInterface())
+		}
+	}
+
+	r := ef.GetResult()
+	f.Result = execution.FuncResult{
+		Present:  r.GetPresent(),
+		Value:    fromValue(r.GetValue()),
+		Metadata: fromValue(r.GetMetadata()),
+	}
+	if formatted := r.GetFormatted(); formatted != "" {
+		f.Result.FormatFunc = func() (string, error) { return formatted, nil }
+	}
+
+	if rc := ef.GetReceipt(); rc != nil {
+		f.Receipt = &execution.Receipt{
+			ID:        rc.GetId(),
+			FuncName:  rc.GetFuncName(),
+			Args:      rc.GetArgs().AsMap(),
+			Approved:  rc.GetApproved(),
+			Outcome:   execution.ReceiptOutcome(rc.GetOutcome()),
+			Error:     rc.GetError(),
+			Timestamp: rc.GetTimestamp().AsTime(),
+			Details:   fromValue(rc.GetDetails()),
+		}
+	}
+	return f
+}
+
+// FromToolSet converts a tool set.
+func FromToolSet(ts *tools.ToolSet) *ToolSet {
+	pts := &ToolSet{TypeDefinitions: make(map[string]*TypeInfo, len(ts.TypeDefinitions))}
+	for _, f := range ts.Functions {
+		pts.Functions = append(pts.Functions, &FuncDefinition{
+			Name:        f.Name,
+			Description: f.Description,
+			Parameters:  fromTypeInfo(f.Parameters),
+			Returns:     fromTypeInfo(f.Returns),
+			SideEffect:  f.SideEffect,
+		})
+	}
+	for name, info := range ts.TypeDefinitions {
+		pts.TypeDefinitions[name] = fromTypeInfo(info)
+	}
+	return pts
+}
+
+func fromTypeInfo(info tools.TypeInfo) *TypeInfo {
+	ti := &TypeInfo{
+		Type:        info.Type,
+		Description: info.Description,
+		Required:    info.Required,
+		Enum:        info.Enum,
+		Pattern:     info.Pattern,
+	}
+	if info.Items != nil {
+		ti.Items = fromTypeInfo(*info.Items)
+	}
+	if info.Properties != nil {
+		ti.Properties = make(map[string]*TypeInfo, len(info.Properties))
+		for name, p := range info.Properties {
+			ti.Properties[name] = fromTypeInfo(p)
+		}
+	}
+	return ti
+}
+
+// ToToolSet converts a tool set back.
+func ToToolSet(pts *ToolSet) *tools.ToolSet {
+	ts := &tools.ToolSet{TypeDefinitions: make(map[string]tools.TypeInfo, len(pts.GetTypeDefinitions()))}
+	for _, f := range pts.GetFunctions() {
+		ts.Functions = append(ts.Functions, tools.FuncDefinition{
+			Name:        f.GetName(),
+			Description: f.GetDescription(),
+			Parameters:  toTypeInfo(f.GetParameters()),
+			Returns:     toTypeInfo(f.GetReturns()),
+			SideEffect:  f.GetSideEffect(),
+		})
+	}
+	for name, info := range pts.GetTypeDefinitions() {
+		ts.TypeDefinitions[name] = toTypeInfo(info)
+	}
+	return ts
+}
+
+func toTypeInfo(ti *TypeInfo) tools.TypeInfo {
+	if ti == nil {
+		return tools.TypeInfo{}
+	}
+	info := tools.TypeInfo{
+		Type:        ti.GetType(),
+		Description: ti.GetDescription(),
+		Required:    ti.GetRequired(),
+		Enum:        ti.GetEnum(),
+		Pattern:     ti.GetPattern(),
+	}
+	if ti.GetItems() != nil {
+		items := toTypeInfo(ti.GetItems())
+		info.Items = &items
+	}
+	if len(ti.GetProperties()) > 0 {
+		info.Properties = make(map[string]tools.TypeInfo, len(ti.GetProperties()))
+		for name, p := range ti.GetProperties() {
+			info.Properties[name] = toTypeInfo(p)
+		}
+	}
+	return info
+}
+
+// toValue converts a Go value into a protobuf Value. Values not supported by
+// structpb (e.g. structs returned by executors) go through their encoding in
+// the value codec registry, which defaults to JSON.
+func toValue(v interface{}) (*structpb.Value, error) {
+	if v == nil {
+		return structpb.NewNullValue(), nil
+	}
+	if _, registered := codec.ValueType(v); !registered {
+		if value, err := structpb.NewValue(v); err == nil {
+			return value, nil
+		}
+	}
+	tv, err := codec.EncodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(tv.Data, &generic); err != nil {
+		return nil, fmt.Errorf("error unmarshaling value: %w", err)
+	}
+	return structpb.NewValue(generic)
+}
+
+func fromValue(v *structpb.Value) interface{} {
+	if v == nil {
+		return nil
+	}
+	return v.AsInterface()
+}
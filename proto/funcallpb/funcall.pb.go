@@ -0,0 +1,1012 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: funcallpb/funcall.proto
+
+package funcallpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Plan is the list of main function calls planned for a request.
+type Plan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Understanding string                 `protobuf:"bytes,1,opt,name=understanding,proto3" json:"understanding,omitempty"`
+	MainFunctions []*PlannedFuncCall     `protobuf:"bytes,2,rep,name=main_functions,json=mainFunctions,proto3" json:"main_functions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Plan) Reset() {
+	*x = Plan{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plan) ProtoMessage() {}
+
+func (x *Plan) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plan.ProtoReflect.Descriptor instead.
+func (*Plan) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Plan) GetUnderstanding() string {
+	if x != nil {
+		return x.Understanding
+	}
+	return ""
+}
+
+func (x *Plan) GetMainFunctions() []*PlannedFuncCall {
+	if x != nil {
+		return x.MainFunctions
+	}
+	return nil
+}
+
+// PlannedFuncCall is a function call planned by the LLM.
+type PlannedFuncCall struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Purpose       string                 `protobuf:"bytes,2,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	Args          map[string]*PlannedArg `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlannedFuncCall) Reset() {
+	*x = PlannedFuncCall{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlannedFuncCall) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlannedFuncCall) ProtoMessage() {}
+
+func (x *PlannedFuncCall) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlannedFuncCall.ProtoReflect.Descriptor instead.
+func (*PlannedFuncCall) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PlannedFuncCall) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PlannedFuncCall) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+func (x *PlannedFuncCall) GetArgs() map[string]*PlannedArg {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+// PlannedArg is either a literal value or a nested function call.
+type PlannedArg struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*PlannedArg_Value
+	//	*PlannedArg_FuncCall
+	Kind          isPlannedArg_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlannedArg) Reset() {
+	*x = PlannedArg{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlannedArg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlannedArg) ProtoMessage() {}
+
+func (x *PlannedArg) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlannedArg.ProtoReflect.Descriptor instead.
+func (*PlannedArg) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PlannedArg) GetKind() isPlannedArg_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *PlannedArg) GetValue() *structpb.Value {
+	if x != nil {
+		if x, ok := x.Kind.(*PlannedArg_Value); ok {
+			return x.Value
+		}
+	}
+	return nil
+}
+
+func (x *PlannedArg) GetFuncCall() *PlannedFuncCall {
+	if x != nil {
+		if x, ok := x.Kind.(*PlannedArg_FuncCall); ok {
+			return x.FuncCall
+		}
+	}
+	return nil
+}
+
+type isPlannedArg_Kind interface {
+	isPlannedArg_Kind()
+}
+
+type PlannedArg_Value struct {
+	Value *structpb.Value `protobuf:"bytes,1,opt,name=value,proto3,oneof"`
+}
+
+type PlannedArg_FuncCall struct {
+	FuncCall *PlannedFuncCall `protobuf:"bytes,2,opt,name=func_call,json=funcCall,proto3,oneof"`
+}
+
+func (*PlannedArg_Value) isPlannedArg_Kind() {}
+
+func (*PlannedArg_FuncCall) isPlannedArg_Kind() {}
+
+// Result is the outcome of the execution of a plan.
+type Result struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FuncCalls     []*ExecutedFuncCall    `protobuf:"bytes,1,rep,name=func_calls,json=funcCalls,proto3" json:"func_calls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Result) GetFuncCalls() []*ExecutedFuncCall {
+	if x != nil {
+		return x.FuncCalls
+	}
+	return nil
+}
+
+// ExecutedFuncCall is a function call together with its result.
+type ExecutedFuncCall struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Name    string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Purpose string                 `protobuf:"bytes,2,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	Args    map[string]*Arg        `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Result  *FuncResult            `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"`
+	// Receipt is set for side-effect functions only.
+	Receipt       *Receipt `protobuf:"bytes,5,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecutedFuncCall) Reset() {
+	*x = ExecutedFuncCall{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutedFuncCall) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutedFuncCall) ProtoMessage() {}
+
+func (x *ExecutedFuncCall) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutedFuncCall.ProtoReflect.Descriptor instead.
+func (*ExecutedFuncCall) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExecutedFuncCall) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ExecutedFuncCall) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+func (x *ExecutedFuncCall) GetArgs() map[string]*Arg {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *ExecutedFuncCall) GetResult() *FuncResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *ExecutedFuncCall) GetReceipt() *Receipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+// Arg is either a literal value or an executed nested function call.
+type Arg struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*Arg_Value
+	//	*Arg_FuncCall
+	Kind          isArg_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Arg) Reset() {
+	*x = Arg{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Arg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Arg) ProtoMessage() {}
+
+func (x *Arg) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Arg.ProtoReflect.Descriptor instead.
+func (*Arg) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Arg) GetKind() isArg_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *Arg) GetValue() *structpb.Value {
+	if x != nil {
+		if x, ok := x.Kind.(*Arg_Value); ok {
+			return x.Value
+		}
+	}
+	return nil
+}
+
+func (x *Arg) GetFuncCall() *ExecutedFuncCall {
+	if x != nil {
+		if x, ok := x.Kind.(*Arg_FuncCall); ok {
+			return x.FuncCall
+		}
+	}
+	return nil
+}
+
+type isArg_Kind interface {
+	isArg_Kind()
+}
+
+type Arg_Value struct {
+	Value *structpb.Value `protobuf:"bytes,1,opt,name=value,proto3,oneof"`
+}
+
+type Arg_FuncCall struct {
+	FuncCall *ExecutedFuncCall `protobuf:"bytes,2,opt,name=func_call,json=funcCall,proto3,oneof"`
+}
+
+func (*Arg_Value) isArg_Kind() {}
+
+func (*Arg_FuncCall) isArg_Kind() {}
+
+// FuncResult is the outcome of a function execution. The formatting
+// function is evaluated at conversion time and stored as text.
+type FuncResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Present       bool                   `protobuf:"varint,1,opt,name=present,proto3" json:"present,omitempty"`
+	Value         *structpb.Value        `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Formatted     string                 `protobuf:"bytes,3,opt,name=formatted,proto3" json:"formatted,omitempty"`
+	Metadata      *structpb.Value        `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FuncResult) Reset() {
+	*x = FuncResult{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FuncResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FuncResult) ProtoMessage() {}
+
+func (x *FuncResult) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FuncResult.ProtoReflect.Descriptor instead.
+func (*FuncResult) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FuncResult) GetPresent() bool {
+	if x != nil {
+		return x.Present
+	}
+	return false
+}
+
+func (x *FuncResult) GetValue() *structpb.Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *FuncResult) GetFormatted() string {
+	if x != nil {
+		return x.Formatted
+	}
+	return ""
+}
+
+func (x *FuncResult) GetMetadata() *structpb.Value {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// Receipt records the execution of a side-effect function.
+type Receipt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	FuncName      string                 `protobuf:"bytes,2,opt,name=func_name,json=funcName,proto3" json:"func_name,omitempty"`
+	Args          *structpb.Struct       `protobuf:"bytes,3,opt,name=args,proto3" json:"args,omitempty"`
+	Approved      bool                   `protobuf:"varint,4,opt,name=approved,proto3" json:"approved,omitempty"`
+	Outcome       string                 `protobuf:"bytes,5,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Details       *structpb.Value        `protobuf:"bytes,8,opt,name=details,proto3" json:"details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Receipt) Reset() {
+	*x = Receipt{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Receipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Receipt) ProtoMessage() {}
+
+func (x *Receipt) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Receipt.ProtoReflect.Descriptor instead.
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Receipt) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Receipt) GetFuncName() string {
+	if x != nil {
+		return x.FuncName
+	}
+	return ""
+}
+
+func (x *Receipt) GetArgs() *structpb.Struct {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *Receipt) GetApproved() bool {
+	if x != nil {
+		return x.Approved
+	}
+	return false
+}
+
+func (x *Receipt) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *Receipt) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Receipt) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Receipt) GetDetails() *structpb.Value {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+// ToolSet describes the available functions and their types.
+type ToolSet struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Functions       []*FuncDefinition      `protobuf:"bytes,1,rep,name=functions,proto3" json:"functions,omitempty"`
+	TypeDefinitions map[string]*TypeInfo   `protobuf:"bytes,2,rep,name=type_definitions,json=typeDefinitions,proto3" json:"type_definitions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ToolSet) Reset() {
+	*x = ToolSet{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolSet) ProtoMessage() {}
+
+func (x *ToolSet) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolSet.ProtoReflect.Descriptor instead.
+func (*ToolSet) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ToolSet) GetFunctions() []*FuncDefinition {
+	if x != nil {
+		return x.Functions
+	}
+	return nil
+}
+
+func (x *ToolSet) GetTypeDefinitions() map[string]*TypeInfo {
+	if x != nil {
+		return x.TypeDefinitions
+	}
+	return nil
+}
+
+type FuncDefinition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Parameters    *TypeInfo              `protobuf:"bytes,3,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	Returns       *TypeInfo              `protobuf:"bytes,4,opt,name=returns,proto3" json:"returns,omitempty"`
+	SideEffect    bool                   `protobuf:"varint,5,opt,name=side_effect,json=sideEffect,proto3" json:"side_effect,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FuncDefinition) Reset() {
+	*x = FuncDefinition{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FuncDefinition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FuncDefinition) ProtoMessage() {}
+
+func (x *FuncDefinition) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FuncDefinition.ProtoReflect.Descriptor instead.
+func (*FuncDefinition) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FuncDefinition) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FuncDefinition) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *FuncDefinition) GetParameters() *TypeInfo {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *FuncDefinition) GetReturns() *TypeInfo {
+	if x != nil {
+		return x.Returns
+	}
+	return nil
+}
+
+func (x *FuncDefinition) GetSideEffect() bool {
+	if x != nil {
+		return x.SideEffect
+	}
+	return false
+}
+
+type TypeInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Items         *TypeInfo              `protobuf:"bytes,3,opt,name=items,proto3" json:"items,omitempty"`
+	Properties    map[string]*TypeInfo   `protobuf:"bytes,4,rep,name=properties,proto3" json:"properties,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Required      []string               `protobuf:"bytes,5,rep,name=required,proto3" json:"required,omitempty"`
+	Enum          []string               `protobuf:"bytes,6,rep,name=enum,proto3" json:"enum,omitempty"`
+	Pattern       string                 `protobuf:"bytes,7,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TypeInfo) Reset() {
+	*x = TypeInfo{}
+	mi := &file_funcallpb_funcall_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TypeInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypeInfo) ProtoMessage() {}
+
+func (x *TypeInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_funcallpb_funcall_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypeInfo.ProtoReflect.Descriptor instead.
+func (*TypeInfo) Descriptor() ([]byte, []int) {
+	return file_funcallpb_funcall_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TypeInfo) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TypeInfo) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *TypeInfo) GetItems() *TypeInfo {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *TypeInfo) GetProperties() map[string]*TypeInfo {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+func (x *TypeInfo) GetRequired() []string {
+	if x != nil {
+		return x.Required
+	}
+	return nil
+}
+
+func (x *TypeInfo) GetEnum() []string {
+	if x != nil {
+		return x.Enum
+	}
+	return nil
+}
+
+func (x *TypeInfo) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+var File_funcallpb_funcall_proto protoreflect.FileDescriptor
+
+const file_funcallpb_funcall_proto_rawDesc = "" +
+	"\n" +
+	"\x17funcallpb/funcall.proto\x12\n" +
+	"funcall.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"p\n" +
+	"\x04Plan\x12$\n" +
+	"\runderstanding\x18\x01 \x01(\tR\runderstanding\x12B\n" +
+	"\x0emain_functions\x18\x02 \x03(\v2\x1b.funcall.v1.PlannedFuncCallR\rmainFunctions\"\xcb\x01\n" +
+	"\x0fPlannedFuncCall\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\apurpose\x18\x02 \x01(\tR\apurpose\x129\n" +
+	"\x04args\x18\x03 \x03(\v2%.funcall.v1.PlannedFuncCall.ArgsEntryR\x04args\x1aO\n" +
+	"\tArgsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.funcall.v1.PlannedArgR\x05value:\x028\x01\"\x80\x01\n" +
+	"\n" +
+	"PlannedArg\x12.\n" +
+	"\x05value\x18\x01 \x01(\v2\x16.google.protobuf.ValueH\x00R\x05value\x12:\n" +
+	"\tfunc_call\x18\x02 \x01(\v2\x1b.funcall.v1.PlannedFuncCallH\x00R\bfuncCallB\x06\n" +
+	"\x04kind\"E\n" +
+	"\x06Result\x12;\n" +
+	"\n" +
+	"func_calls\x18\x01 \x03(\v2\x1c.funcall.v1.ExecutedFuncCallR\tfuncCalls\"\xa5\x02\n" +
+	"\x10ExecutedFuncCall\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\apurpose\x18\x02 \x01(\tR\apurpose\x12:\n" +
+	"\x04args\x18\x03 \x03(\v2&.funcall.v1.ExecutedFuncCall.ArgsEntryR\x04args\x12.\n" +
+	"\x06result\x18\x04 \x01(\v2\x16.funcall.v1.FuncResultR\x06result\x12-\n" +
+	"\areceipt\x18\x05 \x01(\v2\x13.funcall.v1.ReceiptR\areceipt\x1aH\n" +
+	"\tArgsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12%\n" +
+	"\x05value\x18\x02 \x01(\v2\x0f.funcall.v1.ArgR\x05value:\x028\x01\"z\n" +
+	"\x03Arg\x12.\n" +
+	"\x05value\x18\x01 \x01(\v2\x16.google.protobuf.ValueH\x00R\x05value\x12;\n" +
+	"\tfunc_call\x18\x02 \x01(\v2\x1c.funcall.v1.ExecutedFuncCallH\x00R\bfuncCallB\x06\n" +
+	"\x04kind\"\xa6\x01\n" +
+	"\n" +
+	"FuncResult\x12\x18\n" +
+	"\apresent\x18\x01 \x01(\bR\apresent\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x05value\x12\x1c\n" +
+	"\tformatted\x18\x03 \x01(\tR\tformatted\x122\n" +
+	"\bmetadata\x18\x04 \x01(\v2\x16.google.protobuf.ValueR\bmetadata\"\x9b\x02\n" +
+	"\aReceipt\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tfunc_name\x18\x02 \x01(\tR\bfuncName\x12+\n" +
+	"\x04args\x18\x03 \x01(\v2\x17.google.protobuf.StructR\x04args\x12\x1a\n" +
+	"\bapproved\x18\x04 \x01(\bR\bapproved\x12\x18\n" +
+	"\aoutcome\x18\x05 \x01(\tR\aoutcome\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x128\n" +
+	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x120\n" +
+	"\adetails\x18\b \x01(\v2\x16.google.protobuf.ValueR\adetails\"\xf2\x01\n" +
+	"\aToolSet\x128\n" +
+	"\tfunctions\x18\x01 \x03(\v2\x1a.funcall.v1.FuncDefinitionR\tfunctions\x12S\n" +
+	"\x10type_definitions\x18\x02 \x03(\v2(.funcall.v1.ToolSet.TypeDefinitionsEntryR\x0ftypeDefinitions\x1aX\n" +
+	"\x14TypeDefinitionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12*\n" +
+	"\x05value\x18\x02 \x01(\v2\x14.funcall.v1.TypeInfoR\x05value:\x028\x01\"\xcd\x01\n" +
+	"\x0eFuncDefinition\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x124\n" +
+	"\n" +
+	"parameters\x18\x03 \x01(\v2\x14.funcall.v1.TypeInfoR\n" +
+	"parameters\x12.\n" +
+	"\areturns\x18\x04 \x01(\v2\x14.funcall.v1.TypeInfoR\areturns\x12\x1f\n" +
+	"\vside_effect\x18\x05 \x01(\bR\n" +
+	"sideEffect\"\xd1\x02\n" +
+	"\bTypeInfo\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12*\n" +
+	"\x05items\x18\x03 \x01(\v2\x14.funcall.v1.TypeInfoR\x05items\x12D\n" +
+	"\n" +
+	"properties\x18\x04 \x03(\v2$.funcall.v1.TypeInfo.PropertiesEntryR\n" +
+	"properties\x12\x1a\n" +
+	"\brequired\x18\x05 \x03(\tR\brequired\x12\x12\n" +
+	"\x04enum\x18\x06 \x03(\tR\x04enum\x12\x18\n" +
+	"\apattern\x18\a \x01(\tR\apattern\x1aS\n" +
+	"\x0fPropertiesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12*\n" +
+	"\x05value\x18\x02 \x01(\v2\x14.funcall.v1.TypeInfoR\x05value:\x028\x01B8Z6github.com/nlpodyssey/funcallarchitect/proto/funcallpbb\x06proto3"
+
+var (
+	file_funcallpb_funcall_proto_rawDescOnce sync.Once
+	file_funcallpb_funcall_proto_rawDescData []byte
+)
+
+func file_funcallpb_funcall_proto_rawDescGZIP() []byte {
+	file_funcallpb_funcall_proto_rawDescOnce.Do(func() {
+		file_funcallpb_funcall_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_funcallpb_funcall_proto_rawDesc), len(file_funcallpb_funcall_proto_rawDesc)))
+	})
+	return file_funcallpb_funcall_proto_rawDescData
+}
+
+var file_funcallpb_funcall_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_funcallpb_funcall_proto_goTypes = []any{
+	(*Plan)(nil),                  // 0: funcall.v1.Plan
+	(*PlannedFuncCall)(nil),       // 1: funcall.v1.PlannedFuncCall
+	(*PlannedArg)(nil),            // 2: funcall.v1.PlannedArg
+	(*Result)(nil),                // 3: funcall.v1.Result
+	(*ExecutedFuncCall)(nil),      // 4: funcall.v1.ExecutedFuncCall
+	(*Arg)(nil),                   // 5: funcall.v1.Arg
+	(*FuncResult)(nil),            // 6: funcall.v1.FuncResult
+	(*Receipt)(nil),               // 7: funcall.v1.Receipt
+	(*ToolSet)(nil),               // 8: funcall.v1.ToolSet
+	(*FuncDefinition)(nil),        // 9: funcall.v1.FuncDefinition
+	(*TypeInfo)(nil),              // 10: funcall.v1.TypeInfo
+	nil,                           // 11: funcall.v1.PlannedFuncCall.ArgsEntry
+	nil,                           // 12: funcall.v1.ExecutedFuncCall.ArgsEntry
+	nil,                           // 13: funcall.v1.ToolSet.TypeDefinitionsEntry
+	nil,                           // 14: funcall.v1.TypeInfo.PropertiesEntry
+	(*structpb.Value)(nil),        // 15: google.protobuf.Value
+	(*structpb.Struct)(nil),       // 16: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+}
+var file_funcallpb_funcall_proto_depIdxs = []int32{
+	1,  // 0: funcall.v1.Plan.main_functions:type_name -> funcall.v1.PlannedFuncCall
+	11, // 1: funcall.v1.PlannedFuncCall.args:type_name -> funcall.v1.PlannedFuncCall.ArgsEntry
+	15, // 2: funcall.v1.PlannedArg.value:type_name -> google.protobuf.Value
+	1,  // 3: funcall.v1.PlannedArg.func_call:type_name -> funcall.v1.PlannedFuncCall
+	4,  // 4: funcall.v1.Result.func_calls:type_name -> funcall.v1.ExecutedFuncCall
+	12, // 5: funcall.v1.ExecutedFuncCall.args:type_name -> funcall.v1.ExecutedFuncCall.ArgsEntry
+	6,  // 6: funcall.v1.ExecutedFuncCall.result:type_name -> funcall.v1.FuncResult
+	7,  // 7: funcall.v1.ExecutedFuncCall.receipt:type_name -> funcall.v1.Receipt
+	15, // 8: funcall.v1.Arg.value:type_name -> google.protobuf.Value
+	4,  // 9: funcall.v1.Arg.func_call:type_name -> funcall.v1.ExecutedFuncCall
+	15, // 10: funcall.v1.FuncResult.value:type_name -> google.protobuf.Value
+	15, // 11: funcall.v1.FuncResult.metadata:type_name -> google.protobuf.Value
+	16, // 12: funcall.v1.Receipt.args:type_name -> google.protobuf.Struct
+	17, // 13: funcall.v1.Receipt.timestamp:type_name -> google.protobuf.Timestamp
+	15, // 14: funcall.v1.Receipt.details:type_name -> google.protobuf.Value
+	9,  // 15: funcall.v1.ToolSet.functions:type_name -> funcall.v1.FuncDefinition
+	13, // 16: funcall.v1.ToolSet.type_definitions:type_name -> funcall.v1.ToolSet.TypeDefinitionsEntry
+	10, // 17: funcall.v1.FuncDefinition.parameters:type_name -> funcall.v1.TypeInfo
+	10, // 18: funcall.v1.FuncDefinition.returns:type_name -> funcall.v1.TypeInfo
+	10, // 19: funcall.v1.TypeInfo.items:type_name -> funcall.v1.TypeInfo
+	14, // 20: funcall.v1.TypeInfo.properties:type_name -> funcall.v1.TypeInfo.PropertiesEntry
+	2,  // 21: funcall.v1.PlannedFuncCall.ArgsEntry.value:type_name -> funcall.v1.PlannedArg
+	5,  // 22: funcall.v1.ExecutedFuncCall.ArgsEntry.value:type_name -> funcall.v1.Arg
+	10, // 23: funcall.v1.ToolSet.TypeDefinitionsEntry.value:type_name -> funcall.v1.TypeInfo
+	10, // 24: funcall.v1.TypeInfo.PropertiesEntry.value:type_name -> funcall.v1.TypeInfo
+	25, // [25:25] is the sub-list for method output_type
+	25, // [25:25] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
+}
+
+func init() { file_funcallpb_funcall_proto_init() }
+func file_funcallpb_funcall_proto_init() {
+	if File_funcallpb_funcall_proto != nil {
+		return
+	}
+	file_funcallpb_funcall_proto_msgTypes[2].OneofWrappers = []any{
+		(*PlannedArg_Value)(nil),
+		(*PlannedArg_FuncCall)(nil),
+	}
+	file_funcallpb_funcall_proto_msgTypes[5].OneofWrappers = []any{
+		(*Arg_Value)(nil),
+		(*Arg_FuncCall)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_funcallpb_funcall_proto_rawDesc), len(file_funcallpb_funcall_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_funcallpb_funcall_proto_goTypes,
+		DependencyIndexes: file_funcallpb_funcall_proto_depIdxs,
+		MessageInfos:      file_funcallpb_funcall_proto_msgTypes,
+	}.Build()
+	File_funcallpb_funcall_proto = out.File
+	file_funcallpb_funcall_proto_goTypes = nil
+	file_funcallpb_funcall_proto_depIdxs = nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// ProposePlan plans and evaluates a request without executing it, serving
+// the handler.PlanProposal for an operator to review and edit.
+func (a *Server) ProposePlan(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Message   string `json:"message"`
+		SessionID string `json:"session_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	ctx, err := requestContext(ctx, r, request.SessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	proposal, err := a.Agent.ProposePlan(ctx, request.Message, &progress.NoOp{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error planning request: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(proposal)
+}
+
+// InvokeTool executes a single call of the tool named in the path with the
+// arguments in the body, bypassing the LLM, responding like Process.
+// Invalid arguments are rejected with 422.
+func (a *Server) InvokeTool(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		SessionID string         `json:"session_id"`
+		Args      map[string]any `json:"args"`
+	}
+	// Numbers are preserved, as for the plans
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	ctx, err := requestContext(ctx, r, request.SessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := a.Agent.InvokeTool(ctx, r.PathValue("name"), request.Args, &progress.NoOp{})
+	if errors.Is(err, handler.ErrInvalidPlan) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	data, err := postprocessProcessExecution(result, err)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error invoking tool: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeProcessResponse(w, data)
+}
+
+// Autocomplete serves the metadata of the tools for a call being filled in
+// a form, given its partial name or its name and the arguments filled so far
+// (see tools.ToolSet.Autocomplete).
+func (a *Server) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	var call tools.PartialCall
+	if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.Agent.Autocomplete(call))
+}
+
+// ExecutePlan executes the plan submitted for a request, typically an edited
+// proposal, responding like Process. Invalid plans are rejected with 422.
+func (a *Server) ExecutePlan(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Message   string            `json:"message"`
+		SessionID string            `json:"session_id"`
+		Calls     []json.RawMessage `json:"calls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	funcCalls := make([]parser.PlannedFuncCall, len(request.Calls))
+	for i, data := range request.Calls {
+		call, err := parser.DecodeCall(data)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid call %d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		funcCalls[i] = call
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	ctx, err := requestContext(ctx, r, request.SessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := a.Agent.ExecutePlan(ctx, request.Message, funcCalls, &progress.NoOp{})
+	if errors.Is(err, handler.ErrInvalidPlan) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	data, err := postprocessProcessExecution(result, err)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error processing request: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeProcessResponse(w, data)
+}
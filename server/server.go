@@ -17,15 +17,52 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/nlpodyssey/funcallarchitect/agent"
+	"github.com/nlpodyssey/funcallarchitect/analytics"
+	"github.com/nlpodyssey/funcallarchitect/codec"
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/history"
+	"github.com/nlpodyssey/funcallarchitect/logging"
 	"github.com/nlpodyssey/funcallarchitect/progress"
+	"github.com/nlpodyssey/funcallarchitect/session"
+	"github.com/nlpodyssey/funcallarchitect/ui"
+	"github.com/nlpodyssey/funcallarchitect/version"
 )
 
+// SessionIDHeader is the HTTP header binding a request to a conversation session.
+const SessionIDHeader = "X-Session-ID"
+
+// RequestIDHeader is the HTTP header carrying the ID of a request, which tags its log lines.
+const RequestIDHeader = "X-Request-ID"
+
+// IdempotencyKeyHeader is the HTTP header carrying the idempotency key of a
+// request (see handler.IdempotencyCache).
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ReplayedHeader is set to "true" on the responses replaying the stored
+// result of a previous request with the same idempotency key.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// OutputFormatHeader is the HTTP header requesting the representation of the
+// output, in the format of the Accept header (see handler.WithOutputFormat).
+const OutputFormatHeader = "X-Funcall-Output-Format"
+
+// TimeoutHeader is the HTTP header overriding the budget of a request, as a
+// duration such as "30s" (see handler.RequestHandlerConfig.RequestTimeout).
+const TimeoutHeader = "X-Funcall-Timeout"
+
+// FlagsHeader is the HTTP header overriding the feature flags of a request,
+// in the format accepted by handler.ParseFlags.
+const FlagsHeader = "X-Funcall-Flags"
+
 type SSEProgressStream struct {
 	ch chan<- string
 }
@@ -40,7 +77,25 @@ func (se *SSEProgressStream) Send(event string) {
 
 type Server struct {
 	Agent *agent.Agent
-	mu    sync.Mutex
+	// History, if set, serves the execution reports stored by the handler
+	// (see handler.RequestHandlerConfig.History) at /executions/{id}/report.
+	History history.Store
+	// Analytics, if set, serves the planner analytics accumulated by the
+	// handler (see handler.RequestHandlerConfig.Analytics) at /analytics/tools.
+	Analytics analytics.Store
+	// Canary, if set, serves the comparisons of the new version of the tools
+	// in shadow (see handler.RequestHandlerConfig.Canary) at /canary/report.
+	Canary *handler.Canary
+	// StreamRetention is how long the events of the streamed requests are
+	// kept after they end, for the clients reconnecting (see ResumeStream).
+	// DefaultStreamRetention by default.
+	StreamRetention time.Duration
+	// UI, if set, serves the chat frontend configured by it at /ui/.
+	UI *ui.Config
+
+	mu        sync.Mutex
+	streamsMu sync.Mutex
+	streams   map[string]*eventStream
 }
 
 func NewServer(a *agent.Agent) *Server {
@@ -54,7 +109,8 @@ func (a *Server) Process(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var request struct {
-		Message string `json:"message"`
+		Message   string `json:"message"`
+		SessionID string `json:"session_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -65,18 +121,72 @@ func (a *Server) Process(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
-	data, err := postprocessProcessExecution(a.Agent.Process(ctx, request.Message, &progress.NoOp{}))
+	ctx, err := requestContext(ctx, r, request.SessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := a.Agent.Process(ctx, request.Message, &progress.NoOp{})
+	if errors.Is(err, handler.ErrIdempotencyKeyReused) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	data, err := postprocessProcessExecution(result, err)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error processing request: %v", err), http.StatusInternalServerError)
 		return
 	}
+	writeProcessResponse(w, data)
+}
 
+// requestContext binds ctx to the session, the request ID and the feature
+// flags of the request. The session ID in the body, if any, takes precedence
+// over SessionIDHeader.
+func requestContext(ctx context.Context, r *http.Request, sessionID string) (context.Context, error) {
+	if sessionID == "" {
+		sessionID = r.Header.Get(SessionIDHeader)
+	}
+	if sessionID != "" {
+		ctx = session.WithID(ctx, sessionID)
+	}
+	if requestID := r.Header.Get(RequestIDHeader); requestID != "" {
+		ctx = logging.WithRequestID(ctx, requestID)
+	}
+	if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+		ctx = handler.WithIdempotencyKey(ctx, key)
+	}
+	if format := r.Header.Get(OutputFormatHeader); format != "" {
+		ctx = handler.WithOutputFormat(ctx, format)
+	}
+	if header := r.Header.Get(TimeoutHeader); header != "" {
+		timeout, err := time.ParseDuration(header)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header: %w", TimeoutHeader, err)
+		}
+		ctx = handler.WithRequestTimeout(ctx, timeout)
+	}
+	return withFlags(ctx, r)
+}
+
+func writeProcessResponse(w http.ResponseWriter, data Data) {
 	response := struct {
-		Output string `json:"output"`
+		Output      string                     `json:"output"`
+		ContentType string                     `json:"content_type"`
+		Results     []handler.StructuredResult `json:"results,omitempty"`
+		Warnings    []string                   `json:"warnings,omitempty"`
+		ExecutionID string                     `json:"execution_id,omitempty"`
 	}{
-		Output: data.Output,
+		Output:      data.Output,
+		ContentType: data.ContentType,
+		Results:     data.Results,
+		Warnings:    data.Warnings,
+		ExecutionID: data.ExecutionID,
 	}
 
+	if data.Replayed {
+		w.Header().Set(ReplayedHeader, "true")
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -85,30 +195,21 @@ func postprocessProcessExecution(execution *agent.ProcessingResult, err error) (
 	if err != nil {
 		return Data{}, fmt.Errorf("error processing query: %w", err)
 	}
-	results := execution.Execution.MainFuncResults()
-
-	output, err := results.Format("")
-	if err != nil {
-		return Data{}, fmt.Errorf("error formatting results: %v", err)
-	}
-
 	return Data{
-		Output: output,
+		Output:      execution.Output,
+		ContentType: execution.ContentType,
+		Results:     execution.Values,
+		Warnings:    execution.Warnings,
+		ExecutionID: executionID(execution),
+		Replayed:    execution.Replayed,
 	}, nil
 }
 
+// StreamProcess processes the request in the body, streaming its progress and
+// its result as server-sent events. The first event, "request", carries the
+// ID of the request, with which clients dropping the connection resume the
+// stream through ResumeStream: the processing goes on without them.
 func (a *Server) StreamProcess(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
-		return
-	}
-
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, "Failed to read request body", http.StatusBadRequest)
@@ -116,52 +217,288 @@ func (a *Server) StreamProcess(w http.ResponseWriter, r *http.Request) {
 	}
 	message := string(body)
 
-	ctx, cancel := context.WithCancel(r.Context())
-	defer cancel()
+	// The processing outlives the connection, for the clients reconnecting
+	ctx := context.WithoutCancel(r.Context())
 
-	logCh := make(chan string)
-	progressStream := NewSSEProgressStream(logCh)
+	ctx, err = requestContext(ctx, r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	requestID := r.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = history.NewID()
+		ctx = logging.WithRequestID(ctx, requestID)
+	}
+
+	stream, ok := a.openStream(requestID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Request %s is already being processed", requestID), http.StatusConflict)
+		return
+	}
+	w.Header().Set(RequestIDHeader, requestID)
+	flusher, ok := startSSE(w)
+	if !ok {
+		stream.finish()
+		return
+	}
+	stream.add("request", map[string]any{"message": requestID})
 
 	go func() {
-		defer close(logCh)
+		defer stream.finish()
 
-		data, err := postprocessStreamProcessExecution(a.Agent.Process(ctx, message, progressStream))
+		data, err := postprocessStreamProcessExecution(a.Agent.Process(ctx, message, stream))
 		if err != nil {
-			a.sendSSEEvent(w, flusher, "error", map[string]any{"message": err.Error()})
+			stream.add("error", map[string]any{"message": err.Error()})
 			return
 		}
 
 		jsonData, err := json.Marshal(data)
 		if err != nil {
-			a.sendSSEEvent(w, flusher, "error", map[string]any{"message": err.Error()})
+			stream.add("error", map[string]any{"message": err.Error()})
 			return
 		}
 
 		var result map[string]interface{}
 		_ = json.Unmarshal(jsonData, &result)
 
-		a.sendSSEEvent(w, flusher, "result", map[string]any{"message": result})
+		stream.add("result", map[string]any{"message": result})
 	}()
 
-	for {
-		select {
-		case <-ctx.Done():
+	a.serveStream(r.Context(), w, flusher, stream, 0)
+}
+
+// withFlags applies the feature flags requested through FlagsHeader, if any.
+func withFlags(ctx context.Context, r *http.Request) (context.Context, error) {
+	header := r.Header.Get(FlagsHeader)
+	if header == "" {
+		return ctx, nil
+	}
+	flags, err := handler.ParseFlags(header)
+	if err != nil {
+		return ctx, fmt.Errorf("invalid %s header: %w", FlagsHeader, err)
+	}
+	return handler.WithFlags(ctx, flags), nil
+}
+
+// Report serves the execution report with the given ID, as JSON or, with
+// format=markdown or an Accept header preferring it, as Markdown.
+func (a *Server) Report(w http.ResponseWriter, r *http.Request) {
+	if a.History == nil {
+		http.Error(w, "Execution history not available", http.StatusNotFound)
+		return
+	}
+	record, err := a.History.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, history.ErrNotFound) {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading execution: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "markdown" || strings.HasPrefix(r.Header.Get("Accept"), "text/markdown") {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", record.ID+".md"))
+		io.WriteString(w, record.Markdown())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", record.ID+".json"))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(record)
+}
+
+// ExecutionDiff serves the structural comparison of the execution with the
+// given ID with the one of the "with" query parameter (see history.Diff), as
+// JSON or, with format=markdown or an Accept header preferring it, as Markdown.
+func (a *Server) ExecutionDiff(w http.ResponseWriter, r *http.Request) {
+	if a.History == nil {
+		http.Error(w, "Execution history not available", http.StatusNotFound)
+		return
+	}
+	var records [2]*history.Record
+	for i, id := range []string{r.PathValue("id"), r.URL.Query().Get("with")} {
+		record, err := a.History.Get(r.Context(), id)
+		if errors.Is(err, history.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("Execution %q not found", id), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error loading execution: %v", err), http.StatusInternalServerError)
+			return
+		}
+		records[i] = record
+	}
+
+	diff := history.Diff(records[0], records[1])
+	if r.URL.Query().Get("format") == "markdown" || strings.HasPrefix(r.Header.Get("Accept"), "text/markdown") {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		io.WriteString(w, diff.Markdown())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(diff)
+}
+
+// Analysis serves the concurrency analysis of the execution with the given ID
+// (see history.Record.Analyze), as JSON or, with format=text or an Accept
+// header preferring it, as text.
+func (a *Server) Analysis(w http.ResponseWriter, r *http.Request) {
+	if a.History == nil {
+		http.Error(w, "Execution history not available", http.StatusNotFound)
+		return
+	}
+	record, err := a.History.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, history.ErrNotFound) {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading execution: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	analysis := record.Analyze()
+	if r.URL.Query().Get("format") == "text" || strings.HasPrefix(r.Header.Get("Accept"), "text/plain") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, analysis.Text())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(analysis)
+}
+
+// Feedback attaches the feedback in the request body, a JSON object with a
+// rating ("up" or "down") and an optional comment, to the execution with the given ID.
+func (a *Server) Feedback(w http.ResponseWriter, r *http.Request) {
+	if a.History == nil {
+		http.Error(w, "Execution history not available", http.StatusNotFound)
+		return
+	}
+	var feedback struct {
+		Rating  history.Rating `json:"rating"`
+		Comment string         `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&feedback); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if feedback.Rating != history.ThumbsUp && feedback.Rating != history.ThumbsDown {
+		http.Error(w, `Invalid rating: must be "up" or "down"`, http.StatusBadRequest)
+		return
+	}
+
+	err := history.AddFeedback(r.Context(), a.History, r.PathValue("id"), history.Feedback{
+		Rating:  feedback.Rating,
+		Comment: feedback.Comment,
+	})
+	if errors.Is(err, history.ErrNotFound) {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error storing feedback: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Health reports that the server is up, with the version of the code serving
+// the requests (see version.Get).
+func (a *Server) Health(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Status  string       `json:"status"`
+		Version version.Info `json:"version"`
+	}{"ok", version.Get()})
+}
+
+// Choose answers the choice with the ID in the path, sent to a streamed request
+// as a "choice" event, with the index of the chosen option.
+func (a *Server) Choose(w http.ResponseWriter, r *http.Request) {
+	var answer struct {
+		Option *int `json:"option"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&answer); err != nil || answer.Option == nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	err := a.Agent.Choose(r.PathValue("id"), *answer.Option)
+	if errors.Is(err, handler.ErrChoiceNotFound) {
+		http.Error(w, "Choice not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ToolAnalytics serves the planner analytics of each tool, optionally restricted
+// to the days in [from, to), given as YYYY-MM-DD.
+func (a *Server) ToolAnalytics(w http.ResponseWriter, r *http.Request) {
+	if a.Analytics == nil {
+		http.Error(w, "Analytics not available", http.StatusNotFound)
+		return
+	}
+	var from, to time.Time
+	for name, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid %s date: %v", name, err), http.StatusBadRequest)
 			return
-		case logMsg, ok := <-logCh:
-			if !ok {
-				logCh = nil
-			} else {
-				a.sendSSEEvent(w, flusher, "log", map[string]any{"message": logMsg})
-			}
 		}
+		*t = parsed
+	}
 
-		if logCh == nil {
-			break
+	stats, err := a.Analytics.Stats(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading analytics: %v", err), http.StatusInternalServerError)
+		return
+	}
+	type toolStats struct {
+		analytics.ToolStats
+		AcceptanceRate   float64 `json:"acceptance_rate"`
+		SuccessRate      float64 `json:"success_rate"`
+		ContributionRate float64 `json:"contribution_rate"`
+	}
+	response := make([]toolStats, len(stats))
+	for i, s := range stats {
+		response[i] = toolStats{
+			ToolStats:        s,
+			AcceptanceRate:   s.AcceptanceRate(),
+			SuccessRate:      s.SuccessRate(),
+			ContributionRate: s.ContributionRate(),
 		}
 	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CanaryReport serves the comparison of the new version of the tools with the
+// live one, to decide on its promotion.
+func (a *Server) CanaryReport(w http.ResponseWriter, r *http.Request) {
+	if a.Canary == nil {
+		http.Error(w, "Canary not available", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.Canary.Report())
 }
 
-func (a *Server) sendSSEEvent(w http.ResponseWriter, flusher http.Flusher, event string, data map[string]any) {
+func (a *Server) sendSSEEvent(w http.ResponseWriter, flusher http.Flusher, id int, event string, data map[string]any) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -172,14 +509,31 @@ func (a *Server) sendSSEEvent(w http.ResponseWriter, flusher http.Flusher, event
 		return
 	}
 
+	fmt.Fprintf(w, "id: %d\n", id)
 	fmt.Fprintf(w, "event: %s\n", event)
 	fmt.Fprintf(w, "data: %s\n\n", jsonData)
 	flusher.Flush()
 }
 
 func (a *Server) Start(port int) error {
+	http.HandleFunc("GET /health", a.Health)
 	http.HandleFunc("/stream-process", a.StreamProcess)
+	http.HandleFunc("GET /stream-process/{id}", a.ResumeStream)
 	http.HandleFunc("/process", a.Process)
+	http.HandleFunc("GET /executions/{id}/report", a.Report)
+	http.HandleFunc("GET /executions/{id}/analysis", a.Analysis)
+	http.HandleFunc("GET /executions/{id}/diff", a.ExecutionDiff)
+	http.HandleFunc("POST /executions/{id}/feedback", a.Feedback)
+	http.HandleFunc("POST /choices/{id}", a.Choose)
+	http.HandleFunc("GET /analytics/tools", a.ToolAnalytics)
+	http.HandleFunc("GET /canary/report", a.CanaryReport)
+	http.HandleFunc("POST /plans", a.ProposePlan)
+	http.HandleFunc("POST /plans/execute", a.ExecutePlan)
+	http.HandleFunc("POST /tools/autocomplete", a.Autocomplete)
+	http.HandleFunc("POST /tools/{name}/invoke", a.InvokeTool)
+	if a.UI != nil {
+		http.Handle("GET /ui/", http.StripPrefix("/ui", ui.Handler(*a.UI)))
+	}
 	return http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
 }
 
@@ -188,29 +542,79 @@ type Data struct {
 	// Output contains the primary textual response generated by the LLM agent.
 	Output string `json:"output"`
 
+	// ContentType is the content type of Output (see OutputFormatHeader).
+	ContentType string `json:"content_type"`
+
 	// FuncCalls represents a string-encoded list or description of the functions
 	// that were called during the agent's execution.
 	FuncCalls string `json:"func_calls"`
+
+	// Results are the structured results of the main calls, when the
+	// handler is in structured output mode.
+	Results []handler.StructuredResult `json:"results,omitempty"`
+
+	// Warnings report conditions that degraded the processing.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// ExecutionID identifies the execution report of the request.
+	ExecutionID string `json:"execution_id,omitempty"`
+
+	// Rendered are the main results with a presentation hint, for the
+	// clients rendering them as tables, charts or maps.
+	Rendered []RenderedResult `json:"rendered,omitempty"`
+
+	// Replayed reports whether the result is the stored one of a previous
+	// request with the same idempotency key.
+	Replayed bool `json:"replayed,omitempty"`
 }
 
-func postprocessStreamProcessExecution(result *agent.ProcessingResult, err error) (Data, error) {
-	if err != nil {
-		return Data{}, fmt.Errorf("error processing query: %w", err)
+// RenderedResult is a structured result to render according to its hint.
+type RenderedResult struct {
+	Name   string          `json:"name"`
+	Render string          `json:"render"`
+	Value  json.RawMessage `json:"value"`
+	// ValueType is the registered name of the Go type of the value, if any.
+	ValueType string `json:"value_type,omitempty"`
+}
+
+func renderedResults(result *agent.ProcessingResult) []RenderedResult {
+	var rendered []RenderedResult
+	for _, f := range result.Execution.FuncCalls {
+		if f.Render != "" && f.Result.Present {
+			tv, err := codec.EncodeValue(f.Result.Value)
+			if err != nil {
+				continue
+			}
+			rendered = append(rendered, RenderedResult{Name: f.Name, Render: f.Render, Value: tv.Data, ValueType: tv.Type})
+		}
 	}
-	results := result.Execution.MainFuncResults()
+	return rendered
+}
 
-	output, err := results.Format("")
-	if err != nil {
-		return Data{}, fmt.Errorf("error formatting results: %v", err)
+func executionID(result *agent.ProcessingResult) string {
+	if result.Report == nil {
+		return ""
 	}
+	return result.Report.ID
+}
 
+func postprocessStreamProcessExecution(result *agent.ProcessingResult, err error) (Data, error) {
+	if err != nil {
+		return Data{}, fmt.Errorf("error processing query: %w", err)
+	}
 	funcCalls, err := json.MarshalIndent(result.Execution.FuncCalls, "", "  ")
 	if err != nil {
 		return Data{}, fmt.Errorf("error marshaling func calls: %v", err)
 	}
 
 	return Data{
-		Output:    output,
-		FuncCalls: string(funcCalls),
+		Output:      result.Output,
+		ContentType: result.ContentType,
+		FuncCalls:   string(funcCalls),
+		Results:     result.Values,
+		Warnings:    result.Warnings,
+		ExecutionID: executionID(result),
+		Rendered:    renderedResults(result),
+		Replayed:    result.Replayed,
 	}, nil
 }
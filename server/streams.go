@@ -0,0 +1,204 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+// DefaultStreamRetention is the default time the events of a streamed request
+// are kept after it ends, for the clients reconnecting (see Server.StreamRetention).
+const DefaultStreamRetention = 5 * time.Minute
+
+// streamEvent is a server-sent event of a streamed request. Its ID is its
+// position in the stream, starting from 1.
+type streamEvent struct {
+	id   int
+	name string
+	data map[string]any
+}
+
+// eventStream buffers the events of a streamed request, so that the clients
+// reconnecting after a drop can replay the ones they missed.
+type eventStream struct {
+	mu     sync.Mutex
+	events []streamEvent
+	done   bool
+	ended  time.Time
+	// changed is closed, and replaced, at every new event.
+	changed chan struct{}
+}
+
+func newEventStream() *eventStream {
+	return &eventStream{changed: make(chan struct{})}
+}
+
+func (s *eventStream) add(name string, data map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, streamEvent{id: len(s.events) + 1, name: name, data: data})
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *eventStream) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done, s.ended = true, time.Now()
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *eventStream) isDone() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done
+}
+
+// since returns the events after the one with the given ID, whether the
+// stream ended, and a channel closed at the next change.
+func (s *eventStream) since(id int) ([]streamEvent, bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 0 || id > len(s.events) {
+		id = len(s.events)
+	}
+	return s.events[id:], s.done, s.changed
+}
+
+// Send reports the progress of the request as "log" events.
+func (s *eventStream) Send(event string) {
+	s.add("log", map[string]any{"message": event})
+}
+
+// SendChoice asks the client to choose one of the options as "choice" events,
+// answered through Server.Choose.
+func (s *eventStream) SendChoice(choice progress.Choice) {
+	s.add("choice", map[string]any{"message": choice})
+}
+
+// openStream registers the stream of a new request, or returns false when a
+// request with the same ID is still being streamed.
+func (a *Server) openStream(requestID string) (*eventStream, bool) {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	a.purgeStreams()
+	if s, ok := a.streams[requestID]; ok {
+		if !s.isDone() {
+			return nil, false
+		}
+	}
+	if a.streams == nil {
+		a.streams = make(map[string]*eventStream)
+	}
+	s := newEventStream()
+	a.streams[requestID] = s
+	return s, true
+}
+
+func (a *Server) stream(requestID string) (*eventStream, bool) {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	a.purgeStreams()
+	s, ok := a.streams[requestID]
+	return s, ok
+}
+
+// purgeStreams drops the streams ended for longer than the retention time.
+// The caller holds streamsMu.
+func (a *Server) purgeStreams() {
+	retention := a.StreamRetention
+	if retention <= 0 {
+		retention = DefaultStreamRetention
+	}
+	for id, s := range a.streams {
+		s.mu.Lock()
+		expired := s.done && time.Since(s.ended) > retention
+		s.mu.Unlock()
+		if expired {
+			delete(a.streams, id)
+		}
+	}
+}
+
+// serveStream sends the events after lastID, then the new ones as they come,
+// until the stream ends or the client disconnects.
+func (a *Server) serveStream(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, s *eventStream, lastID int) {
+	for {
+		events, done, changed := s.since(lastID)
+		for _, e := range events {
+			a.sendSSEEvent(w, flusher, e.id, e.name, e.data)
+			lastID = e.id
+		}
+		if done {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+	}
+}
+
+// ResumeStream reconnects a client to the stream of the request with the ID in
+// the path, replaying the events after the one in the Last-Event-ID header, or
+// in the last_event_id query parameter, then following the request until its
+// result. All the events are replayed when neither is set.
+func (a *Server) ResumeStream(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.stream(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Stream not found", http.StatusNotFound)
+		return
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastID := 0
+	if lastEventID != "" {
+		var err error
+		if lastID, err = strconv.Atoi(lastEventID); err != nil {
+			http.Error(w, "Invalid last event ID", http.StatusBadRequest)
+			return
+		}
+	}
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+	a.serveStream(r.Context(), w, flusher, s, lastID)
+}
+
+// startSSE sets the headers of an event stream, failing when the response
+// cannot be flushed.
+func startSSE(w http.ResponseWriter) (http.Flusher, bool) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+	}
+	return flusher, ok
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package session provides per-conversation state shared across the requests
+// of the same user session.
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaxFailures is the number of recent failures kept in a session.
+const MaxFailures = 5
+
+// Session holds the state of a conversation.
+type Session struct {
+	ID string
+	// Facts are the key-value pairs remembered on behalf of the user.
+	Facts map[string]string
+	// Variables are the named values shared by the tools across the turns
+	// (see Variables).
+	Variables map[string]any
+	// Failures are the most recent function calls that failed or found nothing,
+	// oldest first.
+	Failures  []Failure
+	UpdatedAt time.Time
+}
+
+// Failure records a function call that failed or found nothing.
+type Failure struct {
+	FuncName string         `json:"func_name"`
+	Args     map[string]any `json:"args,omitempty"`
+	Reason   string         `json:"reason"`
+	At       time.Time      `json:"at"`
+}
+
+// RecordFailures appends the failures, keeping the most recent MaxFailures.
+func (s *Session) RecordFailures(failures ...Failure) {
+	s.Failures = append(s.Failures, failures...)
+	if len(s.Failures) > MaxFailures {
+		s.Failures = s.Failures[len(s.Failures)-MaxFailures:]
+	}
+}
+
+// Store persists sessions.
+type Store interface {
+	// Get returns a copy of the session with the given ID, or an empty session if it does not exist.
+	Get(ctx context.Context, id string) (*Session, error)
+	// Update atomically applies fn to the session with the given ID, creating it if needed.
+	Update(ctx context.Context, id string, fn func(s *Session) error) error
+}
+
+// Pruner is implemented by the stores able to delete the inactive sessions.
+type Pruner interface {
+	// Prune deletes the sessions not updated since before, returning their number.
+	Prune(ctx context.Context, before time.Time) (int, error)
+}
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the session ID.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// IDFromContext returns the session ID carried by ctx, if any.
+func IDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewMemoryStore creates a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]*Session)}
+}
+
+func (m *MemoryStore) Get(_ context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[id]; ok {
+		return s.clone(), nil
+	}
+	return newSession(id), nil
+}
+
+func (m *MemoryStore) Update(_ context.Context, id string, fn func(s *Session) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		s = newSession(id)
+	}
+	updated := s.clone()
+	if err := fn(updated); err != nil {
+		return err
+	}
+	updated.UpdatedAt = time.Now()
+	m.sessions[id] = updated
+	return nil
+}
+
+// Prune deletes the sessions not updated since before.
+func (m *MemoryStore) Prune(_ context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pruned := 0
+	for id, s := range m.sessions {
+		if s.UpdatedAt.Before(before) {
+			delete(m.sessions, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+func newSession(id string) *Session {
+	return &Session{ID: id, Facts: make(map[string]string), Variables: make(map[string]any)}
+}
+
+func (s *Session) clone() *Session {
+	c := *s
+	c.Facts = make(map[string]string, len(s.Facts))
+	for k, v := range s.Facts {
+		c.Facts[k] = v
+	}
+	c.Variables = make(map[string]any, len(s.Variables))
+	for k, v := range s.Variables {
+		c.Variables[k] = v
+	}
+	c.Failures = append([]Failure(nil), s.Failures...)
+	return &c
+}
+
+// FromContext loads the session referenced by ctx from the store.
+func FromContext(ctx context.Context, store Store) (*Session, error) {
+	id, ok := IDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no session associated with the request")
+	}
+	return store.Get(ctx, id)
+}
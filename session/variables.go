@@ -0,0 +1,91 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// Variables are the named variables of a session, e.g. "selected_city", which
+// the tools read and write through the execution context (see
+// VariablesFromContext) to carry state across the turns of a conversation.
+// The values must be encodable as JSON.
+type Variables struct {
+	store Store
+	id    string
+}
+
+// NewVariables returns the variables of the session with the given ID.
+func NewVariables(store Store, id string) *Variables {
+	return &Variables{store: store, id: id}
+}
+
+// Get returns the value of the variable, and whether it is set.
+func (v *Variables) Get(ctx context.Context, name string) (any, bool, error) {
+	s, err := v.store.Get(ctx, v.id)
+	if err != nil {
+		return nil, false, fmt.Errorf("error loading session: %w", err)
+	}
+	value, ok := s.Variables[name]
+	return value, ok, nil
+}
+
+// Set sets the value of the variable.
+func (v *Variables) Set(ctx context.Context, name string, value any) error {
+	return v.update(ctx, func(vars map[string]any) { vars[name] = value })
+}
+
+// Delete unsets the variable.
+func (v *Variables) Delete(ctx context.Context, name string) error {
+	return v.update(ctx, func(vars map[string]any) { delete(vars, name) })
+}
+
+// All returns a copy of the variables set.
+func (v *Variables) All(ctx context.Context) (map[string]any, error) {
+	s, err := v.store.Get(ctx, v.id)
+	if err != nil {
+		return nil, fmt.Errorf("error loading session: %w", err)
+	}
+	return s.Variables, nil
+}
+
+func (v *Variables) update(ctx context.Context, fn func(vars map[string]any)) error {
+	err := v.store.Update(ctx, v.id, func(s *Session) error {
+		if s.Variables == nil {
+			s.Variables = make(map[string]any)
+		}
+		fn(s.Variables)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error updating session: %w", err)
+	}
+	return nil
+}
+
+type variablesContextKey struct{}
+
+// WithVariables returns a copy of ctx carrying the variables of the session.
+func WithVariables(ctx context.Context, v *Variables) context.Context {
+	return context.WithValue(ctx, variablesContextKey{}, v)
+}
+
+// VariablesFromContext returns the variables of the session of the request,
+// if any.
+func VariablesFromContext(ctx context.Context) (*Variables, bool) {
+	v, ok := ctx.Value(variablesContextKey{}).(*Variables)
+	return v, ok
+}
@@ -0,0 +1,280 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package skill turns successful plans into skills: named tools with typed
+// parameters, advertised to the planner as single functions and expanded into
+// the calls of the original plan at execution time.
+package skill
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/history"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// Param is a parameter of a skill, extracted from a literal argument of the plan.
+type Param struct {
+	Name string         `json:"name"`
+	Type tools.TypeInfo `json:"type"`
+	// Example is the value of the argument in the original plan.
+	Example any `json:"example,omitempty"`
+}
+
+// Skill is a plan with its literal arguments turned into parameters.
+type Skill struct {
+	Name        string
+	Description string
+	Params      []Param
+	// Template is the call tree of the plan, with parser.Param placeholders.
+	Template   parser.PlannedFuncCall
+	Returns    tools.TypeInfo
+	SideEffect bool
+}
+
+type skillJSON struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Params      []Param         `json:"params"`
+	Template    json.RawMessage `json:"template"`
+	Returns     tools.TypeInfo  `json:"returns"`
+	SideEffect  bool            `json:"side_effect,omitempty"`
+}
+
+func (s *Skill) MarshalJSON() ([]byte, error) {
+	template, err := json.Marshal(s.Template)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(skillJSON{
+		Name:        s.Name,
+		Description: s.Description,
+		Params:      s.Params,
+		Template:    template,
+		Returns:     s.Returns,
+		SideEffect:  s.SideEffect,
+	})
+}
+
+func (s *Skill) UnmarshalJSON(data []byte) error {
+	var v skillJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	template, err := parser.DecodeCall(v.Template)
+	if err != nil {
+		return fmt.Errorf("error decoding the template of skill %s: %w", v.Name, err)
+	}
+	*s = Skill{
+		Name:        v.Name,
+		Description: v.Description,
+		Params:      v.Params,
+		Template:    template,
+		Returns:     v.Returns,
+		SideEffect:  v.SideEffect,
+	}
+	return nil
+}
+
+// Promote creates a skill from a call tree planned with the tools of ts. Every
+// literal argument becomes a parameter, named after the argument, or after the
+// function and the argument when the name is already taken.
+func Promote(name, description string, call parser.PlannedFuncCall, ts *tools.ToolSet) (*Skill, error) {
+	root, ok := ts.FindTool(call.Name)
+	if !ok {
+		return nil, fmt.Errorf("function %s not found", call.Name)
+	}
+	s := &Skill{Name: name, Description: description, Returns: root.Returns}
+	taken := make(map[string]bool)
+	template, err := s.parameterize(call, ts, taken)
+	if err != nil {
+		return nil, err
+	}
+	s.Template = template
+	return s, nil
+}
+
+func (s *Skill) parameterize(call parser.PlannedFuncCall, ts *tools.ToolSet, taken map[string]bool) (parser.PlannedFuncCall, error) {
+	def, ok := ts.FindTool(call.Name)
+	if !ok {
+		return call, fmt.Errorf("function %s not found", call.Name)
+	}
+	s.SideEffect = s.SideEffect || def.SideEffect
+
+	// Sorted for stable parameter names
+	keys := make([]string, 0, len(call.Args))
+	for key := range call.Args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make(map[string]interface{}, len(call.Args))
+	for _, key := range keys {
+		switch v := call.Args[key].(type) {
+		case *parser.PlannedFuncCall:
+			nested, err := s.parameterize(*v, ts, taken)
+			if err != nil {
+				return call, err
+			}
+			args[key] = &nested
+		case parser.Param:
+			return call, fmt.Errorf("call of %s is already a template", call.Name)
+		default:
+			name := uniqueName(key, call.Name, taken)
+			s.Params = append(s.Params, Param{Name: name, Type: def.Parameters.Properties[key], Example: v})
+			args[key] = parser.Param{Name: name}
+		}
+	}
+	return parser.PlannedFuncCall{Name: call.Name, Purpose: call.Purpose, Args: args}, nil
+}
+
+func uniqueName(arg, funcName string, taken map[string]bool) string {
+	name := arg
+	if taken[name] {
+		name = funcName + "_" + arg
+	}
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s_%s_%d", funcName, arg, i)
+	}
+	taken[name] = true
+	return name
+}
+
+// PromoteRecord creates a skill from the plan of a successful execution
+// stored in the history. The plan must have a single main call.
+func PromoteRecord(name, description string, record *history.Record, ts *tools.ToolSet) (*Skill, error) {
+	if record.Error != "" || len(record.Plans) == 0 {
+		return nil, fmt.Errorf("execution %s was not successful", record.ID)
+	}
+	var calls []json.RawMessage
+	if err := json.Unmarshal(record.Plans[len(record.Plans)-1].Calls, &calls); err != nil {
+		return nil, fmt.Errorf("error decoding the plan of execution %s: %w", record.ID, err)
+	}
+	if len(calls) != 1 {
+		return nil, fmt.Errorf("the plan of execution %s has %d main calls, expected one", record.ID, len(calls))
+	}
+	call, err := parser.DecodeCall(calls[0])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the plan of execution %s: %w", record.ID, err)
+	}
+	return Promote(name, description, call, ts)
+}
+
+// Definition returns the definition advertised to the planner.
+func (s *Skill) Definition() tools.FuncDefinition {
+	params := tools.TypeInfo{Type: "object", Properties: make(map[string]tools.TypeInfo, len(s.Params))}
+	for _, p := range s.Params {
+		params.Properties[p.Name] = p.Type
+		params.Required = append(params.Required, p.Name)
+	}
+	return tools.FuncDefinition{
+		Name:        s.Name,
+		Description: s.Description,
+		Parameters:  params,
+		Returns:     s.Returns,
+		SideEffect:  s.SideEffect,
+	}
+}
+
+// Macro returns the macro expanding the calls of the skill.
+func (s *Skill) Macro() tools.Macro {
+	return tools.Macro{FuncDefinition: s.Definition(), Template: s.Template}
+}
+
+// Set is a collection of skills implementing handler.Tools. Skills can be
+// added after registration, and are available to the next requests.
+type Set struct {
+	mu            sync.Mutex
+	skills        []*Skill
+	orchestrators []*execution.Orchestrator
+}
+
+// NewSet creates a Set with the given skills.
+func NewSet(skills ...*Skill) *Set {
+	s := &Set{}
+	for _, skill := range skills {
+		s.Add(skill)
+	}
+	return s
+}
+
+// Add adds the skill, replacing any skill with the same name.
+func (s *Set) Add(skill *Skill) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	replaced := false
+	for i, existing := range s.skills {
+		if existing.Name == skill.Name {
+			s.skills[i], replaced = skill, true
+		}
+	}
+	if !replaced {
+		s.skills = append(s.skills, skill)
+	}
+	for _, ec := range s.orchestrators {
+		ec.RegisterMacro(skill.Macro())
+	}
+}
+
+// Skills returns the skills of the set.
+func (s *Set) Skills() []*Skill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Skill(nil), s.skills...)
+}
+
+func (s *Set) AvailableTools() *tools.ToolSet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts := &tools.ToolSet{TypeDefinitions: make(map[string]tools.TypeInfo)}
+	for _, skill := range s.skills {
+		ts.Functions = append(ts.Functions, skill.Definition())
+	}
+	return ts
+}
+
+func (s *Set) RegisterWith(ec *execution.Orchestrator) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orchestrators = append(s.orchestrators, ec)
+	for _, skill := range s.skills {
+		ec.RegisterMacro(skill.Macro())
+	}
+	return nil
+}
+
+// Save writes the skills to w as JSON.
+func (s *Set) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s.Skills()); err != nil {
+		return fmt.Errorf("error encoding skills: %w", err)
+	}
+	return nil
+}
+
+// Load reads a Set saved with Save.
+func Load(r io.Reader) (*Set, error) {
+	var skills []*Skill
+	if err := json.NewDecoder(r).Decode(&skills); err != nil {
+		return nil, fmt.Errorf("error decoding skills: %w", err)
+	}
+	return NewSet(skills...), nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+)
+
+// Request represents a request received by the Completer.
+type Request struct {
+	Messages   []llm.Message
+	JSONSchema string
+}
+
+// Completer is a scripted llm.Completer. It answers the planning requests with
+// Plans, in order, and the consistency evaluations by approving every function
+// call not listed in Reject. Any other request is answered by Fallback.
+type Completer struct {
+	// Plans are returned in order; the last one is repeated once exhausted.
+	Plans []string
+	// Reject lists the functions evaluated as inconsistent with the request.
+	Reject []string
+	// Fallback answers the requests issued by LLM-backed tools.
+	Fallback func(messages []llm.Message, jsonSchema string) (string, error)
+
+	mu       sync.Mutex
+	next     int
+	requests []Request
+}
+
+// NewCompleter creates a Completer answering the planning requests with the given plans.
+func NewCompleter(plans ...string) *Completer {
+	return &Completer{Plans: plans}
+}
+
+func (c *Completer) Complete(messages []llm.Message, jsonSchema string) (string, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, Request{Messages: messages, JSONSchema: jsonSchema})
+	c.mu.Unlock()
+
+	switch {
+	case isEvaluation(jsonSchema):
+		return c.evaluate(messages)
+	case len(messages) > 0 && messages[0][0] == "system":
+		return c.plan()
+	case c.Fallback != nil:
+		return c.Fallback(messages, jsonSchema)
+	default:
+		return "", fmt.Errorf("testkit: unexpected request: %s", lastContent(messages))
+	}
+}
+
+// Requests returns the requests received so far.
+func (c *Completer) Requests() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Request(nil), c.requests...)
+}
+
+func (c *Completer) plan() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.Plans) == 0 {
+		return "", fmt.Errorf("testkit: no plan scripted")
+	}
+	plan := c.Plans[min(c.next, len(c.Plans)-1)]
+	c.next++
+	return plan, nil
+}
+
+func (c *Completer) evaluate(messages []llm.Message) (string, error) {
+	content := lastContent(messages)
+	for _, name := range c.Reject {
+		if strings.Contains(content, fmt.Sprintf("%q", name)) {
+			return `{"success": false}`, nil
+		}
+	}
+	return `{"success": true}`, nil
+}
+
+func isEvaluation(jsonSchema string) bool {
+	schema, err := json.Marshal(prompt.FuncCallsEvaluationResponseSchema)
+	return err == nil && jsonSchema == string(schema)
+}
+
+func lastContent(messages []llm.Message) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	return messages[len(messages)-1][1]
+}
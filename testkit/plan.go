@@ -0,0 +1,70 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testkit
+
+import (
+	"encoding/json"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+)
+
+// Call builds a planned function call. Arguments may be other calls
+// (parser.PlannedFuncCall or *parser.PlannedFuncCall), which become nested function calls.
+func Call(name string, args map[string]any) parser.PlannedFuncCall {
+	if args == nil {
+		args = map[string]any{}
+	}
+	return parser.PlannedFuncCall{
+		Name:    name,
+		Purpose: "Call " + name,
+		Args:    args,
+	}
+}
+
+// Plan encodes the calls in the JSON format produced by the planner.
+func Plan(calls ...parser.PlannedFuncCall) string {
+	mainFunctions := make([]any, len(calls))
+	for i, c := range calls {
+		mainFunctions[i] = encodeCall(c)
+	}
+	data, err := json.Marshal(map[string]any{
+		"understanding":  "testkit plan",
+		"main_functions": mainFunctions,
+	})
+	if err != nil {
+		panic("testkit: error encoding plan: " + err.Error())
+	}
+	return string(data)
+}
+
+func encodeCall(c parser.PlannedFuncCall) map[string]any {
+	args := make(map[string]any, len(c.Args))
+	for name, value := range c.Args {
+		switch v := value.(type) {
+		case parser.PlannedFuncCall:
+			args[name] = map[string]any{"func_call": encodeCall(v)}
+		case *parser.PlannedFuncCall:
+			args[name] = map[string]any{"func_call": encodeCall(*v)}
+		default:
+			args[name] = v
+		}
+	}
+	return map[string]any{
+		c.Name: map[string]any{
+			"purpose": c.Purpose,
+			"args":    args,
+		},
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testkit provides helpers for testing Tools without a language model:
+// a scripted Completer, builders for plans and functions to run them through
+// the whole pipeline or the orchestrator only.
+package testkit
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/nlpodyssey/funcallarchitect/execution"
+	"github.com/nlpodyssey/funcallarchitect/handler"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/progress"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// NewHandler creates a RequestHandler for ts answering through the given Completer.
+// Built-in tools are disabled, so that only the functions of ts are available.
+func NewHandler(t testing.TB, ts handler.Tools, completer *Completer) *handler.RequestHandler {
+	t.Helper()
+	h, err := handler.NewRequestHandler(handler.RequestHandlerConfig{
+		Logger:              log.New(io.Discard, "", 0),
+		LLMClient:           completer,
+		Tools:               ts,
+		Timeout:             defaultTimeout,
+		DisableBuiltinTools: true,
+	})
+	if err != nil {
+		t.Fatalf("testkit: error creating handler: %v", err)
+	}
+	return h
+}
+
+// Run processes the query through the whole pipeline, with the Completer answering
+// the planning request with plan.
+func Run(t testing.TB, ts handler.Tools, plan string, query string) *handler.ProcessingResult {
+	t.Helper()
+	result, err := NewHandler(t, ts, NewCompleter(plan)).ProcessUserRequest(context.Background(), query, &progress.NoOp{})
+	if err != nil {
+		t.Fatalf("testkit: error processing %q: %v", query, err)
+	}
+	return result
+}
+
+// Execute runs the function calls through an orchestrator with ts registered,
+// skipping planning and consistency evaluation.
+func Execute(t testing.TB, ts handler.Tools, calls ...parser.PlannedFuncCall) *execution.Result {
+	t.Helper()
+	ec := execution.NewOrchestrator(log.New(io.Discard, "", 0), defaultTimeout, false, ts.AvailableTools())
+	if err := ts.RegisterWith(ec); err != nil {
+		t.Fatalf("testkit: error registering tools: %v", err)
+	}
+	result, err := ec.Execute(context.Background(), calls, &progress.NoOp{})
+	if err != nil {
+		t.Fatalf("testkit: error executing function calls: %v", err)
+	}
+	return result
+}
+
+// Format returns the formatted output of the main results.
+func Format(t testing.TB, result *execution.Result) string {
+	t.Helper()
+	output, err := result.MainFuncResults().Format("")
+	if err != nil {
+		t.Fatalf("testkit: error formatting results: %v", err)
+	}
+	return output
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"sort"
+	"strings"
+)
+
+// PartialCall is a call being filled in a client UI: a function name, possibly
+// incomplete, and the arguments filled so far.
+type PartialCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// Suggestions is the response of Autocomplete.
+type Suggestions struct {
+	// Functions are the functions matching the name of the call, when it does
+	// not name one exactly: the ones starting with it first.
+	Functions []FunctionSuggestion `json:"functions,omitempty"`
+	// Form describes the function named by the call, if any.
+	Form *FunctionForm `json:"form,omitempty"`
+}
+
+// FunctionSuggestion is a function matching a partial name.
+type FunctionSuggestion struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SideEffect  bool   `json:"side_effect,omitempty"`
+}
+
+// FunctionForm describes the parameters of a function, for a form filling
+// its arguments.
+type FunctionForm struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	SideEffect  bool        `json:"side_effect,omitempty"`
+	Parameters  []Parameter `json:"parameters"`
+	Returns     string      `json:"returns"`
+	// Missing are the required parameters without an argument yet.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Parameter is the metadata of a parameter, or of a property of an object,
+// with custom types resolved.
+type Parameter struct {
+	Name        string   `json:"name,omitempty"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Default     any      `json:"default,omitempty"`
+	Examples    []any    `json:"examples,omitempty"`
+	// Items describes the items of arrays.
+	Items *Parameter `json:"items,omitempty"`
+	// Properties describe the properties of objects, required ones first.
+	Properties []Parameter `json:"properties,omitempty"`
+	// Producers are the functions returning values of the custom type of the
+	// parameter, which can fill it with a nested call.
+	Producers []string `json:"producers,omitempty"`
+	// Value is the argument filled so far, if any.
+	Value any `json:"value,omitempty"`
+}
+
+// Autocomplete returns the functions matching the name of the partial call,
+// case-insensitively, or the form of the function it names exactly, with the
+// arguments filled so far.
+func (t *ToolSet) Autocomplete(call PartialCall) *Suggestions {
+	if function, ok := t.FindTool(call.Name); ok {
+		return &Suggestions{Form: t.form(function, call.Args)}
+	}
+	var prefixed, others []FunctionSuggestion
+	name := strings.ToLower(call.Name)
+	for _, f := range t.Functions {
+		s := FunctionSuggestion{Name: f.Name, Description: f.Description, SideEffect: f.SideEffect}
+		switch lower := strings.ToLower(f.Name); {
+		case strings.HasPrefix(lower, name):
+			prefixed = append(prefixed, s)
+		case strings.Contains(lower, name):
+			others = append(others, s)
+		}
+	}
+	return &Suggestions{Functions: append(prefixed, others...)}
+}
+
+func (t *ToolSet) form(function *FuncDefinition, args map[string]any) *FunctionForm {
+	form := &FunctionForm{
+		Name:        function.Name,
+		Description: function.Description,
+		SideEffect:  function.SideEffect,
+		Parameters:  t.properties(function.Parameters, map[string]bool{}),
+		Returns:     function.Returns.Type,
+	}
+	for i := range form.Parameters {
+		p := &form.Parameters[i]
+		if value, ok := args[p.Name]; ok {
+			p.Value = value
+		} else if p.Required {
+			form.Missing = append(form.Missing, p.Name)
+		}
+	}
+	return form
+}
+
+// properties returns the properties of the object type, required ones first
+// in their order, then the others by name. Seen guards against recursive types.
+func (t *ToolSet) properties(info TypeInfo, seen map[string]bool) []Parameter {
+	required := make(map[string]int, len(info.Required))
+	for i, name := range info.Required {
+		required[name] = i
+	}
+	params := make([]Parameter, 0, len(info.Properties))
+	for name, property := range info.Properties {
+		p := t.parameter(name, property, seen)
+		_, p.Required = required[name]
+		params = append(params, p)
+	}
+	sort.Slice(params, func(i, j int) bool {
+		a, b := params[i], params[j]
+		if a.Required != b.Required {
+			return a.Required
+		}
+		if a.Required {
+			return required[a.Name] < required[b.Name]
+		}
+		return a.Name < b.Name
+	})
+	return params
+}
+
+func (t *ToolSet) parameter(name string, info TypeInfo, seen map[string]bool) Parameter {
+	p := Parameter{
+		Name:        name,
+		Type:        info.Type,
+		Description: info.Description,
+		Enum:        info.Enum,
+		Pattern:     info.Pattern,
+		Default:     info.Default,
+		Examples:    info.Examples,
+	}
+	if custom, ok := t.TypeDefinitions[info.Type]; ok {
+		p.Producers = t.producers(info.Type)
+		if p.Description == "" {
+			p.Description = custom.Description
+		}
+		if p.Default == nil {
+			p.Default = custom.Default
+		}
+		if p.Examples == nil {
+			p.Examples = custom.Examples
+		}
+		if seen[info.Type] {
+			return p
+		}
+		seen[info.Type] = true
+		defer delete(seen, info.Type)
+		info = custom
+		p.Enum, p.Pattern = custom.Enum, custom.Pattern
+	}
+	if info.Items != nil {
+		items := t.parameter("", *info.Items, seen)
+		p.Items = &items
+	}
+	if len(info.Properties) > 0 {
+		p.Properties = t.properties(info, seen)
+	}
+	return p
+}
+
+// producers returns the names of the functions returning the type.
+func (t *ToolSet) producers(typeName string) []string {
+	var names []string
+	for _, f := range t.Functions {
+		if f.Returns.Type == typeName || f.Returns.Type == AnyType {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
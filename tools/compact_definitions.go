@@ -0,0 +1,133 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"sort"
+
+	"github.com/nlpodyssey/funcallarchitect/utils/orderedmap"
+)
+
+// compactLegend explains the abbreviated keys to the model.
+const compactLegend = "n=name, d=description, a=args (name: type, or {t=type, d=description, p=properties, req=required}), req=required args, se=side effect, T[]=array of T, types=object types referenced by name"
+
+type compactDefsGenerator struct {
+	tools *ToolSet
+	// pending are the object types referenced but not expanded yet.
+	pending  []string
+	expanded map[string]bool
+}
+
+func (g *compactDefsGenerator) generate() *orderedmap.Type {
+	functions := make([]interface{}, 0, len(g.tools.Functions))
+	for _, function := range g.tools.Functions {
+		f := orderedmap.Map(
+			orderedmap.Pair{Key: "n", Value: function.Name},
+			orderedmap.Pair{Key: "d", Value: function.Description},
+		)
+		params := function.Parameters
+		if definition, ok := g.tools.TypeDefinitions[params.Type]; ok && params.Properties == nil {
+			params = definition
+		}
+		if len(params.Properties) > 0 {
+			f.Items = append(f.Items, orderedmap.Pair{Key: "a", Value: g.properties(params.Properties)})
+		}
+		if len(params.Required) > 0 {
+			f.Items = append(f.Items, orderedmap.Pair{Key: "req", Value: params.Required})
+		}
+		if function.SideEffect {
+			f.Items = append(f.Items, orderedmap.Pair{Key: "se", Value: true})
+		}
+		functions = append(functions, f)
+	}
+
+	types := orderedmap.Map()
+	for len(g.pending) > 0 {
+		sort.Strings(g.pending)
+		name := g.pending[0]
+		g.pending = g.pending[1:]
+		definition := g.tools.TypeDefinitions[name]
+		t := orderedmap.Map()
+		if definition.Description != "" {
+			t.Items = append(t.Items, orderedmap.Pair{Key: "d", Value: definition.Description})
+		}
+		t.Items = append(t.Items, orderedmap.Pair{Key: "p", Value: g.properties(definition.Properties)})
+		if len(definition.Required) > 0 {
+			t.Items = append(t.Items, orderedmap.Pair{Key: "req", Value: definition.Required})
+		}
+		types.Items = append(types.Items, orderedmap.Pair{Key: name, Value: t})
+	}
+
+	definitions := orderedmap.Map(
+		orderedmap.Pair{Key: "legend", Value: compactLegend},
+		orderedmap.Pair{Key: "functions", Value: functions},
+	)
+	if len(types.Items) > 0 {
+		sort.Slice(types.Items, func(i, j int) bool { return types.Items[i].Key < types.Items[j].Key })
+		definitions.Items = append(definitions.Items, orderedmap.Pair{Key: "types", Value: types})
+	}
+	return definitions
+}
+
+// properties renders the properties sorted by name, as their type alone
+// when they have no description.
+func (g *compactDefsGenerator) properties(properties map[string]TypeInfo) *orderedmap.Type {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	props := orderedmap.Map()
+	for _, name := range names {
+		props.Items = append(props.Items, orderedmap.Pair{Key: name, Value: g.typeInfo(properties[name])})
+	}
+	return props
+}
+
+func (g *compactDefsGenerator) typeInfo(info TypeInfo) interface{} {
+	typeName := g.typeName(info)
+	if info.Description == "" && info.Properties == nil {
+		return typeName
+	}
+	t := orderedmap.Map(orderedmap.Pair{Key: "t", Value: typeName})
+	if info.Description != "" {
+		t.Items = append(t.Items, orderedmap.Pair{Key: "d", Value: info.Description})
+	}
+	if info.Properties != nil {
+		t.Items = append(t.Items, orderedmap.Pair{Key: "p", Value: g.properties(info.Properties)})
+		if len(info.Required) > 0 {
+			t.Items = append(t.Items, orderedmap.Pair{Key: "req", Value: info.Required})
+		}
+	}
+	return t
+}
+
+// typeName resolves the aliases and records the object types to expand.
+func (g *compactDefsGenerator) typeName(info TypeInfo) string {
+	if definition, exists := g.tools.TypeDefinitions[info.Type]; exists {
+		if definition.Type == "object" {
+			if !g.expanded[info.Type] {
+				g.expanded[info.Type] = true
+				g.pending = append(g.pending, info.Type)
+			}
+			return info.Type
+		}
+		return g.typeName(definition)
+	}
+	if info.Type == "array" && info.Items != nil {
+		return g.typeName(*info.Items) + "[]"
+	}
+	return info.Type
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// DefinitionFormat selects the rendering of the tool definitions in the prompts.
+type DefinitionFormat int
+
+const (
+	// DefinitionsVerbose renders indented JSON definitions (the default).
+	DefinitionsVerbose DefinitionFormat = iota
+	// DefinitionsCompact renders JSON definitions with abbreviated keys,
+	// without whitespace, and with each object type expanded once, cutting
+	// the prompt tokens of large toolsets.
+	DefinitionsCompact
+	// DefinitionsTypeScript renders the functions as TypeScript-like
+	// signatures, and the object types as interfaces, which smaller models
+	// often follow better than JSON schemas.
+	DefinitionsTypeScript
+)
+
+func (f DefinitionFormat) String() string {
+	switch f {
+	case DefinitionsCompact:
+		return "compact"
+	case DefinitionsTypeScript:
+		return "typescript"
+	default:
+		return "verbose"
+	}
+}
+
+// ParseDefinitionFormat returns the format with the given name.
+func ParseDefinitionFormat(name string) (DefinitionFormat, error) {
+	for _, f := range []DefinitionFormat{DefinitionsVerbose, DefinitionsCompact, DefinitionsTypeScript} {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown definition format %q", name)
+}
+
+// Definitions renders the definitions of the functions in the given format.
+func (t *ToolSet) Definitions(format DefinitionFormat) (string, error) {
+	switch format {
+	case DefinitionsVerbose:
+		definitions, err := t.ToJSONDefinitions()
+		return string(definitions), err
+	case DefinitionsCompact:
+		definitions, err := t.ToCompactDefinitions()
+		return string(definitions), err
+	case DefinitionsTypeScript:
+		return t.ToTypeScriptDefinitions(), nil
+	default:
+		return "", fmt.Errorf("unknown definition format %d", format)
+	}
+}
+
+// ToCompactDefinitions is the DefinitionsCompact counterpart of ToJSONDefinitions.
+func (t *ToolSet) ToCompactDefinitions() (json.RawMessage, error) {
+	definitions, err := (&compactDefsGenerator{tools: t, expanded: make(map[string]bool)}).generate().MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling definitions: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, definitions); err != nil {
+		return nil, fmt.Errorf("error compacting definitions: %w", err)
+	}
+	return buf.Bytes(), nil
+}
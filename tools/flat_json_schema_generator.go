@@ -0,0 +1,87 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// FlatCallRef is the name of the property referencing, in the flat plan format,
+// the call whose result is used as an argument value.
+const FlatCallRef = "ref"
+
+// toFlatJSONSchema generates the schema of the flat plan format: a list of calls
+// identified by an id, whose arguments reference other calls instead of nesting them.
+func (t *toolsJSONSchemaGenerator) toFlatJSONSchema() (json.RawMessage, error) {
+	calls := make([]json.RawMessage, 0, len(t.tools.Functions))
+	for _, function := range t.tools.Functions {
+		args, err := t.transformTypeInfo(function.Parameters, t.tools.TypeDefinitions)
+		if err != nil {
+			return nil, fmt.Errorf("error generating function definition for %s: %w", function.Name, err)
+		}
+		call, err := json.Marshal(map[string]any{
+			"type":                 "object",
+			"description":          function.Description,
+			"additionalProperties": false,
+			"required":             []string{"id", "name", "purpose", "args"},
+			"properties": map[string]any{
+				"id":      map[string]string{"type": "string"},
+				"name":    map[string]string{"const": function.Name},
+				"purpose": map[string]string{"type": "string"},
+				"args":    args,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling function definition for %s: %w", function.Name, err)
+		}
+		calls = append(calls, call)
+	}
+
+	defs := make(map[string]json.RawMessage, len(t.tools.TypeDefinitions)+1)
+	defs["call_ref"] = json.RawMessage(fmt.Sprintf(`{"type":"object","additionalProperties":false,"required":[%q],"properties":{%q:{"type":"string"}}}`, FlatCallRef, FlatCallRef))
+	for _, typeName := range slices.Sorted(maps.Keys(t.tools.TypeDefinitions)) {
+		baseDef, err := t.transformTypeInfo(t.tools.TypeDefinitions[typeName], t.tools.TypeDefinitions)
+		if err != nil {
+			return nil, fmt.Errorf("error generating type definition for %s: %w", typeName, err)
+		}
+		// Values of types returned by functions can be references to calls
+		if t.tools.isProducedArgumentType(typeName) {
+			baseDef = json.RawMessage(fmt.Sprintf(`{"oneOf":[%s,{"$ref":"#/$defs/call_ref"}]}`, baseDef))
+		}
+		defs[typeName] = baseDef
+	}
+
+	return json.Marshal(map[string]any{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"understanding", "calls", "main_calls"},
+		"properties": map[string]any{
+			"understanding": map[string]string{"type": "string"},
+			"calls": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"oneOf": calls},
+			},
+			"main_calls": map[string]any{
+				"type":  "array",
+				"items": map[string]string{"type": "string"},
+			},
+		},
+		"$defs": defs,
+	})
+}
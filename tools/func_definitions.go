@@ -16,6 +16,8 @@ package tools
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/nlpodyssey/funcallarchitect/utils/orderedmap"
 )
@@ -36,6 +38,9 @@ func (t *funcDefsGenerator) generateToolsDefinition() (*orderedmap.Type, error)
 			orderedmap.Pair{Key: "description", Value: function.Description},
 			orderedmap.Pair{Key: "args", Value: t.getTypeInfo(function.Parameters, t.Tools.TypeDefinitions)},
 		)
+		if function.SideEffect {
+			simplifiedFunction.Items = append(simplifiedFunction.Items, orderedmap.Pair{Key: "side_effect", Value: true})
+		}
 
 		functions := simplifiedSchema.Items[0].Value.([]interface{})
 		simplifiedSchema.Items[0].Value = append(functions, simplifiedFunction)
@@ -52,8 +57,8 @@ func (t *funcDefsGenerator) getTypeInfo(info TypeInfo, typeDefinitions map[strin
 
 	if info.Properties != nil {
 		props := orderedmap.Map()
-		for propName, propInfo := range info.Properties {
-			props.Items = append(props.Items, orderedmap.Pair{Key: propName, Value: t.getTypeInfo(propInfo, typeDefinitions)})
+		for _, propName := range slices.Sorted(maps.Keys(info.Properties)) {
+			props.Items = append(props.Items, orderedmap.Pair{Key: propName, Value: t.getTypeInfo(info.Properties[propName], typeDefinitions)})
 		}
 		simplifiedType.Items = append(simplifiedType.Items, orderedmap.Pair{Key: "properties", Value: props})
 	}
@@ -18,6 +18,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"text/template"
 )
@@ -69,16 +71,18 @@ func (t *toolsJSONSchemaGenerator) toJSONSchema() (json.RawMessage, error) {
 		defs = append(defs, fmt.Sprintf(`"%s": %s`, function.Name, string(funcDef)))
 	}
 
-	for typeName, typeInfo := range t.tools.TypeDefinitions {
-		typeDef, err := t.generateTypeDefinition(typeName, typeInfo)
+	// The definitions are sorted by name, so that the schema is stable
+	for _, typeName := range slices.Sorted(maps.Keys(t.tools.TypeDefinitions)) {
+		typeDef, err := t.generateTypeDefinition(typeName, t.tools.TypeDefinitions[typeName])
 		if err != nil {
 			return nil, fmt.Errorf("error generating type definition for %s: %w", typeName, err)
 		}
 		defs = append(defs, fmt.Sprintf(`"%s": %s`, typeName, string(typeDef)))
 	}
 
-	for defName, defValue := range t.generateFuncCallReturningDefinitions() {
-		defs = append(defs, fmt.Sprintf(`"%s": %s`, defName, string(defValue)))
+	returning := t.generateFuncCallReturningDefinitions()
+	for _, defName := range slices.Sorted(maps.Keys(returning)) {
+		defs = append(defs, fmt.Sprintf(`"%s": %s`, defName, string(returning[defName])))
 	}
 
 	var fullSchema bytes.Buffer
@@ -211,8 +215,8 @@ func (t *toolsJSONSchemaGenerator) transformTypeInfo(info TypeInfo, typeDefiniti
 
 	if info.Properties != nil {
 		var propertyStrings []string
-		for name, propInfo := range info.Properties {
-			propDef, err := t.transformTypeInfo(propInfo, typeDefinitions)
+		for _, name := range slices.Sorted(maps.Keys(info.Properties)) {
+			propDef, err := t.transformTypeInfo(info.Properties[name], typeDefinitions)
 			if err != nil {
 				return nil, fmt.Errorf("error transforming property %s: %w", name, err)
 			}
@@ -247,13 +251,27 @@ func (t *toolsJSONSchemaGenerator) generateFuncCallReturningDefinitions() map[st
 	definitions := make(map[string]json.RawMessage)
 	typeToFunctions := make(map[string][]string)
 
+	var anyTypeFunctions []string
 	for _, function := range t.tools.Functions {
 		returnType := function.Returns.Type
+		if returnType == AnyType {
+			anyTypeFunctions = append(anyTypeFunctions, function.Name)
+			continue
+		}
 		if t.tools.isUsedAsArgumentType(returnType) {
 			typeToFunctions[returnType] = append(typeToFunctions[returnType], function.Name)
 		}
 	}
 
+	// Functions returning any type can produce the value of every type used as argument
+	if len(anyTypeFunctions) > 0 {
+		for typeName := range t.tools.TypeDefinitions {
+			if t.tools.isUsedAsArgumentType(typeName) {
+				typeToFunctions[typeName] = append(typeToFunctions[typeName], anyTypeFunctions...)
+			}
+		}
+	}
+
 	definitionTemplate := `{
         "type": "object",
         "required": ["func_call"],
@@ -293,8 +311,9 @@ func (t *toolsJSONSchemaGenerator) generateTypeDefinition(typeName string, typeI
 		return nil, fmt.Errorf("error transforming type info for %s: %w", typeName, err)
 	}
 
-	// Check if this type is used as an argument type (either directly or as an array item)
-	if t.tools.isUsedAsArgumentType(typeName) {
+	// Check if this type is used as an argument type (either directly or as an
+	// array item) and returned by a function, which the argument can call
+	if t.tools.isProducedArgumentType(typeName) {
 		typeDefTemplate := `{"oneOf": [%s, {"$ref": "#/$defs/func_call_returning_%s"}]}`
 		return json.RawMessage(fmt.Sprintf(typeDefTemplate, string(baseDef), typeName)), nil
 	}
@@ -302,6 +321,38 @@ func (t *toolsJSONSchemaGenerator) generateTypeDefinition(typeName string, typeI
 	return baseDef, nil
 }
 
+// typeJSONSchema generates a standalone JSON schema describing a single value of the given type
+// wrapped into a "value" property, so that values of primitive types are valid JSON documents too.
+func (t *toolsJSONSchemaGenerator) typeJSONSchema(typeName string) (json.RawMessage, error) {
+	typeInfo, ok := t.tools.TypeDefinitions[typeName]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s", typeName)
+	}
+
+	root, err := t.transformTypeInfo(typeInfo, t.tools.TypeDefinitions)
+	if err != nil {
+		return nil, fmt.Errorf("error transforming type info for %s: %w", typeName, err)
+	}
+
+	defs := make(map[string]json.RawMessage, len(t.tools.TypeDefinitions))
+	for name, info := range t.tools.TypeDefinitions {
+		def, err := t.transformTypeInfo(info, t.tools.TypeDefinitions)
+		if err != nil {
+			return nil, fmt.Errorf("error transforming type info for %s: %w", name, err)
+		}
+		defs[name] = def
+	}
+
+	return json.Marshal(map[string]any{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"type":                 "object",
+		"properties":           map[string]json.RawMessage{"value": root},
+		"required":             []string{"value"},
+		"additionalProperties": false,
+		"$defs":                defs,
+	})
+}
+
 func isTypeUsedInTypeInfo(typeName string, info TypeInfo) bool {
 	if info.Type == typeName {
 		return true
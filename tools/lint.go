@@ -0,0 +1,215 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nlpodyssey/funcallarchitect/llm"
+)
+
+// The checks of Lint.
+const (
+	// LintMissingDescription flags functions, parameters and types without a
+	// description.
+	LintMissingDescription = "missing-description"
+	// LintAmbiguousName flags parameters with generic names, such as "data"
+	// or "x", which give the planner no hint on the expected value.
+	LintAmbiguousName = "ambiguous-name"
+	// LintOverlappingDescriptions flags pairs of functions whose descriptions
+	// are so similar that the planner may pick the wrong one.
+	LintOverlappingDescriptions = "overlapping-descriptions"
+	// LintUnusedType flags type definitions not referenced by any function.
+	LintUnusedType = "unused-type"
+)
+
+// DefaultOverlapThreshold is the cosine similarity of the embeddings of two
+// descriptions beyond which they overlap.
+const DefaultOverlapThreshold = 0.9
+
+// ambiguousNames are the parameter names that say nothing about the value.
+var ambiguousNames = map[string]bool{
+	"arg": true, "args": true, "data": true, "foo": true, "in": true,
+	"info": true, "input": true, "item": true, "obj": true, "object": true,
+	"param": true, "params": true, "tmp": true, "val": true, "value": true,
+}
+
+// LintIssue is a problem of the definitions likely to confuse the planner.
+type LintIssue struct {
+	// Check is one of the Lint* constants.
+	Check string `json:"check"`
+	// Subject is the function, the parameter ("function.param") or the type
+	// the issue is about.
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Subject, i.Message, i.Check)
+}
+
+// LintOptions configures Lint.
+type LintOptions struct {
+	// Embedder embeds the descriptions of the functions to find the
+	// overlapping ones. The check is skipped when nil.
+	Embedder llm.Embedder
+	// OverlapThreshold defaults to DefaultOverlapThreshold.
+	OverlapThreshold float64
+}
+
+// Lint checks the definitions for the problems likely to confuse the planner,
+// returning the issues sorted by subject. The error only reports the failure
+// of the embedder.
+func (t *ToolSet) Lint(options LintOptions) ([]LintIssue, error) {
+	var issues []LintIssue
+	add := func(check, subject, format string, args ...any) {
+		issues = append(issues, LintIssue{Check: check, Subject: subject, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, function := range t.Functions {
+		if strings.TrimSpace(function.Description) == "" {
+			add(LintMissingDescription, function.Name, "the function has no description")
+		}
+		params := function.Parameters
+		if definition, ok := t.TypeDefinitions[params.Type]; ok && params.Properties == nil {
+			params = definition
+		}
+		for name, param := range params.Properties {
+			subject := function.Name + "." + name
+			if strings.TrimSpace(param.Description) == "" {
+				add(LintMissingDescription, subject, "the parameter has no description")
+			}
+			if isAmbiguousName(name) {
+				add(LintAmbiguousName, subject, "the name %q gives no hint on the expected value; name it after what it is", name)
+			}
+		}
+	}
+
+	used := t.usedTypes()
+	for name, definition := range t.TypeDefinitions {
+		if !used[name] {
+			add(LintUnusedType, name, "the type is not used by any function")
+			continue
+		}
+		if strings.TrimSpace(definition.Description) == "" && definition.Type == "object" {
+			add(LintMissingDescription, name, "the type has no description")
+		}
+	}
+
+	if options.Embedder != nil {
+		overlapping, err := t.overlappingDescriptions(options)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, overlapping...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Subject != issues[j].Subject {
+			return issues[i].Subject < issues[j].Subject
+		}
+		return issues[i].Check < issues[j].Check
+	})
+	return issues, nil
+}
+
+// isAmbiguousName reports whether the name is generic, a single letter, or a
+// generic name followed by a number ("arg2").
+func isAmbiguousName(name string) bool {
+	name = strings.ToLower(name)
+	if utf8.RuneCountInString(name) == 1 {
+		return true
+	}
+	return ambiguousNames[strings.TrimRight(name, "0123456789_")]
+}
+
+// usedTypes returns the custom types referenced by the functions, directly or
+// through other types.
+func (t *ToolSet) usedTypes() map[string]bool {
+	used := make(map[string]bool)
+	var visit func(info TypeInfo)
+	visit = func(info TypeInfo) {
+		if definition, ok := t.TypeDefinitions[info.Type]; ok && !used[info.Type] {
+			used[info.Type] = true
+			visit(definition)
+		}
+		if info.Items != nil {
+			visit(*info.Items)
+		}
+		for _, property := range info.Properties {
+			visit(property)
+		}
+	}
+	for _, function := range t.Functions {
+		visit(function.Parameters)
+		visit(function.Returns)
+	}
+	return used
+}
+
+func (t *ToolSet) overlappingDescriptions(options LintOptions) ([]LintIssue, error) {
+	threshold := options.OverlapThreshold
+	if threshold == 0 {
+		threshold = DefaultOverlapThreshold
+	}
+	vectors := make([][]float32, len(t.Functions))
+	for i, function := range t.Functions {
+		if strings.TrimSpace(function.Description) == "" {
+			continue
+		}
+		vector, err := options.Embedder.CreateEmbedding(function.Description)
+		if err != nil {
+			return nil, fmt.Errorf("error embedding the description of %s: %w", function.Name, err)
+		}
+		vectors[i] = vector
+	}
+
+	var issues []LintIssue
+	for i := range t.Functions {
+		for j := i + 1; j < len(t.Functions); j++ {
+			if vectors[i] == nil || vectors[j] == nil {
+				continue
+			}
+			if similarity := cosineSimilarity(vectors[i], vectors[j]); similarity >= threshold {
+				issues = append(issues, LintIssue{
+					Check:   LintOverlappingDescriptions,
+					Subject: t.Functions[i].Name,
+					Message: fmt.Sprintf("the description is %.2f similar to the one of %s; say what sets them apart", similarity, t.Functions[j].Name),
+				})
+			}
+		}
+	}
+	return issues, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+)
+
+// Macro is a function defined as a template of nested calls, for common
+// multi-step patterns: the planner sees a single simple function, and the
+// orchestrator expands its calls into the template before execution.
+type Macro struct {
+	FuncDefinition
+	// Template is the call tree the macro stands for. Its arguments can be
+	// parser.Param placeholders, replaced with the arguments of the call.
+	Template parser.PlannedFuncCall
+}
+
+// Expand returns the template with the arguments of call. The arguments
+// not given are omitted, so that the required ones are reported as missing.
+func (m *Macro) Expand(call parser.PlannedFuncCall) (parser.PlannedFuncCall, error) {
+	return parser.Instantiate(m.Template, call.Args), nil
+}
+
+// Validate checks that the placeholders of the template are parameters of the
+// macro and, if ts is not nil, that the functions of the template are in ts.
+func (m *Macro) Validate(ts *ToolSet) error {
+	params := m.Template.Params()
+	sort.Strings(params)
+	for _, name := range params {
+		if _, ok := m.Parameters.Properties[name]; !ok {
+			return fmt.Errorf("macro %s: unknown parameter %q in template", m.Name, name)
+		}
+	}
+	if ts == nil {
+		return nil
+	}
+	for _, name := range m.Template.CollectAllNestedFuncCalls() {
+		if _, ok := ts.FindTool(name); !ok {
+			return fmt.Errorf("macro %s: function %s not found", m.Name, name)
+		}
+	}
+	return nil
+}
@@ -19,6 +19,14 @@ import (
 	"fmt"
 )
 
+// AnyType can be used as return type of functions producing values of any type,
+// such as a generic extraction function. They are allowed wherever a custom type
+// is expected as argument.
+const AnyType = "any"
+
+// ToolSet is a set of functions with the types they use. The generated schemas
+// and definitions list the functions in their order, and the types and the
+// properties by name, so that they are stable across runs.
 type ToolSet struct {
 	Functions       []FuncDefinition    `json:"functions"`
 	TypeDefinitions map[string]TypeInfo `json:"type_definitions"`
@@ -29,6 +37,13 @@ type FuncDefinition struct {
 	Description string   `json:"description"`
 	Parameters  TypeInfo `json:"parameters"`
 	Returns     TypeInfo `json:"returns"`
+	// SideEffect marks functions that perform actions (sending messages, writing data...)
+	// rather than just retrieving information. Their execution is subject to approval and auditing.
+	SideEffect bool `json:"side_effect,omitempty"`
+	// Volatile marks functions whose results depend on more than their arguments,
+	// such as the clock or the session of the request. Their results are neither
+	// memoized nor shared with identical calls in flight.
+	Volatile bool `json:"volatile,omitempty"`
 }
 
 type TypeInfo struct {
@@ -39,12 +54,75 @@ type TypeInfo struct {
 	Required    []string            `json:"required,omitempty"`
 	Enum        []string            `json:"enum,omitempty"`
 	Pattern     string              `json:"pattern,omitempty"`
+	// RenderAs is a presentation hint for the values of the type, such as
+	// RenderTable, for client UIs. It is not shown to the model.
+	RenderAs string `json:"render_as,omitempty"`
+	// Default and Examples are values suggested by client UIs filling the
+	// arguments (see Autocomplete). They are not shown to the model.
+	Default  any   `json:"default,omitempty"`
+	Examples []any `json:"examples,omitempty"`
+}
+
+// Presentation hints for TypeInfo.RenderAs.
+const (
+	// RenderTable renders a list of objects as a table, one row per object.
+	RenderTable = "table"
+	// RenderTimeseries renders a list of objects with a time and one or more
+	// numeric fields as a chart.
+	RenderTimeseries = "timeseries"
+	// RenderMapPoint renders an object with latitude and longitude as a point on a map.
+	RenderMapPoint = "map-point"
+)
+
+// RenderHint returns the presentation hint of the values returned by the
+// function, looking it up in the type definitions for custom types.
+func (t *ToolSet) RenderHint(function *FuncDefinition) string {
+	if function.Returns.RenderAs != "" {
+		return function.Returns.RenderAs
+	}
+	return t.TypeDefinitions[function.Returns.Type].RenderAs
+}
+
+// Merge combines several ToolSets into a new one. When the same function or type
+// name is defined more than once, the first definition wins.
+func Merge(sets ...*ToolSet) *ToolSet {
+	merged := &ToolSet{TypeDefinitions: make(map[string]TypeInfo)}
+	seen := make(map[string]bool)
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		for _, function := range set.Functions {
+			if !seen[function.Name] {
+				seen[function.Name] = true
+				merged.Functions = append(merged.Functions, function)
+			}
+		}
+		for name, typeInfo := range set.TypeDefinitions {
+			if _, exists := merged.TypeDefinitions[name]; !exists {
+				merged.TypeDefinitions[name] = typeInfo
+			}
+		}
+	}
+	return merged
 }
 
 func (t *ToolSet) ToJSONSchema() (json.RawMessage, error) {
 	return (&toolsJSONSchemaGenerator{tools: t}).toJSONSchema()
 }
 
+// ToFlatJSONSchema returns the JSON schema of the flat plan format, in which
+// calls reference each other by id instead of being nested.
+func (t *ToolSet) ToFlatJSONSchema() (json.RawMessage, error) {
+	return (&toolsJSONSchemaGenerator{tools: t}).toFlatJSONSchema()
+}
+
+// TypeJSONSchema returns a JSON schema for a value of one of the TypeDefinitions,
+// wrapped into a "value" property.
+func (t *ToolSet) TypeJSONSchema(typeName string) (json.RawMessage, error) {
+	return (&toolsJSONSchemaGenerator{tools: t}).typeJSONSchema(typeName)
+}
+
 func (t *ToolSet) ToJSONDefinitions() (json.RawMessage, error) {
 	definitions, err := (&funcDefsGenerator{Tools: t}).generateToolsDefinition()
 	if err != nil {
@@ -79,3 +157,19 @@ func (t *ToolSet) isUsedAsArgumentType(typeName string) bool {
 	}
 	return false
 }
+
+// isProducedArgumentType reports whether values of the type can be both taken
+// as argument and produced by a function call of the set: only then can the
+// arguments of the type be nested calls. Sets filtered by the caller (e.g. of
+// the unhealthy functions) may lack the producers of a type.
+func (t *ToolSet) isProducedArgumentType(typeName string) bool {
+	if !t.isUsedAsArgumentType(typeName) {
+		return false
+	}
+	for _, function := range t.Functions {
+		if function.Returns.Type == typeName || function.Returns.Type == AnyType {
+			return true
+		}
+	}
+	return false
+}
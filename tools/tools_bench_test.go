@@ -0,0 +1,101 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nlpodyssey/funcallarchitect/builtin"
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// benchmarkToolSet returns the built-in and geo tools, extended with n
+// synthetic functions chained through their types.
+func benchmarkToolSet(n int) *tools.ToolSet {
+	synthetic := &tools.ToolSet{TypeDefinitions: map[string]tools.TypeInfo{}}
+	for i := 0; i < n; i++ {
+		returns := fmt.Sprintf("synthetic_%d_value", i)
+		params := map[string]tools.TypeInfo{
+			"query": {Type: "string", Description: "A free-text query"},
+			"limit": {Type: "integer", Description: "Maximum number of items"},
+		}
+		if i > 0 {
+			params["input"] = tools.TypeInfo{Type: fmt.Sprintf("synthetic_%d_value", i-1), Description: "The previous value"}
+		}
+		synthetic.Functions = append(synthetic.Functions, tools.FuncDefinition{
+			Name:        fmt.Sprintf("synthetic_%d", i),
+			Description: "A synthetic function used for benchmarking.",
+			Parameters:  tools.TypeInfo{Type: "object", Properties: params, Required: []string{"query"}},
+			Returns:     tools.TypeInfo{Type: returns},
+		})
+		synthetic.TypeDefinitions[returns] = tools.TypeInfo{
+			Type: "object",
+			Properties: map[string]tools.TypeInfo{
+				"id":    {Type: "string"},
+				"score": {Type: "number"},
+				"tags":  {Type: "array", Items: &tools.TypeInfo{Type: "string"}},
+			},
+		}
+	}
+
+	return tools.Merge(
+		(&builtin.Calculator{}).AvailableTools(),
+		(&builtin.DateTime{}).AvailableTools(),
+		geo.New().AvailableTools(),
+		synthetic,
+	)
+}
+
+var benchmarkSizes = []int{0, 10, 50}
+
+func BenchmarkToJSONSchema(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		ts := benchmarkToolSet(n)
+		b.Run(fmt.Sprintf("functions=%d", len(ts.Functions)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ts.ToJSONSchema(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkToJSONDefinitions(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		ts := benchmarkToolSet(n)
+		b.Run(fmt.Sprintf("functions=%d", len(ts.Functions)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ts.ToJSONDefinitions(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTypeJSONSchema(b *testing.B) {
+	ts := benchmarkToolSet(10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ts.TypeJSONSchema(geo.CoordinatesType); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
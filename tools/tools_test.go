@@ -0,0 +1,147 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nlpodyssey/funcallarchitect/contrib/geo"
+	"github.com/nlpodyssey/funcallarchitect/parser"
+	"github.com/nlpodyssey/funcallarchitect/tools"
+)
+
+// stabilityRuns is the number of generations compared: the iteration order of
+// the maps changes between runs, so unsorted output would differ quickly.
+const stabilityRuns = 20
+
+func TestGenerationIsStable(t *testing.T) {
+	generators := map[string]func(ts *tools.ToolSet) ([]byte, error){
+		"JSON schema": func(ts *tools.ToolSet) ([]byte, error) {
+			return ts.ToJSONSchema()
+		},
+		"flat JSON schema": func(ts *tools.ToolSet) ([]byte, error) {
+			return ts.ToFlatJSONSchema()
+		},
+		"type JSON schema": func(ts *tools.ToolSet) ([]byte, error) {
+			return ts.TypeJSONSchema(geo.CoordinatesType)
+		},
+	}
+	for _, format := range []tools.DefinitionFormat{tools.DefinitionsVerbose, tools.DefinitionsCompact, tools.DefinitionsTypeScript} {
+		generators[format.String()+" definitions"] = func(ts *tools.ToolSet) ([]byte, error) {
+			definitions, err := ts.Definitions(format)
+			return []byte(definitions), err
+		}
+	}
+
+	for name, generate := range generators {
+		t.Run(name, func(t *testing.T) {
+			want, err := generate(benchmarkToolSet(10))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < stabilityRuns; i++ {
+				got, err := generate(benchmarkToolSet(10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("run %d differs:\n%s\nwant:\n%s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaWithoutProducersHasNoDanglingRefs(t *testing.T) {
+	// find_weather takes coordinates, but their producer was filtered out of
+	// the set (e.g. as unhealthy)
+	ts := &tools.ToolSet{
+		Functions: []tools.FuncDefinition{{
+			Name: "find_weather",
+			Parameters: tools.TypeInfo{
+				Type:       "object",
+				Properties: map[string]tools.TypeInfo{"location": {Type: "coordinates"}},
+				Required:   []string{"location"},
+			},
+			Returns: tools.TypeInfo{Type: "string"},
+		}},
+		TypeDefinitions: map[string]tools.TypeInfo{
+			"coordinates": {
+				Type: "object",
+				Properties: map[string]tools.TypeInfo{
+					"latitude":  {Type: "number"},
+					"longitude": {Type: "number"},
+				},
+			},
+		},
+	}
+	for name, generate := range map[string]func() (json.RawMessage, error){
+		"JSON schema":      ts.ToJSONSchema,
+		"flat JSON schema": ts.ToFlatJSONSchema,
+	} {
+		t.Run(name, func(t *testing.T) {
+			schema, err := generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRefs(t, schema)
+			if strings.Contains(string(schema), "func_call_returning_coordinates") || strings.Contains(string(schema), `"#/$defs/call_ref"`) {
+				t.Fatalf("coordinates can be a nested call without producers:\n%s", schema)
+			}
+		})
+	}
+}
+
+var refPattern = regexp.MustCompile(`"\$ref":"#/\$defs/([^"]+)"`)
+
+// checkRefs fails the test if the schema refers to definitions it lacks.
+func checkRefs(t *testing.T, schema json.RawMessage) {
+	t.Helper()
+	var doc struct {
+		Defs map[string]json.RawMessage `json:"$defs"`
+	}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range refPattern.FindAllStringSubmatch(string(schema), -1) {
+		if _, ok := doc.Defs[m[1]]; !ok {
+			t.Errorf("dangling reference to %s", m[1])
+		}
+	}
+}
+
+func TestNestedFuncCallsAreStable(t *testing.T) {
+	call := parser.PlannedFuncCall{
+		Name: "outer",
+		Args: map[string]any{
+			"a": &parser.PlannedFuncCall{Name: "first"},
+			"b": &parser.PlannedFuncCall{Name: "second"},
+			"c": &parser.PlannedFuncCall{Name: "third"},
+			"d": &parser.PlannedFuncCall{Name: "fourth"},
+			"e": "literal",
+		},
+	}
+	want := []string{"outer", "first", "second", "third", "fourth"}
+	for i := 0; i < stabilityRuns; i++ {
+		if got := call.CollectAllNestedFuncCalls(); !slices.Equal(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
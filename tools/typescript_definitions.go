@@ -0,0 +1,157 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ToTypeScriptDefinitions is the DefinitionsTypeScript counterpart of
+// ToJSONDefinitions. The functions are declared as types in a "functions"
+// namespace, in the style of the function definitions many models are
+// trained on, preceded by the interfaces of the object types they use.
+func (t *ToolSet) ToTypeScriptDefinitions() string {
+	return (&tsDefsGenerator{tools: t, expanded: make(map[string]bool)}).generate()
+}
+
+type tsDefsGenerator struct {
+	tools *ToolSet
+	// pending are the object types referenced but not declared yet.
+	pending  []string
+	expanded map[string]bool
+}
+
+func (g *tsDefsGenerator) generate() string {
+	var functions strings.Builder
+	functions.WriteString("namespace functions {\n")
+	for _, function := range g.tools.Functions {
+		functions.WriteString("\n")
+		writeTSComment(&functions, "", function.Description)
+		if function.SideEffect {
+			functions.WriteString("// Side effect: performs an action, subject to approval.\n")
+		}
+		params := function.Parameters
+		if definition, ok := g.tools.TypeDefinitions[params.Type]; ok && params.Properties == nil {
+			params = definition
+		}
+		returns := "void"
+		if function.Returns.Type != "" {
+			returns = g.typeName(function.Returns, "")
+		}
+		fmt.Fprintf(&functions, "type %s = (_: %s) => %s;\n", function.Name, g.object(params, ""), returns)
+	}
+	functions.WriteString("\n} // namespace functions\n")
+
+	var interfaces strings.Builder
+	for len(g.pending) > 0 {
+		sort.Strings(g.pending)
+		name := g.pending[0]
+		g.pending = g.pending[1:]
+		definition := g.tools.TypeDefinitions[name]
+		writeTSComment(&interfaces, "", definition.Description)
+		fmt.Fprintf(&interfaces, "interface %s %s\n\n", name, g.object(definition, ""))
+	}
+	return interfaces.String() + functions.String()
+}
+
+// object renders the properties sorted by name, the optional ones marked
+// with "?".
+func (g *tsDefsGenerator) object(info TypeInfo, indent string) string {
+	if len(info.Properties) == 0 {
+		return "{}"
+	}
+	names := make([]string, 0, len(info.Properties))
+	for name := range info.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	required := make(map[string]bool, len(info.Required))
+	for _, name := range info.Required {
+		required[name] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	inner := indent + "  "
+	for _, name := range names {
+		property := info.Properties[name]
+		writeTSComment(&b, inner, property.Description)
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s%s%s: %s;\n", inner, name, optional, g.typeName(property, inner))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// typeName maps the type to TypeScript, resolving the aliases and recording
+// the object types to declare.
+func (g *tsDefsGenerator) typeName(info TypeInfo, indent string) string {
+	if definition, exists := g.tools.TypeDefinitions[info.Type]; exists {
+		if definition.Type == "object" {
+			if !g.expanded[info.Type] {
+				g.expanded[info.Type] = true
+				g.pending = append(g.pending, info.Type)
+			}
+			return info.Type
+		}
+		return g.typeName(definition, indent)
+	}
+	if len(info.Enum) > 0 {
+		values := make([]string, len(info.Enum))
+		for i, value := range info.Enum {
+			values[i] = fmt.Sprintf("%q", value)
+		}
+		return strings.Join(values, " | ")
+	}
+	switch info.Type {
+	case "array":
+		if info.Items == nil {
+			return "any[]"
+		}
+		item := g.typeName(*info.Items, indent)
+		if strings.Contains(item, " | ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if info.Properties == nil {
+			return "Record<string, any>"
+		}
+		return g.object(info, indent)
+	case "integer":
+		// TypeScript has no integer type; the JSON schema of the plan still
+		// tells them apart
+		return "number"
+	case "":
+		return "any"
+	default:
+		return info.Type
+	}
+}
+
+// writeTSComment writes the description as line comments.
+func writeTSComment(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/nlpodyssey/funcallarchitect/parser"
+)
+
+// maxTypeDepth bounds the resolution of custom types defined in terms of
+// other custom types, which also stops cyclic definitions.
+const maxTypeDepth = 16
+
+// ValidateValue checks that the JSON encoding of value conforms to the type,
+// resolving custom types through the type definitions of the ToolSet.
+func (t *ToolSet) ValidateValue(info TypeInfo, value any) error {
+	return t.validateValue(info, value, "value")
+}
+
+func (t *ToolSet) validateValue(info TypeInfo, value any, path string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error marshaling value: %w", err)
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return fmt.Errorf("error unmarshaling value: %w", err)
+	}
+	return t.validate(info, decoded, path, 0)
+}
+
+// ValidateCall checks a planned call against the definitions of the ToolSet:
+// the functions must be defined, the required arguments given, the literal
+// arguments must conform to the types of the parameters, and the nested calls
+// must return them.
+func (t *ToolSet) ValidateCall(call parser.PlannedFuncCall) error {
+	return t.validateCall(&call, call.Name)
+}
+
+func (t *ToolSet) validateCall(call *parser.PlannedFuncCall, path string) error {
+	def, ok := t.FindTool(call.Name)
+	if !ok {
+		return fmt.Errorf("%s: function %s not found", path, call.Name)
+	}
+	for _, name := range def.Parameters.Required {
+		if _, ok := call.Args[name]; !ok {
+			return fmt.Errorf("%s: missing required argument %s", path, name)
+		}
+	}
+	names := make([]string, 0, len(call.Args))
+	for name := range call.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		param, ok := def.Parameters.Properties[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown argument %s", path, name)
+		}
+		nested, ok := call.Args[name].(*parser.PlannedFuncCall)
+		if !ok {
+			if err := t.validateValue(param, call.Args[name], path+"."+name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := t.validateCall(nested, path+"."+name); err != nil {
+			return err
+		}
+		nestedDef, _ := t.FindTool(nested.Name)
+		returns := nestedDef.Returns.Type
+		if param.Type != returns && !isAnyType(param.Type) && !isAnyType(returns) {
+			return fmt.Errorf("%s.%s: %s returns %s, expected %s", path, name, nested.Name, returns, param.Type)
+		}
+	}
+	return nil
+}
+
+func isAnyType(typ string) bool {
+	return typ == "" || typ == AnyType
+}
+
+func (t *ToolSet) validate(info TypeInfo, v any, path string, depth int) error {
+	if depth > maxTypeDepth {
+		return fmt.Errorf("%s: type %s is nested too deeply", path, info.Type)
+	}
+	switch info.Type {
+	case "", AnyType:
+		return nil
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string, got %s", path, jsonType(v))
+		}
+		if len(info.Enum) > 0 && !slices.Contains(info.Enum, s) {
+			return fmt.Errorf("%s: %q is not one of %v", path, s, info.Enum)
+		}
+		if info.Pattern != "" {
+			if re, err := regexp.Compile(info.Pattern); err == nil && !re.MatchString(s) {
+				return fmt.Errorf("%s: %q does not match %s", path, s, info.Pattern)
+			}
+		}
+	case "number", "integer":
+		n, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("%s: expected a number, got %s", path, jsonType(v))
+		}
+		if info.Type == "integer" && n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected an integer, got %v", path, n)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %s", path, jsonType(v))
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %s", path, jsonType(v))
+		}
+		if info.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := t.validate(*info.Items, item, fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %s", path, jsonType(v))
+		}
+		for _, name := range info.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, name)
+			}
+		}
+		names := make([]string, 0, len(info.Properties))
+		for name := range info.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := obj[name]
+			if !ok || prop == nil {
+				continue
+			}
+			if err := t.validate(info.Properties[name], prop, path+"."+name, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		def, ok := t.TypeDefinitions[info.Type]
+		if !ok {
+			return fmt.Errorf("%s: unknown type %s", path, info.Type)
+		}
+		return t.validate(def, v, path, depth+1)
+	}
+	return nil
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ui serves a chat frontend of the server package: the requests are
+// streamed with their progress, the executed plans are rendered as trees and,
+// in review mode, the plans are submitted for approval before their execution.
+package ui
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed index.html
+var templateFS embed.FS
+
+//go:embed favicon.ico
+var defaultFavicon []byte
+
+var index = template.Must(template.ParseFS(templateFS, "index.html"))
+
+// Branding is the identity shown in the header and the title of the page.
+type Branding struct {
+	CompanyNamePrefix  string `yaml:"company_name_prefix"`
+	CompanyNameSuffix  string `yaml:"company_name_suffix"`
+	CompanySuffixColor string `yaml:"company_suffix_color"`
+	ProductName        string `yaml:"product_name"`
+	EnvironmentName    string `yaml:"environment_name"`
+	// AccentColor is the CSS color of the prompt, the progress and the links.
+	AccentColor string `yaml:"product_accent_color"`
+	// Favicon is the icon of the page, in ICO format. A default one if empty.
+	Favicon []byte `yaml:"-"`
+}
+
+// Config configures the frontend.
+type Config struct {
+	Branding `yaml:",inline"`
+	// InitialQuestion prefills the prompt.
+	InitialQuestion string `yaml:"initial_question"`
+	// Review submits the plans for approval before executing them (see
+	// server.Server.ProposePlan), asking to rephrase the requests that
+	// can't be planned.
+	Review bool `yaml:"review"`
+	// APIBase is the path prefix of the endpoints of the server package,
+	// empty when the frontend is served by the server itself (see
+	// server.Server.UI).
+	APIBase string `yaml:"api_base"`
+}
+
+// DefaultConfig returns the configuration with the FunCallArchitect branding.
+func DefaultConfig() Config {
+	return Config{
+		Branding: Branding{
+			CompanyNameSuffix:  "FunCallArchitect",
+			CompanySuffixColor: defaultAccentColor,
+			ProductName:        "Chat",
+			EnvironmentName:    "Terminal",
+			AccentColor:        defaultAccentColor,
+		},
+	}
+}
+
+const defaultAccentColor = "#45a049"
+
+// LoadConfig reads the configuration from a YAML file, on top of DefaultConfig.
+func LoadConfig(filename string) (Config, error) {
+	config := DefaultConfig()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Config{}, fmt.Errorf("error reading config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+	return config, nil
+}
+
+// Handler serves the page at "/" and its icon at "/favicon.ico". Mounted
+// under a prefix, it must be reached with a trailing slash, the page
+// referring to its icon by a relative path.
+func Handler(config Config) http.Handler {
+	if config.AccentColor == "" {
+		config.AccentColor = defaultAccentColor
+	}
+	if config.CompanySuffixColor == "" {
+		config.CompanySuffixColor = config.AccentColor
+	}
+	favicon := config.Favicon
+	if len(favicon) == 0 {
+		favicon = defaultFavicon
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := index.Execute(w, config); err != nil {
+			http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("GET /favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/x-icon")
+		w.Write(favicon)
+	})
+	return mux
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The NLP Odyssey Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version reports the version of the code in use, recorded with the
+// artifacts it produces (execution reports, audit receipts), so that they can
+// be traced back to it.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/nlpodyssey/funcallarchitect/prompt"
+)
+
+// modulePath is the path of the module, looked up in the build information.
+const modulePath = "github.com/nlpodyssey/funcallarchitect"
+
+// SchemaVersion is the version of the format of the stored artifacts, bumped
+// on incompatible changes.
+const SchemaVersion = 1
+
+// Version and Commit can be set at build time, e.g. with
+//
+//	-ldflags "-X github.com/nlpodyssey/funcallarchitect/version.Version=v1.2.0 -X github.com/nlpodyssey/funcallarchitect/version.Commit=$(git rev-parse HEAD)"
+//
+// Otherwise, they are read from the build information of the binary: the
+// version of the module, when it is a dependency, and the commit of the
+// checkout, when it is the main module.
+var (
+	Version string
+	Commit  string
+)
+
+// Build identifies the code producing an artifact.
+type Build struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	// Modified reports whether the checkout had uncommitted changes.
+	Modified bool `json:"modified,omitempty"`
+	Schema   int  `json:"schema"`
+}
+
+// Info is the complete version information, with the prompt templates in use.
+type Info struct {
+	Build
+	GoVersion string `json:"go_version"`
+	// Prompts are the latest versions of the prompt templates, by name; the
+	// handlers may pin others (see prompt.Pins).
+	Prompts map[string]int `json:"prompts"`
+}
+
+// Current returns the build of the running code.
+func Current() Build {
+	return current()
+}
+
+// Get returns the version information of the running code.
+func Get() Info {
+	return Info{
+		Build:     current(),
+		GoVersion: runtime.Version(),
+		Prompts:   prompt.Pins(nil).All(),
+	}
+}
+
+// current reads the build information once.
+var current = sync.OnceValue(func() Build {
+	b := Build{Version: Version, Commit: Commit, Schema: SchemaVersion}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return withDefaults(b)
+	}
+	module := &info.Main
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			module = dep
+		}
+	}
+	if b.Version == "" && module.Path == modulePath {
+		b.Version = module.Version
+	}
+	if module == &info.Main {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if b.Commit == "" {
+					b.Commit = s.Value
+				}
+			case "vcs.modified":
+				b.Modified = s.Value == "true"
+			}
+		}
+	}
+	return withDefaults(b)
+})
+
+func withDefaults(b Build) Build {
+	if b.Version == "" {
+		b.Version = "(devel)"
+	}
+	return b
+}